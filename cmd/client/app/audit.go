@@ -0,0 +1,149 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"keeper-project/internal/audit"
+	"keeper-project/pkg/keeperclient"
+)
+
+var (
+	auditMaxAge int
+	auditBreach string
+)
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+
+	auditCmd.Flags().IntVar(&auditMaxAge, "max-age", 365, "report passwords not changed for that many days, 0 disables the check")
+	auditCmd.Flags().StringVar(&auditBreach, "breach", "",
+		"check passwords against a local Pwned Passwords SHA-1 dataset: the file ordered by hash or a directory of range files")
+}
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "check the vault for weak, reused and old passwords",
+	Long: `decrypt all credentials and cards locally and report passwords used for several sites,
+passwords that are easy to guess, passwords not changed for --max-age days and expired cards.
+With --breach the passwords are looked up in a local copy of a breached passwords dataset
+by the first 5 hex digits of their SHA-1, full hashes are never sent anywhere`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := connect(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		var breaches audit.BreachProvider
+		if auditBreach != "" {
+			breaches, err = audit.NewFileProvider(auditBreach)
+			if err != nil {
+				return fmt.Errorf("unable to open breach dataset: %w", err)
+			}
+		}
+
+		creds, cards, err := loadAudit(cmd.Context(), client)
+		if err != nil {
+			return apiError("unable to get data", err)
+		}
+
+		report := audit.Run(creds, cards, audit.Options{
+			MaxAge: time.Duration(auditMaxAge) * 24 * time.Hour,
+			Now:    time.Now(),
+		})
+		if breaches != nil {
+			err = report.CheckBreaches(cmd.Context(), creds, breaches)
+			if err != nil {
+				return fmt.Errorf("unable to check breaches: %w", err)
+			}
+		}
+
+		return render(report, func(w io.Writer) {
+			printAudit(w, report, len(creds), len(cards))
+		})
+	},
+}
+
+// loadAudit fetches and decrypts every credentials entry and card.
+func loadAudit(ctx context.Context, client *keeperclient.Client) ([]audit.Credential, []audit.Card, error) {
+	keys, err := listKeys(ctx, client.ListCredentials)
+	if err != nil {
+		return nil, nil, err
+	}
+	creds := make([]audit.Credential, 0, len(keys))
+	for _, key := range keys {
+		cred, err := client.GetCredentials(ctx, key.Id)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		c := audit.Credential{ID: key.Id, Site: cred.Site, Login: cred.Login, Password: cred.Password}
+		if key.UpdatedAt != nil {
+			c.UpdatedAt = *key.UpdatedAt
+		}
+		creds = append(creds, c)
+	}
+
+	keys, err = listKeys(ctx, client.ListCards)
+	if err != nil {
+		return nil, nil, err
+	}
+	cards := make([]audit.Card, 0, len(keys))
+	for _, key := range keys {
+		card, err := client.GetCard(ctx, key.Id)
+		if err != nil {
+			return nil, nil, err
+		}
+		cards = append(cards, audit.Card{ID: key.Id, Number: card.Number, Expiration: card.Expiration})
+	}
+	return creds, cards, nil
+}
+
+func printAudit(w io.Writer, r *audit.Report, creds, cards int) {
+	fmt.Fprintf(w, "Checked %d credentials and %d cards\n", creds, cards)
+	if r.Problems() == 0 {
+		fmt.Fprintln(w, "No problems found")
+		return
+	}
+
+	if len(r.Breached) > 0 {
+		fmt.Fprintln(w, "\nBreached passwords:")
+		for _, b := range r.Breached {
+			fmt.Fprintf(w, "  %s: seen %d times, ID: %s\n", b, b.Count, b.ID)
+		}
+	}
+	if len(r.Reused) > 0 {
+		fmt.Fprintln(w, "\nReused passwords:")
+		for _, group := range r.Reused {
+			names := make([]string, 0, len(group.Entries))
+			for _, e := range group.Entries {
+				names = append(names, e.String())
+			}
+			fmt.Fprintf(w, "  %d entries share a password: %s\n", len(group.Entries), strings.Join(names, ", "))
+		}
+	}
+	if len(r.Weak) > 0 {
+		fmt.Fprintln(w, "\nWeak passwords:")
+		for _, weak := range r.Weak {
+			fmt.Fprintf(w, "  %s: %s (%.0f bits), ID: %s\n", weak, weak.Strength, weak.Entropy, weak.ID)
+		}
+	}
+	if len(r.Old) > 0 {
+		fmt.Fprintf(w, "\nNot changed for %d days:\n", auditMaxAge)
+		for _, o := range r.Old {
+			fmt.Fprintf(w, "  %s: %d days, ID: %s\n", o, o.Days, o.ID)
+		}
+	}
+	if len(r.Expired) > 0 {
+		fmt.Fprintln(w, "\nExpired cards:")
+		for _, e := range r.Expired {
+			fmt.Fprintf(w, "  *%s: expired %s, ID: %s\n", e.Last4, e.Expiration, e.ID)
+		}
+	}
+}
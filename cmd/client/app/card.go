@@ -1,17 +1,23 @@
 package app
 
 import (
-	"encoding/json"
 	"fmt"
-	"net/http"
+	"io"
 
-	"github.com/go-resty/resty/v2"
 	"github.com/spf13/cobra"
 
-	"keeper-project/internal/crypto"
 	"keeper-project/types"
 )
 
+// cardView is the schema of card get.
+type cardView struct {
+	ID         string `json:"id"`
+	Number     string `json:"number"`
+	Expiration string `json:"expiration"`
+	CVV        string `json:"cvv"`
+	Metadata   string `json:"metadata"`
+}
+
 var cardCmd = &cobra.Command{
 	Use:   "card",
 	Short: "easily store your card info",
@@ -26,6 +32,9 @@ func init() {
 	cardCmd.AddCommand(cardGetCmd)
 	cardCmd.AddCommand(cardDeleteCmd)
 	cardCmd.AddCommand(cardUpdateCmd)
+
+	cardsList.register(cardsListCmd, "created, updated or name")
+	cardCopy.register(cardGetCmd, "number", "cvv", "expiration")
 }
 
 var cardCreateCmd = &cobra.Command{
@@ -33,54 +42,25 @@ var cardCreateCmd = &cobra.Command{
 	Short: "save card information",
 	Long:  `save card information`,
 	Args:  cobra.ExactArgs(4),
-	Run: func(cmd *cobra.Command, args []string) {
-		client := resty.New()
-		token, err := auth(client)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := connect(cmd.Context())
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 
-		number, err := crypto.Encrypt(password, args[0])
-		if err != nil {
-			fmt.Printf("failed to encrypt: %v\n", err)
-			return
-		}
-		exp, err := crypto.Encrypt(password, args[1])
-		if err != nil {
-			fmt.Printf("failed to encrypt: %v\n", err)
-			return
-		}
-		cvv, err := crypto.Encrypt(password, args[2])
-		if err != nil {
-			fmt.Printf("failed to encrypt: %v\n", err)
-			return
-		}
-		md, err := crypto.Encrypt(password, args[3])
-		if err != nil {
-			fmt.Printf("failed to encrypt: %v\n", err)
-			return
-		}
-
-		data := fmt.Sprintf("{\"number\": \"%s\", \"expiration\": \"%s\", \"cvv\": \"%s\", \"metadata\": \"%s\"}",
-			number, exp, cvv, md)
-
-		res, err := client.R().
-			SetHeader("Content-Type", "application/json").
-			SetHeader("Authorization", token).
-			SetBody(data).
-			Post(fmt.Sprintf("http://%s/api/secret/card", serverURL))
+		err = client.CreateCard(cmd.Context(), types.CreateCardRequest{
+			Number:       args[0],
+			Expiration:   args[1],
+			CVV:          args[2],
+			Metadata:     args[3],
+			SearchTokens: cardTokens(args[0], args[3]),
+		})
 		if err != nil {
-			fmt.Println("Unable to save data", err)
-			return
+			return apiError("failed to save", err)
 		}
 
-		if res.StatusCode() != http.StatusAccepted {
-			fmt.Printf("Failed to save: %s\n", res.Body())
-			return
-		}
-
-		fmt.Println("Successfully saved")
+		info("Successfully saved")
+		return nil
 	},
 }
 
@@ -88,99 +68,60 @@ var cardsListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "get saved cards list",
 	Long:  `get saved cards list`,
-	Run: func(cmd *cobra.Command, args []string) {
-		client := resty.New()
-
-		token, err := auth(client)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := connect(cmd.Context())
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 
-		var result []*types.Key
-
-		res, err := client.R().
-			SetHeader("Authorization", token).
-			SetResult(&result).
-			Get(fmt.Sprintf("http://%s/api/secret/cards", serverURL))
+		result, next, total, err := fetchList(cmd.Context(), client.ListCards, &cardsList, keyName)
 		if err != nil {
-			fmt.Println("Unable to save data", err)
-			return
+			return apiError("failed to get", err)
 		}
 
-		if res.StatusCode() != http.StatusOK {
-			fmt.Printf("Failed to get: %s\n", res.Body())
-			return
-		}
-
-		for i := range result {
-			decrypted, err := crypto.Decrypt(password, result[i].Key)
-			if err != nil {
-				fmt.Printf("failed to decrypt: %v\n", err)
-				return
-			}
-			result[i].Key = "*" + decrypted[len(decrypted)-4:]
-			fmt.Printf("Number: %s, ID: %s\n", result[i].Key, result[i].Id)
-		}
+		return renderKeys("NUMBER", result, next, total, func(number string) string {
+			return "*" + lastDigits(number)
+		})
 	},
 }
 
 var cardGetCmd = &cobra.Command{
 	Use:   "get [id]",
 	Short: "get card info by id",
-	Long:  `get card info by id, you can find ids in list command`,
-	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		client := resty.New()
-		token, err := auth(client)
+	Long: `get card info by id, you can find ids in list command.
+With --copy the field is put on the clipboard and cleared after --clear-after instead of printed`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := connect(cmd.Context())
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 
-		var result types.CardInfo
-
-		res, err := client.R().
-			SetHeader("Content-Type", "application/json").
-			SetHeader("Authorization", token).
-			SetResult(&result).
-			Get(fmt.Sprintf("http://%s/api/secret/card/%s", serverURL, args[0]))
+		result, err := client.GetCard(cmd.Context(), args[0])
 		if err != nil {
-			fmt.Println("Unable to save data", err)
-			return
-		}
-
-		if res.StatusCode() != http.StatusOK {
-			fmt.Printf("Failed to get: %s\n", res.Body())
-			return
+			return apiError("failed to get", err)
 		}
 
-		result.Number, err = crypto.Decrypt(password, result.Number)
-		if err != nil {
-			fmt.Printf("failed to decrypt: %v\n", err)
-			return
-		}
-		result.Expiration, err = crypto.Decrypt(password, result.Expiration)
-		if err != nil {
-			fmt.Printf("failed to decrypt: %v\n", err)
-			return
-		}
-		result.CVV, err = crypto.Decrypt(password, result.CVV)
-		if err != nil {
-			fmt.Printf("failed to decrypt: %v\n", err)
-			return
-		}
-		result.Metadata, err = crypto.Decrypt(password, result.Metadata)
-		if err != nil {
-			fmt.Printf("failed to decrypt: %v\n", err)
-			return
+		if cardCopy.enabled() {
+			err = cardCopy.copy(cmd, "card *"+lastDigits(result.Number), map[string]string{
+				"number":     result.Number,
+				"cvv":        result.CVV,
+				"expiration": result.Expiration,
+			})
+			if err != nil {
+				return fmt.Errorf("unable to copy: %w", err)
+			}
+			return nil
 		}
 
-		s, err := json.MarshalIndent(result, "", "\t")
-		if err != nil {
-			fmt.Println("failed to print: ", err)
-		}
-		fmt.Println(string(s))
+		view := cardView{ID: args[0], Number: result.Number, Expiration: result.Expiration, CVV: result.CVV, Metadata: result.Metadata}
+		return render(view, func(w io.Writer) {
+			fmt.Fprintf(w, "ID:\t%s\n", view.ID)
+			fmt.Fprintf(w, "Number:\t%s\n", view.Number)
+			fmt.Fprintf(w, "Expiration:\t%s\n", view.Expiration)
+			fmt.Fprintf(w, "CVV:\t%s\n", view.CVV)
+			fmt.Fprintf(w, "Metadata:\t%s\n", view.Metadata)
+		})
 	},
 }
 
@@ -189,29 +130,19 @@ var cardDeleteCmd = &cobra.Command{
 	Short: "delete card info by id",
 	Long:  `delete card info by id, you can find ids in list command`,
 	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		client := resty.New()
-		token, err := auth(client)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := connect(cmd.Context())
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 
-		res, err := client.R().
-			SetHeader("Content-Type", "application/json").
-			SetHeader("Authorization", token).
-			Delete(fmt.Sprintf("http://%s/api/secret/card/%s", serverURL, args[0]))
+		err = client.DeleteCard(cmd.Context(), args[0])
 		if err != nil {
-			fmt.Println("Unable to delete data", err)
-			return
-		}
-
-		if res.StatusCode() != http.StatusNoContent {
-			fmt.Printf("Failed to delete: %s\n", res.Body())
-			return
+			return apiError("failed to delete", err)
 		}
 
-		fmt.Println("Successfully deleted")
+		info("Successfully moved to trash")
+		return nil
 	},
 }
 
@@ -220,53 +151,25 @@ var cardUpdateCmd = &cobra.Command{
 	Short: "update card information",
 	Long:  `update card information`,
 	Args:  cobra.ExactArgs(5),
-	Run: func(cmd *cobra.Command, args []string) {
-		client := resty.New()
-		token, err := auth(client)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := connect(cmd.Context())
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 
-		number, err := crypto.Encrypt(password, args[1])
+		err = client.UpdateCard(cmd.Context(), types.CreateCardRequest{
+			ID:           args[0],
+			Number:       args[1],
+			Expiration:   args[2],
+			CVV:          args[3],
+			Metadata:     args[4],
+			SearchTokens: cardTokens(args[1], args[4]),
+		})
 		if err != nil {
-			fmt.Printf("failed to encrypt: %v\n", err)
-			return
-		}
-		exp, err := crypto.Encrypt(password, args[2])
-		if err != nil {
-			fmt.Printf("failed to encrypt: %v\n", err)
-			return
-		}
-		cvv, err := crypto.Encrypt(password, args[3])
-		if err != nil {
-			fmt.Printf("failed to encrypt: %v\n", err)
-			return
-		}
-		md, err := crypto.Encrypt(password, args[4])
-		if err != nil {
-			fmt.Printf("failed to encrypt: %v\n", err)
-			return
-		}
-
-		data := fmt.Sprintf("{\"id\": \"%s\",\"number\": \"%s\", \"expiration\": \"%s\", \"cvv\": \"%s\", \"metadata\": \"%s\"}",
-			args[0], number, exp, cvv, md)
-
-		res, err := client.R().
-			SetHeader("Content-Type", "application/json").
-			SetHeader("Authorization", token).
-			SetBody(data).
-			Put(fmt.Sprintf("http://%s/api/secret/card", serverURL))
-		if err != nil {
-			fmt.Println("Unable to save data", err)
-			return
-		}
-
-		if res.StatusCode() != http.StatusOK {
-			fmt.Printf("Failed to save: %s\n", res.Body())
-			return
+			return apiError("failed to save", err)
 		}
 
-		fmt.Println("Successfully updated")
+		info("Successfully updated")
+		return nil
 	},
 }
@@ -0,0 +1,64 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"keeper-project/internal/clipboard"
+)
+
+// copyFlags are the flags of the get commands putting a field on the
+// clipboard instead of printing the record.
+type copyFlags struct {
+	field      string
+	clearAfter time.Duration
+	fields     []string
+}
+
+var (
+	credCopy copyFlags
+	cardCopy copyFlags
+)
+
+func (f *copyFlags) register(cmd *cobra.Command, fields ...string) {
+	f.fields = fields
+	cmd.Flags().StringVar(&f.field, "copy", "",
+		"copy the field to the clipboard instead of printing the record: "+strings.Join(fields, ", "))
+	cmd.Flags().DurationVar(&f.clearAfter, "clear-after", 45*time.Second, "clear the clipboard after that time, 0 keeps the field")
+}
+
+func (f *copyFlags) enabled() bool {
+	return f.field != ""
+}
+
+// copy puts the field on the clipboard and waits to clear it, Ctrl+C clears it at once.
+func (f *copyFlags) copy(cmd *cobra.Command, name string, values map[string]string) error {
+	if !slices.Contains(f.fields, f.field) {
+		return usageError(fmt.Errorf("unknown field %q, use %s", f.field, strings.Join(f.fields, ", ")))
+	}
+
+	cb, err := clipboard.New()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+	defer stop()
+
+	if f.clearAfter > 0 {
+		info("Copied %s of %s to the clipboard, it will be cleared in %s", f.field, name, f.clearAfter)
+	} else {
+		info("Copied %s of %s to the clipboard", f.field, name)
+	}
+	err = clipboard.CopyAndClear(ctx, cb, values[f.field], f.clearAfter)
+	if err == nil && f.clearAfter > 0 {
+		info("Clipboard cleared")
+	}
+	return err
+}
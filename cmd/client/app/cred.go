@@ -1,17 +1,23 @@
 package app
 
 import (
-	"encoding/json"
 	"fmt"
-	"net/http"
+	"io"
 
-	"github.com/go-resty/resty/v2"
 	"github.com/spf13/cobra"
 
-	"keeper-project/internal/crypto"
 	"keeper-project/types"
 )
 
+// credView is the schema of credentials get.
+type credView struct {
+	ID       string `json:"id"`
+	Site     string `json:"site"`
+	Login    string `json:"login"`
+	Password string `json:"password"`
+	Metadata string `json:"metadata"`
+}
+
 var credCmd = &cobra.Command{
 	Use:   "credentials",
 	Short: "easily store your credentials",
@@ -26,61 +32,46 @@ func init() {
 	credCmd.AddCommand(credGetCmd)
 	credCmd.AddCommand(credDeleteCmd)
 	credCmd.AddCommand(credUpdateCmd)
+
+	credsList.register(credsListCmd, "created, updated or name")
+	credCopy.register(credGetCmd, "password", "login", "site")
+
+	credCreateCmd.Flags().BoolVar(&credCreateGen.enabled, "generate", false, "generate the password, leave out its argument")
+	credCreateGen.register(credCreateCmd)
+	credUpdateCmd.Flags().BoolVar(&credUpdateGen.enabled, "generate", false, "generate the password, leave out its argument")
+	credUpdateGen.register(credUpdateCmd)
 }
 
 var credCreateCmd = &cobra.Command{
 	Use:   "create [site] [login] [password] [metadata]",
 	Short: "save credentials",
-	Long:  `save credentials`,
-	Args:  cobra.ExactArgs(4),
-	Run: func(cmd *cobra.Command, args []string) {
-		client := resty.New()
-		token, err := auth(client)
+	Long: `save credentials, with --generate the password is generated and printed
+instead of taken from the arguments`,
+	Args: credCreateGen.argsWithPassword(4),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := connect(cmd.Context())
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 
-		site, err := crypto.Encrypt(password, args[0])
-		if err != nil {
-			fmt.Printf("failed to encrypt: %v\n", err)
-			return
-		}
-		lgn, err := crypto.Encrypt(password, args[1])
+		args, err = credCreateGen.withPassword(args, 2)
 		if err != nil {
-			fmt.Printf("failed to encrypt: %v\n", err)
-			return
+			return fmt.Errorf("unable to generate password: %w", err)
 		}
-		pass, err := crypto.Encrypt(password, args[2])
-		if err != nil {
-			fmt.Printf("failed to encrypt: %v\n", err)
-			return
-		}
-		md, err := crypto.Encrypt(password, args[3])
-		if err != nil {
-			fmt.Printf("failed to encrypt: %v\n", err)
-			return
-		}
-
-		data := fmt.Sprintf("{\"site\": \"%s\", \"login\": \"%s\", \"password\": \"%s\", \"metadata\": \"%s\"}",
-			site, lgn, pass, md)
 
-		res, err := client.R().
-			SetHeader("Content-Type", "application/json").
-			SetHeader("Authorization", token).
-			SetBody(data).
-			Post(fmt.Sprintf("http://%s/api/secret/cred", serverURL))
+		err = client.CreateCredentials(cmd.Context(), types.CreateCredentialsRequest{
+			Site:         args[0],
+			Login:        args[1],
+			Password:     args[2],
+			Metadata:     args[3],
+			SearchTokens: credTokens(args[0], args[1], args[3]),
+		})
 		if err != nil {
-			fmt.Println("Unable to save data", err)
-			return
+			return apiError("failed to save", err)
 		}
 
-		if res.StatusCode() != http.StatusAccepted {
-			fmt.Printf("Failed to save: %s\n", res.Body())
-			return
-		}
-
-		fmt.Println("Successfully saved")
+		info("Successfully saved")
+		return credCreateGen.printGenerated(args[2])
 	},
 }
 
@@ -88,98 +79,58 @@ var credsListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "get saved credentials list",
 	Long:  `get saved credentials list`,
-	Run: func(cmd *cobra.Command, args []string) {
-		client := resty.New()
-
-		token, err := auth(client)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := connect(cmd.Context())
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 
-		var result []*types.Key
-
-		res, err := client.R().
-			SetHeader("Authorization", token).
-			SetResult(&result).
-			Get(fmt.Sprintf("http://%s/api/secret/creds", serverURL))
+		result, next, total, err := fetchList(cmd.Context(), client.ListCredentials, &credsList, keyName)
 		if err != nil {
-			fmt.Println("Unable to save data", err)
-			return
+			return apiError("failed to get", err)
 		}
 
-		if res.StatusCode() != http.StatusOK {
-			fmt.Printf("Failed to get: %s\n", res.Body())
-			return
-		}
-
-		for i := range result {
-			decrypted, err := crypto.Decrypt(password, result[i].Key)
-			if err != nil {
-				fmt.Printf("failed to decrypt: %v\n", err)
-				return
-			}
-			fmt.Printf("Site: %s, ID: %s\n", decrypted, result[i].Id)
-		}
+		return renderKeys("SITE", result, next, total, nil)
 	},
 }
 
 var credGetCmd = &cobra.Command{
 	Use:   "get [id]",
 	Short: "get credentials by id",
-	Long:  `get credentials by id, you can find ids in list command`,
-	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		client := resty.New()
-		token, err := auth(client)
+	Long: `get credentials by id, you can find ids in list command.
+With --copy the field is put on the clipboard and cleared after --clear-after instead of printed`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := connect(cmd.Context())
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 
-		var result types.Credentials
-
-		res, err := client.R().
-			SetHeader("Content-Type", "application/json").
-			SetHeader("Authorization", token).
-			SetResult(&result).
-			Get(fmt.Sprintf("http://%s/api/secret/cred/%s", serverURL, args[0]))
+		result, err := client.GetCredentials(cmd.Context(), args[0])
 		if err != nil {
-			fmt.Println("Unable to save data", err)
-			return
-		}
-
-		if res.StatusCode() != http.StatusOK {
-			fmt.Printf("Failed to get: %s\n", res.Body())
-			return
+			return apiError("failed to get", err)
 		}
 
-		result.Site, err = crypto.Decrypt(password, result.Site)
-		if err != nil {
-			fmt.Printf("failed to decrypt: %v\n", err)
-			return
-		}
-		result.Login, err = crypto.Decrypt(password, result.Login)
-		if err != nil {
-			fmt.Printf("failed to decrypt: %v\n", err)
-			return
-		}
-		result.Password, err = crypto.Decrypt(password, result.Password)
-		if err != nil {
-			fmt.Printf("failed to decrypt: %v\n", err)
-			return
-		}
-		result.Metadata, err = crypto.Decrypt(password, result.Metadata)
-		if err != nil {
-			fmt.Printf("failed to decrypt: %v\n", err)
-			return
+		if credCopy.enabled() {
+			err = credCopy.copy(cmd, result.Login+"@"+result.Site, map[string]string{
+				"password": result.Password,
+				"login":    result.Login,
+				"site":     result.Site,
+			})
+			if err != nil {
+				return fmt.Errorf("unable to copy: %w", err)
+			}
+			return nil
 		}
 
-		s, err := json.MarshalIndent(result, "", "\t")
-		if err != nil {
-			fmt.Println("failed to print: ", err)
-		}
-		fmt.Println(string(s))
+		view := credView{ID: args[0], Site: result.Site, Login: result.Login, Password: result.Password, Metadata: result.Metadata}
+		return render(view, func(w io.Writer) {
+			fmt.Fprintf(w, "ID:\t%s\n", view.ID)
+			fmt.Fprintf(w, "Site:\t%s\n", view.Site)
+			fmt.Fprintf(w, "Login:\t%s\n", view.Login)
+			fmt.Fprintf(w, "Password:\t%s\n", view.Password)
+			fmt.Fprintf(w, "Metadata:\t%s\n", view.Metadata)
+		})
 	},
 }
 
@@ -188,84 +139,52 @@ var credDeleteCmd = &cobra.Command{
 	Short: "delete credentials by id",
 	Long:  `delete credentials by id, you can find ids in list command`,
 	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		client := resty.New()
-		token, err := auth(client)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := connect(cmd.Context())
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 
-		res, err := client.R().
-			SetHeader("Content-Type", "application/json").
-			SetHeader("Authorization", token).
-			Delete(fmt.Sprintf("http://%s/api/secret/cred/%s", serverURL, args[0]))
+		err = client.DeleteCredentials(cmd.Context(), args[0])
 		if err != nil {
-			fmt.Println("Unable to delete data", err)
-			return
+			return apiError("failed to delete", err)
 		}
 
-		if res.StatusCode() != http.StatusNoContent {
-			fmt.Printf("Failed to delete: %s\n", res.Body())
-			return
-		}
-
-		fmt.Println("Successfully deleted")
+		info("Successfully moved to trash")
+		return nil
 	},
 }
 
 var credUpdateCmd = &cobra.Command{
 	Use:   "update [id] [site] [login] [password] [metadata]",
 	Short: "update credentials",
-	Long:  `update credentials`,
-	Args:  cobra.ExactArgs(5),
-	Run: func(cmd *cobra.Command, args []string) {
-		client := resty.New()
-		token, err := auth(client)
+	Long: `update credentials, with --generate the password is generated and printed
+instead of taken from the arguments`,
+	Args: credUpdateGen.argsWithPassword(5),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := connect(cmd.Context())
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 
-		site, err := crypto.Encrypt(password, args[1])
-		if err != nil {
-			fmt.Printf("failed to encrypt: %v\n", err)
-			return
-		}
-		lgn, err := crypto.Encrypt(password, args[2])
-		if err != nil {
-			fmt.Printf("failed to encrypt: %v\n", err)
-			return
-		}
-		pass, err := crypto.Encrypt(password, args[3])
+		args, err = credUpdateGen.withPassword(args, 3)
 		if err != nil {
-			fmt.Printf("failed to encrypt: %v\n", err)
-			return
+			return fmt.Errorf("unable to generate password: %w", err)
 		}
-		md, err := crypto.Encrypt(password, args[4])
-		if err != nil {
-			fmt.Printf("failed to encrypt: %v\n", err)
-			return
-		}
-
-		data := fmt.Sprintf("{\"id\": \"%s\",\"site\": \"%s\", \"login\": \"%s\", \"password\": \"%s\", \"metadata\": \"%s\"}",
-			args[0], site, lgn, pass, md)
 
-		res, err := client.R().
-			SetHeader("Content-Type", "application/json").
-			SetHeader("Authorization", token).
-			SetBody(data).
-			Put(fmt.Sprintf("http://%s/api/secret/cred", serverURL))
+		err = client.UpdateCredentials(cmd.Context(), types.UpdateCredentialsRequest{
+			ID:           args[0],
+			Site:         args[1],
+			Login:        args[2],
+			Password:     args[3],
+			Metadata:     args[4],
+			SearchTokens: credTokens(args[1], args[2], args[4]),
+		})
 		if err != nil {
-			fmt.Println("Unable to save data", err)
-			return
-		}
-
-		if res.StatusCode() != http.StatusOK {
-			fmt.Printf("Failed to save: %s\n", res.Body())
-			return
+			return apiError("failed to save", err)
 		}
 
-		fmt.Println("Successfully updated")
+		info("Successfully updated")
+		return credUpdateGen.printGenerated(args[3])
 	},
 }
@@ -1,14 +1,19 @@
 package app
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
-	"net/http"
+	"io"
+	"os"
+	"time"
 
-	"github.com/go-resty/resty/v2"
+	"github.com/docker/go-units"
 	"github.com/spf13/cobra"
 
 	"keeper-project/internal/crypto"
-	"keeper-project/types"
+	"keeper-project/pkg/keeperclient"
 )
 
 var fileCmd = &cobra.Command{
@@ -24,43 +29,67 @@ func init() {
 	fileCmd.AddCommand(filesListCmd)
 	fileCmd.AddCommand(fileGetCmd)
 	fileCmd.AddCommand(fileDeleteCmd)
+
+	fileCreateCmd.Flags().BoolVar(&dedupUpload, "dedup", false,
+		"encrypt identical files identically, so contents you have stored already aren't sent again; the server learns which of your files are the same")
+	fileGetCmd.Flags().BoolVar(&resumeDownload, "resume", false, "continue an interrupted download into an existing file")
+
+	filesList.register(filesListCmd, "created, name or size")
+}
+
+var (
+	resumeDownload bool
+	dedupUpload    bool
+)
+
+// fileView is an item of file list.
+type fileView struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	Hash      string    `json:"hash"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// fileGetView is the schema of file get, Size is the size of the saved file.
+type fileGetView struct {
+	ID       string `json:"id"`
+	Path     string `json:"path"`
+	Size     int64  `json:"size"`
+	Metadata string `json:"metadata"`
 }
 
 var fileCreateCmd = &cobra.Command{
 	Use:   "create [path] [metadata]",
 	Short: "save file and metadata",
-	Long:  `save file and metadata`,
+	Long:  `save file and metadata, the file is sent in chunks and an interrupted upload continues when the command is run again`,
 	Args:  cobra.ExactArgs(2),
-	Run: func(cmd *cobra.Command, args []string) {
-		client := resty.New()
-		token, err := auth(client)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := connect(cmd.Context())
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 
-		md, err := crypto.Encrypt(password, args[1])
-		if err != nil {
-			fmt.Printf("failed to encrypt: %v\n", err)
-			return
+		var salt []byte
+		if dedupUpload {
+			var id string
+			id, salt, err = linkStoredFile(cmd.Context(), client, args[0], args[1])
+			if err != nil {
+				return fmt.Errorf("unable to save data: %w", err)
+			}
+			if id != "" {
+				info("Contents already stored, successfully saved, ID: %s", id)
+				return nil
+			}
 		}
 
-		res, err := client.R().
-			SetFormData(map[string]string{"Metadata": md}).
-			SetHeader("Authorization", token).
-			SetFile("file", args[0]).
-			Post(fmt.Sprintf("http://%s/api/secret/file", serverURL))
+		id, err := uploadLocalFile(cmd.Context(), client, args[0], args[1], salt)
 		if err != nil {
-			fmt.Println("Unable to save data", err)
-			return
-		}
-
-		if res.StatusCode() != http.StatusCreated {
-			fmt.Printf("Failed to save: %s\n", res.Body())
-			return
+			return fmt.Errorf("unable to save data: %w", err)
 		}
 
-		fmt.Println("Successfully saved")
+		info("Successfully saved, ID: %s", id)
+		return nil
 	},
 }
 
@@ -68,72 +97,128 @@ var filesListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "get saved files list",
 	Long:  `get saved files list`,
-	Run: func(cmd *cobra.Command, args []string) {
-		client := resty.New()
-
-		token, err := auth(client)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := connect(cmd.Context())
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 
-		var result []*types.Key
-
-		res, err := client.R().
-			SetHeader("Authorization", token).
-			SetResult(&result).
-			Get(fmt.Sprintf("http://%s/api/secret/files", serverURL))
+		result, next, total, err := fetchList(cmd.Context(), client.ListFiles, &filesList, fileName)
 		if err != nil {
-			fmt.Println("Unable to save data", err)
-			return
+			return apiError("failed to get", err)
 		}
 
-		if res.StatusCode() != http.StatusOK {
-			fmt.Printf("Failed to get: %s\n", res.Body())
-			return
+		items := make([]fileView, 0, len(result))
+		for _, f := range result {
+			items = append(items, fileView{ID: f.ID, Name: f.Name, Size: f.Size, Hash: f.Hash, CreatedAt: f.CreatedAt})
 		}
 
-		for i := range result {
-			fmt.Printf("Name: %s, ID: %s\n", result[i].Key, result[i].Id)
-		}
+		view := newListView(items, next, total)
+		return render(view, func(w io.Writer) {
+			fmt.Fprintln(w, "NAME\tID\tSIZE\tCREATED")
+			for _, f := range items {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", f.Name, f.ID, units.HumanSize(float64(f.Size)), formatTime(&f.CreatedAt))
+			}
+			printNextPage(w, len(items), next, view.Total)
+		})
 	},
 }
 
 var fileGetCmd = &cobra.Command{
 	Use:   "get [id] [path-to-save]",
 	Short: "get file by id",
-	Long:  `get file by id, you can find ids in list command`,
+	Long:  `get file by id, you can find ids in list command. The file is decrypted and verified while it is written`,
 	Args:  cobra.ExactArgs(2),
-	Run: func(cmd *cobra.Command, args []string) {
-		client := resty.New()
-		token, err := auth(client)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := connect(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		// only whole decrypted chunks are ever written, so a download is resumed
+		// from the last complete chunk of the local file.
+		var (
+			offset int64
+			first  int64
+			start  int64
+			salt   []byte
+		)
+		if resumeDownload {
+			if info, err := os.Stat(args[1]); err == nil && info.Size() > 0 {
+				salt, _, err = getFileHeader(cmd.Context(), client, args[0])
+				switch {
+				case err == nil:
+					first = info.Size() / crypto.FileChunkSize
+					offset = first * crypto.FileChunkSize
+					start = crypto.ChunkOffset(first)
+				case errors.Is(err, crypto.ErrNotEncrypted):
+					offset = info.Size()
+					start = offset
+				default:
+					return apiError("unable to get data", err)
+				}
+			}
+		}
+
+		d, err := client.DownloadFile(cmd.Context(), args[0], start, 0)
+		if errors.Is(err, keeperclient.ErrRangeNotSatisfiable) {
+			return errors.New("file is already downloaded")
+		}
 		if err != nil {
-			fmt.Println(err)
-			return
+			return apiError("failed to get", err)
+		}
+		defer d.Body.Close()
+
+		// the server sends the whole file when it can't seek
+		if d.Offset == 0 {
+			offset, first, salt = 0, 0, nil
+		}
+
+		src := bufio.NewReader(d.Body)
+		var content io.Reader = src
+		if salt == nil && offset == 0 {
+			prefix, _ := src.Peek(crypto.FileHeaderSize)
+			if crypto.IsEncryptedFile(prefix) {
+				salt, err = crypto.ReadFileHeader(src)
+				if err != nil {
+					return fmt.Errorf("unable to get data: %w", err)
+				}
+			} else {
+				fmt.Fprintln(os.Stderr, "Warning: the file was stored unencrypted")
+			}
+		}
+		if salt != nil {
+			fc, err := crypto.NewFileCipher(password, salt)
+			if err != nil {
+				return fmt.Errorf("failed to decrypt: %w", err)
+			}
+			content = fc.NewReader(src, first)
 		}
 
-		res, err := client.R().
-			SetHeader("Content-Type", "application/json").
-			SetHeader("Authorization", token).
-			SetOutput(args[1]).
-			Get(fmt.Sprintf("http://%s/api/secret/file/%s", serverURL, args[0]))
+		out, err := os.OpenFile(args[1], os.O_CREATE|os.O_WRONLY, 0600)
 		if err != nil {
-			fmt.Println("Unable to save data", err)
-			return
+			return fmt.Errorf("unable to open file: %w", err)
 		}
+		defer out.Close()
 
-		if res.StatusCode() != http.StatusOK {
-			fmt.Printf("Failed to get: %s\n", res.Body())
-			return
+		err = out.Truncate(offset)
+		if err == nil {
+			_, err = out.Seek(offset, io.SeekStart)
+		}
+		if err != nil {
+			return fmt.Errorf("unable to write file: %w", err)
 		}
 
-		metadata, err := crypto.Decrypt(password, res.Header().Get("Meta"))
+		n, err := io.Copy(out, content)
 		if err != nil {
-			fmt.Printf("failed to decrypt: %v\n", err)
-			return
+			return fmt.Errorf("download failed after %d bytes, run again with --resume: %w", offset+n, err)
 		}
 
-		fmt.Println("Metadata: ", metadata)
+		view := fileGetView{ID: args[0], Path: args[1], Size: offset + n, Metadata: d.Metadata}
+		return render(view, func(w io.Writer) {
+			fmt.Fprintf(w, "Saved:\t%s\n", view.Path)
+			fmt.Fprintf(w, "Metadata:\t%s\n", view.Metadata)
+		})
 	},
 }
 
@@ -142,28 +227,31 @@ var fileDeleteCmd = &cobra.Command{
 	Short: "delete file by id",
 	Long:  `delete file by id, you can find ids in list command`,
 	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		client := resty.New()
-		token, err := auth(client)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := connect(cmd.Context())
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 
-		res, err := client.R().
-			SetHeader("Content-Type", "application/json").
-			SetHeader("Authorization", token).
-			Delete(fmt.Sprintf("http://%s/api/secret/file/%s", serverURL, args[0]))
+		err = client.DeleteFile(cmd.Context(), args[0])
 		if err != nil {
-			fmt.Println("Unable to delete data", err)
-			return
-		}
-
-		if res.StatusCode() != http.StatusNoContent {
-			fmt.Printf("Failed to delete: %s\n", res.Body())
-			return
+			return apiError("failed to delete", err)
 		}
 
-		fmt.Println("Successfully deleted")
+		info("Successfully moved to trash")
+		return nil
 	},
 }
+
+// getFileHeader fetches the header and the metadata of a stored file,
+// crypto.ErrNotEncrypted is returned along with the metadata for files stored unencrypted.
+func getFileHeader(ctx context.Context, client *keeperclient.Client, id string) ([]byte, string, error) {
+	d, err := client.DownloadFile(ctx, id, 0, int64(crypto.FileHeaderSize))
+	if err != nil {
+		return nil, "", err
+	}
+	defer d.Body.Close()
+
+	salt, err := crypto.ReadFileHeader(d.Body)
+	return salt, d.Metadata, err
+}
@@ -0,0 +1,140 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"keeper-project/internal/generator"
+)
+
+// password generation modes
+const (
+	modeChars         = "chars"
+	modePassphrase    = "passphrase"
+	modePronounceable = "pronounceable"
+)
+
+// generateFlags are the flags of the generate command, the credentials
+// commands use them with --generate.
+type generateFlags struct {
+	enabled   bool
+	mode      string
+	opts      generator.Options
+	words     int
+	separator string
+}
+
+var (
+	generateCmdFlags generateFlags
+	credCreateGen    generateFlags
+	credUpdateGen    generateFlags
+)
+
+func (f *generateFlags) register(cmd *cobra.Command) {
+	d := generator.DefaultOptions
+	cmd.Flags().StringVar(&f.mode, "mode", modeChars, "chars, passphrase of diceware words or pronounceable")
+	cmd.Flags().IntVar(&f.opts.Length, "length", d.Length, "length of chars and pronounceable passwords")
+	cmd.Flags().BoolVar(&f.opts.Lower, "lower", d.Lower, "use lower case letters")
+	cmd.Flags().BoolVar(&f.opts.Upper, "upper", d.Upper, "use upper case letters")
+	cmd.Flags().BoolVar(&f.opts.Digits, "digits", d.Digits, "use digits")
+	cmd.Flags().BoolVar(&f.opts.Symbols, "symbols", d.Symbols, "use symbols")
+	cmd.Flags().BoolVar(&f.opts.ExcludeAmbiguous, "exclude-ambiguous", false, "do not use characters like l, 1, O and 0")
+	cmd.Flags().IntVar(&f.words, "words", 6, "number of words of a passphrase")
+	cmd.Flags().StringVar(&f.separator, "separator", "-", "separator of the words of a passphrase")
+}
+
+func (f *generateFlags) generate() (string, error) {
+	switch f.mode {
+	case modeChars:
+		return generator.Password(f.opts)
+	case modePassphrase:
+		return generator.Passphrase(f.words, f.separator)
+	case modePronounceable:
+		return generator.Pronounceable(f.opts.Length)
+	}
+	return "", fmt.Errorf("unknown mode %q, use chars, passphrase or pronounceable", f.mode)
+}
+
+// withPassword returns the arguments with a generated password inserted at i
+// when --generate is set, otherwise it warns about a weak password at i.
+func (f *generateFlags) withPassword(args []string, i int) ([]string, error) {
+	if !f.enabled {
+		warnWeak(args[i])
+		return args, nil
+	}
+
+	pw, err := f.generate()
+	if err != nil {
+		return nil, err
+	}
+	return append(append(args[:i:i], pw), args[i:]...), nil
+}
+
+// generatedView is the schema of generate and of the password generated by
+// --generate.
+type generatedView struct {
+	Password string  `json:"password"`
+	Strength string  `json:"strength,omitempty"`
+	Entropy  float64 `json:"entropy,omitempty"`
+}
+
+// printGenerated shows the password once the record is saved.
+func (f *generateFlags) printGenerated(pw string) error {
+	if !f.enabled {
+		return nil
+	}
+	return render(generatedView{Password: pw}, func(w io.Writer) {
+		fmt.Fprintf(w, "Generated password: %s\n", pw)
+	})
+}
+
+// argsWithPassword accepts n arguments, one less when the password is generated.
+func (f *generateFlags) argsWithPassword(n int) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if f.enabled {
+			return cobra.ExactArgs(n-1)(cmd, args)
+		}
+		return cobra.ExactArgs(n)(cmd, args)
+	}
+}
+
+func warnWeak(pw string) {
+	s := generator.Estimate(pw)
+	if s.Weak() {
+		fmt.Fprintf(os.Stderr, "Warning: the password is %s (%.0f bits), consider --generate\n", s, s.Entropy)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(generateCmd)
+
+	generateCmdFlags.register(generateCmd)
+}
+
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "generate a random password",
+	Long: `generate a random password of characters, a passphrase of diceware words
+or a pronounceable password, and print its estimated strength`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pw, err := generateCmdFlags.generate()
+		if err != nil {
+			return fmt.Errorf("unable to generate password: %w", err)
+		}
+
+		s := generator.Estimate(pw)
+		if generateCmdFlags.mode == modePassphrase {
+			s = generator.Grade(min(s.Entropy, generator.PassphraseEntropy(generateCmdFlags.words)))
+		}
+		view := generatedView{Password: pw, Strength: s.String(), Entropy: math.Round(s.Entropy)}
+		return render(view, func(w io.Writer) {
+			fmt.Fprintln(w, pw)
+			fmt.Fprintf(w, "Strength: %s (%.0f bits)\n", s, s.Entropy)
+		})
+	},
+}
@@ -1,8 +1,8 @@
 package app
 
 import (
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
 
 	"github.com/spf13/cobra"
@@ -12,22 +12,21 @@ var pingCmd = &cobra.Command{
 	Use:   "ping",
 	Short: "Check server connection",
 	Long:  `Check server connection`,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		if serverURL == "" {
-			fmt.Println("Please specify server addr")
-			return
+			return usageError(errors.New("please specify server addr"))
 		}
 
 		resp, err := http.Get("http://" + serverURL + "/ping")
 		if err != nil {
-			log.Fatalln(err)
-			return
+			return fmt.Errorf("bad connection: %w", err)
 		}
-		if resp.StatusCode == http.StatusOK {
-			fmt.Println("Connection is OK")
-		} else {
-			fmt.Printf("Bad connection: %v\n", resp.StatusCode)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("bad connection: %v", resp.StatusCode)
 		}
+		info("Connection is OK")
+		return nil
 	},
 }
 
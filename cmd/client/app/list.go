@@ -0,0 +1,189 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"keeper-project/types"
+)
+
+// listFlags are the flags of the list commands. Without --limit every page is
+// fetched, with it a single page is shown together with the cursor of the
+// next one to pass to --cursor.
+type listFlags struct {
+	sort   string
+	desc   bool
+	limit  int
+	cursor string
+	since  string
+}
+
+var (
+	notesList listFlags
+	cardsList listFlags
+	credsList listFlags
+	filesList listFlags
+)
+
+func (f *listFlags) register(cmd *cobra.Command, sorts string) {
+	cmd.Flags().StringVar(&f.sort, "sort", types.SortCreated, "sort by "+sorts)
+	cmd.Flags().BoolVar(&f.desc, "desc", false, "sort in descending order")
+	cmd.Flags().IntVar(&f.limit, "limit", 0, "show a single page of that many records, 0 shows all")
+	cmd.Flags().StringVar(&f.cursor, "cursor", "", "show the page after the one that printed the cursor")
+	cmd.Flags().StringVar(&f.since, "since", "", "show records created since the date (2006-01-02) or RFC 3339 time")
+}
+
+// options parses the flags into the options of the list.
+func (f *listFlags) options() (types.ListOptions, error) {
+	opts := types.ListOptions{Sort: f.sort, Desc: f.desc, Cursor: f.cursor}
+	if f.sort == types.SortName && f.cursor != "" {
+		return opts, usageError(fmt.Errorf("--cursor can't be used with --sort %s, the names are sorted by the client", types.SortName))
+	}
+	if f.since == "" {
+		return opts, nil
+	}
+
+	var err error
+	opts.Since, err = time.Parse(time.RFC3339, f.since)
+	if err != nil {
+		opts.Since, err = time.Parse(time.DateOnly, f.since)
+	}
+	if err != nil {
+		return opts, usageError(fmt.Errorf("incorrect since %q", f.since))
+	}
+	return opts, nil
+}
+
+// lister is a list method of the client.
+type lister[T any] func(context.Context, types.ListOptions) (*types.Page[T], error)
+
+// fetchList gets the records selected by the flags and the cursor of the next
+// page, which is only set when a single page was requested. name returns the
+// decrypted name of an item: the server stores the names encrypted and can't
+// sort by them, so sorting by name fetches the whole list and sorts it here.
+func fetchList[T any](ctx context.Context, list lister[T], f *listFlags, name func(T) string) ([]T, string, int64, error) {
+	opts, err := f.options()
+	if err != nil {
+		return nil, "", 0, err
+	}
+	if opts.Sort == types.SortName {
+		items, total, err := fetchAll(ctx, list, types.ListOptions{Since: opts.Since})
+		if err != nil {
+			return nil, "", 0, err
+		}
+		sortByName(items, name, opts.Desc)
+		if f.limit > 0 && len(items) > f.limit {
+			items = items[:f.limit]
+		}
+		return items, "", total, nil
+	}
+	if f.limit > 0 {
+		opts.Limit = f.limit
+		page, err := list(ctx, opts)
+		if err != nil {
+			return nil, "", 0, err
+		}
+		return page.Items, page.Next, page.Total, nil
+	}
+
+	items, total, err := fetchAll(ctx, list, opts)
+	return items, "", total, err
+}
+
+// fetchAll follows the cursors until the last page of the list.
+func fetchAll[T any](ctx context.Context, list lister[T], opts types.ListOptions) ([]T, int64, error) {
+	opts.Limit = types.MaxPageSize
+
+	var all []T
+	for {
+		page, err := list(ctx, opts)
+		if err != nil {
+			return nil, 0, err
+		}
+		all = append(all, page.Items...)
+		if page.Next == "" {
+			return all, page.Total, nil
+		}
+		opts.Cursor = page.Next
+	}
+}
+
+// sortByName sorts the items by their names ignoring the case, the order of
+// the server breaks ties.
+func sortByName[T any](items []T, name func(T) string, desc bool) {
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := strings.ToLower(name(items[i])), strings.ToLower(name(items[j]))
+		if desc {
+			return a > b
+		}
+		return a < b
+	})
+}
+
+// keyName is the name of an item of the notes, cards and credentials lists.
+func keyName(k *types.Key) string {
+	return k.Key
+}
+
+func fileName(f *types.FileInfo) string {
+	return f.Name
+}
+
+// listView is the schema of the list commands, NextCursor is only set when a
+// single page was requested and there are more.
+type listView[T any] struct {
+	Items      []T    `json:"items"`
+	Total      int64  `json:"total"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+func newListView[T any](items []T, next string, total int64) listView[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return listView[T]{Items: items, Total: max(total, int64(len(items))), NextCursor: next}
+}
+
+// keyView is a decrypted item of the notes, cards and credentials lists.
+type keyView struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// renderKeys renders the names of a list under the header of the name
+// column, show may shorten a name.
+func renderKeys(header string, keys []*types.Key, next string, total int64, show func(string) string) error {
+	items := make([]keyView, 0, len(keys))
+	for _, k := range keys {
+		name := k.Key
+		if show != nil {
+			name = show(name)
+		}
+		items = append(items, keyView{ID: k.Id, Name: name, CreatedAt: k.CreatedAt, UpdatedAt: k.UpdatedAt})
+	}
+
+	view := newListView(items, next, total)
+	return render(view, func(w io.Writer) {
+		fmt.Fprintf(w, "%s\tID\tCREATED\tUPDATED\n", header)
+		for _, item := range items {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", item.Name, item.ID, formatTime(item.CreatedAt), formatTime(item.UpdatedAt))
+		}
+		printNextPage(w, len(items), next, view.Total)
+	})
+}
+
+// printNextPage tells how to get the rest of a single page listing.
+func printNextPage(w io.Writer, shown int, next string, total int64) {
+	if next == "" {
+		return
+	}
+	fmt.Fprintf(w, "Shown %d of %d, next page: --cursor %s\n", shown, total, next)
+}
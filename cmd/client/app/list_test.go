@@ -0,0 +1,41 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"keeper-project/types"
+)
+
+func TestFetchList_SortByName(t *testing.T) {
+	var requested []types.ListOptions
+	list := func(_ context.Context, opts types.ListOptions) (*types.Page[*types.Key], error) {
+		requested = append(requested, opts)
+		if opts.Cursor == "" {
+			return &types.Page[*types.Key]{Items: []*types.Key{{Id: "1", Key: "gitlab"}, {Id: "2", Key: "Bank"}}, Total: 3, Next: "next"}, nil
+		}
+		return &types.Page[*types.Key]{Items: []*types.Key{{Id: "3", Key: "aws"}}, Total: 3}, nil
+	}
+
+	// the names are encrypted on the server, the whole list is sorted by the client
+	f := &listFlags{sort: types.SortName, desc: true, limit: 2}
+	items, next, total, err := fetchList(context.Background(), list, f, keyName)
+	require.NoError(t, err)
+	assert.Equal(t, []*types.Key{{Id: "1", Key: "gitlab"}, {Id: "2", Key: "Bank"}}, items)
+	assert.Empty(t, next)
+	assert.Equal(t, int64(3), total)
+	require.Len(t, requested, 2)
+	assert.Equal(t, types.ListOptions{Limit: types.MaxPageSize}, requested[0])
+
+	f = &listFlags{sort: types.SortName}
+	items, _, _, err = fetchList(context.Background(), list, f, keyName)
+	require.NoError(t, err)
+	assert.Equal(t, []*types.Key{{Id: "3", Key: "aws"}, {Id: "2", Key: "Bank"}, {Id: "1", Key: "gitlab"}}, items)
+
+	f = &listFlags{sort: types.SortName, cursor: "next"}
+	_, _, _, err = fetchList(context.Background(), list, f, keyName)
+	assert.ErrorContains(t, err, "--cursor can't be used")
+}
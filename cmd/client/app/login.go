@@ -1,35 +1,39 @@
 package app
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"net/http"
 
-	"github.com/go-resty/resty/v2"
+	"keeper-project/pkg/keeperclient"
 )
 
-func auth(client *resty.Client) (string, error) {
+// connect returns the client logged in with the flags, it encrypts the
+// records with the password.
+func connect(ctx context.Context) (*keeperclient.Client, error) {
 	if login == "" || password == "" {
-		return "", errors.New("Please provide login and password flags or register first")
+		return nil, usageError(errors.New("please provide login and password flags or register first"))
 	}
 
-	if serverURL == "" {
-		return "", errors.New("Please specify server addr flag")
+	client, err := newClient()
+	if err != nil {
+		return nil, err
 	}
 
-	data := fmt.Sprintf("{\"login\": \"%s\", \"password\": \"%s\"}", login, password)
-
-	res, err := client.R().
-		SetHeader("Content-Type", "application/json").
-		SetBody(data).
-		Post(fmt.Sprintf("http://%s/api/user/login", serverURL))
+	err = client.Login(ctx, login, password)
 	if err != nil {
-		return "", err
+		var respErr *keeperclient.APIError
+		if errors.As(err, &respErr) {
+			return nil, &exitError{code: exitAuth, err: fmt.Errorf("failed to login: %s", respErr.Message)}
+		}
+		return nil, fmt.Errorf("failed to login: %w", err)
 	}
+	return client, nil
+}
 
-	if res.StatusCode() != http.StatusOK {
-		return "", errors.New(fmt.Sprintf("Failed to login: %s\n", res.Body()))
+func newClient() (*keeperclient.Client, error) {
+	if serverURL == "" {
+		return nil, usageError(errors.New("please specify server addr flag"))
 	}
-
-	return res.Header().Get("Authorization"), nil
+	return keeperclient.New("http://"+serverURL, keeperclient.WithCipher(keeperclient.PasswordCipher(password))), nil
 }
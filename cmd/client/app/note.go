@@ -1,17 +1,22 @@
 package app
 
 import (
-	"encoding/json"
 	"fmt"
-	"net/http"
+	"io"
 
-	"github.com/go-resty/resty/v2"
 	"github.com/spf13/cobra"
 
-	"keeper-project/internal/crypto"
 	"keeper-project/types"
 )
 
+// noteView is the schema of note get.
+type noteView struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Text     string `json:"text"`
+	Metadata string `json:"metadata"`
+}
+
 var noteCmd = &cobra.Command{
 	Use:   "note",
 	Short: "easily store your notes",
@@ -26,6 +31,8 @@ func init() {
 	noteCmd.AddCommand(noteGetCmd)
 	noteCmd.AddCommand(noteDeleteCmd)
 	noteCmd.AddCommand(noteUpdateCmd)
+
+	notesList.register(notesListCmd, "created, updated or name")
 }
 
 var noteCreateCmd = &cobra.Command{
@@ -33,49 +40,24 @@ var noteCreateCmd = &cobra.Command{
 	Short: "save notes with title",
 	Long:  `save notes with title`,
 	Args:  cobra.ExactArgs(3),
-	Run: func(cmd *cobra.Command, args []string) {
-		client := resty.New()
-		token, err := auth(client)
-		if err != nil {
-			fmt.Println(err)
-			return
-		}
-
-		key, err := crypto.Encrypt(password, args[0])
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := connect(cmd.Context())
 		if err != nil {
-			fmt.Printf("failed to encrypt: %v\n", err)
-			return
+			return err
 		}
-		text, err := crypto.Encrypt(password, args[1])
-		if err != nil {
-			fmt.Printf("failed to encrypt: %v\n", err)
-			return
-		}
-		md, err := crypto.Encrypt(password, args[2])
-		if err != nil {
-			fmt.Printf("failed to encrypt: %v\n", err)
-			return
-		}
-
-		data := fmt.Sprintf("{\"key\": \"%s\", \"data\": \"%s\", \"metadata\": \"%s\"}",
-			key, text, md)
 
-		res, err := client.R().
-			SetHeader("Content-Type", "application/json").
-			SetHeader("Authorization", token).
-			SetBody(data).
-			Post(fmt.Sprintf("http://%s/api/secret/text", serverURL))
+		err = client.CreateNote(cmd.Context(), types.CreateNoteRequest{
+			Key:          args[0],
+			Data:         args[1],
+			Metadata:     args[2],
+			SearchTokens: noteTokens(args[0], args[1], args[2]),
+		})
 		if err != nil {
-			fmt.Println("Unable to save data", err)
-			return
+			return apiError("failed to save", err)
 		}
 
-		if res.StatusCode() != http.StatusAccepted {
-			fmt.Printf("Failed to save: %s\n", res.Body())
-			return
-		}
-
-		fmt.Println("Successfully saved")
+		info("Successfully saved")
+		return nil
 	},
 }
 
@@ -83,39 +65,18 @@ var notesListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "get saved notes list",
 	Long:  `get saved notes list`,
-	Run: func(cmd *cobra.Command, args []string) {
-		client := resty.New()
-
-		token, err := auth(client)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := connect(cmd.Context())
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 
-		var result []*types.Key
-
-		res, err := client.R().
-			SetHeader("Authorization", token).
-			SetResult(&result).
-			Get(fmt.Sprintf("http://%s/api/secret/texts", serverURL))
+		result, next, total, err := fetchList(cmd.Context(), client.ListNotes, &notesList, keyName)
 		if err != nil {
-			fmt.Println("Unable to save data", err)
-			return
-		}
-
-		if res.StatusCode() != http.StatusOK {
-			fmt.Printf("Failed to get: %s\n", res.Body())
-			return
+			return apiError("failed to get", err)
 		}
 
-		for i := range result {
-			decrypted, err := crypto.Decrypt(password, result[i].Key)
-			if err != nil {
-				fmt.Printf("failed to decrypt: %v\n", err)
-				return
-			}
-			fmt.Printf("Title: %s, ID: %s\n", decrypted, result[i].Id)
-		}
+		return renderKeys("TITLE", result, next, total, nil)
 	},
 }
 
@@ -124,52 +85,24 @@ var noteGetCmd = &cobra.Command{
 	Short: "get note by id",
 	Long:  `get note by id, you can find ids in list command`,
 	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		client := resty.New()
-		token, err := auth(client)
-		if err != nil {
-			fmt.Println(err)
-			return
-		}
-
-		var result types.Note
-
-		res, err := client.R().
-			SetHeader("Content-Type", "application/json").
-			SetHeader("Authorization", token).
-			SetResult(&result).
-			Get(fmt.Sprintf("http://%s/api/secret/text/%s", serverURL, args[0]))
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := connect(cmd.Context())
 		if err != nil {
-			fmt.Println("Unable to save data", err)
-			return
-		}
-
-		if res.StatusCode() != http.StatusOK {
-			fmt.Printf("Failed to get: %s\n", res.Body())
-			return
+			return err
 		}
 
-		result.Key, err = crypto.Decrypt(password, result.Key)
+		result, err := client.GetNote(cmd.Context(), args[0])
 		if err != nil {
-			fmt.Printf("failed to decrypt: %v\n", err)
-			return
-		}
-		result.Text, err = crypto.Decrypt(password, result.Text)
-		if err != nil {
-			fmt.Printf("failed to decrypt: %v\n", err)
-			return
-		}
-		result.Metadata, err = crypto.Decrypt(password, result.Metadata)
-		if err != nil {
-			fmt.Printf("failed to decrypt: %v\n", err)
-			return
+			return apiError("failed to get", err)
 		}
 
-		s, err := json.MarshalIndent(result, "", "\t")
-		if err != nil {
-			fmt.Println("failed to print: ", err)
-		}
-		fmt.Println(string(s))
+		view := noteView{ID: args[0], Title: result.Key, Text: result.Text, Metadata: result.Metadata}
+		return render(view, func(w io.Writer) {
+			fmt.Fprintf(w, "ID:\t%s\n", view.ID)
+			fmt.Fprintf(w, "Title:\t%s\n", view.Title)
+			fmt.Fprintf(w, "Text:\t%s\n", view.Text)
+			fmt.Fprintf(w, "Metadata:\t%s\n", view.Metadata)
+		})
 	},
 }
 
@@ -178,29 +111,19 @@ var noteDeleteCmd = &cobra.Command{
 	Short: "delete note by id",
 	Long:  `delete note by id, you can find ids in list command`,
 	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		client := resty.New()
-		token, err := auth(client)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := connect(cmd.Context())
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 
-		res, err := client.R().
-			SetHeader("Content-Type", "application/json").
-			SetHeader("Authorization", token).
-			Delete(fmt.Sprintf("http://%s/api/secret/text/%s", serverURL, args[0]))
+		err = client.DeleteNote(cmd.Context(), args[0])
 		if err != nil {
-			fmt.Println("Unable to delete data", err)
-			return
-		}
-
-		if res.StatusCode() != http.StatusNoContent {
-			fmt.Printf("Failed to delete: %s\n", res.Body())
-			return
+			return apiError("failed to delete", err)
 		}
 
-		fmt.Println("Successfully deleted")
+		info("Successfully moved to trash")
+		return nil
 	},
 }
 
@@ -209,48 +132,24 @@ var noteUpdateCmd = &cobra.Command{
 	Short: "update note",
 	Long:  `update note`,
 	Args:  cobra.ExactArgs(4),
-	Run: func(cmd *cobra.Command, args []string) {
-		client := resty.New()
-		token, err := auth(client)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := connect(cmd.Context())
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 
-		title, err := crypto.Encrypt(password, args[1])
+		err = client.UpdateNote(cmd.Context(), types.UpdateNoteRequest{
+			ID:           args[0],
+			Key:          args[1],
+			Data:         args[2],
+			Metadata:     args[3],
+			SearchTokens: noteTokens(args[1], args[2], args[3]),
+		})
 		if err != nil {
-			fmt.Printf("failed to encrypt: %v\n", err)
-			return
-		}
-		text, err := crypto.Encrypt(password, args[2])
-		if err != nil {
-			fmt.Printf("failed to encrypt: %v\n", err)
-			return
-		}
-		md, err := crypto.Encrypt(password, args[3])
-		if err != nil {
-			fmt.Printf("failed to encrypt: %v\n", err)
-			return
-		}
-
-		data := fmt.Sprintf("{\"id\": \"%s\",\"key\": \"%s\", \"data\": \"%s\", \"metadata\": \"%s\"}",
-			args[0], title, text, md)
-
-		res, err := client.R().
-			SetHeader("Content-Type", "application/json").
-			SetHeader("Authorization", token).
-			SetBody(data).
-			Put(fmt.Sprintf("http://%s/api/secret/text", serverURL))
-		if err != nil {
-			fmt.Println("Unable to save data", err)
-			return
-		}
-
-		if res.StatusCode() != http.StatusOK {
-			fmt.Printf("Failed to save: %s\n", res.Body())
-			return
+			return apiError("failed to save", err)
 		}
 
-		fmt.Println("Successfully updated")
+		info("Successfully updated")
+		return nil
 	},
 }
@@ -0,0 +1,218 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// output formats of the --output flag
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+	outputEnv   = "env"
+)
+
+// envPrefix starts the names of the variables of the env format.
+const envPrefix = "KEEPER"
+
+var outputFormat string
+
+// stdout is where the results are rendered.
+var stdout io.Writer = os.Stdout
+
+func checkOutput() error {
+	switch outputFormat {
+	case outputTable, outputJSON, outputYAML, outputEnv:
+		return nil
+	}
+	return usageError(fmt.Errorf("unknown output format %q, use table, json, yaml or env", outputFormat))
+}
+
+// render prints the result in the output format, the table format is
+// written by table into a tabwriter. The other formats follow the JSON
+// encoding of v, so its json tags are the schema of every format.
+func render(v any, table func(w io.Writer)) error {
+	if outputFormat == outputTable {
+		tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+		table(tw)
+		return tw.Flush()
+	}
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to print: %w", err)
+	}
+	if outputFormat == outputJSON {
+		_, err = fmt.Fprintln(stdout, string(data))
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	tree, err := decodeOrdered(dec)
+	if err != nil {
+		return fmt.Errorf("failed to print: %w", err)
+	}
+
+	if outputFormat == outputYAML {
+		out, err := yaml.Marshal(yamlNode(tree))
+		if err != nil {
+			return fmt.Errorf("failed to print: %w", err)
+		}
+		_, err = stdout.Write(out)
+		return err
+	}
+
+	var lines []string
+	envLines(envPrefix, tree, &lines)
+	_, err = fmt.Fprintln(stdout, strings.Join(lines, "\n"))
+	return err
+}
+
+// info prints a message for people, it goes to stderr unless the output is
+// a table so that the other formats stay parseable.
+func info(format string, a ...any) {
+	fmt.Fprintf(infoWriter(), format+"\n", a...)
+}
+
+// infoWriter is where info prints.
+func infoWriter() io.Writer {
+	if outputFormat == outputTable {
+		return stdout
+	}
+	return os.Stderr
+}
+
+// formatTime prints the times of the tables.
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(time.DateTime)
+}
+
+// field is a member of a JSON object, objects are kept as []field so that
+// YAML and env keep the order of the JSON encoding.
+type field struct {
+	key   string
+	value any
+}
+
+func decodeOrdered(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		obj := []field{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, field{key: key.(string), value: value})
+		}
+		_, err = dec.Token()
+		return obj, err
+	case json.Delim('['):
+		arr := []any{}
+		for dec.More() {
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, value)
+		}
+		_, err = dec.Token()
+		return arr, err
+	}
+	if _, ok := tok.(json.Delim); ok {
+		return nil, errors.New("unexpected delimiter")
+	}
+	return tok, nil
+}
+
+func yamlNode(v any) *yaml.Node {
+	switch v := v.(type) {
+	case []field:
+		n := &yaml.Node{Kind: yaml.MappingNode}
+		for _, f := range v {
+			n.Content = append(n.Content, yamlScalar("!!str", f.key), yamlNode(f.value))
+		}
+		return n
+	case []any:
+		n := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, item := range v {
+			n.Content = append(n.Content, yamlNode(item))
+		}
+		return n
+	case string:
+		return yamlScalar("!!str", v)
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return yamlScalar("!!int", v.String())
+		}
+		return yamlScalar("!!float", v.String())
+	case bool:
+		return yamlScalar("!!bool", strconv.FormatBool(v))
+	}
+	return yamlScalar("!!null", "null")
+}
+
+func yamlScalar(tag, value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value}
+}
+
+// envLines flattens the value into shell variable assignments: the names
+// are the upper cased keys joined with _, the items of arrays are numbered
+// from 0 and NAME_COUNT holds their number.
+func envLines(name string, v any, lines *[]string) {
+	switch v := v.(type) {
+	case []field:
+		for _, f := range v {
+			envLines(name+"_"+envName(f.key), f.value, lines)
+		}
+	case []any:
+		*lines = append(*lines, name+"_COUNT="+strconv.Itoa(len(v)))
+		for i, item := range v {
+			envLines(name+"_"+strconv.Itoa(i), item, lines)
+		}
+	case nil:
+		*lines = append(*lines, name+"=")
+	default:
+		*lines = append(*lines, name+"="+shellQuote(fmt.Sprint(v)))
+	}
+}
+
+func envName(key string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, key)
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
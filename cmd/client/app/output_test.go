@@ -0,0 +1,102 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	view := newListView([]keyView{
+		{ID: "1", Name: "github.com"},
+		{ID: "2", Name: "it's mine"},
+	}, "next", 5)
+
+	tests := []struct {
+		name   string
+		format string
+		want   string
+	}{
+		{
+			name:   "table",
+			format: outputTable,
+			want:   "NAME        ID\ngithub.com  1\nit's mine   2\n",
+		},
+		{
+			name:   "json",
+			format: outputJSON,
+			want: `{
+  "items": [
+    {
+      "id": "1",
+      "name": "github.com"
+    },
+    {
+      "id": "2",
+      "name": "it's mine"
+    }
+  ],
+  "total": 5,
+  "next_cursor": "next"
+}
+`,
+		},
+		{
+			name:   "yaml",
+			format: outputYAML,
+			want: `items:
+    - id: "1"
+      name: github.com
+    - id: "2"
+      name: it's mine
+total: 5
+next_cursor: next
+`,
+		},
+		{
+			name:   "env",
+			format: outputEnv,
+			want: `KEEPER_ITEMS_COUNT=2
+KEEPER_ITEMS_0_ID='1'
+KEEPER_ITEMS_0_NAME='github.com'
+KEEPER_ITEMS_1_ID='2'
+KEEPER_ITEMS_1_NAME='it'\''s mine'
+KEEPER_TOTAL='5'
+KEEPER_NEXT_CURSOR='next'
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			prev := stdout
+			stdout, outputFormat = &buf, tt.format
+			defer func() { stdout, outputFormat = prev, outputTable }()
+
+			err := render(view, func(w io.Writer) {
+				fmt.Fprintln(w, "NAME\tID")
+				for _, item := range view.Items {
+					fmt.Fprintf(w, "%s\t%s\n", item.Name, item.ID)
+				}
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestCheckOutput(t *testing.T) {
+	defer func() { outputFormat = outputTable }()
+
+	outputFormat = outputYAML
+	assert.NoError(t, checkOutput())
+
+	outputFormat = "xml"
+	var exitErr *exitError
+	require.ErrorAs(t, checkOutput(), &exitErr)
+	assert.Equal(t, exitUsage, exitErr.code)
+}
@@ -1,24 +1,116 @@
 package app
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
+
+	"keeper-project/pkg/keeperclient"
 )
 
 var login, password, serverURL string
 
+// exit codes of the client
+const (
+	exitFailure  = 1
+	exitUsage    = 2
+	exitAuth     = 3
+	exitNotFound = 4
+)
+
 var rootCmd = &cobra.Command{
 	Use:   "keeper",
 	Short: "keep your secrets safe",
-	Long:  `keep your secrets safe`,
+	Long: `keep your secrets safe.
+Exit codes: 0 success, 1 failure, 2 wrong usage, 3 failed login, 4 no such record`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return checkOutput()
+	},
+}
+
+// exitError is an error ending the client with the code, a quiet error
+// isn't printed.
+type exitError struct {
+	code  int
+	err   error
+	quiet bool
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+func usageError(err error) error {
+	return &exitError{code: exitUsage, err: err}
+}
+
+// apiError is the error of a failed request, an unexpected response of the
+// server ends the client with the matching exit code.
+func apiError(msg string, err error) error {
+	var respErr *keeperclient.APIError
+	if !errors.As(err, &respErr) {
+		return fmt.Errorf("%s: %w", msg, err)
+	}
+
+	err = fmt.Errorf("%s: %s", msg, respErr.Message)
+	switch respErr.StatusCode {
+	case http.StatusUnauthorized:
+		return &exitError{code: exitAuth, err: err}
+	case http.StatusNotFound:
+		return &exitError{code: exitNotFound, err: err}
+	case http.StatusBadRequest:
+		return usageError(err)
+	}
+	return err
 }
 
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	wrapArgs(rootCmd)
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return usageError(err)
+	})
+
+	err := rootCmd.Execute()
+	if err == nil {
+		return
+	}
+
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		if !exitErr.quiet {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+		}
+		os.Exit(exitErr.code)
+	}
+	fmt.Fprintln(os.Stderr, "Error:", err)
+	if strings.HasPrefix(err.Error(), "unknown command") {
+		os.Exit(exitUsage)
+	}
+	os.Exit(exitFailure)
+}
+
+// wrapArgs makes the argument errors of the commands usage errors.
+func wrapArgs(cmd *cobra.Command) {
+	if validate := cmd.Args; validate != nil {
+		cmd.Args = func(cmd *cobra.Command, args []string) error {
+			if err := validate(cmd, args); err != nil {
+				return usageError(err)
+			}
+			return nil
+		}
+	}
+	for _, sub := range cmd.Commands() {
+		wrapArgs(sub)
 	}
 }
 
@@ -26,4 +118,5 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&login, "l", "", "login for using go-keeper system")
 	rootCmd.PersistentFlags().StringVar(&password, "p", "", "password for using go-keeper system")
 	rootCmd.PersistentFlags().StringVar(&serverURL, "s", "", "go-keeper server address")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", outputTable, "output format: table, json, yaml or env")
 }
@@ -0,0 +1,335 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/spf13/cobra"
+
+	"keeper-project/internal/secretref"
+	"keeper-project/pkg/keeperclient"
+	"keeper-project/types"
+)
+
+// defaultEnvFile is read when no --env-file or --env is given.
+const defaultEnvFile = ".env"
+
+// envFlags are the flags of the commands reading variables with references.
+type envFlags struct {
+	files []string
+	vars  []string
+}
+
+var (
+	runEnv      envFlags
+	envCmdFlags envFlags
+	injectOut   string
+)
+
+func (f *envFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&f.files, "env-file", nil, "read NAME=VALUE lines from the file, "+defaultEnvFile+" by default")
+	cmd.Flags().StringArrayVar(&f.vars, "env", nil, "set NAME=VALUE, the value may hold references")
+}
+
+// load reads the variables of the flags and resolves their references, the
+// variables of --env come last so they override the files.
+func (f *envFlags) load(r *refResolver) ([]secretref.Var, error) {
+	files := f.files
+	if len(files) == 0 && len(f.vars) == 0 {
+		if _, err := os.Stat(defaultEnvFile); err == nil {
+			files = []string{defaultEnvFile}
+		}
+	}
+
+	var vars []secretref.Var
+	for _, path := range files {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open file: %w", err)
+		}
+		fileVars, err := secretref.ParseEnv(file)
+		file.Close()
+		if err != nil {
+			return nil, usageError(fmt.Errorf("%s: %w", path, err))
+		}
+		vars = append(vars, fileVars...)
+	}
+	for _, s := range f.vars {
+		v, err := secretref.ParseVar(s)
+		if err != nil {
+			return nil, usageError(err)
+		}
+		vars = append(vars, v)
+	}
+
+	for i := range vars {
+		value, err := secretref.Expand(vars[i].Value, r.resolve)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", vars[i].Name, err)
+		}
+		vars[i].Value = value
+	}
+	return vars, nil
+}
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+	rootCmd.AddCommand(envCmd)
+	rootCmd.AddCommand(injectCmd)
+
+	runEnv.register(runCmd)
+	runCmd.Flags().SetInterspersed(false)
+	envCmdFlags.register(envCmd)
+	injectCmd.Flags().StringVar(&injectOut, "out", "", "write the result to the file instead of stdout")
+}
+
+var runCmd = &cobra.Command{
+	Use:   "run [command] [args]",
+	Short: "run a command with secrets in its environment",
+	Long: `run a command with the variables of --env-file and --env set, references like
+keeper://cred/<id>/password in their values are replaced with the decrypted secrets.
+Variables of the current environment holding a reference are resolved as well.
+The command exits with the code of the child process`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r := &refResolver{ctx: cmd.Context()}
+		env, err := r.environ(os.Environ())
+		if err != nil {
+			return err
+		}
+		vars, err := runEnv.load(r)
+		if err != nil {
+			return err
+		}
+		for _, v := range vars {
+			env = append(env, v.Name+"="+v.Value)
+		}
+
+		child := exec.Command(args[0], args[1:]...)
+		child.Env = env
+		child.Stdin, child.Stdout, child.Stderr = os.Stdin, os.Stdout, os.Stderr
+		err = child.Start()
+		if err != nil {
+			return fmt.Errorf("unable to run %s: %w", args[0], err)
+		}
+
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			for s := range sigs {
+				_ = child.Process.Signal(s)
+			}
+		}()
+		err = child.Wait()
+		signal.Stop(sigs)
+		close(sigs)
+
+		var childErr *exec.ExitError
+		if errors.As(err, &childErr) {
+			code := childErr.ExitCode()
+			if code < 0 {
+				code = exitFailure
+			}
+			return &exitError{code: code, err: err, quiet: true}
+		}
+		return err
+	},
+}
+
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "print variables with their secrets resolved",
+	Long: `print the variables of --env-file and --env with their references resolved
+as export lines for the shell, or as an object with --output json or yaml`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		vars, err := envCmdFlags.load(&refResolver{ctx: cmd.Context()})
+		if err != nil {
+			return err
+		}
+
+		switch outputFormat {
+		case outputJSON, outputYAML:
+			values := make(map[string]string, len(vars))
+			for _, v := range vars {
+				values[v.Name] = v.Value
+			}
+			return render(values, nil)
+		}
+
+		prefix := "export "
+		if outputFormat == outputEnv {
+			prefix = ""
+		}
+		for _, v := range vars {
+			fmt.Fprintf(stdout, "%s%s=%s\n", prefix, v.Name, shellQuote(v.Value))
+		}
+		return nil
+	},
+}
+
+var injectCmd = &cobra.Command{
+	Use:   "inject [template]",
+	Short: "render a template with secrets substituted",
+	Long: `replace every reference like keeper://note/<title> in the template with the decrypted
+secret, the template is read from stdin when it is omitted or -`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var (
+			data []byte
+			err  error
+		)
+		if len(args) == 0 || args[0] == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(args[0])
+		}
+		if err != nil {
+			return fmt.Errorf("unable to read template: %w", err)
+		}
+
+		out, err := secretref.Expand(string(data), (&refResolver{ctx: cmd.Context()}).resolve)
+		if err != nil {
+			return err
+		}
+
+		if injectOut == "" {
+			_, err = io.WriteString(stdout, out)
+			return err
+		}
+		err = os.WriteFile(injectOut, []byte(out), 0600)
+		if err != nil {
+			return fmt.Errorf("unable to write file: %w", err)
+		}
+		return nil
+	},
+}
+
+// refLists are the lists of the records of each kind of secretref.
+func refLists(client *keeperclient.Client) map[string]lister[*types.Key] {
+	return map[string]lister[*types.Key]{
+		secretref.KindNote: client.ListNotes,
+		secretref.KindCard: client.ListCards,
+		secretref.KindCred: client.ListCredentials,
+	}
+}
+
+// refResolver fetches and decrypts the records the references point to, it
+// logs in on the first reference and fetches every record and list once.
+type refResolver struct {
+	ctx     context.Context
+	client  *keeperclient.Client
+	records map[string]map[string]string
+	names   map[string][]*types.Key
+}
+
+func (r *refResolver) resolve(ref secretref.Ref) (string, error) {
+	if r.client == nil {
+		client, err := connect(r.ctx)
+		if err != nil {
+			return "", err
+		}
+		r.client = client
+		r.records = make(map[string]map[string]string)
+		r.names = make(map[string][]*types.Key)
+	}
+
+	id, err := r.id(ref)
+	if err != nil {
+		return "", err
+	}
+
+	fields, ok := r.records[ref.Kind+"/"+id]
+	if !ok {
+		fields, err = getFields(r.ctx, r.client, ref.Kind, id)
+		if err != nil {
+			return "", apiError("failed to get", err)
+		}
+		r.records[ref.Kind+"/"+id] = fields
+	}
+	return fields[ref.Field], nil
+}
+
+// id finds the record by its title, card number or site unless the name is an id.
+func (r *refResolver) id(ref secretref.Ref) (string, error) {
+	if _, err := uuid.FromString(ref.Name); err == nil {
+		return ref.Name, nil
+	}
+
+	keys, ok := r.names[ref.Kind]
+	if !ok {
+		var err error
+		keys, err = listKeys(r.ctx, refLists(r.client)[ref.Kind])
+		if err != nil {
+			return "", apiError("unable to get data", err)
+		}
+		r.names[ref.Kind] = keys
+	}
+
+	var ids []string
+	for _, k := range keys {
+		if k.Key == ref.Name {
+			ids = append(ids, k.Id)
+		}
+	}
+	switch len(ids) {
+	case 0:
+		return "", &exitError{code: exitNotFound, err: fmt.Errorf("no %s named %q", ref.Kind, ref.Name)}
+	case 1:
+		return ids[0], nil
+	}
+	return "", fmt.Errorf("%d records named %q, use the id", len(ids), ref.Name)
+}
+
+// getFields gets the decrypted fields of a record of the kind of secretref,
+// they are keyed by the names of secretref.Fields.
+func getFields(ctx context.Context, client *keeperclient.Client, kind, id string) (map[string]string, error) {
+	switch kind {
+	case secretref.KindNote:
+		note, err := client.GetNote(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{"text": note.Text, "title": note.Key, "metadata": note.Metadata}, nil
+	case secretref.KindCard:
+		card, err := client.GetCard(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{"number": card.Number, "expiration": card.Expiration, "cvv": card.CVV, "metadata": card.Metadata}, nil
+	}
+
+	cred, err := client.GetCredentials(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"password": cred.Password, "login": cred.Login, "site": cred.Site, "metadata": cred.Metadata}, nil
+}
+
+// environ resolves the variables of the environment whose whole value is a reference.
+func (r *refResolver) environ(env []string) ([]string, error) {
+	resolved := make([]string, 0, len(env))
+	for _, kv := range env {
+		name, value, _ := strings.Cut(kv, "=")
+		if secretref.IsRef(value) {
+			ref, err := secretref.Parse(value)
+			if err != nil {
+				return nil, usageError(fmt.Errorf("%s: %w", name, err))
+			}
+			value, err = r.resolve(ref)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		resolved = append(resolved, name+"="+value)
+	}
+	return resolved, nil
+}
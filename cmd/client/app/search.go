@@ -0,0 +1,209 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"keeper-project/internal/search"
+	"keeper-project/pkg/keeperclient"
+	"keeper-project/types"
+)
+
+var (
+	searchKinds   []string
+	searchLimit   int
+	searchFuzzy   bool
+	searchReindex bool
+)
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+
+	searchCmd.Flags().StringSliceVar(&searchKinds, "kind", nil, "search only these kinds: text, card or cred")
+	searchCmd.Flags().IntVar(&searchLimit, "limit", 20, "show at most this many records")
+	searchCmd.Flags().BoolVar(&searchFuzzy, "fuzzy", false, "find records holding half of the trigrams of the query")
+	searchCmd.Flags().BoolVar(&searchReindex, "reindex", false, "send the search tokens of every record before searching")
+}
+
+var searchCmd = &cobra.Command{
+	Use:   "search [query]",
+	Short: "find notes, cards and credentials by their words",
+	Long: `find notes, cards and credentials by the words of their titles, texts, sites, logins and metadata,
+the server only sees keyed hashes of the words, results are ranked after decryption.
+Passwords and card numbers other than the last 4 digits are not indexed.
+Run with --reindex once to index the records saved by older clients`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 && !searchReindex {
+			return usageError(errors.New("please provide the query"))
+		}
+
+		client, err := connect(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		if searchReindex {
+			n, err := reindex(cmd.Context(), client)
+			if err != nil {
+				return fmt.Errorf("unable to reindex: %w", err)
+			}
+			info("Indexed %d records", n)
+		}
+		if len(args) == 0 {
+			return nil
+		}
+
+		tokens := search.NewIndex(login, password).QueryTokens(args[0])
+		if len(tokens) == 0 {
+			return usageError(errors.New("the query has no words"))
+		}
+		req := types.SearchRequest{Tokens: tokens, Kinds: searchKinds, Limit: types.MaxPageSize}
+		if searchFuzzy {
+			req.MinMatch = (len(tokens) + 1) / 2
+		}
+
+		hits, err := client.Search(cmd.Context(), req)
+		if err != nil {
+			return apiError("failed to search", err)
+		}
+
+		found, err := rankHits(cmd.Context(), client, args[0], hits)
+		if err != nil {
+			return fmt.Errorf("unable to get data: %w", err)
+		}
+		if searchLimit > 0 && len(found) > searchLimit {
+			found = found[:searchLimit]
+		}
+		return render(found, func(w io.Writer) {
+			if len(found) == 0 {
+				fmt.Fprintln(w, "Nothing found")
+				return
+			}
+			fmt.Fprintln(w, "KIND\tNAME\tID")
+			for _, f := range found {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", f.Kind, f.Title, f.ID)
+			}
+		})
+	},
+}
+
+// searchResult is a decrypted record found by the server, Score is the
+// rank of search.Score and Matched the number of tokens the server matched.
+type searchResult struct {
+	Kind    string `json:"kind"`
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Score   int    `json:"score"`
+	Matched int    `json:"matched"`
+}
+
+// rankHits decrypts the records the server found, drops the ones matching
+// trigrams but not the words of the query and puts the best matches first.
+// Fuzzy searches keep every hit, the ones with more matched tokens first.
+func rankHits(ctx context.Context, client *keeperclient.Client, query string, hits []types.SearchHit) ([]searchResult, error) {
+	found := make([]searchResult, 0, len(hits))
+	for _, hit := range hits {
+		title, texts, err := fetchSearchable(ctx, client, hit.Kind, hit.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		score := search.Score(query, texts...)
+		if score == 0 && !searchFuzzy {
+			continue
+		}
+		found = append(found, searchResult{Kind: hit.Kind, ID: hit.ID, Title: title, Score: score, Matched: hit.Matched})
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].Score != found[j].Score {
+			return found[i].Score > found[j].Score
+		}
+		return found[i].Matched > found[j].Matched
+	})
+	return found, nil
+}
+
+// fetchSearchable gets the record, it returns its title and the texts its
+// search tokens are made of.
+func fetchSearchable(ctx context.Context, client *keeperclient.Client, kind, id string) (string, []string, error) {
+	switch kind {
+	case types.KindNote:
+		note, err := client.GetNote(ctx, id)
+		if err != nil {
+			return "", nil, err
+		}
+		return note.Key, []string{note.Key, note.Text, note.Metadata}, nil
+	case types.KindCard:
+		card, err := client.GetCard(ctx, id)
+		if err != nil {
+			return "", nil, err
+		}
+		return "*" + lastDigits(card.Number), cardTexts(card.Number, card.Metadata), nil
+	case types.KindCredentials:
+		cred, err := client.GetCredentials(ctx, id)
+		if err != nil {
+			return "", nil, err
+		}
+		return cred.Login + "@" + cred.Site, []string{cred.Site, cred.Login, cred.Metadata}, nil
+	}
+	return "", nil, fmt.Errorf("unknown kind %q", kind)
+}
+
+// reindex replaces the search tokens of every record with the ones made from
+// its decrypted contents.
+func reindex(ctx context.Context, client *keeperclient.Client) (int, error) {
+	lists := map[string]lister[*types.Key]{
+		types.KindNote:        client.ListNotes,
+		types.KindCard:        client.ListCards,
+		types.KindCredentials: client.ListCredentials,
+	}
+	index := search.NewIndex(login, password)
+
+	var n int
+	for _, kind := range types.SearchKinds {
+		keys, err := listKeys(ctx, lists[kind])
+		if err != nil {
+			return n, err
+		}
+
+		for _, key := range keys {
+			_, texts, err := fetchSearchable(ctx, client, kind, key.Id)
+			if err != nil {
+				return n, err
+			}
+
+			err = client.SetSearchTokens(ctx, kind, key.Id, index.Tokens(texts...))
+			if err != nil {
+				return n, err
+			}
+			n++
+		}
+	}
+	return n, nil
+}
+
+// noteTokens, cardTokens and credTokens return the search tokens sent with
+// the plain texts of the records, secrets are never indexed.
+func noteTokens(title, text, metadata string) []string {
+	return search.NewIndex(login, password).Tokens(title, text, metadata)
+}
+
+func cardTokens(number, metadata string) []string {
+	return search.NewIndex(login, password).Tokens(cardTexts(number, metadata)...)
+}
+
+func credTokens(site, siteLogin, metadata string) []string {
+	return search.NewIndex(login, password).Tokens(site, siteLogin, metadata)
+}
+
+func cardTexts(number, metadata string) []string {
+	return []string{lastDigits(strings.ReplaceAll(number, " ", "")), metadata}
+}
@@ -0,0 +1,108 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"keeper-project/types"
+)
+
+// trashView is an item of trash list.
+type trashView struct {
+	Kind      string    `json:"kind"`
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "restore or finally remove deleted records",
+	Long: `deleted notes, cards, credentials and files are kept in the trash
+until the server retention window expires or the trash is emptied`,
+}
+
+func init() {
+	rootCmd.AddCommand(trashCmd)
+
+	trashCmd.AddCommand(trashListCmd)
+	trashCmd.AddCommand(trashRestoreCmd)
+	trashCmd.AddCommand(trashEmptyCmd)
+}
+
+var trashListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "get deleted records list",
+	Long:  `get deleted records list`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := connect(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		result, err := client.Trash(cmd.Context())
+		if err != nil {
+			return apiError("failed to get", err)
+		}
+
+		items := make([]trashView, 0, len(result))
+		for _, item := range result {
+			name := item.Key
+			if item.Kind == types.KindCard {
+				name = "*" + lastDigits(name)
+			}
+			items = append(items, trashView{Kind: item.Kind, ID: item.Id, Name: name, DeletedAt: item.DeletedAt})
+		}
+
+		return render(newListView(items, "", 0), func(w io.Writer) {
+			fmt.Fprintln(w, "KIND\tNAME\tID\tDELETED")
+			for _, item := range items {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", item.Kind, item.Name, item.ID, formatTime(&item.DeletedAt))
+			}
+		})
+	},
+}
+
+var trashRestoreCmd = &cobra.Command{
+	Use:   "restore [kind] [id]",
+	Short: "restore deleted record",
+	Long:  `restore deleted record, kind is one of text, card, cred or file; you can find ids in list command`,
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := connect(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		err = client.RestoreFromTrash(cmd.Context(), args[0], args[1])
+		if err != nil {
+			return apiError("failed to restore", err)
+		}
+
+		info("Successfully restored")
+		return nil
+	},
+}
+
+var trashEmptyCmd = &cobra.Command{
+	Use:   "empty",
+	Short: "permanently remove everything in the trash",
+	Long:  `permanently remove everything in the trash`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := connect(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		err = client.EmptyTrash(cmd.Context())
+		if err != nil {
+			return apiError("failed to empty trash", err)
+		}
+
+		info("Trash is empty")
+		return nil
+	},
+}
@@ -0,0 +1,109 @@
+package app
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"keeper-project/internal/secretref"
+	"keeper-project/internal/tui"
+	"keeper-project/pkg/keeperclient"
+	"keeper-project/types"
+)
+
+func init() {
+	rootCmd.AddCommand(tuiCmd)
+}
+
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "browse and edit the vault in a full screen interface",
+	Long: `browse notes, cards and credentials in a full screen interface: filter the lists,
+open records with their secrets hidden until revealed, create, edit and delete them`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := connect(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		return tui.Run(&tuiBackend{ctx: cmd.Context(), client: client})
+	},
+}
+
+// tuiBackend is the tui.Backend of the server, the kinds of tui.Kinds are
+// the kinds of secretref.
+type tuiBackend struct {
+	ctx    context.Context
+	client *keeperclient.Client
+}
+
+func (b *tuiBackend) List(kind string) ([]tui.Item, error) {
+	keys, err := listKeys(b.ctx, refLists(b.client)[kind])
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]tui.Item, 0, len(keys))
+	for _, k := range keys {
+		name := k.Key
+		if kind == secretref.KindCard {
+			name = "*" + lastDigits(name)
+		}
+		items = append(items, tui.Item{ID: k.Id, Name: name})
+	}
+	return items, nil
+}
+
+func (b *tuiBackend) Get(kind, id string) (map[string]string, error) {
+	return getFields(b.ctx, b.client, kind, id)
+}
+
+// Save creates the record when id is empty and replaces it otherwise.
+func (b *tuiBackend) Save(kind, id string, values map[string]string) error {
+	switch kind {
+	case secretref.KindNote:
+		tokens := noteTokens(values["title"], values["text"], values["metadata"])
+		if id == "" {
+			return b.client.CreateNote(b.ctx, types.CreateNoteRequest{
+				Key: values["title"], Data: values["text"], Metadata: values["metadata"], SearchTokens: tokens,
+			})
+		}
+		return b.client.UpdateNote(b.ctx, types.UpdateNoteRequest{
+			ID: id, Key: values["title"], Data: values["text"], Metadata: values["metadata"], SearchTokens: tokens,
+		})
+	case secretref.KindCard:
+		card := types.CreateCardRequest{
+			ID:           id,
+			Number:       values["number"],
+			Expiration:   values["expiration"],
+			CVV:          values["cvv"],
+			Metadata:     values["metadata"],
+			SearchTokens: cardTokens(values["number"], values["metadata"]),
+		}
+		if id == "" {
+			return b.client.CreateCard(b.ctx, card)
+		}
+		return b.client.UpdateCard(b.ctx, card)
+	}
+
+	tokens := credTokens(values["site"], values["login"], values["metadata"])
+	if id == "" {
+		return b.client.CreateCredentials(b.ctx, types.CreateCredentialsRequest{
+			Site: values["site"], Login: values["login"], Password: values["password"], Metadata: values["metadata"], SearchTokens: tokens,
+		})
+	}
+	return b.client.UpdateCredentials(b.ctx, types.UpdateCredentialsRequest{
+		ID: id, Site: values["site"], Login: values["login"], Password: values["password"], Metadata: values["metadata"], SearchTokens: tokens,
+	})
+}
+
+func (b *tuiBackend) Delete(kind, id string) error {
+	switch kind {
+	case secretref.KindNote:
+		return b.client.DeleteNote(b.ctx, id)
+	case secretref.KindCard:
+		return b.client.DeleteCard(b.ctx, id)
+	}
+	return b.client.DeleteCredentials(b.ctx, id)
+}
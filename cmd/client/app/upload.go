@@ -0,0 +1,280 @@
+package app
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/docker/go-units"
+
+	"keeper-project/internal/crypto"
+	"keeper-project/pkg/keeperclient"
+	"keeper-project/types"
+)
+
+// chunkRetries is how many times a single chunk is sent before the upload is given up.
+const chunkRetries = 3
+
+// uploadState is kept in the user cache dir, so an interrupted upload of the same
+// file continues from the chunks the server already has. The salt is kept as well:
+// encryption with the same salt reproduces the chunks uploaded before.
+type uploadState struct {
+	ID   string `json:"id"`
+	Salt []byte `json:"salt"`
+}
+
+// uploadLocalFile uploads the file at path, an interrupted upload of the same
+// file is resumed.
+func uploadLocalFile(ctx context.Context, client *keeperclient.Client, path, metadata string, salt []byte) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("unable to open file: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", fmt.Errorf("unable to open file: %w", err)
+	}
+
+	return uploadFile(ctx, client, f, filepath.Base(path), info.Size(), metadata, salt, uploadStatePath(path, info))
+}
+
+// uploadFile encrypts size bytes of r on the fly, sends them through the
+// resumable upload protocol and returns the new file id. A random salt is used
+// when salt is nil. The session is kept in statePath to resume it, an empty
+// statePath starts a new one every time.
+func uploadFile(ctx context.Context, client *keeperclient.Client, r io.Reader, name string, size int64,
+	metadata string, salt []byte, statePath string) (string, error) {
+	var (
+		upload *types.UploadSession
+		state  uploadState
+		err    error
+	)
+	if statePath != "" {
+		upload, state = resumeUpload(ctx, client, statePath)
+	}
+	if upload == nil {
+		state.Salt = salt
+		if state.Salt == nil {
+			state.Salt, err = crypto.NewSalt()
+			if err != nil {
+				return "", fmt.Errorf("failed to encrypt: %w", err)
+			}
+		}
+
+		upload, err = client.CreateUpload(ctx, types.CreateUploadRequest{
+			Name:     name,
+			Size:     crypto.EncryptedFileSize(size),
+			Metadata: metadata,
+		})
+		if err != nil {
+			return "", apiError("failed to start upload", err)
+		}
+		state.ID = upload.ID
+		if statePath != "" {
+			saveUploadState(statePath, state)
+		}
+	}
+
+	fc, err := crypto.NewFileCipher(password, state.Salt)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt: %w", err)
+	}
+
+	// the file is encrypted through a pipe, so only a single chunk is held in memory.
+	pr, pw := io.Pipe()
+	defer pr.Close()
+	go func() {
+		w, err := fc.NewWriter(pw)
+		if err == nil {
+			_, err = io.Copy(w, r)
+		}
+		if err == nil {
+			err = w.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	received := make(map[int]types.UploadChunkInfo, len(upload.Chunks))
+	for _, c := range upload.Chunks {
+		received[c.Number] = c
+	}
+
+	buf := make([]byte, upload.ChunkSize)
+	var sent int64
+	for n := 1; n <= upload.ChunksCount(); n++ {
+		chunk := buf[:upload.ChunkLength(n)]
+		_, err = io.ReadFull(pr, chunk)
+		if err != nil {
+			fmt.Fprintln(infoWriter())
+			return "", fmt.Errorf("unable to read file: %w", err)
+		}
+		if !chunkReceived(received, n, chunk) {
+			err = putChunk(ctx, client, upload.ID, n, chunk)
+			if err != nil {
+				fmt.Fprintln(infoWriter())
+				return "", err
+			}
+		}
+
+		sent += int64(len(chunk))
+		fmt.Fprintf(infoWriter(), "\rUploaded %s / %s (%d%%)", units.HumanSize(float64(sent)),
+			units.HumanSize(float64(upload.Size)), sent*100/upload.Size)
+	}
+	fmt.Fprintln(infoWriter())
+
+	_, err = client.CompleteUpload(ctx, upload.ID)
+	if err != nil {
+		return "", apiError("failed to complete upload", err)
+	}
+
+	if statePath != "" {
+		_ = os.Remove(statePath)
+	}
+	return upload.ID, nil
+}
+
+// linkStoredFile encrypts the file with the convergent salt and adds it without
+// sending the contents when the server has them already. It returns the salt
+// to upload the file with otherwise.
+func linkStoredFile(ctx context.Context, client *keeperclient.Client, path, metadata string) (string, []byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to open file: %w", err)
+	}
+	defer f.Close()
+
+	salt, err := crypto.ConvergentSalt(password, f)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to read file: %w", err)
+	}
+	_, err = f.Seek(0, io.SeekStart)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to read file: %w", err)
+	}
+
+	fc, err := crypto.NewFileCipher(password, salt)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to encrypt: %w", err)
+	}
+	h := sha256.New()
+	w, err := fc.NewWriter(h)
+	if err == nil {
+		_, err = io.Copy(w, f)
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to encrypt: %w", err)
+	}
+	hash := hex.EncodeToString(h.Sum(nil))
+
+	stored, err := client.HasBlob(ctx, hash)
+	if err != nil {
+		return "", nil, apiError("unable to check file", err)
+	}
+	if !stored {
+		return "", salt, nil
+	}
+
+	info, err := client.LinkFile(ctx, types.LinkFileRequest{Name: filepath.Base(path), Hash: hash, Metadata: metadata})
+	switch {
+	case err == nil:
+		return info.ID, nil, nil
+	case errors.Is(err, keeperclient.ErrNotFound):
+		// the contents were removed since the check
+		return "", salt, nil
+	default:
+		return "", nil, apiError("failed to save file", err)
+	}
+}
+
+// resumeUpload returns the session saved for the file or nil if there is none left on the server.
+func resumeUpload(ctx context.Context, client *keeperclient.Client, statePath string) (*types.UploadSession, uploadState) {
+	var state uploadState
+
+	data, err := os.ReadFile(statePath)
+	if err != nil {
+		return nil, state
+	}
+
+	if err = json.Unmarshal(data, &state); err != nil || state.ID == "" {
+		return nil, uploadState{}
+	}
+
+	upload, err := client.GetUpload(ctx, state.ID)
+	if err != nil {
+		return nil, uploadState{}
+	}
+
+	info("Resuming upload from %s", units.HumanSize(float64(upload.Offset)))
+	return upload, state
+}
+
+// chunkReceived tells whether the server has the chunk already. The digest is
+// compared when the server reports it, the storage may only know the size,
+// e.g. of the parts encrypted with SSE-C.
+func chunkReceived(received map[int]types.UploadChunkInfo, n int, chunk []byte) bool {
+	c, ok := received[n]
+	if !ok || c.Size != int64(len(chunk)) {
+		return false
+	}
+	if c.MD5 == "" {
+		return true
+	}
+	sum := md5.Sum(chunk)
+	return c.MD5 == base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// putChunk sends the chunk again when it was corrupted on the way, the client
+// retries the failures of the network and of the server itself.
+func putChunk(ctx context.Context, client *keeperclient.Client, uploadID string, number int, chunk []byte) error {
+	var err error
+	for i := 0; i < chunkRetries; i++ {
+		err = client.PutChunk(ctx, uploadID, number, chunk)
+		var respErr *keeperclient.APIError
+		if !errors.As(err, &respErr) || respErr.StatusCode != http.StatusBadRequest {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to upload chunk %d, run the command again to resume: %w", number, err)
+	}
+	return nil
+}
+
+func uploadStatePath(path string, info os.FileInfo) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+
+	key := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%s|%d|%d", serverURL, login, abs, info.Size(), info.ModTime().UnixNano())))
+	return filepath.Join(dir, "keeper", "uploads", hex.EncodeToString(key[:])+".json")
+}
+
+func saveUploadState(statePath string, state uploadState) {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return
+	}
+	if err = os.MkdirAll(filepath.Dir(statePath), 0700); err != nil {
+		return
+	}
+	_ = os.WriteFile(statePath, data, 0600)
+}
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"keeper-project/types"
+)
+
+func TestChunkReceived(t *testing.T) {
+	chunk := []byte("chunk")
+	sum := md5.Sum(chunk)
+	digest := base64.StdEncoding.EncodeToString(sum[:])
+
+	tests := []struct {
+		name string
+		info types.UploadChunkInfo
+		want bool
+	}{
+		{
+			name: "same digest",
+			info: types.UploadChunkInfo{Number: 1, Size: 5, ETag: "etag", MD5: digest},
+			want: true,
+		},
+		{
+			name: "encrypted part, only the size is known",
+			info: types.UploadChunkInfo{Number: 1, Size: 5, ETag: "etag"},
+			want: true,
+		},
+		{
+			name: "other digest",
+			info: types.UploadChunkInfo{Number: 1, Size: 5, ETag: "etag", MD5: base64.StdEncoding.EncodeToString(make([]byte, md5.Size))},
+		},
+		{
+			name: "other size",
+			info: types.UploadChunkInfo{Number: 1, Size: 4, ETag: "etag", MD5: digest},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received := map[int]types.UploadChunkInfo{tt.info.Number: tt.info}
+			assert.Equal(t, tt.want, chunkReceived(received, 1, chunk))
+			assert.False(t, chunkReceived(received, 2, chunk))
+		})
+	}
+}
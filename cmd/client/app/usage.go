@@ -0,0 +1,56 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/docker/go-units"
+	"github.com/spf13/cobra"
+)
+
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "show stored data and quotas",
+	Long: `show how much you store and how much you are allowed to,
+records in the trash count until they are purged`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := connect(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		usage, err := client.Usage(cmd.Context())
+		if err != nil {
+			return apiError("failed to get", err)
+		}
+
+		return render(usage, func(w io.Writer) {
+			fmt.Fprintf(w, "Files:\t%d, %s\n", usage.Files, usageLine(usage.FileBytes, usage.Quota.FileBytes, true))
+			fmt.Fprintf(w, "Notes:\t%s\n", usageLine(usage.Notes, usage.Quota.Notes, false))
+			fmt.Fprintf(w, "Cards:\t%s\n", usageLine(usage.Cards, usage.Quota.Cards, false))
+			fmt.Fprintf(w, "Credentials:\t%s\n", usageLine(usage.Credentials, usage.Quota.Credentials, false))
+			if usage.Quota.MaxFileSize > 0 {
+				fmt.Fprintf(w, "Max file size:\t%s\n", units.HumanSize(float64(usage.Quota.MaxFileSize)))
+			}
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(usageCmd)
+}
+
+func usageLine(used, limit int64, bytes bool) string {
+	format := func(n int64) string {
+		if bytes {
+			return units.HumanSize(float64(n))
+		}
+		return strconv.FormatInt(n, 10)
+	}
+
+	if limit <= 0 {
+		return format(used) + " of unlimited"
+	}
+	return fmt.Sprintf("%s of %s (%d%%)", format(used), format(limit), used*100/limit)
+}
@@ -1,10 +1,8 @@
 package app
 
 import (
-	"fmt"
-	"net/http"
+	"errors"
 
-	"github.com/go-resty/resty/v2"
 	"github.com/spf13/cobra"
 )
 
@@ -25,34 +23,22 @@ var registerUserCmd = &cobra.Command{
 	Short: "register in go-keeper system",
 	Long:  `register in go-keeper system`,
 	Args:  cobra.ExactArgs(2),
-	Run: func(cmd *cobra.Command, args []string) {
-		client := resty.New()
+	RunE: func(cmd *cobra.Command, args []string) error {
 		if args[0] == "" || args[1] == "" {
-			fmt.Println("Please provide non empty login and password")
-			return
+			return usageError(errors.New("please provide non empty login and password"))
 		}
 
-		if serverURL == "" {
-			fmt.Println("Please specify server addr flag")
-			return
-		}
-
-		data := fmt.Sprintf("{\"login\": \"%s\", \"password\": \"%s\"}", args[0], args[1])
-
-		res, err := client.R().
-			SetHeader("Content-Type", "application/json").
-			SetBody(data).
-			Post(fmt.Sprintf("http://%s/api/user/register", serverURL))
+		client, err := newClient()
 		if err != nil {
-			fmt.Println("Failed to register: ", err)
-			return
+			return err
 		}
 
-		if res.StatusCode() != http.StatusOK {
-			fmt.Printf("Failed to register: %s\n", res.Body())
-			return
+		err = client.Register(cmd.Context(), args[0], args[1])
+		if err != nil {
+			return apiError("failed to register", err)
 		}
 
-		fmt.Println("Successfully registered. Now you can use your creds in other commands by setting up --l and --p flags")
+		info("Successfully registered. Now you can use your creds in other commands by setting up --l and --p flags")
+		return nil
 	},
 }
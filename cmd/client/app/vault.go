@@ -0,0 +1,667 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+
+	"keeper-project/internal/crypto"
+	"keeper-project/internal/importers"
+	"keeper-project/internal/vault"
+	"keeper-project/pkg/keeperclient"
+	"keeper-project/types"
+)
+
+// duplicate handling modes of import, a record is a duplicate when a note has
+// the same title, a card the same number, credentials the same site and login
+// or a file the same name.
+const (
+	duplicatesSkip      = "skip"
+	duplicatesOverwrite = "overwrite"
+	duplicatesKeep      = "keep"
+)
+
+// passphraseEnv is the environment variable with the passphrase of the archive.
+const passphraseEnv = "KEEPER_PASSPHRASE"
+
+var (
+	vaultPassphrase     string
+	vaultPassphraseFile string
+	importDryRun        bool
+	importDuplicates    string
+	importFrom          string
+)
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importCmd)
+
+	for _, cmd := range []*cobra.Command{exportCmd, importCmd} {
+		cmd.Flags().StringVar(&vaultPassphrase, "passphrase", "",
+			"passphrase of the archive, it is visible to other users of the system, prefer the prompt, "+passphraseEnv+" or --passphrase-file")
+		cmd.Flags().StringVar(&vaultPassphraseFile, "passphrase-file", "", "file with the passphrase of the archive on the first line")
+	}
+	importCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "only show what would be imported")
+	importCmd.Flags().StringVar(&importDuplicates, "duplicates", duplicatesSkip,
+		"what to do with records already in the account: skip, overwrite or keep both")
+	importCmd.Flags().StringVar(&importFrom, "from", "",
+		"import an export of another password manager: "+strings.Join(importers.Formats(), ", "))
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export [path]",
+	Short: "export the whole vault into an encrypted archive",
+	Long: `export notes, cards, credentials and files into a single archive encrypted with a passphrase,
+the archive can be imported into any account with the import command`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		passphrase, err := readPassphrase(true)
+		if err != nil {
+			return err
+		}
+
+		client, err := connect(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		manifest, ids, salts, err := exportManifest(cmd.Context(), client)
+		if err != nil {
+			return fmt.Errorf("unable to get data: %w", err)
+		}
+
+		out, err := os.OpenFile(args[0], os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		if err != nil {
+			return fmt.Errorf("unable to open file: %w", err)
+		}
+		defer out.Close()
+
+		buf := bufio.NewWriter(out)
+		w, err := vault.NewWriter(buf, passphrase, manifest)
+		if err != nil {
+			return fmt.Errorf("unable to write archive: %w", err)
+		}
+		for i := range manifest.Files {
+			err = exportFile(cmd.Context(), client, w, ids[i], salts[i])
+			if err != nil {
+				return fmt.Errorf("unable to export file %s: %w", manifest.Files[i].Name, err)
+			}
+		}
+		err = w.Close()
+		if err == nil {
+			err = buf.Flush()
+		}
+		if err != nil {
+			return fmt.Errorf("unable to write archive: %w", err)
+		}
+
+		info("Exported %d notes, %d cards, %d credentials and %d files",
+			len(manifest.Notes), len(manifest.Cards), len(manifest.Credentials), len(manifest.Files))
+		return nil
+	},
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import [path]",
+	Short: "import an archive made by export",
+	Long: `import notes, cards, credentials and files from an archive made by export,
+the whole archive is verified before anything is imported.
+With --from the file is an unencrypted export of another password manager,
+its entries are shown in a table before they are imported`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch importDuplicates {
+		case duplicatesSkip, duplicatesOverwrite, duplicatesKeep:
+		default:
+			return usageError(errors.New("unknown duplicates mode, use skip, overwrite or keep"))
+		}
+
+		if importFrom != "" {
+			return importForeign(cmd.Context(), args[0])
+		}
+
+		passphrase, err := readPassphrase(false)
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("unable to open file: %w", err)
+		}
+		defer f.Close()
+
+		r, err := vault.NewReader(bufio.NewReader(f), passphrase)
+		if err == nil {
+			err = r.Verify()
+		}
+		if err != nil {
+			return fmt.Errorf("unable to read archive: %w", err)
+		}
+
+		client, err := connect(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		existing := &existingRecords{}
+		if importDuplicates != duplicatesKeep {
+			existing, err = loadExistingRecords(cmd.Context(), client)
+			if err != nil {
+				return apiError("unable to get data", err)
+			}
+		}
+
+		_, err = f.Seek(0, io.SeekStart)
+		if err == nil {
+			r, err = vault.NewReader(bufio.NewReader(f), passphrase)
+		}
+		if err != nil {
+			return fmt.Errorf("unable to read archive: %w", err)
+		}
+
+		im := &importer{client: client, existing: existing}
+		err = im.run(cmd.Context(), r)
+		info("Created: %d, overwritten: %d, skipped: %d", im.created, im.overwritten, im.skipped)
+		if err != nil {
+			return fmt.Errorf("import stopped: %w", err)
+		}
+		return nil
+	},
+}
+
+// readPassphrase takes the passphrase of the archive from --passphrase,
+// --passphrase-file or KEEPER_PASSPHRASE, and asks for it when stdin is a
+// terminal otherwise. A new passphrase is asked twice when confirm is set.
+func readPassphrase(confirm bool) (string, error) {
+	var (
+		passphrase string
+		err        error
+	)
+	switch {
+	case vaultPassphrase != "":
+		passphrase = vaultPassphrase
+	case vaultPassphraseFile != "":
+		var data []byte
+		data, err = os.ReadFile(vaultPassphraseFile)
+		if err != nil {
+			return "", fmt.Errorf("unable to read passphrase: %w", err)
+		}
+		passphrase, _, _ = strings.Cut(string(data), "\n")
+		passphrase = strings.TrimSuffix(passphrase, "\r")
+	case os.Getenv(passphraseEnv) != "":
+		passphrase = os.Getenv(passphraseEnv)
+	case term.IsTerminal(os.Stdin.Fd()):
+		passphrase, err = promptPassphrase("Passphrase: ")
+		if err == nil && confirm && passphrase != "" {
+			var again string
+			again, err = promptPassphrase("Repeat passphrase: ")
+			if err == nil && again != passphrase {
+				return "", errors.New("passphrases don't match")
+			}
+		}
+		if err != nil {
+			return "", fmt.Errorf("unable to read passphrase: %w", err)
+		}
+	}
+
+	if passphrase == "" {
+		return "", usageError(fmt.Errorf("please provide the passphrase with --passphrase-file, %s or the prompt", passphraseEnv))
+	}
+	return passphrase, nil
+}
+
+// promptPassphrase reads a line from the terminal without echoing it.
+func promptPassphrase(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	data, err := term.ReadPassword(os.Stdin.Fd())
+	fmt.Fprintln(os.Stderr)
+	return string(data), err
+}
+
+// importForeign imports the export of another password manager.
+func importForeign(ctx context.Context, path string) error {
+	imp, err := importers.Get(importFrom)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("unable to open file: %w", err)
+	}
+	defer f.Close()
+
+	res, err := imp.Parse(f)
+	if err != nil {
+		return fmt.Errorf("unable to parse file: %w", err)
+	}
+	printImportPreview(res)
+
+	client, err := connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	existing := &existingRecords{}
+	if importDuplicates != duplicatesKeep {
+		existing, err = loadExistingRecords(ctx, client)
+		if err != nil {
+			return apiError("unable to get data", err)
+		}
+	}
+
+	im := &importer{client: client, existing: existing}
+	err = im.importRecords(ctx, &vault.Manifest{Notes: res.Notes, Cards: res.Cards, Credentials: res.Credentials})
+	info("Created: %d, overwritten: %d, skipped: %d", im.created, im.overwritten, im.skipped)
+	if err != nil {
+		return fmt.Errorf("import stopped: %w", err)
+	}
+	return nil
+}
+
+// printImportPreview shows the parsed entries without their secrets.
+func printImportPreview(res *importers.Result) {
+	tw := tabwriter.NewWriter(infoWriter(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TYPE\tNAME\tLOGIN\tMETADATA")
+	for _, c := range res.Credentials {
+		fmt.Fprintf(tw, "credentials\t%s\t%s\t%s\n", c.Site, c.Login, previewMetadata(c.Metadata))
+	}
+	for _, c := range res.Cards {
+		fmt.Fprintf(tw, "card\t*%s\t\t%s\n", lastDigits(c.Number), previewMetadata(c.Metadata))
+	}
+	for _, n := range res.Notes {
+		fmt.Fprintf(tw, "note\t%s\t\t%s\n", n.Key, previewMetadata(n.Metadata))
+	}
+	tw.Flush()
+	info("%d credentials, %d cards, %d notes", len(res.Credentials), len(res.Cards), len(res.Notes))
+}
+
+// previewMetadata shortens the metadata to the names of its fields.
+func previewMetadata(md string) string {
+	var names []string
+	for _, line := range strings.Split(md, "\n") {
+		if name, _, ok := strings.Cut(line, ": "); ok {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// exportManifest fetches all the records, files are listed with their ids
+// and salts to be downloaded afterwards.
+func exportManifest(ctx context.Context, client *keeperclient.Client) (*vault.Manifest, []string, [][]byte, error) {
+	manifest := &vault.Manifest{CreatedAt: time.Now().UTC()}
+
+	keys, err := listKeys(ctx, client.ListNotes)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for _, k := range keys {
+		note, err := client.GetNote(ctx, k.Id)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("note %s: %w", k.Id, err)
+		}
+		manifest.Notes = append(manifest.Notes, *note)
+	}
+
+	keys, err = listKeys(ctx, client.ListCards)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for _, k := range keys {
+		card, err := client.GetCard(ctx, k.Id)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("card %s: %w", k.Id, err)
+		}
+		card.ID = ""
+		manifest.Cards = append(manifest.Cards, *card)
+	}
+
+	keys, err = listKeys(ctx, client.ListCredentials)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for _, k := range keys {
+		cred, err := client.GetCredentials(ctx, k.Id)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("credentials %s: %w", k.Id, err)
+		}
+		manifest.Credentials = append(manifest.Credentials, *cred)
+	}
+
+	files, err := listFiles(ctx, client)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	ids := make([]string, 0, len(files))
+	salts := make([][]byte, 0, len(files))
+	for _, info := range files {
+		salt, md, err := getFileHeader(ctx, client, info.ID)
+		size := info.Size
+		switch {
+		case err == nil:
+			size, err = crypto.PlainFileSize(info.Size)
+		case errors.Is(err, crypto.ErrNotEncrypted):
+			err = nil
+		}
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("file %s: %w", info.ID, err)
+		}
+
+		manifest.Files = append(manifest.Files, vault.File{
+			Name:     info.Name,
+			Metadata: md,
+			Size:     size,
+		})
+		ids = append(ids, info.ID)
+		salts = append(salts, salt)
+	}
+
+	return manifest, ids, salts, nil
+}
+
+// exportFile streams the decrypted contents of the stored file into the archive.
+func exportFile(ctx context.Context, client *keeperclient.Client, w *vault.Writer, id string, salt []byte) error {
+	d, err := client.DownloadFile(ctx, id, 0, 0)
+	if err != nil {
+		return err
+	}
+	defer d.Body.Close()
+
+	var content io.Reader = d.Body
+	if salt != nil {
+		_, err = crypto.ReadFileHeader(d.Body)
+		if err != nil {
+			return err
+		}
+		fc, err := crypto.NewFileCipher(password, salt)
+		if err != nil {
+			return err
+		}
+		content = fc.NewReader(d.Body, 0)
+	}
+	return w.AddFile(content)
+}
+
+// existingRecords maps the records of the account to their ids for duplicate detection.
+type existingRecords struct {
+	notes map[string]string
+	cards map[string]string
+	creds map[string]string
+	files map[string]string
+}
+
+func credentialsKey(site, login string) string {
+	return site + "\n" + login
+}
+
+func loadExistingRecords(ctx context.Context, client *keeperclient.Client) (*existingRecords, error) {
+	existing := &existingRecords{
+		notes: make(map[string]string),
+		cards: make(map[string]string),
+		creds: make(map[string]string),
+		files: make(map[string]string),
+	}
+
+	for _, kind := range []struct {
+		list lister[*types.Key]
+		ids  map[string]string
+	}{{client.ListNotes, existing.notes}, {client.ListCards, existing.cards}} {
+		keys, err := listKeys(ctx, kind.list)
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range keys {
+			kind.ids[k.Key] = k.Id
+		}
+	}
+
+	// the list holds sites only, logins are fetched one by one
+	keys, err := listKeys(ctx, client.ListCredentials)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		cred, err := client.GetCredentials(ctx, k.Id)
+		if err != nil {
+			return nil, err
+		}
+		existing.creds[credentialsKey(cred.Site, cred.Login)] = k.Id
+	}
+
+	files, err := listFiles(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range files {
+		existing.files[info.Name] = info.ID
+	}
+
+	return existing, nil
+}
+
+type importer struct {
+	client   *keeperclient.Client
+	existing *existingRecords
+
+	created, overwritten, skipped int
+}
+
+// action returns what to do with a record given the id of its duplicate.
+func (im *importer) action(kind, name, existingID string) string {
+	action := "create"
+	if existingID != "" {
+		switch importDuplicates {
+		case duplicatesSkip:
+			action = "skip"
+		case duplicatesOverwrite:
+			action = "overwrite"
+		}
+	}
+
+	switch action {
+	case "create":
+		im.created++
+	case "skip":
+		im.skipped++
+	case "overwrite":
+		im.overwritten++
+	}
+	if importDryRun || action == "skip" {
+		info("%s %s %q", action, kind, name)
+	}
+	return action
+}
+
+func (im *importer) run(ctx context.Context, r *vault.Reader) error {
+	err := im.importRecords(ctx, r.Manifest)
+	if err != nil {
+		return err
+	}
+	return im.importFiles(ctx, r)
+}
+
+// importRecords imports the notes, cards and credentials of the manifest in
+// batches of types.MaxBatchOps operations.
+func (im *importer) importRecords(ctx context.Context, m *vault.Manifest) error {
+	b := &recordBatch{importer: im}
+
+	for _, note := range m.Notes {
+		id := im.existing.notes[note.Key]
+		err := b.add(ctx, im.action("note", note.Key, id), types.KindNote, id, "note "+strconv.Quote(note.Key),
+			types.CreateNoteRequest{
+				Key: note.Key, Data: note.Text, Metadata: note.Metadata, SearchTokens: noteTokens(note.Key, note.Text, note.Metadata),
+			})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, card := range m.Cards {
+		id := im.existing.cards[card.Number]
+		err := b.add(ctx, im.action("card", "*"+lastDigits(card.Number), id), types.KindCard, id, "card *"+lastDigits(card.Number),
+			types.CreateCardRequest{
+				Number:       card.Number,
+				Expiration:   card.Expiration,
+				CVV:          card.CVV,
+				Metadata:     card.Metadata,
+				SearchTokens: cardTokens(card.Number, card.Metadata),
+			})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, cred := range m.Credentials {
+		id := im.existing.creds[credentialsKey(cred.Site, cred.Login)]
+		err := b.add(ctx, im.action("credentials", cred.Login+"@"+cred.Site, id), types.KindCredentials, id,
+			"credentials "+cred.Login+"@"+cred.Site,
+			types.CreateCredentialsRequest{
+				Site: cred.Site, Login: cred.Login, Password: cred.Password, Metadata: cred.Metadata,
+				SearchTokens: credTokens(cred.Site, cred.Login, cred.Metadata),
+			})
+		if err != nil {
+			return err
+		}
+	}
+
+	return b.flush(ctx)
+}
+
+// recordBatch collects the records to import into atomic batches, a failed
+// batch leaves none of its records and is taken off the counters.
+type recordBatch struct {
+	importer *importer
+
+	ops     []types.BatchOp
+	names   []string
+	actions []string
+}
+
+// add queues the record for the action, the batch is sent once it is full.
+func (b *recordBatch) add(ctx context.Context, action, kind, id, name string, data any) error {
+	if importDryRun || action == "skip" {
+		return nil
+	}
+
+	op := types.OpCreate
+	if action == "overwrite" {
+		op = types.OpUpdate
+	} else {
+		id = ""
+	}
+	bop, err := keeperclient.NewBatchOp(op, kind, id, data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	b.ops = append(b.ops, bop)
+	b.names = append(b.names, name)
+	b.actions = append(b.actions, action)
+	if len(b.ops) < types.MaxBatchOps {
+		return nil
+	}
+	return b.flush(ctx)
+}
+
+func (b *recordBatch) flush(ctx context.Context) error {
+	if len(b.ops) == 0 {
+		return nil
+	}
+	defer func() {
+		b.ops, b.names, b.actions = b.ops[:0], b.names[:0], b.actions[:0]
+	}()
+
+	resp, err := b.importer.client.Batch(ctx, types.BatchRequest{Mode: types.BatchAtomic, Ops: b.ops})
+	if err == nil {
+		return nil
+	}
+
+	for _, action := range b.actions {
+		if action == "overwrite" {
+			b.importer.overwritten--
+		} else {
+			b.importer.created--
+		}
+	}
+	if resp != nil {
+		for i, res := range resp.Results {
+			if i < len(b.names) && res.Status >= http.StatusBadRequest && res.Status != http.StatusFailedDependency {
+				return fmt.Errorf("%s: %s", b.names[i], res.Error)
+			}
+		}
+	}
+	return err
+}
+
+func (im *importer) importFiles(ctx context.Context, r *vault.Reader) error {
+	for {
+		f, content, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		id := im.existing.files[f.Name]
+		action := im.action("file", f.Name, id)
+		if importDryRun || action == "skip" {
+			continue
+		}
+
+		err = im.importFile(ctx, f, content)
+		if err != nil {
+			return fmt.Errorf("file %q: %w", f.Name, err)
+		}
+		// the replaced file goes to the trash only once the new one is stored
+		if action == "overwrite" {
+			err = im.client.DeleteFile(ctx, id)
+			if err != nil {
+				return fmt.Errorf("file %q: %w", f.Name, err)
+			}
+		}
+	}
+}
+
+// importFile streams the contents straight into a new upload, the plain text
+// never touches the disk.
+func (im *importer) importFile(ctx context.Context, f *vault.File, content io.Reader) error {
+	name := filepath.Base(f.Name)
+	if name == "." || name == string(filepath.Separator) {
+		name = "file"
+	}
+
+	_, err := uploadFile(ctx, im.client, content, name, f.Size, f.Metadata, nil, "")
+	return err
+}
+
+func listKeys(ctx context.Context, list lister[*types.Key]) ([]*types.Key, error) {
+	keys, _, err := fetchAll(ctx, list, types.ListOptions{})
+	return keys, err
+}
+
+func listFiles(ctx context.Context, client *keeperclient.Client) ([]*types.FileInfo, error) {
+	files, _, err := fetchAll(ctx, client.ListFiles, types.ListOptions{})
+	return files, err
+}
+
+func lastDigits(number string) string {
+	if len(number) < 4 {
+		return number
+	}
+	return number[len(number)-4:]
+}
@@ -0,0 +1,110 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"keeper-project/internal/vault"
+	"keeper-project/pkg/keeperclient"
+	"keeper-project/types"
+)
+
+func TestReadPassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "passphrase")
+	require.NoError(t, os.WriteFile(path, []byte("from file\r\nsecond line\n"), 0600))
+	empty := filepath.Join(t.TempDir(), "empty")
+	require.NoError(t, os.WriteFile(empty, []byte("\n"), 0600))
+
+	tests := []struct {
+		name    string
+		flag    string
+		file    string
+		env     string
+		want    string
+		wantErr bool
+	}{
+		{name: "flag", flag: "from flag", file: path, env: "from env", want: "from flag"},
+		{name: "file", file: path, env: "from env", want: "from file"},
+		{name: "env", env: "from env", want: "from env"},
+		{name: "empty file", file: empty, wantErr: true},
+		{name: "missing file", file: filepath.Join(t.TempDir(), "missing"), wantErr: true},
+		// stdin of the tests is not a terminal, so nothing is asked
+		{name: "none", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vaultPassphrase, vaultPassphraseFile = tt.flag, tt.file
+			t.Cleanup(func() { vaultPassphrase, vaultPassphraseFile = "", "" })
+			t.Setenv(passphraseEnv, tt.env)
+
+			got, err := readPassphrase(false)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestImportRecords_Batches(t *testing.T) {
+	var batches [][]types.BatchOp
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/secret/batch", r.URL.Path)
+		var req types.BatchRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		batches = append(batches, req.Ops)
+
+		resp := types.BatchResponse{Mode: req.Mode, Results: make([]types.BatchResult, len(req.Ops))}
+		// an atomic batch fails with the status of the failed operation
+		status := http.StatusOK
+		for i, op := range req.Ops {
+			resp.Results[i].Status = http.StatusCreated
+			if op.Kind == types.KindCard {
+				status = http.StatusBadRequest
+				resp.Results[i] = types.BatchResult{Status: http.StatusBadRequest, Error: "incorrect card number"}
+			} else if status != http.StatusOK {
+				resp.Results[i].Status = http.StatusFailedDependency
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer ts.Close()
+
+	m := &vault.Manifest{}
+	for i := 0; i <= types.MaxBatchOps; i++ {
+		m.Notes = append(m.Notes, types.Note{Key: "note " + strconv.Itoa(i), Text: "text"})
+	}
+	m.Credentials = []types.Credentials{{Site: "github.com", Login: "me", Password: "s3cret"}}
+
+	im := &importer{client: keeperclient.New(ts.URL), existing: &existingRecords{notes: map[string]string{"note 0": "1"}}}
+	require.NoError(t, im.importRecords(context.Background(), m))
+	require.Len(t, batches, 2)
+	assert.Len(t, batches[0], types.MaxBatchOps)
+	assert.Len(t, batches[1], 1)
+	assert.Equal(t, types.KindCredentials, batches[1][0].Kind)
+	assert.Equal(t, []int{types.MaxBatchOps + 1, 0, 1}, []int{im.created, im.overwritten, im.skipped})
+
+	// a failed batch is reported by the record that failed it and isn't counted
+	batches = nil
+	im = &importer{client: keeperclient.New(ts.URL), existing: &existingRecords{}}
+	err := im.importRecords(context.Background(), &vault.Manifest{
+		Notes: []types.Note{{Key: "note", Text: "text"}},
+		Cards: []types.CardInfo{{Number: "1234", Expiration: "12/30", CVV: "123"}},
+	})
+	assert.EqualError(t, err, "card *1234: incorrect card number")
+	assert.Len(t, batches, 1)
+	assert.Zero(t, im.created)
+}
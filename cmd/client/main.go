@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"os"
 
 	"keeper-project/cmd/client/app"
 )
@@ -12,6 +13,7 @@ var (
 )
 
 func main() {
-	fmt.Printf("Build version: %s\nBuild date: %s\n", buildVersion, buildTime)
+	// stdout is left to the output of the commands, eval "$(keeper env)" and --output rely on it
+	fmt.Fprintf(os.Stderr, "Build version: %s\nBuild date: %s\n", buildVersion, buildTime)
 	app.Execute()
 }
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/docker/go-units"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"keeper-project/internal/store/postgres/quotas"
+	"keeper-project/types"
+)
+
+// setQuota replaces the per-user override of the default quota, e.g.
+//
+//	server set-quota <user_id> file_bytes=50GB notes=1000 max_file_size=2GB
+//
+// Sizes take the units of -max-upload-size and alike, 0 means no limit.
+func setQuota(ctx context.Context, logger *zap.Logger, db *sql.DB, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: set-quota <user_id> [limit=value ...]")
+	}
+	userID := args[0]
+	if _, err := uuid.Parse(userID); err != nil {
+		return fmt.Errorf("incorrect user id %q", userID)
+	}
+
+	quota, err := parseQuota(args[1:])
+	if err != nil {
+		return err
+	}
+
+	err = quotas.NewRepository(db, types.Quota{}).SetQuota(ctx, userID, quota)
+	if err != nil {
+		return err
+	}
+	logger.Info("quota set", zap.String("user", userID), zap.Strings("limits", args[1:]))
+	return nil
+}
+
+func parseQuota(args []string) (types.QuotaOverride, error) {
+	var quota types.QuotaOverride
+	limits := map[string]struct {
+		limit **int64
+		bytes bool
+	}{
+		"file_bytes":    {&quota.FileBytes, true},
+		"max_file_size": {&quota.MaxFileSize, true},
+		"notes":         {&quota.Notes, false},
+		"cards":         {&quota.Cards, false},
+		"credentials":   {&quota.Credentials, false},
+	}
+
+	for _, arg := range args {
+		name, value, ok := strings.Cut(arg, "=")
+		l, known := limits[name]
+		if !ok || !known {
+			return quota, fmt.Errorf("incorrect limit %q, want one of file_bytes, max_file_size, notes, cards, credentials=<value>", arg)
+		}
+
+		var (
+			n   int64
+			err error
+		)
+		if l.bytes {
+			n, err = units.RAMInBytes(value)
+		} else {
+			n, err = strconv.ParseInt(value, 10, 64)
+		}
+		if err != nil || n < 0 {
+			return quota, fmt.Errorf("incorrect value of %s: %q", name, value)
+		}
+		*l.limit = &n
+	}
+	return quota, nil
+}
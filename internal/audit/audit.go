@@ -0,0 +1,178 @@
+// Package audit checks the health of a decrypted vault: passwords used for
+// several entries, weak passwords, passwords not changed for a long time and
+// expired cards. It runs on the client, passwords never leave it and are not
+// part of the report.
+package audit
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"keeper-project/internal/generator"
+)
+
+// Credential is a decrypted credentials entry.
+type Credential struct {
+	ID        string
+	Site      string
+	Login     string
+	Password  string
+	UpdatedAt time.Time
+}
+
+// Card is a decrypted card.
+type Card struct {
+	ID         string
+	Number     string
+	Expiration string
+}
+
+// Options of Run, entries not updated for MaxAge are old, zero disables the check.
+type Options struct {
+	MaxAge time.Duration
+	Now    time.Time
+}
+
+// Entry names a credentials entry in the report.
+type Entry struct {
+	ID    string `json:"id"`
+	Site  string `json:"site"`
+	Login string `json:"login"`
+}
+
+func (e Entry) String() string {
+	return e.Login + "@" + e.Site
+}
+
+// Reused are the entries sharing one password.
+type Reused struct {
+	Entries []Entry `json:"entries"`
+}
+
+// Weak is an entry with a password that is easy to guess.
+type Weak struct {
+	Entry
+	Strength string  `json:"strength"`
+	Entropy  float64 `json:"entropy"`
+}
+
+// Old is an entry not updated for Days days.
+type Old struct {
+	Entry
+	UpdatedAt time.Time `json:"updated_at"`
+	Days      int       `json:"days"`
+}
+
+// Expired is a card past its expiration, Last4 are the last digits of its number.
+type Expired struct {
+	ID         string `json:"id"`
+	Last4      string `json:"last4"`
+	Expiration string `json:"expiration"`
+}
+
+// Report lists the problems found, every list is sorted to keep the output stable.
+type Report struct {
+	Reused  []Reused  `json:"reused"`
+	Weak    []Weak    `json:"weak"`
+	Old     []Old     `json:"old"`
+	Expired []Expired `json:"expired_cards"`
+	// Breached is only set by CheckBreaches.
+	Breached []Breached `json:"breached,omitempty"`
+}
+
+// Problems is the number of entries and cards with problems.
+func (r *Report) Problems() int {
+	n := len(r.Weak) + len(r.Old) + len(r.Expired) + len(r.Breached)
+	for _, group := range r.Reused {
+		n += len(group.Entries)
+	}
+	return n
+}
+
+// Run checks the credentials and the cards.
+func Run(creds []Credential, cards []Card, opts Options) *Report {
+	r := &Report{Reused: []Reused{}, Weak: []Weak{}, Old: []Old{}, Expired: []Expired{}}
+
+	creds = slices.Clone(creds)
+	sort.SliceStable(creds, func(i, j int) bool {
+		return creds[i].Site+"\x00"+creds[i].Login < creds[j].Site+"\x00"+creds[j].Login
+	})
+
+	byPassword := make(map[string][]Entry)
+	var passwords []string
+	for _, c := range creds {
+		e := Entry{ID: c.ID, Site: c.Site, Login: c.Login}
+
+		if c.Password != "" {
+			if _, ok := byPassword[c.Password]; !ok {
+				passwords = append(passwords, c.Password)
+			}
+			byPassword[c.Password] = append(byPassword[c.Password], e)
+
+			s := generator.Estimate(c.Password)
+			if s.Weak() {
+				r.Weak = append(r.Weak, Weak{Entry: e, Strength: s.String(), Entropy: s.Entropy})
+			}
+		}
+
+		if opts.MaxAge > 0 && !c.UpdatedAt.IsZero() && opts.Now.Sub(c.UpdatedAt) >= opts.MaxAge {
+			r.Old = append(r.Old, Old{Entry: e, UpdatedAt: c.UpdatedAt, Days: int(opts.Now.Sub(c.UpdatedAt).Hours() / 24)})
+		}
+	}
+
+	for _, pw := range passwords {
+		if entries := byPassword[pw]; len(entries) > 1 {
+			r.Reused = append(r.Reused, Reused{Entries: entries})
+		}
+	}
+	sort.SliceStable(r.Reused, func(i, j int) bool {
+		return len(r.Reused[i].Entries) > len(r.Reused[j].Entries)
+	})
+	sort.SliceStable(r.Old, func(i, j int) bool {
+		return r.Old[i].UpdatedAt.Before(r.Old[j].UpdatedAt)
+	})
+
+	for _, c := range cards {
+		end, err := ExpirationEnd(c.Expiration)
+		if err != nil || opts.Now.Before(end) {
+			continue
+		}
+		r.Expired = append(r.Expired, Expired{ID: c.ID, Last4: lastDigits(c.Number), Expiration: c.Expiration})
+	}
+	return r
+}
+
+// ExpirationEnd parses the MM/YY or MM/YYYY expiration of a card, the card
+// expires when its month is over.
+func ExpirationEnd(exp string) (time.Time, error) {
+	month, year, ok := strings.Cut(strings.TrimSpace(exp), "/")
+	m, err := strconv.Atoi(strings.TrimSpace(month))
+	if !ok || err != nil || m < 1 || m > 12 {
+		return time.Time{}, fmt.Errorf("incorrect expiration %q", exp)
+	}
+	y, err := strconv.Atoi(strings.TrimSpace(year))
+	if err != nil || y < 0 {
+		return time.Time{}, fmt.Errorf("incorrect expiration %q", exp)
+	}
+	if y < 100 {
+		y += 2000
+	}
+	return time.Date(y, time.Month(m)+1, 1, 0, 0, 0, 0, time.UTC), nil
+}
+
+func lastDigits(number string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r < '0' || r > '9' {
+			return -1
+		}
+		return r
+	}, number)
+	if len(digits) < 4 {
+		return digits
+	}
+	return digits[len(digits)-4:]
+}
@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	strong := "Xk9#mQ2$vL7@pR4&wZ1!"
+
+	creds := []Credential{
+		{ID: "1", Site: "b.com", Login: "bob", Password: strong, UpdatedAt: now.AddDate(0, 0, -10)},
+		{ID: "2", Site: "a.com", Login: "ann", Password: strong, UpdatedAt: now.AddDate(0, 0, -400)},
+		{ID: "3", Site: "c.com", Login: "cat", Password: "password1", UpdatedAt: now.AddDate(0, 0, -100)},
+		{ID: "4", Site: "d.com", Login: "dan", Password: "Tz8!pW4#nR6$", UpdatedAt: now.AddDate(-2, 0, 0)},
+	}
+	cards := []Card{
+		{ID: "5", Number: "4111 1111 1111 1234", Expiration: "05/24"},
+		{ID: "6", Number: "4111111111115678", Expiration: "06/24"},
+		{ID: "7", Number: "4111111111119012", Expiration: "12/2030"},
+		{ID: "8", Number: "4111111111113456", Expiration: "soon"},
+	}
+
+	r := Run(creds, cards, Options{MaxAge: 365 * 24 * time.Hour, Now: now})
+
+	require.Len(t, r.Reused, 1)
+	assert.Equal(t, []Entry{{ID: "2", Site: "a.com", Login: "ann"}, {ID: "1", Site: "b.com", Login: "bob"}}, r.Reused[0].Entries)
+
+	require.Len(t, r.Weak, 1)
+	assert.Equal(t, "cat@c.com", r.Weak[0].String())
+	assert.Equal(t, "very weak", r.Weak[0].Strength)
+
+	require.Len(t, r.Old, 2)
+	assert.Equal(t, "4", r.Old[0].ID)
+	assert.Equal(t, 731, r.Old[0].Days)
+	assert.Equal(t, "2", r.Old[1].ID)
+	assert.Equal(t, 400, r.Old[1].Days)
+
+	assert.Equal(t, []Expired{{ID: "5", Last4: "1234", Expiration: "05/24"}}, r.Expired)
+	assert.Equal(t, 6, r.Problems())
+}
+
+func TestRun_Empty(t *testing.T) {
+	r := Run(nil, nil, Options{Now: time.Now()})
+	assert.Zero(t, r.Problems())
+	assert.NotNil(t, r.Reused)
+	assert.NotNil(t, r.Expired)
+}
+
+func TestExpirationEnd(t *testing.T) {
+	end, err := ExpirationEnd("12/24")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), end)
+
+	end, err = ExpirationEnd(" 3 / 2027 ")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2027, 4, 1, 0, 0, 0, 0, time.UTC), end)
+
+	for _, exp := range []string{"", "13/24", "1224", "ab/cd"} {
+		_, err = ExpirationEnd(exp)
+		assert.Error(t, err, exp)
+	}
+}
@@ -0,0 +1,238 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PrefixLength is the number of hex digits of the SHA-1 of a password sent
+// to a BreachProvider, the rest of the hash never leaves the client.
+const PrefixLength = 5
+
+// BreachProvider looks up the hash ranges of breached passwords the way the
+// Have I Been Pwned range API does: it gets the first PrefixLength upper case
+// hex digits of the SHA-1 of a password and returns the remaining digits of
+// the breached hashes starting with them, with the number of times each was seen.
+type BreachProvider interface {
+	Range(ctx context.Context, prefix string) (map[string]int64, error)
+}
+
+// Breached is an entry with a password found in breaches Count times.
+type Breached struct {
+	Entry
+	Count int64 `json:"count"`
+}
+
+// CheckBreaches looks up the passwords of the credentials with the provider
+// and adds the breached ones to the report.
+func (r *Report) CheckBreaches(ctx context.Context, creds []Credential, provider BreachProvider) error {
+	r.Breached = []Breached{}
+	ranges := make(map[string]map[string]int64)
+
+	for _, c := range creds {
+		if c.Password == "" {
+			continue
+		}
+
+		sum := sha1.Sum([]byte(c.Password))
+		hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+		prefix, suffix := hash[:PrefixLength], hash[PrefixLength:]
+
+		suffixes, ok := ranges[prefix]
+		if !ok {
+			var err error
+			suffixes, err = provider.Range(ctx, prefix)
+			if err != nil {
+				return fmt.Errorf("breach check: %w", err)
+			}
+			ranges[prefix] = suffixes
+		}
+
+		if n := suffixes[suffix]; n > 0 {
+			r.Breached = append(r.Breached, Breached{Entry: Entry{ID: c.ID, Site: c.Site, Login: c.Login}, Count: n})
+		}
+	}
+
+	sort.SliceStable(r.Breached, func(i, j int) bool {
+		return r.Breached[i].Count > r.Breached[j].Count
+	})
+	return nil
+}
+
+// NewFileProvider opens a local copy of the Pwned Passwords SHA-1 dataset,
+// so that the check works without network. The path is either the file of
+// HASH:COUNT lines ordered by hash, searched in place, or a directory of
+// range files named after the prefixes holding SUFFIX:COUNT lines, as made by
+// the official downloader.
+func NewFileProvider(path string) (BreachProvider, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return rangeDir(path), nil
+	}
+	return &sortedFile{path: path, size: info.Size()}, nil
+}
+
+// rangeDir is a directory of range files.
+type rangeDir string
+
+func (dir rangeDir) Range(_ context.Context, prefix string) (map[string]int64, error) {
+	if err := checkPrefix(prefix); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(filepath.Join(string(dir), prefix+".txt"))
+	if errors.Is(err, os.ErrNotExist) {
+		f, err = os.Open(filepath.Join(string(dir), prefix))
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	suffixes := make(map[string]int64)
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		suffix, n, err := parseLine(sc.Text())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name(), err)
+		}
+		if suffix != "" {
+			suffixes[suffix] = n
+		}
+	}
+	return suffixes, sc.Err()
+}
+
+// sortedFile is the file of whole hashes ordered by hash, its ranges are
+// found with a binary search over the byte offsets.
+type sortedFile struct {
+	path string
+	size int64
+}
+
+func (sf *sortedFile) Range(ctx context.Context, prefix string) (map[string]int64, error) {
+	if err := checkPrefix(prefix); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(sf.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// the smallest offset whose next line is not below the prefix
+	var searchErr error
+	start := sort.Search(int(sf.size), func(off int) bool {
+		if searchErr != nil || ctx.Err() != nil {
+			return true
+		}
+		_, line, err := lineAt(f, int64(off))
+		if errors.Is(err, io.EOF) {
+			return true
+		}
+		if err != nil {
+			searchErr = err
+			return true
+		}
+		return string(line) >= prefix
+	})
+	if searchErr != nil {
+		return nil, searchErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	off, _, err := lineAt(f, int64(start))
+	if errors.Is(err, io.EOF) {
+		return map[string]int64{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	suffixes := make(map[string]int64)
+	sc := bufio.NewScanner(io.NewSectionReader(f, off, sf.size-off))
+	for sc.Scan() {
+		hash, n, err := parseLine(sc.Text())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", sf.path, err)
+		}
+		if !strings.HasPrefix(hash, prefix) {
+			break
+		}
+		suffixes[hash[len(prefix):]] = n
+	}
+	return suffixes, sc.Err()
+}
+
+// lineAt returns the first line starting at or after off and its offset.
+func lineAt(r io.ReaderAt, off int64) (int64, []byte, error) {
+	buf := make([]byte, 128)
+
+	if off > 0 {
+		// skip the rest of the line off-1 is in
+		for pos := off - 1; ; pos += int64(len(buf)) {
+			n, err := r.ReadAt(buf, pos)
+			if i := bytes.IndexByte(buf[:n], '\n'); i >= 0 {
+				off = pos + int64(i) + 1
+				break
+			}
+			if err != nil {
+				return 0, nil, err
+			}
+		}
+	}
+
+	var line []byte
+	for pos := off; ; pos += int64(len(buf)) {
+		n, err := r.ReadAt(buf, pos)
+		if i := bytes.IndexByte(buf[:n], '\n'); i >= 0 {
+			return off, bytes.TrimSpace(append(line, buf[:i]...)), nil
+		}
+		line = append(line, buf[:n]...)
+		if errors.Is(err, io.EOF) && len(line) > 0 {
+			return off, bytes.TrimSpace(line), nil
+		}
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+}
+
+// parseLine parses a HASH:COUNT line, blank lines give an empty hash.
+func parseLine(line string) (string, int64, error) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return "", 0, nil
+	}
+
+	hash, count, ok := strings.Cut(line, ":")
+	n, err := strconv.ParseInt(count, 10, 64)
+	if !ok || err != nil {
+		return "", 0, fmt.Errorf("incorrect line %q", line)
+	}
+	return strings.ToUpper(hash), n, nil
+}
+
+func checkPrefix(prefix string) error {
+	if len(prefix) != PrefixLength || strings.Trim(prefix, "0123456789ABCDEF") != "" {
+		return fmt.Errorf("incorrect hash prefix %q", prefix)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sha1Hex(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// breachFile writes a dataset ordered by hash with the passwords and some
+// filler hashes around them.
+func breachFile(t *testing.T, passwords map[string]int64) string {
+	t.Helper()
+
+	var lines []string
+	for pw, n := range passwords {
+		lines = append(lines, sha1Hex(pw)+":"+strconv.FormatInt(n, 10))
+	}
+	for i := 0; i < 500; i++ {
+		lines = append(lines, sha1Hex("filler"+strconv.Itoa(i))+":1")
+	}
+	sort.Strings(lines)
+
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\r\n")), 0600))
+	return path
+}
+
+func TestSortedFile_Range(t *testing.T) {
+	path := breachFile(t, map[string]int64{"password1": 2400000, "qwerty": 1000})
+	provider, err := NewFileProvider(path)
+	require.NoError(t, err)
+
+	hash := sha1Hex("password1")
+	suffixes, err := provider.Range(context.Background(), hash[:PrefixLength])
+	require.NoError(t, err)
+	assert.Equal(t, int64(2400000), suffixes[hash[PrefixLength:]])
+	for suffix := range suffixes {
+		assert.Len(t, suffix, 40-PrefixLength)
+	}
+
+	// every line of the file is found, the first and the last ones too
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	for _, line := range strings.Split(string(data), "\r\n") {
+		suffixes, err := provider.Range(context.Background(), line[:PrefixLength])
+		require.NoError(t, err)
+		assert.Contains(t, suffixes, line[PrefixLength:40])
+	}
+
+	suffixes, err = provider.Range(context.Background(), "FFFFF")
+	require.NoError(t, err)
+	assert.Empty(t, suffixes)
+
+	_, err = provider.Range(context.Background(), sha1Hex("x"))
+	assert.Error(t, err)
+}
+
+func TestRangeDir_Range(t *testing.T) {
+	dir := t.TempDir()
+	hash := sha1Hex("letmein")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, hash[:PrefixLength]+".txt"),
+		[]byte("0018A45C4D1DEF81644B54AB7F969B88D65:1\n"+hash[PrefixLength:]+":42\n"), 0600))
+
+	provider, err := NewFileProvider(dir)
+	require.NoError(t, err)
+
+	suffixes, err := provider.Range(context.Background(), hash[:PrefixLength])
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"0018A45C4D1DEF81644B54AB7F969B88D65": 1, hash[PrefixLength:]: 42}, suffixes)
+
+	_, err = provider.Range(context.Background(), "00000")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+// prefixProvider records the prefixes it is asked for.
+type prefixProvider struct {
+	inner    BreachProvider
+	prefixes []string
+	err      error
+}
+
+func (p *prefixProvider) Range(ctx context.Context, prefix string) (map[string]int64, error) {
+	p.prefixes = append(p.prefixes, prefix)
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.inner.Range(ctx, prefix)
+}
+
+func TestReport_CheckBreaches(t *testing.T) {
+	inner, err := NewFileProvider(breachFile(t, map[string]int64{"password1": 2400000, "qwerty": 1000}))
+	require.NoError(t, err)
+	provider := &prefixProvider{inner: inner}
+
+	creds := []Credential{
+		{ID: "1", Site: "a.com", Login: "ann", Password: "qwerty"},
+		{ID: "2", Site: "b.com", Login: "bob", Password: "Xk9#mQ2$vL7@pR4&wZ1!"},
+		{ID: "3", Site: "c.com", Login: "cat", Password: "password1"},
+		{ID: "4", Site: "d.com", Login: "dan", Password: "qwerty"},
+	}
+
+	r := Run(creds, nil, Options{})
+	require.NoError(t, r.CheckBreaches(context.Background(), creds, provider))
+
+	require.Len(t, r.Breached, 3)
+	assert.Equal(t, "3", r.Breached[0].ID)
+	assert.Equal(t, int64(2400000), r.Breached[0].Count)
+	assert.Equal(t, int64(1000), r.Breached[1].Count)
+
+	// only the prefixes are looked up, once per password
+	assert.Len(t, provider.prefixes, 3)
+	for _, prefix := range provider.prefixes {
+		assert.Len(t, prefix, PrefixLength)
+	}
+
+	provider.err = errors.New("offline")
+	assert.ErrorContains(t, r.CheckBreaches(context.Background(), creds, provider), "offline")
+}
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -18,7 +19,13 @@ func init() {
 }
 
 func GetUserID(r *http.Request) (string, error) {
-	_, claims, err := jwtauth.FromContext(r.Context())
+	return UserIDFromContext(r.Context())
+}
+
+// UserIDFromContext returns the user of the token verified by jwtauth, the
+// HTTP and the gRPC servers keep it in the context of the request.
+func UserIDFromContext(ctx context.Context) (string, error) {
+	_, claims, err := jwtauth.FromContext(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,170 @@
+// Package clipboard puts secrets on the system clipboard and takes them off
+// again after a while. The system clipboard is driven by the copy and paste
+// tools of the platform: wl-clipboard on Wayland, xclip or xsel on X11 and
+// pbcopy on macOS.
+package clipboard
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// writeWaitDelay bounds the wait for the output of a copy tool after it exits:
+// xclip and wl-copy fork a process that keeps the selection and inherits the
+// stderr of the tool until something else is copied.
+const writeWaitDelay = 200 * time.Millisecond
+
+// ErrUnavailable is returned by New when no clipboard tool is found.
+var ErrUnavailable = errors.New("clipboard: no clipboard tool found, install wl-clipboard or xclip")
+
+// Clipboard is a text clipboard.
+type Clipboard interface {
+	Write(text string) error
+	Read() (string, error)
+}
+
+// Command is a clipboard driven by external tools, Clear is run instead of
+// Copy to empty the clipboard when set.
+type Command struct {
+	Copy  []string
+	Paste []string
+	Clear []string
+}
+
+// tools are tried in order, a tool is used when its environment variable is
+// set, or always when there is none, and both of its commands are found.
+var tools = []struct {
+	env string
+	cmd Command
+}{
+	{"WAYLAND_DISPLAY", Command{
+		Copy:  []string{"wl-copy"},
+		Paste: []string{"wl-paste", "--no-newline"},
+		Clear: []string{"wl-copy", "--clear"},
+	}},
+	{"DISPLAY", Command{
+		Copy:  []string{"xclip", "-selection", "clipboard", "-in"},
+		Paste: []string{"xclip", "-selection", "clipboard", "-out"},
+	}},
+	{"DISPLAY", Command{
+		Copy:  []string{"xsel", "--clipboard", "--input"},
+		Paste: []string{"xsel", "--clipboard", "--output"},
+	}},
+	{"", Command{
+		Copy:  []string{"pbcopy"},
+		Paste: []string{"pbpaste"},
+	}},
+}
+
+// New returns the system clipboard.
+func New() (Clipboard, error) {
+	for _, tool := range tools {
+		if tool.env != "" && os.Getenv(tool.env) == "" {
+			continue
+		}
+		if _, err := exec.LookPath(tool.cmd.Copy[0]); err != nil {
+			continue
+		}
+		if _, err := exec.LookPath(tool.cmd.Paste[0]); err != nil {
+			continue
+		}
+		return tool.cmd, nil
+	}
+	return nil, ErrUnavailable
+}
+
+func (c Command) Write(text string) error {
+	args := c.Copy
+	if text == "" && len(c.Clear) > 0 {
+		args = c.Clear
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = writeWaitDelay
+	err := cmd.Run()
+	if err != nil && !errors.Is(err, exec.ErrWaitDelay) {
+		return commandError(args[0], err, []byte(stderr.String()))
+	}
+	return nil
+}
+
+func (c Command) Read() (string, error) {
+	cmd := exec.Command(c.Paste[0], c.Paste[1:]...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", commandError(c.Paste[0], err, []byte(stderr.String()))
+	}
+	return string(out), nil
+}
+
+func commandError(name string, err error, out []byte) error {
+	if msg := strings.TrimSpace(string(out)); msg != "" {
+		return errors.New("clipboard: " + name + ": " + msg)
+	}
+	return errors.New("clipboard: " + name + ": " + err.Error())
+}
+
+// Fake is an in-memory clipboard for tests.
+type Fake struct {
+	mu     sync.Mutex
+	text   string
+	writes int
+}
+
+func (f *Fake) Write(text string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.text = text
+	f.writes++
+	return nil
+}
+
+func (f *Fake) Read() (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.text, nil
+}
+
+// Writes is the number of writes, clearing included.
+func (f *Fake) Writes() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.writes
+}
+
+// CopyAndClear puts the text on the clipboard and waits for the timeout or
+// for ctx to be done, then clears the clipboard unless something else was
+// copied meanwhile. A zero timeout leaves the text on the clipboard.
+func CopyAndClear(ctx context.Context, cb Clipboard, text string, timeout time.Duration) error {
+	err := cb.Write(text)
+	if err != nil || timeout <= 0 {
+		return err
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+
+	current, err := cb.Read()
+	if err != nil {
+		// it is safer to clear a clipboard that can't be read
+		return cb.Write("")
+	}
+	if current != text {
+		return nil
+	}
+	return cb.Write("")
+}
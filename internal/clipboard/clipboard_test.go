@@ -0,0 +1,118 @@
+package clipboard
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyAndClear(t *testing.T) {
+	cb := &Fake{}
+
+	require.NoError(t, CopyAndClear(context.Background(), cb, "secret", 10*time.Millisecond))
+	text, err := cb.Read()
+	require.NoError(t, err)
+	assert.Empty(t, text)
+	assert.Equal(t, 2, cb.Writes())
+}
+
+func TestCopyAndClear_Cancel(t *testing.T) {
+	cb := &Fake{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	require.NoError(t, CopyAndClear(ctx, cb, "secret", time.Hour))
+	assert.Less(t, time.Since(start), time.Minute)
+
+	text, err := cb.Read()
+	require.NoError(t, err)
+	assert.Empty(t, text)
+}
+
+// copied replaces the secret on the clipboard while CopyAndClear waits.
+type copied struct {
+	Fake
+}
+
+func (c *copied) Write(text string) error {
+	err := c.Fake.Write(text)
+	if text == "secret" {
+		err = c.Fake.Write("something else")
+	}
+	return err
+}
+
+func TestCopyAndClear_KeepsOtherText(t *testing.T) {
+	cb := &copied{}
+
+	require.NoError(t, CopyAndClear(context.Background(), cb, "secret", time.Millisecond))
+	text, err := cb.Read()
+	require.NoError(t, err)
+	assert.Equal(t, "something else", text)
+}
+
+func TestCopyAndClear_NoTimeout(t *testing.T) {
+	cb := &Fake{}
+
+	require.NoError(t, CopyAndClear(context.Background(), cb, "secret", 0))
+	text, err := cb.Read()
+	require.NoError(t, err)
+	assert.Equal(t, "secret", text)
+}
+
+func TestCommand(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no shell")
+	}
+
+	file := filepath.Join(t.TempDir(), "clipboard")
+	cb := Command{
+		Copy:  []string{"sh", "-c", "cat > " + file},
+		Paste: []string{"sh", "-c", "cat " + file},
+	}
+
+	require.NoError(t, cb.Write("secret"))
+	text, err := cb.Read()
+	require.NoError(t, err)
+	assert.Equal(t, "secret", text)
+
+	require.NoError(t, cb.Write(""))
+	text, err = cb.Read()
+	require.NoError(t, err)
+	assert.Empty(t, text)
+
+	cb.Paste = []string{"sh", "-c", "echo no display >&2; exit 1"}
+	_, err = cb.Read()
+	assert.EqualError(t, err, "clipboard: sh: no display")
+
+	cb.Copy = []string{"sh", "-c", "echo no display >&2; exit 1"}
+	assert.EqualError(t, cb.Write("secret"), "clipboard: sh: no display")
+}
+
+func TestCommand_ForkingTool(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no shell")
+	}
+
+	// like xclip the tool leaves a child keeping the selection, which holds
+	// the stdout and stderr of the tool open
+	file := filepath.Join(t.TempDir(), "clipboard")
+	cb := Command{
+		Copy:  []string{"sh", "-c", "cat > " + file + "; sleep 5 &"},
+		Paste: []string{"sh", "-c", "cat " + file},
+	}
+
+	start := time.Now()
+	require.NoError(t, cb.Write("secret"))
+	assert.Less(t, time.Since(start), 3*time.Second)
+
+	text, err := cb.Read()
+	require.NoError(t, err)
+	assert.Equal(t, "secret", text)
+}
@@ -0,0 +1,295 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/hkdf"
+)
+
+// Files are encrypted with the STREAM construction: the content is split into
+// FileChunkSize pieces, every piece is sealed with AES-GCM under a per-file key
+// and a nonce made of the chunk counter and a flag marking the last chunk, so
+// reordered, dropped or truncated chunks fail authentication.
+//
+// Layout: magic | salt | chunk 0 | chunk 1 | ... | last chunk.
+
+// FileChunkSize is the plaintext size of every chunk except the last one.
+const FileChunkSize = 64 << 10
+
+// FileHeaderSize is the size of the magic and salt preceding the first chunk.
+const FileHeaderSize = len(fileMagic) + saltSize
+
+const (
+	fileMagic = "KPRF\x01"
+	saltSize  = 32
+	tagSize   = 16
+)
+
+// Argon2id parameters of StretchPassphrase, changing them breaks existing archives.
+const (
+	argonTime    = 3
+	argonMemory  = 64 << 10
+	argonThreads = 4
+)
+
+var ErrNotEncrypted = errors.New("crypto: not an encrypted file")
+var ErrTruncated = errors.New("crypto: encrypted file is truncated")
+
+// FileCipher encrypts and decrypts the contents of a single file.
+type FileCipher struct {
+	aead cipher.AEAD
+	salt []byte
+}
+
+// NewSalt returns a random salt for a new file.
+func NewSalt() ([]byte, error) {
+	salt := make([]byte, saltSize)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+// ConvergentSalt derives the salt from the password and the file contents, so the
+// same file is encrypted to the same bytes and the server can store it once.
+// It reveals to the server which of the user's files are identical.
+func ConvergentSalt(password string, r io.Reader) ([]byte, error) {
+	key := make([]byte, 32)
+	_, err := io.ReadFull(hkdf.New(sha256.New, []byte(password), nil, []byte("keeper convergent salt")), key)
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sha256.New, key)
+	_, err = io.Copy(mac, r)
+	if err != nil {
+		return nil, err
+	}
+	return mac.Sum(nil), nil
+}
+
+// NewFileCipher derives the file key from the password and the file salt.
+func NewFileCipher(password string, salt []byte) (*FileCipher, error) {
+	if len(salt) != saltSize {
+		return nil, fmt.Errorf("crypto: salt must be %d bytes", saltSize)
+	}
+
+	key := make([]byte, 32)
+	_, err := io.ReadFull(hkdf.New(sha256.New, []byte(password), salt, []byte("keeper file")), key)
+	if err != nil {
+		return nil, err
+	}
+
+	aesblock, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aesgcm, err := cipher.NewGCM(aesblock)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileCipher{aead: aesgcm, salt: salt}, nil
+}
+
+// ReadFileHeader reads the header of an encrypted file and returns its salt.
+func ReadFileHeader(r io.Reader) ([]byte, error) {
+	header := make([]byte, FileHeaderSize)
+	_, err := io.ReadFull(r, header)
+	if err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, ErrNotEncrypted
+		}
+		return nil, err
+	}
+	if !IsEncryptedFile(header) {
+		return nil, ErrNotEncrypted
+	}
+	return header[len(fileMagic):], nil
+}
+
+// IsEncryptedFile reports whether the data starts with an encrypted file header.
+func IsEncryptedFile(prefix []byte) bool {
+	return bytes.HasPrefix(prefix, []byte(fileMagic))
+}
+
+// EncryptedFileSize returns the size of the encrypted file for the given plaintext size.
+func EncryptedFileSize(size int64) int64 {
+	chunks := (size + FileChunkSize - 1) / FileChunkSize
+	if chunks == 0 {
+		chunks = 1
+	}
+	return int64(FileHeaderSize) + size + chunks*tagSize
+}
+
+// PlainFileSize returns the plaintext size of an encrypted file of the given size.
+func PlainFileSize(size int64) (int64, error) {
+	body := size - int64(FileHeaderSize)
+	full, rest := body/(FileChunkSize+tagSize), body%(FileChunkSize+tagSize)
+	if body < tagSize || (rest > 0 && rest < tagSize) {
+		return 0, ErrTruncated
+	}
+	if rest > 0 {
+		return full*FileChunkSize + rest - tagSize, nil
+	}
+	return full * FileChunkSize, nil
+}
+
+// StretchPassphrase derives a password for NewFileCipher from a passphrase
+// chosen by the user with Argon2id, so that guessing it offline is expensive.
+func StretchPassphrase(passphrase string, salt []byte) string {
+	key := argon2.IDKey([]byte(passphrase), salt, argonTime, argonMemory, argonThreads, 32)
+	return hex.EncodeToString(key)
+}
+
+// ChunkOffset returns the offset of the encrypted chunk n in the encrypted file.
+func ChunkOffset(n int64) int64 {
+	return int64(FileHeaderSize) + n*(FileChunkSize+tagSize)
+}
+
+// NewWriter writes the header to dst and returns a writer encrypting into it.
+// Close must be called to seal the last chunk, it doesn't close dst.
+func (c *FileCipher) NewWriter(dst io.Writer) (io.WriteCloser, error) {
+	_, err := dst.Write(append([]byte(fileMagic), c.salt...))
+	if err != nil {
+		return nil, err
+	}
+	return &encryptWriter{cipher: c, dst: dst, buf: make([]byte, 0, FileChunkSize)}, nil
+}
+
+// NewReader returns a reader decrypting src, which must be positioned right
+// after the header when first is 0 or at ChunkOffset(first) otherwise.
+func (c *FileCipher) NewReader(src io.Reader, first int64) io.Reader {
+	return &decryptReader{cipher: c, src: src, counter: uint64(first), buf: make([]byte, FileChunkSize+tagSize)}
+}
+
+func (c *FileCipher) nonce(counter uint64, last bool) []byte {
+	nonce := make([]byte, c.aead.NonceSize())
+	binary.BigEndian.PutUint64(nonce[len(nonce)-9:], counter)
+	if last {
+		nonce[len(nonce)-1] = 1
+	}
+	return nonce
+}
+
+type encryptWriter struct {
+	cipher  *FileCipher
+	dst     io.Writer
+	buf     []byte
+	counter uint64
+	closed  bool
+}
+
+func (w *encryptWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errors.New("crypto: write to closed writer")
+	}
+
+	total := len(p)
+	for len(p) > 0 {
+		// a full chunk is sealed only once more data arrives, the last one is sealed by Close.
+		if len(w.buf) == FileChunkSize {
+			err := w.seal(false)
+			if err != nil {
+				return total - len(p), err
+			}
+		}
+		n := copy(w.buf[len(w.buf):FileChunkSize], p)
+		w.buf = w.buf[:len(w.buf)+n]
+		p = p[n:]
+	}
+	return total, nil
+}
+
+func (w *encryptWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	return w.seal(true)
+}
+
+func (w *encryptWriter) seal(last bool) error {
+	out := w.cipher.aead.Seal(nil, w.cipher.nonce(w.counter, last), w.buf, nil)
+	w.counter++
+	w.buf = w.buf[:0]
+	_, err := w.dst.Write(out)
+	return err
+}
+
+type decryptReader struct {
+	cipher  *FileCipher
+	src     io.Reader
+	buf     []byte
+	plain   []byte
+	counter uint64
+	done    bool
+	err     error
+}
+
+func (r *decryptReader) Read(p []byte) (int, error) {
+	for len(r.plain) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		if r.done {
+			return 0, io.EOF
+		}
+		r.plain, r.err = r.open()
+	}
+
+	n := copy(p, r.plain)
+	r.plain = r.plain[n:]
+	return n, nil
+}
+
+func (r *decryptReader) open() ([]byte, error) {
+	n, err := io.ReadFull(r.src, r.buf)
+	switch {
+	case errors.Is(err, io.EOF):
+		return nil, ErrTruncated
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		// only the last chunk may be shorter than a full one
+		return r.openChunk(r.buf[:n], true)
+	case err != nil:
+		return nil, err
+	}
+
+	plain, err := r.openChunk(r.buf, false)
+	if err == nil {
+		return plain, nil
+	}
+
+	plain, err = r.openChunk(r.buf, true)
+	if err != nil {
+		return nil, err
+	}
+	var extra [1]byte
+	if n, _ := r.src.Read(extra[:]); n > 0 {
+		return nil, errors.New("crypto: data after the last chunk")
+	}
+	return plain, nil
+}
+
+func (r *decryptReader) openChunk(chunk []byte, last bool) ([]byte, error) {
+	plain, err := r.cipher.aead.Open(nil, r.cipher.nonce(r.counter, last), chunk, nil)
+	if err != nil {
+		return nil, fmt.Errorf("crypto: chunk %d: %w", r.counter, err)
+	}
+	r.counter++
+	r.done = last
+	return plain, nil
+}
@@ -0,0 +1,146 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encryptFile(t *testing.T, password string, salt, data []byte) []byte {
+	c, err := NewFileCipher(password, salt)
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	w, err := c.NewWriter(&out)
+	require.NoError(t, err)
+	_, err = w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	return out.Bytes()
+}
+
+func decryptFile(password string, data []byte) ([]byte, error) {
+	src := bytes.NewReader(data)
+	salt, err := ReadFileHeader(src)
+	if err != nil {
+		return nil, err
+	}
+	c, err := NewFileCipher(password, salt)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(c.NewReader(src, 0))
+}
+
+func TestFileCipher_RoundTrip(t *testing.T) {
+	salt, err := NewSalt()
+	require.NoError(t, err)
+
+	for _, size := range []int{0, 1, FileChunkSize - 1, FileChunkSize, FileChunkSize + 1, 3 * FileChunkSize} {
+		data := make([]byte, size)
+		_, err = rand.Read(data)
+		require.NoError(t, err)
+
+		enc := encryptFile(t, "test_pass", salt, data)
+		assert.Equal(t, EncryptedFileSize(int64(size)), int64(len(enc)), "size %d", size)
+
+		dec, err := decryptFile("test_pass", enc)
+		require.NoError(t, err, "size %d", size)
+		assert.Equal(t, data, append([]byte{}, dec...), "size %d", size)
+	}
+}
+
+func TestFileCipher_Deterministic(t *testing.T) {
+	salt, err := NewSalt()
+	require.NoError(t, err)
+
+	data := bytes.Repeat([]byte("a"), 2*FileChunkSize)
+	assert.Equal(t, encryptFile(t, "test_pass", salt, data), encryptFile(t, "test_pass", salt, data))
+}
+
+func TestFileCipher_Integrity(t *testing.T) {
+	salt, err := NewSalt()
+	require.NoError(t, err)
+
+	data := bytes.Repeat([]byte("a"), 2*FileChunkSize+10)
+	enc := encryptFile(t, "test_pass", salt, data)
+
+	_, err = decryptFile("test_pass2", enc)
+	assert.Error(t, err)
+
+	tampered := append([]byte{}, enc...)
+	tampered[FileHeaderSize+10] ^= 1
+	_, err = decryptFile("test_pass", tampered)
+	assert.Error(t, err)
+
+	// dropping the last chunk leaves a stream ending on a non-final chunk
+	_, err = decryptFile("test_pass", enc[:ChunkOffset(2)])
+	assert.ErrorIs(t, err, ErrTruncated)
+
+	_, err = decryptFile("test_pass", enc[:len(enc)-1])
+	assert.Error(t, err)
+
+	_, err = decryptFile("test_pass", []byte("plain text"))
+	assert.ErrorIs(t, err, ErrNotEncrypted)
+}
+
+func TestFileCipher_ReadFromChunk(t *testing.T) {
+	salt, err := NewSalt()
+	require.NoError(t, err)
+
+	data := make([]byte, 3*FileChunkSize+100)
+	_, err = rand.Read(data)
+	require.NoError(t, err)
+	enc := encryptFile(t, "test_pass", salt, data)
+
+	c, err := NewFileCipher("test_pass", salt)
+	require.NoError(t, err)
+
+	dec, err := io.ReadAll(c.NewReader(bytes.NewReader(enc[ChunkOffset(2):]), 2))
+	require.NoError(t, err)
+	assert.Equal(t, data[2*FileChunkSize:], dec)
+}
+
+func TestConvergentSalt(t *testing.T) {
+	data := []byte("the same file uploaded twice")
+
+	first, err := ConvergentSalt("password", bytes.NewReader(data))
+	require.NoError(t, err)
+	second, err := ConvergentSalt("password", bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.Equal(t, encryptFile(t, "password", first, data), encryptFile(t, "password", second, data))
+
+	other, err := ConvergentSalt("password", bytes.NewReader(append(data, '!')))
+	require.NoError(t, err)
+	assert.NotEqual(t, first, other)
+
+	other, err = ConvergentSalt("other password", bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.NotEqual(t, first, other)
+}
+
+func TestPlainFileSize(t *testing.T) {
+	for _, size := range []int64{0, 1, FileChunkSize - 1, FileChunkSize, FileChunkSize + 1, 3 * FileChunkSize} {
+		plain, err := PlainFileSize(EncryptedFileSize(size))
+		require.NoError(t, err)
+		assert.Equal(t, size, plain, size)
+	}
+
+	_, err := PlainFileSize(int64(FileHeaderSize))
+	assert.ErrorIs(t, err, ErrTruncated)
+}
+
+func TestStretchPassphrase(t *testing.T) {
+	salt := bytes.Repeat([]byte{1}, saltSize)
+
+	key := StretchPassphrase("passphrase", salt)
+	assert.Equal(t, key, StretchPassphrase("passphrase", salt))
+	assert.NotEqual(t, key, StretchPassphrase("other", salt))
+	assert.NotEqual(t, key, StretchPassphrase("passphrase", bytes.Repeat([]byte{2}, saltSize)))
+}
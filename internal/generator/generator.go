@@ -0,0 +1,197 @@
+// Package generator makes random passwords and estimates the strength of the
+// ones chosen by users. All randomness comes from crypto/rand.
+package generator
+
+import (
+	"crypto/rand"
+	_ "embed"
+	"errors"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// Character sets of Options.
+const (
+	Lower   = "abcdefghijklmnopqrstuvwxyz"
+	Upper   = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	Digits  = "0123456789"
+	Symbols = "!#$%&()*+,-./:;<=>?@[]^_{|}~"
+
+	// ambiguous characters are easy to mistake for each other when read.
+	ambiguous = "Il1O0o|"
+)
+
+// Limits of the generated passwords.
+const (
+	MinLength = 4
+	MaxLength = 1024
+	MinWords  = 3
+	MaxWords  = 64
+)
+
+// wordlist is a diceware list of 1296 short words, one per line after the
+// four dice rolls selecting it, so passphrases can also be made with dice.
+//
+//go:embed wordlist.txt
+var wordlist string
+
+var words = parseWordlist(wordlist)
+
+var (
+	ErrLength  = errors.New("generator: length out of range")
+	ErrCharset = errors.New("generator: no characters to choose from")
+	ErrWords   = errors.New("generator: number of words out of range")
+)
+
+// Options selects the characters of a password, at least one character of
+// every selected set is used.
+type Options struct {
+	Length           int
+	Lower            bool
+	Upper            bool
+	Digits           bool
+	Symbols          bool
+	ExcludeAmbiguous bool
+}
+
+// DefaultOptions are 20 characters of all the sets.
+var DefaultOptions = Options{Length: 20, Lower: true, Upper: true, Digits: true, Symbols: true}
+
+func (opts Options) sets() []string {
+	var sets []string
+	for _, set := range []struct {
+		on    bool
+		chars string
+	}{{opts.Lower, Lower}, {opts.Upper, Upper}, {opts.Digits, Digits}, {opts.Symbols, Symbols}} {
+		if !set.on {
+			continue
+		}
+		chars := set.chars
+		if opts.ExcludeAmbiguous {
+			chars = strings.Map(func(r rune) rune {
+				if strings.ContainsRune(ambiguous, r) {
+					return -1
+				}
+				return r
+			}, chars)
+		}
+		sets = append(sets, chars)
+	}
+	return sets
+}
+
+// Password returns a random password of the characters selected by opts.
+func Password(opts Options) (string, error) {
+	sets := opts.sets()
+	if len(sets) == 0 {
+		return "", ErrCharset
+	}
+	if opts.Length < max(MinLength, len(sets)) || opts.Length > MaxLength {
+		return "", ErrLength
+	}
+
+	all := strings.Join(sets, "")
+	pw := make([]byte, opts.Length)
+	for i := range pw {
+		set := all
+		if i < len(sets) {
+			set = sets[i]
+		}
+		n, err := randInt(len(set))
+		if err != nil {
+			return "", err
+		}
+		pw[i] = set[n]
+	}
+
+	err := shuffle(pw)
+	if err != nil {
+		return "", err
+	}
+	return string(pw), nil
+}
+
+// Passphrase returns n random words of the diceware list joined with sep.
+func Passphrase(n int, sep string) (string, error) {
+	if n < MinWords || n > MaxWords {
+		return "", ErrWords
+	}
+
+	phrase := make([]string, n)
+	for i := range phrase {
+		k, err := randInt(len(words))
+		if err != nil {
+			return "", err
+		}
+		phrase[i] = words[k]
+	}
+	return strings.Join(phrase, sep), nil
+}
+
+// PassphraseEntropy is the exact entropy of a passphrase of n words, the
+// estimate of Estimate is higher as it does not know the separator.
+func PassphraseEntropy(n int) float64 {
+	return float64(n) * math.Log2(float64(len(words)))
+}
+
+// pronounceable syllables alternate the consonants and the vowels, a few
+// common pairs of consonants make them less regular.
+var (
+	consonants = []string{"b", "c", "d", "f", "g", "h", "j", "k", "l", "m", "n", "p", "r", "s", "t", "v", "w", "z",
+		"br", "ch", "cl", "dr", "fl", "gr", "pl", "sh", "st", "th", "tr"}
+	vowels = []string{"a", "e", "i", "o", "u", "ai", "ea", "ee", "oo", "ou"}
+)
+
+// Pronounceable returns a lower case password of the given length made of
+// syllables that are easy to read out and type.
+func Pronounceable(length int) (string, error) {
+	if length < MinLength || length > MaxLength {
+		return "", ErrLength
+	}
+
+	var sb strings.Builder
+	for vowel := false; sb.Len() < length; vowel = !vowel {
+		parts := consonants
+		if vowel {
+			parts = vowels
+		}
+		n, err := randInt(len(parts))
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(parts[n])
+	}
+	return sb.String()[:length], nil
+}
+
+func randInt(n int) (int, error) {
+	k, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(k.Int64()), nil
+}
+
+// shuffle is the Fisher-Yates shuffle.
+func shuffle(b []byte) error {
+	for i := len(b) - 1; i > 0; i-- {
+		j, err := randInt(i + 1)
+		if err != nil {
+			return err
+		}
+		b[i], b[j] = b[j], b[i]
+	}
+	return nil
+}
+
+func parseWordlist(list string) []string {
+	lines := strings.Split(strings.TrimSpace(list), "\n")
+	parsed := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if _, word, ok := strings.Cut(line, "\t"); ok {
+			parsed = append(parsed, word)
+		}
+	}
+	return parsed
+}
@@ -0,0 +1,116 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWordlist(t *testing.T) {
+	require.Len(t, words, 6*6*6*6)
+
+	seen := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		assert.NotContains(t, seen, w)
+		assert.Equal(t, strings.ToLower(w), w)
+		seen[w] = struct{}{}
+	}
+}
+
+func TestPassword(t *testing.T) {
+	pw, err := Password(DefaultOptions)
+	require.NoError(t, err)
+	assert.Len(t, pw, 20)
+	for _, set := range []string{Lower, Upper, Digits, Symbols} {
+		assert.True(t, strings.ContainsAny(pw, set), "%q has none of %q", pw, set)
+	}
+
+	pw, err = Password(Options{Length: 64, Digits: true})
+	require.NoError(t, err)
+	assert.Empty(t, strings.Trim(pw, Digits))
+
+	pw, err = Password(Options{Length: 256, Lower: true, Upper: true, Digits: true, ExcludeAmbiguous: true})
+	require.NoError(t, err)
+	assert.False(t, strings.ContainsAny(pw, ambiguous))
+
+	other, err := Password(Options{Length: 256, Lower: true, Upper: true, Digits: true, ExcludeAmbiguous: true})
+	require.NoError(t, err)
+	assert.NotEqual(t, pw, other)
+}
+
+func TestPassword_Errors(t *testing.T) {
+	_, err := Password(Options{Length: 20})
+	assert.ErrorIs(t, err, ErrCharset)
+
+	_, err = Password(Options{Length: 3, Lower: true})
+	assert.ErrorIs(t, err, ErrLength)
+
+	_, err = Password(Options{Length: MaxLength + 1, Lower: true})
+	assert.ErrorIs(t, err, ErrLength)
+}
+
+func TestPassphrase(t *testing.T) {
+	phrase, err := Passphrase(6, "-")
+	require.NoError(t, err)
+
+	parts := strings.Split(phrase, "-")
+	require.Len(t, parts, 6)
+	for _, w := range parts {
+		assert.True(t, isWord(w), w)
+	}
+
+	_, err = Passphrase(2, "-")
+	assert.ErrorIs(t, err, ErrWords)
+}
+
+func TestPronounceable(t *testing.T) {
+	pw, err := Pronounceable(16)
+	require.NoError(t, err)
+	assert.Len(t, pw, 16)
+	assert.Empty(t, strings.Trim(pw, Lower))
+
+	_, err = Pronounceable(2)
+	assert.ErrorIs(t, err, ErrLength)
+}
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		password string
+		label    string
+	}{
+		{password: "", label: "very weak"},
+		{password: "password1", label: "very weak"},
+		{password: "Qwerty123", label: "very weak"},
+		{password: "aaaaaaaaaaaaaaaaaaaaaaaa", label: "very weak"},
+		{password: "abcdefgh12345678", label: "very weak"},
+		{password: "zoom-acid-able", label: "fair"},
+		{password: "x7#Kp2!q", label: "fair"},
+		{password: "lamp gold crab zoom acid", label: "strong"},
+		{password: "Xk9#mQ2$vL7@pR4&wZ1!", label: "very strong"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			assert.Equal(t, tt.label, Estimate(tt.password).String())
+		})
+	}
+
+	assert.Less(t, Estimate("lime-lamp-gold").Entropy, Estimate("lime-lamp-gold-tulip").Entropy)
+	assert.Less(t, Estimate("lamp").Entropy, Estimate("qzvx").Entropy)
+}
+
+func TestEstimate_Generated(t *testing.T) {
+	pw, err := Password(DefaultOptions)
+	require.NoError(t, err)
+	assert.False(t, Estimate(pw).Weak())
+
+	phrase, err := Passphrase(6, " ")
+	require.NoError(t, err)
+	assert.False(t, Estimate(phrase).Weak())
+}
+
+func TestPassphraseEntropy(t *testing.T) {
+	assert.InDelta(t, 62.04, PassphraseEntropy(6), 0.01)
+	assert.Equal(t, "strong", Grade(PassphraseEntropy(6)).String())
+}
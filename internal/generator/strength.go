@@ -0,0 +1,169 @@
+package generator
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Strength is the estimated entropy of a password in bits, Score sorts it
+// into one of the five grades from very weak to very strong.
+type Strength struct {
+	Entropy float64
+	Score   int
+}
+
+var grades = []struct {
+	below float64
+	label string
+}{
+	{28, "very weak"},
+	{36, "weak"},
+	{60, "fair"},
+	{80, "strong"},
+	{math.Inf(1), "very strong"},
+}
+
+func (s Strength) String() string {
+	return grades[s.Score].label
+}
+
+// Weak reports whether the password is easy to guess offline.
+func (s Strength) Weak() bool {
+	return s.Score < 2
+}
+
+// common are the passwords tried first by every attack, all of them get the
+// lowest grade whatever their length.
+var common = map[string]struct{}{}
+
+func init() {
+	for _, pw := range strings.Fields(`123456 123456789 12345678 12345 1234567 1234567890 111111 000000 123123
+		654321 666666 121212 112233 qwerty qwerty123 qwertyuiop 1q2w3e4r 1qaz2wsx asdfgh asdfghjkl zxcvbnm
+		password password1 password123 passw0rd p@ssw0rd admin admin123 root toor letmein welcome welcome1
+		iloveyou monkey dragon master sunshine princess football baseball shadow superman batman trustno1
+		abc123 starwars whatever freedom hello hello123 login secret changeme default guest test test123`) {
+		common[pw] = struct{}{}
+	}
+}
+
+// Estimate guesses how many bits an attacker has to search to find the
+// password. Runs of the same character, sequences like abc or 321 and the
+// words of the passphrase list are counted as single guesses, common
+// passwords get almost no entropy at all.
+func Estimate(password string) Strength {
+	runes := []rune(password)
+	if len(runes) == 0 {
+		return Strength{}
+	}
+	if _, ok := common[strings.ToLower(password)]; ok {
+		return Grade(math.Log2(float64(len(common))))
+	}
+
+	perChar := math.Log2(float64(poolSize(runes)))
+	perWord := math.Log2(float64(len(words)))
+	lower := []rune(strings.ToLower(password))
+
+	// best[i] is the cheapest way to produce the first i runes
+	best := make([]float64, len(runes)+1)
+	for i := 1; i <= len(runes); i++ {
+		best[i] = best[i-1] + perChar
+		for j := i - 3; j >= 0; j-- {
+			part := lower[j:i]
+			if isRepeat(part) || isSequence(part) {
+				best[i] = min(best[i], best[j]+perChar+math.Log2(float64(len(part))))
+				continue
+			}
+			// a longer part is no run either, only words are left
+			if len(part) > maxWordLength {
+				break
+			}
+			if isWord(string(part)) {
+				cost := perWord
+				if string(part) != string(runes[j:i]) {
+					cost++
+				}
+				best[i] = min(best[i], best[j]+cost)
+			}
+		}
+	}
+	return Grade(best[len(runes)])
+}
+
+// Grade returns the strength of a password with the given entropy.
+func Grade(entropy float64) Strength {
+	s := Strength{Entropy: entropy}
+	for s.Score < len(grades)-1 && entropy >= grades[s.Score].below {
+		s.Score++
+	}
+	return s
+}
+
+// poolSize is the number of characters of the classes the password uses.
+func poolSize(runes []rune) int {
+	var lower, upper, digit, symbol, other bool
+	for _, r := range runes {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < unicode.MaxASCII:
+			symbol = true
+		default:
+			other = true
+		}
+	}
+
+	var size int
+	for _, class := range []struct {
+		used bool
+		size int
+	}{{lower, 26}, {upper, 26}, {digit, 10}, {symbol, 33}, {other, 100}} {
+		if class.used {
+			size += class.size
+		}
+	}
+	return size
+}
+
+func isRepeat(runes []rune) bool {
+	for _, r := range runes[1:] {
+		if r != runes[0] {
+			return false
+		}
+	}
+	return true
+}
+
+func isSequence(runes []rune) bool {
+	step := runes[1] - runes[0]
+	if step != 1 && step != -1 {
+		return false
+	}
+	for i := 2; i < len(runes); i++ {
+		if runes[i]-runes[i-1] != step {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	wordSet       = make(map[string]struct{}, len(words))
+	maxWordLength int
+)
+
+func init() {
+	for _, w := range words {
+		wordSet[w] = struct{}{}
+		maxWordLength = max(maxWordLength, len(w))
+	}
+}
+
+func isWord(s string) bool {
+	_, ok := wordSet[s]
+	return ok
+}
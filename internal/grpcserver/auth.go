@@ -0,0 +1,58 @@
+package grpcserver
+
+import (
+	"context"
+	"crypto/subtle"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"keeper-project/internal/auth"
+	"keeper-project/pkg/keeperpb"
+	"keeper-project/types"
+)
+
+type authService struct {
+	keeperpb.UnimplementedAuthServer
+	*server
+}
+
+func (s *authService) Register(ctx context.Context, req *keeperpb.LoginRequest) (*keeperpb.Token, error) {
+	if req.GetLogin() == "" || req.GetPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing login or password")
+	}
+
+	usr := (&types.User{Login: req.GetLogin(), Password: req.GetPassword()}).ToDB()
+
+	err := s.userRepo.CreateUser(ctx, usr)
+	if err != nil {
+		return nil, toStatus(err, "unable to create user")
+	}
+	return token(usr.ID)
+}
+
+func (s *authService) Login(ctx context.Context, req *keeperpb.LoginRequest) (*keeperpb.Token, error) {
+	if req.GetLogin() == "" || req.GetPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing login or password")
+	}
+
+	usr, err := s.userRepo.GetByLogin(ctx, req.GetLogin())
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "unable to find user: "+err.Error())
+	}
+
+	// ToDB hashes the password the way the stored one is hashed
+	given := (&types.User{ID: usr.ID, Password: req.GetPassword()}).ToDB().Password
+	if subtle.ConstantTimeCompare([]byte(given), []byte(usr.Password)) != 1 {
+		return nil, status.Error(codes.Unauthenticated, "wrong login or password")
+	}
+	return token(usr.ID)
+}
+
+func token(userID string) (*keeperpb.Token, error) {
+	tokenString, err := auth.GenerateToken(userID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "unable to generate token: "+err.Error())
+	}
+	return &keeperpb.Token{Token: tokenString}, nil
+}
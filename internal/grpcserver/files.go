@@ -0,0 +1,175 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/docker/go-units"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"keeper-project/pkg/keeperpb"
+	"keeper-project/types"
+)
+
+// downloadChunkSize is the size of the chunks of the downloaded contents,
+// well under the 4 MiB message limit of gRPC.
+const downloadChunkSize = 64 * units.KiB
+
+type filesService struct {
+	keeperpb.UnimplementedFilesServer
+	*server
+}
+
+func (s *filesService) Upload(stream keeperpb.Files_UploadServer) error {
+	ctx := stream.Context()
+	userID, err := userID(ctx)
+	if err != nil {
+		return err
+	}
+
+	first, err := stream.Recv()
+	if err != nil {
+		return status.Error(codes.InvalidArgument, "unable to read header: "+err.Error())
+	}
+	header := first.GetHeader()
+	if header == nil || header.GetName() == "" {
+		return status.Error(codes.InvalidArgument, "the first message must be the header with the name")
+	}
+
+	dto := types.CreateFileDTO{
+		Name:     header.GetName(),
+		Size:     header.GetSize(),
+		Metadata: header.GetMetadata(),
+		Reader:   &uploadReader{stream: stream},
+	}
+	if dto.Size <= 0 {
+		dto.Size = -1
+	}
+
+	err = s.fileService.Create(ctx, userID, dto)
+	if err != nil {
+		// the errors of the stream and of uploadReader are statuses already
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		return toStatus(err, "unable to store file")
+	}
+	return stream.SendAndClose(&emptypb.Empty{})
+}
+
+// uploadReader reads the contents from the chunks of the stream.
+type uploadReader struct {
+	stream keeperpb.Files_UploadServer
+	chunk  []byte
+}
+
+func (r *uploadReader) Read(p []byte) (int, error) {
+	for len(r.chunk) == 0 {
+		msg, err := r.stream.Recv()
+		if err != nil {
+			// io.EOF once the client closes the stream
+			return 0, err
+		}
+		if msg.GetHeader() != nil {
+			return 0, status.Error(codes.InvalidArgument, "unexpected header")
+		}
+		r.chunk = msg.GetChunk()
+	}
+
+	n := copy(p, r.chunk)
+	r.chunk = r.chunk[n:]
+	return n, nil
+}
+
+func (s *filesService) Download(req *keeperpb.GetRequest, stream keeperpb.Files_DownloadServer) error {
+	ctx := stream.Context()
+	userID, err := userID(ctx)
+	if err != nil {
+		return err
+	}
+	if req.GetId() == "" {
+		return status.Error(codes.InvalidArgument, "empty file id")
+	}
+
+	f, err := s.fileService.GetFile(ctx, userID, req.GetId())
+	if err != nil {
+		return toStatus(err, "unable to get file")
+	}
+	defer f.Content.Close()
+
+	err = stream.Send(&keeperpb.DownloadResponse{Part: &keeperpb.DownloadResponse_Header{Header: &keeperpb.FileHeader{
+		Id:       f.ID,
+		Name:     f.Name,
+		Size:     f.Size,
+		Metadata: f.Metadata,
+		Modified: timestamppb.New(f.ModTime),
+	}}})
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, downloadChunkSize)
+	for {
+		n, err := f.Content.Read(buf)
+		if n > 0 {
+			sendErr := stream.Send(&keeperpb.DownloadResponse{Part: &keeperpb.DownloadResponse_Chunk{Chunk: buf[:n]}})
+			if sendErr != nil {
+				return sendErr
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			s.logger.Error("failed to send file", zap.String("id", req.GetId()), zap.Error(err))
+			return status.Error(codes.Internal, "failed to read file: "+err.Error())
+		}
+	}
+}
+
+func (s *filesService) List(ctx context.Context, req *keeperpb.ListRequest) (*keeperpb.FilesPage, error) {
+	userID, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := listOptions(req, types.SortCreated, types.SortUpdated, types.SortSize)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := s.fileService.GetFilesList(ctx, userID, opts)
+	if err != nil {
+		return nil, toStatus(err, "unable to list files")
+	}
+
+	resp := &keeperpb.FilesPage{Total: page.Total, Next: page.Next}
+	for _, info := range page.Items {
+		resp.Items = append(resp.Items, &keeperpb.FileInfo{
+			Id:        info.ID,
+			Name:      info.Name,
+			Size:      info.Size,
+			Hash:      info.Hash,
+			CreatedAt: timestamppb.New(info.CreatedAt),
+		})
+	}
+	return resp, nil
+}
+
+func (s *filesService) Delete(ctx context.Context, req *keeperpb.GetRequest) (*emptypb.Empty, error) {
+	userID, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.fileService.Delete(ctx, userID, req.GetId())
+	if err != nil {
+		return nil, toStatus(err, "unable to delete")
+	}
+	return &emptypb.Empty{}, nil
+}
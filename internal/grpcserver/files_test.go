@@ -0,0 +1,200 @@
+package grpcserver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"keeper-project/pkg/keeperpb"
+	"keeper-project/types"
+)
+
+func header(h *keeperpb.FileHeader) *keeperpb.UploadRequest {
+	return &keeperpb.UploadRequest{Part: &keeperpb.UploadRequest_Header{Header: h}}
+}
+
+func chunk(data string) *keeperpb.UploadRequest {
+	return &keeperpb.UploadRequest{Part: &keeperpb.UploadRequest_Chunk{Chunk: []byte(data)}}
+}
+
+// upload sends the messages and returns the status of the call.
+func upload(t *testing.T, client keeperpb.FilesClient, msgs ...*keeperpb.UploadRequest) error {
+	stream, err := client.Upload(withToken(t, testUserID))
+	require.NoError(t, err)
+	for _, msg := range msgs {
+		// the server may fail the call before it reads all the messages
+		if err := stream.Send(msg); err != nil {
+			break
+		}
+	}
+	_, err = stream.CloseAndRecv()
+	return err
+}
+
+func TestFiles_Upload(t *testing.T) {
+	m := newMocks(t)
+	client := keeperpb.NewFilesClient(startServer(t, m))
+
+	m.files.EXPECT().Create(gomock.Any(), testUserID, gomock.Any()).DoAndReturn(func(_ context.Context, _ string, dto types.CreateFileDTO) error {
+		assert.Equal(t, "file.txt", dto.Name)
+		assert.Equal(t, int64(11), dto.Size)
+		assert.Equal(t, "meta", dto.Metadata)
+		data, err := io.ReadAll(dto.Reader)
+		require.NoError(t, err)
+		assert.Equal(t, "hello world", string(data))
+		return nil
+	})
+	err := upload(t, client,
+		header(&keeperpb.FileHeader{Name: "file.txt", Size: 11, Metadata: "meta"}),
+		chunk("hello"), chunk(" "), chunk("world"))
+	require.NoError(t, err)
+
+	// the size is unknown
+	m.files.EXPECT().Create(gomock.Any(), testUserID, gomock.Any()).DoAndReturn(func(_ context.Context, _ string, dto types.CreateFileDTO) error {
+		assert.Equal(t, int64(-1), dto.Size)
+		data, err := io.ReadAll(dto.Reader)
+		require.NoError(t, err)
+		assert.Empty(t, data)
+		return nil
+	})
+	err = upload(t, client, header(&keeperpb.FileHeader{Name: "empty.txt"}))
+	require.NoError(t, err)
+
+	m.files.EXPECT().Create(gomock.Any(), testUserID, gomock.Any()).Return(types.ErrQuotaExceeded)
+	err = upload(t, client, header(&keeperpb.FileHeader{Name: "file.txt"}))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestFiles_Upload_invalid(t *testing.T) {
+	m := newMocks(t)
+	client := keeperpb.NewFilesClient(startServer(t, m))
+
+	err := upload(t, client, chunk("hello"))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	err = upload(t, client, header(&keeperpb.FileHeader{Size: 5}), chunk("hello"))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	err = upload(t, client)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	m.files.EXPECT().Create(gomock.Any(), testUserID, gomock.Any()).DoAndReturn(func(_ context.Context, _ string, dto types.CreateFileDTO) error {
+		_, err := io.ReadAll(dto.Reader)
+		return err
+	})
+	err = upload(t, client, header(&keeperpb.FileHeader{Name: "file.txt"}), chunk("hello"), header(&keeperpb.FileHeader{Name: "other.txt"}))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestFiles_Upload_maxSize(t *testing.T) {
+	m := newMocks(t)
+	client := keeperpb.NewFilesClient(startServer(t, m))
+
+	// the limit of the user is checked by the file service
+	m.files.EXPECT().Create(gomock.Any(), testUserID, gomock.Any()).Return(fmt.Errorf("%w: 8 bytes allowed", types.ErrFileTooLarge))
+	err := upload(t, client, header(&keeperpb.FileHeader{Name: "file.txt", Size: 9}), chunk("123456789"))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	m.files.EXPECT().Create(gomock.Any(), testUserID, gomock.Any()).Return(fmt.Errorf("%w: 9 bytes declared", types.ErrSizeMismatch))
+	err = upload(t, client, header(&keeperpb.FileHeader{Name: "file.txt", Size: 9}), chunk("12345"))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestFiles_Download(t *testing.T) {
+	m := newMocks(t)
+	client := keeperpb.NewFilesClient(startServer(t, m))
+	ctx := withToken(t, testUserID)
+
+	content := strings.Repeat("a", downloadChunkSize) + "tail"
+	modified := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	m.files.EXPECT().GetFile(gomock.Any(), testUserID, "1").Return(&types.File{
+		ID:       "1",
+		Name:     "file.txt",
+		Size:     int64(len(content)),
+		ModTime:  modified,
+		Metadata: "meta",
+		Content:  io.NopCloser(strings.NewReader(content)),
+	}, nil)
+
+	stream, err := client.Download(ctx, &keeperpb.GetRequest{Id: "1"})
+	require.NoError(t, err)
+
+	first, err := stream.Recv()
+	require.NoError(t, err)
+	h := first.GetHeader()
+	require.NotNil(t, h)
+	assert.Equal(t, "file.txt", h.GetName())
+	assert.Equal(t, int64(len(content)), h.GetSize())
+	assert.Equal(t, "meta", h.GetMetadata())
+	assert.Equal(t, modified, h.GetModified().AsTime())
+
+	var buf bytes.Buffer
+	chunks := 0
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		buf.Write(msg.GetChunk())
+		chunks++
+	}
+	assert.Equal(t, content, buf.String())
+	assert.Equal(t, 2, chunks)
+
+	m.files.EXPECT().GetFile(gomock.Any(), testUserID, "missing").Return(nil, types.ErrNotFound)
+	stream, err = client.Download(ctx, &keeperpb.GetRequest{Id: "missing"})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	stream, err = client.Download(ctx, &keeperpb.GetRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestFiles_List(t *testing.T) {
+	m := newMocks(t)
+	client := keeperpb.NewFilesClient(startServer(t, m))
+	ctx := withToken(t, testUserID)
+
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	m.files.EXPECT().GetFilesList(gomock.Any(), testUserID, types.ListOptions{Sort: types.SortSize, Limit: 10}).
+		Return(&types.Page[*types.FileInfo]{Items: []*types.FileInfo{{ID: "1", Name: "file.txt", Size: 5, Hash: "hash", CreatedAt: created}}, Total: 1}, nil)
+
+	page, err := client.List(ctx, &keeperpb.ListRequest{Sort: types.SortSize, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), page.GetTotal())
+	require.Len(t, page.GetItems(), 1)
+	info := page.GetItems()[0]
+	assert.Equal(t, "file.txt", info.GetName())
+	assert.Equal(t, int64(5), info.GetSize())
+	assert.Equal(t, "hash", info.GetHash())
+	assert.Equal(t, created, info.GetCreatedAt().AsTime())
+}
+
+func TestFiles_Delete(t *testing.T) {
+	m := newMocks(t)
+	client := keeperpb.NewFilesClient(startServer(t, m))
+	ctx := withToken(t, testUserID)
+
+	m.files.EXPECT().Delete(gomock.Any(), testUserID, "1").Return(nil)
+	_, err := client.Delete(ctx, &keeperpb.GetRequest{Id: "1"})
+	require.NoError(t, err)
+
+	m.files.EXPECT().Delete(gomock.Any(), testUserID, "missing").Return(types.ErrNotFound)
+	_, err = client.Delete(ctx, &keeperpb.GetRequest{Id: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
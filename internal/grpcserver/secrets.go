@@ -0,0 +1,324 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+
+	uuid "github.com/satori/go.uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"keeper-project/internal/store"
+	"keeper-project/pkg/keeperpb"
+	"keeper-project/types"
+)
+
+// secretSorts are the sort fields of the notes, cards and credentials lists.
+var secretSorts = []string{types.SortCreated, types.SortUpdated}
+
+// listOptions converts the request the way the HTTP server reads the query
+// parameters, the limit defaults to types.DefaultPageSize.
+func listOptions(req *keeperpb.ListRequest, sorts ...string) (types.ListOptions, error) {
+	opts := types.ListOptions{
+		Sort:   req.GetSort(),
+		Desc:   req.GetDesc(),
+		Limit:  int(req.GetLimit()),
+		Cursor: req.GetCursor(),
+		Offset: int(req.GetOffset()),
+	}
+	if opts.Limit == 0 {
+		opts.Limit = types.DefaultPageSize
+	}
+	if req.GetSince() != nil {
+		opts.Since = req.GetSince().AsTime()
+	}
+
+	err := opts.Validate(sorts...)
+	if err != nil {
+		return opts, status.Error(codes.InvalidArgument, "incorrect list parameters: "+err.Error())
+	}
+	return opts, nil
+}
+
+func keysPage(page *types.Page[types.Key]) *keeperpb.KeysPage {
+	resp := &keeperpb.KeysPage{Total: page.Total, Next: page.Next}
+	for _, key := range page.Items {
+		item := &keeperpb.Key{Id: key.Id, Key: key.Key}
+		if key.CreatedAt != nil {
+			item.CreatedAt = timestamppb.New(*key.CreatedAt)
+		}
+		if key.UpdatedAt != nil {
+			item.UpdatedAt = timestamppb.New(*key.UpdatedAt)
+		}
+		resp.Items = append(resp.Items, item)
+	}
+	return resp
+}
+
+func invalid(err error) error {
+	return status.Error(codes.InvalidArgument, "incorrect data: "+err.Error())
+}
+
+// updateTokens returns the search tokens of an update: nil keeps the stored
+// ones and an empty slice removes them. Proto3 doesn't tell an empty repeated
+// field from a missing one, so removing them takes clear_search_tokens.
+func updateTokens(tokens []string, clearTokens bool) ([]string, error) {
+	if !clearTokens {
+		if len(tokens) == 0 {
+			return nil, nil
+		}
+		return tokens, nil
+	}
+	if len(tokens) > 0 {
+		return nil, errors.New("search tokens can't be set and cleared at once")
+	}
+	return []string{}, nil
+}
+
+// secrets holds the calls the services of the notes, cards and credentials
+// share.
+type secrets[T any] struct {
+	*server
+	repo store.Secrets[T]
+	kind string
+}
+
+func (s secrets[T]) create(ctx context.Context, secret *T, tokens []string) (*keeperpb.CreateResponse, error) {
+	userID, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	id := uuid.NewV4().String()
+
+	err = s.createInQuota(ctx, userID, s.kind, func(ctx context.Context) error {
+		return s.saveIndexed(ctx, userID, s.kind, id, tokens, func(ctx context.Context) error {
+			return s.repo.Create(ctx, userID, id, secret)
+		})
+	})
+	if err != nil {
+		return nil, toStatus(err, "failed to create")
+	}
+	return &keeperpb.CreateResponse{Id: id}, nil
+}
+
+func (s secrets[T]) get(ctx context.Context, id string) (*T, error) {
+	userID, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := s.repo.Get(ctx, userID, id)
+	if err != nil {
+		return nil, toStatus(err, "failed to get from db")
+	}
+	return secret, nil
+}
+
+func (s secrets[T]) list(ctx context.Context, req *keeperpb.ListRequest) (*keeperpb.KeysPage, error) {
+	userID, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := listOptions(req, secretSorts...)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := s.repo.GetKeysList(ctx, userID, opts)
+	if err != nil {
+		return nil, toStatus(err, "failed to get from db")
+	}
+	return keysPage(page), nil
+}
+
+func (s secrets[T]) update(ctx context.Context, id string, secret *T, tokens []string) (*emptypb.Empty, error) {
+	userID, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if id == "" {
+		return nil, status.Error(codes.InvalidArgument, "incorrect data: empty id")
+	}
+
+	err = s.saveIndexed(ctx, userID, s.kind, id, tokens, func(ctx context.Context) error {
+		return s.repo.Update(ctx, userID, id, secret)
+	})
+	if err != nil {
+		return nil, toStatus(err, "failed to update")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s secrets[T]) delete(ctx context.Context, id string) (*emptypb.Empty, error) {
+	userID, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.Delete(ctx, userID, id)
+	if err != nil {
+		return nil, toStatus(err, "failed to delete from db")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+type notesService struct {
+	keeperpb.UnimplementedNotesServer
+	*server
+}
+
+func (s *notesService) secrets() secrets[types.Note] {
+	return secrets[types.Note]{
+		server: s.server,
+		repo:   s.notesRepo,
+		kind:   types.KindNote,
+	}
+}
+
+func (s *notesService) Create(ctx context.Context, req *keeperpb.CreateNoteRequest) (*keeperpb.CreateResponse, error) {
+	create := types.CreateNoteRequest{Key: req.GetKey(), Data: req.GetText(), Metadata: req.GetMetadata(), SearchTokens: req.GetSearchTokens()}
+	note, err := create.Validate()
+	if err != nil {
+		return nil, invalid(err)
+	}
+	return s.secrets().create(ctx, note, req.GetSearchTokens())
+}
+
+func (s *notesService) Get(ctx context.Context, req *keeperpb.GetRequest) (*keeperpb.Note, error) {
+	note, err := s.secrets().get(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	return &keeperpb.Note{Id: req.GetId(), Key: note.Key, Text: note.Text, Metadata: note.Metadata}, nil
+}
+
+func (s *notesService) List(ctx context.Context, req *keeperpb.ListRequest) (*keeperpb.KeysPage, error) {
+	return s.secrets().list(ctx, req)
+}
+
+func (s *notesService) Update(ctx context.Context, req *keeperpb.Note) (*emptypb.Empty, error) {
+	update := types.UpdateNoteRequest{ID: req.GetId(), Key: req.GetKey(), Data: req.GetText(), Metadata: req.GetMetadata(), SearchTokens: req.GetSearchTokens()}
+	note, err := update.Validate()
+	if err != nil {
+		return nil, invalid(err)
+	}
+	tokens, err := updateTokens(req.GetSearchTokens(), req.GetClearSearchTokens())
+	if err != nil {
+		return nil, invalid(err)
+	}
+	return s.secrets().update(ctx, req.GetId(), note, tokens)
+}
+
+func (s *notesService) Delete(ctx context.Context, req *keeperpb.GetRequest) (*emptypb.Empty, error) {
+	return s.secrets().delete(ctx, req.GetId())
+}
+
+type cardsService struct {
+	keeperpb.UnimplementedCardsServer
+	*server
+}
+
+func (s *cardsService) secrets() secrets[types.CardInfo] {
+	return secrets[types.CardInfo]{
+		server: s.server,
+		repo:   s.cardsRepo,
+		kind:   types.KindCard,
+	}
+}
+
+func (s *cardsService) Create(ctx context.Context, req *keeperpb.CreateCardRequest) (*keeperpb.CreateResponse, error) {
+	create := types.CreateCardRequest{Number: req.GetNumber(), Expiration: req.GetExpiration(), CVV: req.GetCvv(),
+		Metadata: req.GetMetadata(), SearchTokens: req.GetSearchTokens()}
+	card, err := create.Validate()
+	if err != nil {
+		return nil, invalid(err)
+	}
+	return s.secrets().create(ctx, card, req.GetSearchTokens())
+}
+
+func (s *cardsService) Get(ctx context.Context, req *keeperpb.GetRequest) (*keeperpb.Card, error) {
+	card, err := s.secrets().get(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	return &keeperpb.Card{Id: req.GetId(), Number: card.Number, Expiration: card.Expiration, Cvv: card.CVV, Metadata: card.Metadata}, nil
+}
+
+func (s *cardsService) List(ctx context.Context, req *keeperpb.ListRequest) (*keeperpb.KeysPage, error) {
+	return s.secrets().list(ctx, req)
+}
+
+func (s *cardsService) Update(ctx context.Context, req *keeperpb.Card) (*emptypb.Empty, error) {
+	update := types.CreateCardRequest{ID: req.GetId(), Number: req.GetNumber(), Expiration: req.GetExpiration(), CVV: req.GetCvv(),
+		Metadata: req.GetMetadata(), SearchTokens: req.GetSearchTokens()}
+	card, err := update.Validate()
+	if err != nil {
+		return nil, invalid(err)
+	}
+	tokens, err := updateTokens(req.GetSearchTokens(), req.GetClearSearchTokens())
+	if err != nil {
+		return nil, invalid(err)
+	}
+	return s.secrets().update(ctx, req.GetId(), card, tokens)
+}
+
+func (s *cardsService) Delete(ctx context.Context, req *keeperpb.GetRequest) (*emptypb.Empty, error) {
+	return s.secrets().delete(ctx, req.GetId())
+}
+
+type credsService struct {
+	keeperpb.UnimplementedCredentialsServer
+	*server
+}
+
+func (s *credsService) secrets() secrets[types.Credentials] {
+	return secrets[types.Credentials]{
+		server: s.server,
+		repo:   s.credsRepo,
+		kind:   types.KindCredentials,
+	}
+}
+
+func (s *credsService) Create(ctx context.Context, req *keeperpb.CreateCredentialsRequest) (*keeperpb.CreateResponse, error) {
+	create := types.CreateCredentialsRequest{Site: req.GetSite(), Login: req.GetLogin(), Password: req.GetPassword(),
+		Metadata: req.GetMetadata(), SearchTokens: req.GetSearchTokens()}
+	creds, err := create.Validate()
+	if err != nil {
+		return nil, invalid(err)
+	}
+	return s.secrets().create(ctx, creds, req.GetSearchTokens())
+}
+
+func (s *credsService) Get(ctx context.Context, req *keeperpb.GetRequest) (*keeperpb.Credential, error) {
+	creds, err := s.secrets().get(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	return &keeperpb.Credential{Id: req.GetId(), Site: creds.Site, Login: creds.Login, Password: creds.Password, Metadata: creds.Metadata}, nil
+}
+
+func (s *credsService) List(ctx context.Context, req *keeperpb.ListRequest) (*keeperpb.KeysPage, error) {
+	return s.secrets().list(ctx, req)
+}
+
+func (s *credsService) Update(ctx context.Context, req *keeperpb.Credential) (*emptypb.Empty, error) {
+	update := types.UpdateCredentialsRequest{ID: req.GetId(), Site: req.GetSite(), Login: req.GetLogin(), Password: req.GetPassword(),
+		Metadata: req.GetMetadata(), SearchTokens: req.GetSearchTokens()}
+	creds, err := update.Validate()
+	if err != nil {
+		return nil, invalid(err)
+	}
+	tokens, err := updateTokens(req.GetSearchTokens(), req.GetClearSearchTokens())
+	if err != nil {
+		return nil, invalid(err)
+	}
+	return s.secrets().update(ctx, req.GetId(), creds, tokens)
+}
+
+func (s *credsService) Delete(ctx context.Context, req *keeperpb.GetRequest) (*emptypb.Empty, error) {
+	return s.secrets().delete(ctx, req.GetId())
+}
@@ -0,0 +1,262 @@
+package grpcserver
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"keeper-project/pkg/keeperpb"
+	"keeper-project/types"
+)
+
+// fakeTx runs the calls without a transaction.
+type fakeTx struct {
+	calls int
+}
+
+func (tx *fakeTx) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx.calls++
+	return fn(ctx)
+}
+
+func (tx *fakeTx) Savepoint(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func TestNotes(t *testing.T) {
+	tx := &fakeTx{}
+	m := newMocks(t)
+	conn := startServer(t, m, WithTx(tx))
+	client := keeperpb.NewNotesClient(conn)
+	ctx := withToken(t, testUserID)
+
+	var id string
+	m.notes.EXPECT().Create(gomock.Any(), testUserID, gomock.Any(), &types.Note{Key: "key", Text: "text", Metadata: "meta"}).
+		DoAndReturn(func(_ context.Context, _, noteID string, _ *types.Note) error {
+			id = noteID
+			return nil
+		})
+	created, err := client.Create(ctx, &keeperpb.CreateNoteRequest{Key: "key", Text: "text", Metadata: "meta"})
+	require.NoError(t, err)
+	assert.Equal(t, id, created.GetId())
+
+	_, err = client.Create(ctx, &keeperpb.CreateNoteRequest{Text: "text"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	m.notes.EXPECT().Get(gomock.Any(), testUserID, id).Return(&types.Note{Key: "key", Text: "text", Metadata: "meta"}, nil)
+	note, err := client.Get(ctx, &keeperpb.GetRequest{Id: id})
+	require.NoError(t, err)
+	assert.Equal(t, id, note.GetId())
+	assert.Equal(t, "text", note.GetText())
+
+	m.notes.EXPECT().Get(gomock.Any(), testUserID, "missing").Return(nil, sql.ErrNoRows)
+	_, err = client.Get(ctx, &keeperpb.GetRequest{Id: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	m.notes.EXPECT().Update(gomock.Any(), testUserID, id, &types.Note{Key: "key", Text: "new"}).Return(nil)
+	_, err = client.Update(ctx, &keeperpb.Note{Id: id, Key: "key", Text: "new"})
+	require.NoError(t, err)
+
+	_, err = client.Update(ctx, &keeperpb.Note{Key: "key"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	m.notes.EXPECT().Update(gomock.Any(), testUserID, "missing", gomock.Any()).Return(sql.ErrNoRows)
+	_, err = client.Update(ctx, &keeperpb.Note{Id: "missing", Key: "key"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	m.notes.EXPECT().Delete(gomock.Any(), testUserID, id).Return(nil)
+	_, err = client.Delete(ctx, &keeperpb.GetRequest{Id: id})
+	require.NoError(t, err)
+
+	m.notes.EXPECT().Delete(gomock.Any(), testUserID, id).Return(sql.ErrConnDone)
+	_, err = client.Delete(ctx, &keeperpb.GetRequest{Id: id})
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	// no search, so nothing is saved in a transaction
+	assert.Zero(t, tx.calls)
+}
+
+func TestNotes_List(t *testing.T) {
+	m := newMocks(t)
+	conn := startServer(t, m)
+	client := keeperpb.NewNotesClient(conn)
+	ctx := withToken(t, testUserID)
+
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	since := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	m.notes.EXPECT().GetKeysList(gomock.Any(), testUserID, types.ListOptions{Sort: types.SortUpdated, Desc: true, Limit: types.DefaultPageSize, Since: since}).
+		Return(&types.Page[types.Key]{Items: []types.Key{{Id: "1", Key: "key", CreatedAt: &created}}, Total: 2, Next: "next"}, nil)
+
+	page, err := client.List(ctx, &keeperpb.ListRequest{Sort: types.SortUpdated, Desc: true, Since: timestamppb.New(since)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), page.GetTotal())
+	assert.Equal(t, "next", page.GetNext())
+	require.Len(t, page.GetItems(), 1)
+	assert.Equal(t, "key", page.GetItems()[0].GetKey())
+	assert.Equal(t, created, page.GetItems()[0].GetCreatedAt().AsTime())
+	assert.Nil(t, page.GetItems()[0].GetUpdatedAt())
+
+	_, err = client.List(ctx, &keeperpb.ListRequest{Sort: types.SortSize})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.List(ctx, &keeperpb.ListRequest{Limit: types.MaxPageSize + 1})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestCards(t *testing.T) {
+	m := newMocks(t)
+	conn := startServer(t, m)
+	client := keeperpb.NewCardsClient(conn)
+	ctx := withToken(t, testUserID)
+
+	card := &types.CardInfo{Number: "4111", Expiration: "12/30", CVV: "123"}
+	m.cards.EXPECT().Create(gomock.Any(), testUserID, gomock.Any(), card).Return(nil)
+	created, err := client.Create(ctx, &keeperpb.CreateCardRequest{Number: "4111", Expiration: "12/30", Cvv: "123"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, created.GetId())
+
+	_, err = client.Create(ctx, &keeperpb.CreateCardRequest{Number: "4111"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	m.cards.EXPECT().Get(gomock.Any(), testUserID, "1").Return(&types.CardInfo{ID: "1", Number: "4111", Expiration: "12/30", CVV: "123"}, nil)
+	got, err := client.Get(ctx, &keeperpb.GetRequest{Id: "1"})
+	require.NoError(t, err)
+	assert.Equal(t, "123", got.GetCvv())
+
+	m.cards.EXPECT().Update(gomock.Any(), testUserID, "1", &types.CardInfo{ID: "1", Number: "4111", Expiration: "01/31", CVV: "123"}).Return(nil)
+	_, err = client.Update(ctx, &keeperpb.Card{Id: "1", Number: "4111", Expiration: "01/31", Cvv: "123"})
+	require.NoError(t, err)
+
+	_, err = client.Update(ctx, &keeperpb.Card{Number: "4111", Expiration: "01/31", Cvv: "123"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	m.cards.EXPECT().GetKeysList(gomock.Any(), testUserID, gomock.Any()).Return(&types.Page[types.Key]{}, nil)
+	page, err := client.List(ctx, &keeperpb.ListRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, page.GetItems())
+
+	m.cards.EXPECT().Delete(gomock.Any(), testUserID, "1").Return(sql.ErrNoRows)
+	_, err = client.Delete(ctx, &keeperpb.GetRequest{Id: "1"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestCredentials(t *testing.T) {
+	m := newMocks(t)
+	conn := startServer(t, m)
+	client := keeperpb.NewCredentialsClient(conn)
+	ctx := withToken(t, testUserID)
+
+	m.creds.EXPECT().Create(gomock.Any(), testUserID, gomock.Any(), &types.Credentials{Site: "site", Login: "login", Password: "secret"}).Return(nil)
+	_, err := client.Create(ctx, &keeperpb.CreateCredentialsRequest{Site: "site", Login: "login", Password: "secret"})
+	require.NoError(t, err)
+
+	m.creds.EXPECT().Get(gomock.Any(), testUserID, "1").Return(&types.Credentials{Site: "site", Login: "login", Password: "secret"}, nil)
+	got, err := client.Get(ctx, &keeperpb.GetRequest{Id: "1"})
+	require.NoError(t, err)
+	assert.Equal(t, "1", got.GetId())
+	assert.Equal(t, "secret", got.GetPassword())
+
+	m.creds.EXPECT().Update(gomock.Any(), testUserID, "1", &types.Credentials{Site: "site", Login: "login"}).Return(nil)
+	_, err = client.Update(ctx, &keeperpb.Credential{Id: "1", Site: "site", Login: "login"})
+	require.NoError(t, err)
+
+	m.creds.EXPECT().GetKeysList(gomock.Any(), testUserID, gomock.Any()).Return(nil, types.ErrInvalidCursor)
+	_, err = client.List(ctx, &keeperpb.ListRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	m.creds.EXPECT().Delete(gomock.Any(), testUserID, "1").Return(nil)
+	_, err = client.Delete(ctx, &keeperpb.GetRequest{Id: "1"})
+	require.NoError(t, err)
+}
+
+func TestSecrets_quota(t *testing.T) {
+	m := newMocks(t)
+	conn := startServer(t, m, WithQuotas(m.quotas))
+	client := keeperpb.NewCardsClient(conn)
+	ctx := withToken(t, testUserID)
+	req := &keeperpb.CreateCardRequest{Number: "4111", Expiration: "12/30", Cvv: "123"}
+
+	m.quotas.EXPECT().GetUsage(gomock.Any(), testUserID).Return(&types.Usage{Cards: 1, Quota: types.Quota{Cards: 1}}, nil)
+	_, err := client.Create(ctx, req)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	m.quotas.EXPECT().GetUsage(gomock.Any(), testUserID).Return(&types.Usage{Cards: 1, Quota: types.Quota{Cards: 2}}, nil)
+	m.cards.EXPECT().Create(gomock.Any(), testUserID, gomock.Any(), gomock.Any()).Return(nil)
+	_, err = client.Create(ctx, req)
+	assert.NoError(t, err)
+}
+
+func TestSecrets_quotaLocked(t *testing.T) {
+	tx := &fakeTx{}
+	m := newMocks(t)
+	conn := startServer(t, m, WithQuotas(m.quotas), WithTx(tx))
+	client := keeperpb.NewCardsClient(conn)
+	ctx := withToken(t, testUserID)
+	req := &keeperpb.CreateCardRequest{Number: "4111", Expiration: "12/30", Cvv: "123"}
+
+	m.quotas.EXPECT().LockUsage(gomock.Any(), testUserID).Return(&types.Usage{Cards: 1, Quota: types.Quota{Cards: 1}}, nil)
+	_, err := client.Create(ctx, req)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, 1, tx.calls)
+
+	gomock.InOrder(
+		m.quotas.EXPECT().LockUsage(gomock.Any(), testUserID).Return(&types.Usage{Cards: 1, Quota: types.Quota{Cards: 2}}, nil),
+		m.cards.EXPECT().Create(gomock.Any(), testUserID, gomock.Any(), gomock.Any()).Return(nil),
+	)
+	_, err = client.Create(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, tx.calls)
+}
+
+func TestSecrets_searchTokens(t *testing.T) {
+	tx := &fakeTx{}
+	m := newMocks(t)
+	conn := startServer(t, m, WithTx(tx), WithSearch(m.search))
+	client := keeperpb.NewNotesClient(conn)
+	ctx := withToken(t, testUserID)
+
+	var id string
+	gomock.InOrder(
+		m.notes.EXPECT().Create(gomock.Any(), testUserID, gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _, noteID string, _ *types.Note) error {
+				id = noteID
+				return nil
+			}),
+		m.search.EXPECT().SetTokens(gomock.Any(), testUserID, types.KindNote, gomock.Any(), []string{"abc"}).
+			DoAndReturn(func(_ context.Context, _, _, noteID string, _ []string) error {
+				assert.Equal(t, id, noteID)
+				return nil
+			}),
+	)
+	_, err := client.Create(ctx, &keeperpb.CreateNoteRequest{Key: "key", SearchTokens: []string{"abc"}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, tx.calls)
+
+	// the tokens are kept when the request has none
+	m.notes.EXPECT().Update(gomock.Any(), testUserID, "1", gomock.Any()).Return(nil)
+	_, err = client.Update(ctx, &keeperpb.Note{Id: "1", Key: "key"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, tx.calls)
+
+	// and removed with clear_search_tokens
+	gomock.InOrder(
+		m.notes.EXPECT().Update(gomock.Any(), testUserID, "1", gomock.Any()).Return(nil),
+		m.search.EXPECT().SetTokens(gomock.Any(), testUserID, types.KindNote, "1", []string{}).Return(nil),
+	)
+	_, err = client.Update(ctx, &keeperpb.Note{Id: "1", Key: "key", ClearSearchTokens: true})
+	require.NoError(t, err)
+	assert.Equal(t, 2, tx.calls)
+
+	_, err = client.Update(ctx, &keeperpb.Note{Id: "1", Key: "key", SearchTokens: []string{"abc"}, ClearSearchTokens: true})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.Create(ctx, &keeperpb.CreateNoteRequest{Key: "key", SearchTokens: []string{"not a token"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,239 @@
+// Package grpcserver serves the gRPC API of pkg/keeperpb. It works with the
+// stores of the HTTP server and the same tokens: the interceptors verify the
+// bearer token of the authorization metadata the way jwtauth verifies the
+// Authorization header.
+package grpcserver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-chi/jwtauth"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"keeper-project/internal/auth"
+	"keeper-project/internal/store"
+	"keeper-project/pkg/keeperpb"
+	"keeper-project/types"
+)
+
+type server struct {
+	logger      *zap.Logger
+	userRepo    store.User
+	notesRepo   store.Secrets[types.Note]
+	credsRepo   store.Secrets[types.Credentials]
+	cardsRepo   store.Secrets[types.CardInfo]
+	fileService store.FileService
+	quotas      store.Quotas
+	tx          store.Tx
+	searchRepo  store.Search
+}
+
+// Option configures optional server behaviour.
+type Option func(*server)
+
+// WithQuotas limits what users can store.
+func WithQuotas(quotas store.Quotas) Option {
+	return func(s *server) {
+		s.quotas = quotas
+	}
+}
+
+// WithTx saves the records and their search tokens in transactions of tx.
+func WithTx(tx store.Tx) Option {
+	return func(s *server) {
+		s.tx = tx
+	}
+}
+
+// WithSearch keeps the search tokens sent with the records.
+func WithSearch(search store.Search) Option {
+	return func(s *server) {
+		s.searchRepo = search
+	}
+}
+
+// NewServer returns the gRPC server with every service of keeperpb registered.
+func NewServer(logger *zap.Logger,
+	user store.User,
+	notesRepo store.Secrets[types.Note],
+	credsRepo store.Secrets[types.Credentials],
+	cardsRepo store.Secrets[types.CardInfo],
+	fileService store.FileService,
+	opts ...Option) *grpc.Server {
+	s := &server{
+		logger:      logger,
+		userRepo:    user,
+		notesRepo:   notesRepo,
+		credsRepo:   credsRepo,
+		cardsRepo:   cardsRepo,
+		fileService: fileService,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(s.logUnary, authUnary),
+		grpc.ChainStreamInterceptor(s.logStream, authStream),
+	)
+	keeperpb.RegisterAuthServer(srv, &authService{server: s})
+	keeperpb.RegisterNotesServer(srv, &notesService{server: s})
+	keeperpb.RegisterCardsServer(srv, &cardsService{server: s})
+	keeperpb.RegisterCredentialsServer(srv, &credsService{server: s})
+	keeperpb.RegisterFilesServer(srv, &filesService{server: s})
+	return srv
+}
+
+// public lists the methods called without a token.
+var public = map[string]bool{
+	keeperpb.Auth_Register_FullMethodName: true,
+	keeperpb.Auth_Login_FullMethodName:    true,
+}
+
+func authUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if public[info.FullMethod] {
+		return handler(ctx, req)
+	}
+	ctx, err := authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func authStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if public[info.FullMethod] {
+		return handler(srv, ss)
+	}
+	ctx, err := authenticate(ss.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+}
+
+// authenticate verifies the token of the authorization metadata and keeps it
+// in the context for auth.UserIDFromContext.
+func authenticate(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "no token")
+	}
+
+	// the same prefix check as jwtauth.TokenFromHeader
+	bearer := values[0]
+	if len(bearer) <= 7 || !strings.EqualFold(bearer[:7], "Bearer ") {
+		return nil, status.Error(codes.Unauthenticated, "bearer token expected")
+	}
+
+	token, err := jwtauth.VerifyToken(auth.TokenAuth, bearer[7:])
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return jwtauth.NewContext(ctx, token, nil), nil
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *server) logUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	resp, err := handler(ctx, req)
+	s.logger.Debug("gRPC call", zap.String("method", info.FullMethod), zap.Stringer("code", status.Code(err)))
+	return resp, err
+}
+
+func (s *server) logStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	err := handler(srv, ss)
+	s.logger.Debug("gRPC stream", zap.String("method", info.FullMethod), zap.Stringer("code", status.Code(err)))
+	return err
+}
+
+// userID returns the user of the verified token.
+func userID(ctx context.Context) (string, error) {
+	id, err := auth.UserIDFromContext(ctx)
+	if err != nil {
+		return "", status.Error(codes.Unauthenticated, err.Error())
+	}
+	return id, nil
+}
+
+// toStatus converts an error of the stores to the status of the call, msg
+// prefixes the message of the unexpected errors.
+func toStatus(err error, msg string) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows), errors.Is(err, types.ErrNotFound):
+		return status.Error(codes.NotFound, "no such record")
+	case errors.Is(err, types.ErrQuotaExceeded), errors.Is(err, types.ErrFileTooLarge):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, types.ErrSizeMismatch):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, types.ErrInvalidCursor):
+		return status.Error(codes.InvalidArgument, "incorrect list parameters: "+err.Error())
+	case errors.Is(err, types.ErrUserAlreadyExists), errors.Is(err, types.ErrRecordAlreadyExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	}
+	return status.Error(codes.Internal, msg+": "+err.Error())
+}
+
+// createInQuota runs create when one more record of the kind fits the quota of
+// the user. With a transaction the quota stays locked until create is done, so
+// concurrent requests can't exceed it together.
+func (s *server) createInQuota(ctx context.Context, userID, kind string, create func(ctx context.Context) error) error {
+	if s.quotas == nil {
+		return create(ctx)
+	}
+
+	check := func(ctx context.Context, getUsage func(ctx context.Context, userID string) (*types.Usage, error)) error {
+		usage, err := getUsage(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to check quota: %w", err)
+		}
+		err = usage.Check(kind, 0)
+		if err != nil {
+			return err
+		}
+		return create(ctx)
+	}
+	if s.tx == nil {
+		return check(ctx, s.quotas.GetUsage)
+	}
+	return s.tx.InTx(ctx, func(ctx context.Context) error {
+		return check(ctx, s.quotas.LockUsage)
+	})
+}
+
+// saveIndexed runs save and replaces the search tokens of the record, in one
+// transaction when the server has one. The tokens are kept as they are when
+// they are nil, an empty slice removes them.
+func (s *server) saveIndexed(ctx context.Context, userID, kind, id string, tokens []string, save func(ctx context.Context) error) error {
+	if tokens == nil || s.searchRepo == nil {
+		return save(ctx)
+	}
+
+	index := func(ctx context.Context) error {
+		err := save(ctx)
+		if err != nil {
+			return err
+		}
+		return s.searchRepo.SetTokens(ctx, userID, kind, id, tokens)
+	}
+	if s.tx == nil {
+		return index(ctx)
+	}
+	return s.tx.InTx(ctx, index)
+}
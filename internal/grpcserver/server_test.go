@@ -0,0 +1,151 @@
+package grpcserver
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"keeper-project/internal/auth"
+	"keeper-project/internal/mocks"
+	"keeper-project/pkg/keeperpb"
+	"keeper-project/types"
+)
+
+const testUserID = "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83"
+
+type testMocks struct {
+	users  *mocks.MockUser
+	notes  *mocks.MockNotesSecret[types.Note]
+	cards  *mocks.MockCardSecret[types.CardInfo]
+	creds  *mocks.MockCredsSecret[types.Credentials]
+	files  *mocks.MockFileService
+	quotas *mocks.MockQuotas
+	search *mocks.MockSearch
+}
+
+func newMocks(t *testing.T) testMocks {
+	mockCtrl := gomock.NewController(t)
+	return testMocks{
+		users:  mocks.NewMockUser(mockCtrl),
+		notes:  mocks.NewMockNotesSecret(mockCtrl),
+		cards:  mocks.NewMockCardSecret(mockCtrl),
+		creds:  mocks.NewMockCredsSecret(mockCtrl),
+		files:  mocks.NewMockFileService(mockCtrl),
+		quotas: mocks.NewMockQuotas(mockCtrl),
+		search: mocks.NewMockSearch(mockCtrl),
+	}
+}
+
+// startServer serves the stores of the mocks over an in-memory connection.
+func startServer(t *testing.T, m testMocks, opts ...Option) *grpc.ClientConn {
+	lis := bufconn.Listen(1 << 20)
+	srv := NewServer(zap.L(), m.users, m.notes, m.creds, m.cards, m.files, opts...)
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// withToken returns the context of the calls of the user.
+func withToken(t *testing.T, userID string) context.Context {
+	token, err := auth.GenerateToken(userID)
+	require.NoError(t, err)
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+func TestAuth_Register(t *testing.T) {
+	m := newMocks(t)
+	conn := startServer(t, m)
+	client := keeperpb.NewAuthClient(conn)
+	ctx := context.Background()
+
+	m.users.EXPECT().CreateUser(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, usr *types.User) error {
+		assert.Equal(t, "bob", usr.Login)
+		assert.NotEqual(t, "secret", usr.Password)
+		return nil
+	})
+	token, err := client.Register(ctx, &keeperpb.LoginRequest{Login: "bob", Password: "secret"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, token.GetToken())
+
+	m.users.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(types.ErrUserAlreadyExists)
+	_, err = client.Register(ctx, &keeperpb.LoginRequest{Login: "bob", Password: "secret"})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	_, err = client.Register(ctx, &keeperpb.LoginRequest{Login: "bob"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestAuth_Login(t *testing.T) {
+	m := newMocks(t)
+	conn := startServer(t, m)
+	client := keeperpb.NewAuthClient(conn)
+	ctx := context.Background()
+
+	usr := (&types.User{ID: testUserID, Login: "bob", Password: "secret"}).ToDB()
+	m.users.EXPECT().GetByLogin(gomock.Any(), "bob").Return(usr, nil).Times(2)
+	m.users.EXPECT().GetByLogin(gomock.Any(), "alice").Return(nil, sql.ErrNoRows)
+
+	token, err := client.Login(ctx, &keeperpb.LoginRequest{Login: "bob", Password: "secret"})
+	require.NoError(t, err)
+
+	// the token is accepted by the other services
+	m.notes.EXPECT().Get(gomock.Any(), testUserID, "1").Return(&types.Note{Key: "key"}, nil)
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token.GetToken())
+	_, err = keeperpb.NewNotesClient(conn).Get(ctx, &keeperpb.GetRequest{Id: "1"})
+	assert.NoError(t, err)
+
+	_, err = client.Login(context.Background(), &keeperpb.LoginRequest{Login: "bob", Password: "wrong"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = client.Login(context.Background(), &keeperpb.LoginRequest{Login: "alice", Password: "secret"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestServer_authentication(t *testing.T) {
+	conn := startServer(t, newMocks(t))
+	notes := keeperpb.NewNotesClient(conn)
+	files := keeperpb.NewFilesClient(conn)
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+	}{
+		{name: "no token", ctx: context.Background()},
+		{name: "not bearer", ctx: metadata.AppendToOutgoingContext(context.Background(), "authorization", "Basic Ym9iOnNlY3JldA==")},
+		{name: "incorrect token", ctx: metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer token")},
+		{name: "no user", ctx: withToken(t, "")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := notes.Get(tt.ctx, &keeperpb.GetRequest{Id: "1"})
+			assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+			// the streams are checked as well
+			stream, err := files.Download(tt.ctx, &keeperpb.GetRequest{Id: "1"})
+			require.NoError(t, err)
+			_, err = stream.Recv()
+			assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		})
+	}
+}
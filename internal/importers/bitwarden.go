@@ -0,0 +1,148 @@
+package importers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Bitwarden item types.
+const (
+	bitwardenLogin      = 1
+	bitwardenSecureNote = 2
+	bitwardenCard       = 3
+	bitwardenIdentity   = 4
+)
+
+type bitwardenExport struct {
+	Encrypted bool `json:"encrypted"`
+	Folders   []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"folders"`
+	Items []bitwardenItem `json:"items"`
+}
+
+type bitwardenItem struct {
+	Type     int    `json:"type"`
+	Name     string `json:"name"`
+	Notes    string `json:"notes"`
+	FolderID string `json:"folderId"`
+	Fields   []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"fields"`
+	Login *struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		TOTP     string `json:"totp"`
+		URIs     []struct {
+			URI string `json:"uri"`
+		} `json:"uris"`
+	} `json:"login"`
+	Card *struct {
+		CardholderName string `json:"cardholderName"`
+		Brand          string `json:"brand"`
+		Number         string `json:"number"`
+		ExpMonth       string `json:"expMonth"`
+		ExpYear        string `json:"expYear"`
+		Code           string `json:"code"`
+	} `json:"card"`
+	Identity map[string]interface{} `json:"identity"`
+}
+
+// bitwardenImporter parses the unencrypted JSON export of Bitwarden.
+type bitwardenImporter struct{}
+
+func (bitwardenImporter) Parse(r io.Reader) (*Result, error) {
+	var export bitwardenExport
+	err := json.NewDecoder(r).Decode(&export)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode Bitwarden export: %w", err)
+	}
+	if export.Encrypted {
+		return nil, errors.New("encrypted Bitwarden exports are not supported, export as unencrypted JSON")
+	}
+
+	folders := make(map[string]string, len(export.Folders))
+	for _, f := range export.Folders {
+		folders[f.ID] = f.Name
+	}
+
+	res := &Result{}
+	for _, item := range export.Items {
+		e := entry{title: item.Name, notes: item.Notes}
+		e.meta.add("folder", folders[item.FolderID])
+
+		switch item.Type {
+		case bitwardenLogin:
+			if item.Login != nil {
+				e.username = item.Login.Username
+				e.password = item.Login.Password
+				for i, uri := range item.Login.URIs {
+					if i == 0 {
+						e.url = uri.URI
+					} else {
+						e.meta.add("url", uri.URI)
+					}
+				}
+				e.meta.add("totp", item.Login.TOTP)
+			}
+		case bitwardenCard:
+			e.card = &card{}
+			if item.Card != nil {
+				e.card.number = item.Card.Number
+				e.card.cvv = item.Card.Code
+				e.card.expiration = expiration(item.Card.ExpMonth, item.Card.ExpYear)
+				e.meta.add("cardholder", item.Card.CardholderName)
+				e.meta.add("brand", item.Card.Brand)
+			}
+		case bitwardenIdentity:
+			var text metadata
+			for _, key := range sortedKeys(item.Identity) {
+				if v, ok := item.Identity[key].(string); ok {
+					text.add(key, v)
+				}
+			}
+			text.add("notes", item.Notes)
+			e.notes = text.String()
+		case bitwardenSecureNote:
+		default:
+			return nil, fmt.Errorf("item %q has unknown type %d", item.Name, item.Type)
+		}
+
+		for _, f := range item.Fields {
+			e.meta.add(f.Name, f.Value)
+		}
+		res.add(e)
+	}
+
+	return res, nil
+}
+
+// expiration formats the card expiration as MM/YY.
+func expiration(month, year string) string {
+	month, year = strings.TrimSpace(month), strings.TrimSpace(year)
+	if month == "" || year == "" {
+		return ""
+	}
+	if len(month) == 1 {
+		month = "0" + month
+	}
+	if len(year) == 4 {
+		year = year[2:]
+	}
+	return month + "/" + year
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
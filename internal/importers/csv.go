@@ -0,0 +1,112 @@
+package importers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// csvImporter maps the columns of a CSV export with a header row, every field
+// lists the accepted column names in lower case. Other columns go to metadata.
+type csvImporter struct {
+	title, url, username, password, notes []string
+	// required columns of the format, checked to tell formats apart
+	required []string
+}
+
+var keepassCSV = csvImporter{
+	// KeePassXC and KeePass 2 export different column sets
+	title:    []string{"title", "account"},
+	url:      []string{"url", "web site"},
+	username: []string{"username", "login name"},
+	password: []string{"password"},
+	notes:    []string{"notes", "comments"},
+	required: []string{"password"},
+}
+
+var onePasswordCSV = csvImporter{
+	title:    []string{"title"},
+	url:      []string{"url", "website"},
+	username: []string{"username"},
+	password: []string{"password"},
+	notes:    []string{"notes", "notesplain"},
+	required: []string{"title", "password"},
+}
+
+var chromeCSV = csvImporter{
+	title:    []string{"name"},
+	url:      []string{"url"},
+	username: []string{"username"},
+	password: []string{"password"},
+	notes:    []string{"note"},
+	required: []string{"name", "url", "username", "password"},
+}
+
+func (c csvImporter) Parse(r io.Reader) (*Result, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+	for _, name := range c.required {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("no %q column, is it the right format?", name)
+		}
+	}
+
+	known := make(map[int]bool)
+	index := func(names []string) int {
+		for _, name := range names {
+			if i, ok := columns[name]; ok {
+				known[i] = true
+				return i
+			}
+		}
+		return -1
+	}
+	title, url, username, pass, notes := index(c.title), index(c.url), index(c.username), index(c.password), index(c.notes)
+
+	res := &Result{}
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return res, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		value := func(i int) string {
+			if i < 0 || i >= len(record) {
+				return ""
+			}
+			return record[i]
+		}
+		field := func(i int) string {
+			return strings.TrimSpace(value(i))
+		}
+		// spaces around a password or in notes may be meaningful, they are kept
+		e := entry{
+			title:    field(title),
+			url:      field(url),
+			username: field(username),
+			password: value(pass),
+			notes:    value(notes),
+		}
+		for i, name := range header {
+			if !known[i] {
+				e.meta.add(strings.TrimSpace(name), field(i))
+			}
+		}
+		res.add(e)
+	}
+}
@@ -0,0 +1,155 @@
+// Package importers parses the exports of other password managers into
+// keeper records. Fields without a counterpart in keeper end up in the
+// metadata of the record as "name: value" lines.
+package importers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"keeper-project/types"
+)
+
+// Result holds the parsed records in plain text.
+type Result struct {
+	Credentials []types.Credentials
+	Notes       []types.Note
+	Cards       []types.CardInfo
+}
+
+// Importer parses a single export format.
+type Importer interface {
+	Parse(r io.Reader) (*Result, error)
+}
+
+var importers = map[string]Importer{
+	"bitwarden":   bitwardenImporter{},
+	"keepass-csv": keepassCSV,
+	"keepass-xml": keepassXMLImporter{},
+	"1password":   onePasswordCSV,
+	"chrome":      chromeCSV,
+}
+
+var ErrUnknownFormat = errors.New("importers: unknown format")
+
+// Get returns the importer of the format, see Formats.
+func Get(format string) (Importer, error) {
+	imp, ok := importers[format]
+	if !ok {
+		return nil, fmt.Errorf("%w %q, use one of %s", ErrUnknownFormat, format, strings.Join(Formats(), ", "))
+	}
+	return imp, nil
+}
+
+// Formats returns the names of the supported formats.
+func Formats() []string {
+	names := make([]string, 0, len(importers))
+	for name := range importers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// untitled names records the export has no name for, keeper requires one.
+const untitled = "untitled"
+
+// metadata collects the fields keeper has no place for in export order.
+type metadata []string
+
+func (m *metadata) add(name, value string) {
+	value = strings.TrimSpace(value)
+	if value != "" {
+		*m = append(*m, name+": "+value)
+	}
+}
+
+// addVerbatim adds the value with its spaces, e.g. a password.
+func (m *metadata) addVerbatim(name, value string) {
+	if value != "" {
+		*m = append(*m, name+": "+value)
+	}
+}
+
+func (m metadata) String() string {
+	return strings.Join(m, "\n")
+}
+
+// entry is a login, note or card as most formats describe it.
+type entry struct {
+	title    string
+	url      string
+	username string
+	password string
+	notes    string
+
+	card *card
+	meta metadata
+}
+
+type card struct {
+	number     string
+	expiration string
+	cvv        string
+}
+
+// add converts the entry into a keeper record. Keeper requires a site and login
+// of credentials and all the fields of a card, entries lacking them are stored
+// as notes so that nothing is lost.
+func (res *Result) add(e entry) {
+	title := firstNonEmpty(e.title, e.url, untitled)
+
+	switch {
+	case e.card != nil && e.card.number != "" && e.card.expiration != "" && e.card.cvv != "":
+		meta := append(metadata{}, e.meta...)
+		meta.add("title", e.title)
+		meta.add("notes", e.notes)
+		res.Cards = append(res.Cards, types.CardInfo{
+			Number:     e.card.number,
+			Expiration: e.card.expiration,
+			CVV:        e.card.cvv,
+			Metadata:   meta.String(),
+		})
+	case e.card != nil:
+		var text metadata
+		text.add("number", e.card.number)
+		text.add("expiration", e.card.expiration)
+		text.add("cvv", e.card.cvv)
+		text.addVerbatim("notes", e.notes)
+		res.Notes = append(res.Notes, types.Note{Key: title, Text: text.String(), Metadata: e.meta.String()})
+	case e.username != "":
+		meta := append(metadata{}, e.meta...)
+		if e.url != "" {
+			meta.add("title", e.title)
+		}
+		meta.add("notes", e.notes)
+		res.Credentials = append(res.Credentials, types.Credentials{
+			Site:     firstNonEmpty(e.url, e.title, untitled),
+			Login:    e.username,
+			Password: e.password,
+			Metadata: meta.String(),
+		})
+	case e.password != "":
+		var text metadata
+		text.add("url", e.url)
+		text.addVerbatim("password", e.password)
+		text.addVerbatim("notes", e.notes)
+		res.Notes = append(res.Notes, types.Note{Key: title, Text: text.String(), Metadata: e.meta.String()})
+	case strings.TrimSpace(e.notes) != "" || e.title != "" || len(e.meta) > 0:
+		meta := append(metadata{}, e.meta...)
+		meta.add("url", e.url)
+		res.Notes = append(res.Notes, types.Note{Key: title, Text: e.notes, Metadata: meta.String()})
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,143 @@
+package importers
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"keeper-project/types"
+)
+
+func parseFixture(t *testing.T, format, name string) *Result {
+	t.Helper()
+
+	imp, err := Get(format)
+	require.NoError(t, err)
+
+	f, err := os.Open(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	defer f.Close()
+
+	res, err := imp.Parse(f)
+	require.NoError(t, err)
+	return res
+}
+
+func TestBitwarden(t *testing.T) {
+	res := parseFixture(t, "bitwarden", "bitwarden.json")
+
+	assert.Equal(t, []types.Credentials{{
+		Site:     "https://github.com",
+		Login:    "octocat",
+		Password: "hunter2",
+		Metadata: "folder: Work\nurl: https://gist.github.com\ntotp: otpauth://totp/GitHub?secret=ABC\npin: 1234\n" +
+			"title: GitHub\nnotes: recovery codes in the safe",
+	}}, res.Credentials)
+	assert.Equal(t, []types.CardInfo{{
+		Number:     "4111111111111111",
+		Expiration: "07/29",
+		CVV:        "123",
+		Metadata:   "cardholder: John Doe\nbrand: Visa\ntitle: Visa",
+	}}, res.Cards)
+	assert.Equal(t, []types.Note{
+		{Key: "Wi-Fi", Text: "guest network: welcome"},
+		{Key: "Me", Text: "firstName: John\nlastName: Doe"},
+	}, res.Notes)
+}
+
+func TestBitwarden_Encrypted(t *testing.T) {
+	_, err := bitwardenImporter{}.Parse(strings.NewReader(`{"encrypted": true, "items": []}`))
+	assert.Error(t, err)
+}
+
+func TestKeepassCSV(t *testing.T) {
+	res := parseFixture(t, "keepass-csv", "keepass.csv")
+
+	require.Len(t, res.Credentials, 1)
+	assert.Equal(t, "https://github.com", res.Credentials[0].Site)
+	assert.Equal(t, "octocat", res.Credentials[0].Login)
+	assert.Equal(t, "hunter2", res.Credentials[0].Password)
+	assert.Contains(t, res.Credentials[0].Metadata, "Group: Root/Internet")
+	assert.Contains(t, res.Credentials[0].Metadata, "notes: recovery codes in the safe")
+
+	// a password without a login can't be credentials in keeper
+	assert.Equal(t, []types.Note{{Key: "Wi-Fi", Text: "password: welcome\nnotes: guest network",
+		Metadata: "Group: Root\nIcon: 0\nLast Modified: 2024-01-01T00:00:00Z\nCreated: 2024-01-01T00:00:00Z"}}, res.Notes)
+}
+
+func TestKeepassXML(t *testing.T) {
+	res := parseFixture(t, "keepass-xml", "keepass.xml")
+
+	assert.Equal(t, []types.Credentials{{
+		Site:     "https://github.com",
+		Login:    "octocat",
+		Password: "hunter2",
+		Metadata: "pin: 1234\ntitle: GitHub\nnotes: recovery codes in the safe",
+	}}, res.Credentials)
+	assert.Equal(t, []types.Note{{Key: "Alarm code", Text: "4321", Metadata: "group: Home"}}, res.Notes)
+}
+
+func TestOnePassword(t *testing.T) {
+	res := parseFixture(t, "1password", "1password.csv")
+
+	require.Len(t, res.Credentials, 1)
+	assert.Equal(t, "octocat", res.Credentials[0].Login)
+	assert.Contains(t, res.Credentials[0].Metadata, "OTPAuth: otpauth://totp/GitHub?secret=ABC")
+	assert.Contains(t, res.Credentials[0].Metadata, "Tags: work")
+
+	require.Len(t, res.Notes, 1)
+	assert.Equal(t, "Shopping list", res.Notes[0].Key)
+	assert.Equal(t, "milk\neggs", res.Notes[0].Text)
+}
+
+func TestChrome(t *testing.T) {
+	res := parseFixture(t, "chrome", "chrome.csv")
+
+	assert.Equal(t, []types.Credentials{{
+		Site:     "https://github.com/login",
+		Login:    "octocat",
+		Password: "hunter2",
+		Metadata: "title: github.com",
+	}}, res.Credentials)
+	assert.Equal(t, []types.Note{{Key: "example.com", Text: "url: https://example.com/\npassword: pin-only"}}, res.Notes)
+}
+
+func TestCSV_WrongFormat(t *testing.T) {
+	imp, err := Get("chrome")
+	require.NoError(t, err)
+
+	f, err := os.Open(filepath.Join("testdata", "1password.csv"))
+	require.NoError(t, err)
+	defer f.Close()
+
+	_, err = imp.Parse(f)
+	assert.Error(t, err)
+}
+
+func TestGet_UnknownFormat(t *testing.T) {
+	_, err := Get("lastpass")
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+	assert.Equal(t, []string{"1password", "bitwarden", "chrome", "keepass-csv", "keepass-xml"}, Formats())
+}
+
+func TestCSV_Spaces(t *testing.T) {
+	imp, err := Get("chrome")
+	require.NoError(t, err)
+
+	res, err := imp.Parse(strings.NewReader("name,url,username,password,note\n" +
+		" github.com , https://github.com , octocat ,  two spaces , indented\n" +
+		"wifi,,, pin ,\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []types.Credentials{{
+		Site:     "https://github.com",
+		Login:    "octocat",
+		Password: "  two spaces ",
+		Metadata: "title: github.com\nnotes: indented",
+	}}, res.Credentials)
+	assert.Equal(t, []types.Note{{Key: "wifi", Text: "password:  pin "}}, res.Notes)
+}
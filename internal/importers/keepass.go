@@ -0,0 +1,78 @@
+package importers
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type keepassFile struct {
+	Root struct {
+		Groups []keepassGroup `xml:"Group"`
+	} `xml:"Root"`
+}
+
+type keepassGroup struct {
+	Name    string         `xml:"Name"`
+	Entries []keepassEntry `xml:"Entry"`
+	Groups  []keepassGroup `xml:"Group"`
+}
+
+type keepassEntry struct {
+	Strings []struct {
+		Key   string `xml:"Key"`
+		Value string `xml:"Value"`
+	} `xml:"String"`
+}
+
+// keepassXMLImporter parses the KeePass 2 XML export, the group path of an
+// entry goes to the metadata. The history of entries is not imported.
+type keepassXMLImporter struct{}
+
+func (keepassXMLImporter) Parse(r io.Reader) (*Result, error) {
+	var file keepassFile
+	err := xml.NewDecoder(r).Decode(&file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode KeePass XML: %w", err)
+	}
+
+	res := &Result{}
+	for _, g := range file.Root.Groups {
+		// the top group is the database itself
+		addKeepassGroup(res, g, "")
+	}
+	return res, nil
+}
+
+func addKeepassGroup(res *Result, g keepassGroup, path string) {
+	for _, kpe := range g.Entries {
+		e := entry{}
+		e.meta.add("group", path)
+		for _, s := range kpe.Strings {
+			switch s.Key {
+			case "Title":
+				e.title = strings.TrimSpace(s.Value)
+			case "URL":
+				e.url = strings.TrimSpace(s.Value)
+			case "UserName":
+				e.username = strings.TrimSpace(s.Value)
+			case "Password":
+				e.password = s.Value
+			case "Notes":
+				e.notes = s.Value
+			default:
+				e.meta.add(s.Key, s.Value)
+			}
+		}
+		res.add(e)
+	}
+
+	for _, sub := range g.Groups {
+		subPath := sub.Name
+		if path != "" {
+			subPath = path + "/" + sub.Name
+		}
+		addKeepassGroup(res, sub, subPath)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	context "context"
 	types "keeper-project/types"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -35,6 +36,35 @@ func (m *MockFileService) EXPECT() *MockFileServiceMockRecorder {
 	return m.recorder
 }
 
+// AbortUpload mocks base method.
+func (m *MockFileService) AbortUpload(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AbortUpload", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AbortUpload indicates an expected call of AbortUpload.
+func (mr *MockFileServiceMockRecorder) AbortUpload(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AbortUpload", reflect.TypeOf((*MockFileService)(nil).AbortUpload), arg0, arg1, arg2)
+}
+
+// CompleteUpload mocks base method.
+func (m *MockFileService) CompleteUpload(arg0 context.Context, arg1, arg2 string) (*types.UploadSession, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteUpload", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*types.UploadSession)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompleteUpload indicates an expected call of CompleteUpload.
+func (mr *MockFileServiceMockRecorder) CompleteUpload(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteUpload", reflect.TypeOf((*MockFileService)(nil).CompleteUpload), arg0, arg1, arg2)
+}
+
 // Create mocks base method.
 func (m *MockFileService) Create(arg0 context.Context, arg1 string, arg2 types.CreateFileDTO) error {
 	m.ctrl.T.Helper()
@@ -49,6 +79,21 @@ func (mr *MockFileServiceMockRecorder) Create(arg0, arg1, arg2 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockFileService)(nil).Create), arg0, arg1, arg2)
 }
 
+// CreateUpload mocks base method.
+func (m *MockFileService) CreateUpload(arg0 context.Context, arg1 string, arg2 types.CreateUploadRequest) (*types.UploadSession, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUpload", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*types.UploadSession)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUpload indicates an expected call of CreateUpload.
+func (mr *MockFileServiceMockRecorder) CreateUpload(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUpload", reflect.TypeOf((*MockFileService)(nil).CreateUpload), arg0, arg1, arg2)
+}
+
 // Delete mocks base method.
 func (m *MockFileService) Delete(arg0 context.Context, arg1, arg2 string) error {
 	m.ctrl.T.Helper()
@@ -63,6 +108,35 @@ func (mr *MockFileServiceMockRecorder) Delete(arg0, arg1, arg2 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockFileService)(nil).Delete), arg0, arg1, arg2)
 }
 
+// EmptyTrash mocks base method.
+func (m *MockFileService) EmptyTrash(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EmptyTrash", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EmptyTrash indicates an expected call of EmptyTrash.
+func (mr *MockFileServiceMockRecorder) EmptyTrash(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EmptyTrash", reflect.TypeOf((*MockFileService)(nil).EmptyTrash), arg0, arg1)
+}
+
+// GetDeletedList mocks base method.
+func (m *MockFileService) GetDeletedList(arg0 context.Context, arg1 string) ([]types.TrashItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeletedList", arg0, arg1)
+	ret0, _ := ret[0].([]types.TrashItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeletedList indicates an expected call of GetDeletedList.
+func (mr *MockFileServiceMockRecorder) GetDeletedList(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeletedList", reflect.TypeOf((*MockFileService)(nil).GetDeletedList), arg0, arg1)
+}
+
 // GetFile mocks base method.
 func (m *MockFileService) GetFile(arg0 context.Context, arg1, arg2 string) (*types.File, error) {
 	m.ctrl.T.Helper()
@@ -79,16 +153,120 @@ func (mr *MockFileServiceMockRecorder) GetFile(arg0, arg1, arg2 interface{}) *go
 }
 
 // GetFilesList mocks base method.
-func (m *MockFileService) GetFilesList(arg0 context.Context, arg1 string) ([]*types.Key, error) {
+func (m *MockFileService) GetFilesList(arg0 context.Context, arg1 string, arg2 types.ListOptions) (*types.Page[*types.FileInfo], error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetFilesList", arg0, arg1)
-	ret0, _ := ret[0].([]*types.Key)
+	ret := m.ctrl.Call(m, "GetFilesList", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*types.Page[*types.FileInfo])
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetFilesList indicates an expected call of GetFilesList.
-func (mr *MockFileServiceMockRecorder) GetFilesList(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockFileServiceMockRecorder) GetFilesList(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFilesList", reflect.TypeOf((*MockFileService)(nil).GetFilesList), arg0, arg1, arg2)
+}
+
+// GetUpload mocks base method.
+func (m *MockFileService) GetUpload(arg0 context.Context, arg1, arg2 string) (*types.UploadSession, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUpload", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*types.UploadSession)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUpload indicates an expected call of GetUpload.
+func (mr *MockFileServiceMockRecorder) GetUpload(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUpload", reflect.TypeOf((*MockFileService)(nil).GetUpload), arg0, arg1, arg2)
+}
+
+// HasBlob mocks base method.
+func (m *MockFileService) HasBlob(arg0 context.Context, arg1, arg2 string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasBlob", arg0, arg1, arg2)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasBlob indicates an expected call of HasBlob.
+func (mr *MockFileServiceMockRecorder) HasBlob(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasBlob", reflect.TypeOf((*MockFileService)(nil).HasBlob), arg0, arg1, arg2)
+}
+
+// Link mocks base method.
+func (m *MockFileService) Link(arg0 context.Context, arg1 string, arg2 types.LinkFileRequest) (*types.FileInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Link", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*types.FileInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Link indicates an expected call of Link.
+func (mr *MockFileServiceMockRecorder) Link(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Link", reflect.TypeOf((*MockFileService)(nil).Link), arg0, arg1, arg2)
+}
+
+// PurgeDeleted mocks base method.
+func (m *MockFileService) PurgeDeleted(arg0 context.Context, arg1 time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeleted", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeleted indicates an expected call of PurgeDeleted.
+func (mr *MockFileServiceMockRecorder) PurgeDeleted(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeleted", reflect.TypeOf((*MockFileService)(nil).PurgeDeleted), arg0, arg1)
+}
+
+// PurgeUploads mocks base method.
+func (m *MockFileService) PurgeUploads(arg0 context.Context, arg1 time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeUploads", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeUploads indicates an expected call of PurgeUploads.
+func (mr *MockFileServiceMockRecorder) PurgeUploads(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeUploads", reflect.TypeOf((*MockFileService)(nil).PurgeUploads), arg0, arg1)
+}
+
+// Restore mocks base method.
+func (m *MockFileService) Restore(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockFileServiceMockRecorder) Restore(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockFileService)(nil).Restore), arg0, arg1, arg2)
+}
+
+// UploadChunk mocks base method.
+func (m *MockFileService) UploadChunk(arg0 context.Context, arg1, arg2 string, arg3 types.UploadChunk) (*types.UploadChunkInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadChunk", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*types.UploadChunkInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UploadChunk indicates an expected call of UploadChunk.
+func (mr *MockFileServiceMockRecorder) UploadChunk(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFilesList", reflect.TypeOf((*MockFileService)(nil).GetFilesList), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadChunk", reflect.TypeOf((*MockFileService)(nil).UploadChunk), arg0, arg1, arg2, arg3)
 }
@@ -8,6 +8,7 @@ import (
 	context "context"
 	types "keeper-project/types"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -35,6 +36,49 @@ func (m *MockStorage) EXPECT() *MockStorageMockRecorder {
 	return m.recorder
 }
 
+// AbortStaleUploads mocks base method.
+func (m *MockStorage) AbortStaleUploads(ctx context.Context, bucketName string, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AbortStaleUploads", ctx, bucketName, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AbortStaleUploads indicates an expected call of AbortStaleUploads.
+func (mr *MockStorageMockRecorder) AbortStaleUploads(ctx, bucketName, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AbortStaleUploads", reflect.TypeOf((*MockStorage)(nil).AbortStaleUploads), ctx, bucketName, before)
+}
+
+// AbortUpload mocks base method.
+func (m *MockStorage) AbortUpload(ctx context.Context, bucketName string, upload *types.UploadSession) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AbortUpload", ctx, bucketName, upload)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AbortUpload indicates an expected call of AbortUpload.
+func (mr *MockStorageMockRecorder) AbortUpload(ctx, bucketName, upload interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AbortUpload", reflect.TypeOf((*MockStorage)(nil).AbortUpload), ctx, bucketName, upload)
+}
+
+// CompleteUpload mocks base method.
+func (m *MockStorage) CompleteUpload(ctx context.Context, bucketName string, upload *types.UploadSession) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteUpload", ctx, bucketName, upload)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteUpload indicates an expected call of CompleteUpload.
+func (mr *MockStorageMockRecorder) CompleteUpload(ctx, bucketName, upload interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteUpload", reflect.TypeOf((*MockStorage)(nil).CompleteUpload), ctx, bucketName, upload)
+}
+
 // CreateFile mocks base method.
 func (m *MockStorage) CreateFile(ctx context.Context, bucketName string, file *types.File) error {
 	m.ctrl.T.Helper()
@@ -49,6 +93,20 @@ func (mr *MockStorageMockRecorder) CreateFile(ctx, bucketName, file interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFile", reflect.TypeOf((*MockStorage)(nil).CreateFile), ctx, bucketName, file)
 }
 
+// CreateUpload mocks base method.
+func (m *MockStorage) CreateUpload(ctx context.Context, bucketName string, upload *types.UploadSession) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUpload", ctx, bucketName, upload)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateUpload indicates an expected call of CreateUpload.
+func (mr *MockStorageMockRecorder) CreateUpload(ctx, bucketName, upload interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUpload", reflect.TypeOf((*MockStorage)(nil).CreateUpload), ctx, bucketName, upload)
+}
+
 // DeleteFile mocks base method.
 func (m *MockStorage) DeleteFile(ctx context.Context, bucketName, fileName string) error {
 	m.ctrl.T.Helper()
@@ -63,6 +121,21 @@ func (mr *MockStorageMockRecorder) DeleteFile(ctx, bucketName, fileName interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFile", reflect.TypeOf((*MockStorage)(nil).DeleteFile), ctx, bucketName, fileName)
 }
 
+// GetBuckets mocks base method.
+func (m *MockStorage) GetBuckets(ctx context.Context) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBuckets", ctx)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBuckets indicates an expected call of GetBuckets.
+func (mr *MockStorageMockRecorder) GetBuckets(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBuckets", reflect.TypeOf((*MockStorage)(nil).GetBuckets), ctx)
+}
+
 // GetFile mocks base method.
 func (m *MockStorage) GetFile(ctx context.Context, bucketName, fileName string) (*types.File, error) {
 	m.ctrl.T.Helper()
@@ -78,17 +151,32 @@ func (mr *MockStorageMockRecorder) GetFile(ctx, bucketName, fileName interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFile", reflect.TypeOf((*MockStorage)(nil).GetFile), ctx, bucketName, fileName)
 }
 
-// GetFilesList mocks base method.
-func (m *MockStorage) GetFilesList(ctx context.Context, bucketName string) ([]*types.Key, error) {
+// GetUpload mocks base method.
+func (m *MockStorage) GetUpload(ctx context.Context, bucketName, uploadID string) (*types.UploadSession, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUpload", ctx, bucketName, uploadID)
+	ret0, _ := ret[0].(*types.UploadSession)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUpload indicates an expected call of GetUpload.
+func (mr *MockStorageMockRecorder) GetUpload(ctx, bucketName, uploadID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUpload", reflect.TypeOf((*MockStorage)(nil).GetUpload), ctx, bucketName, uploadID)
+}
+
+// PutChunk mocks base method.
+func (m *MockStorage) PutChunk(ctx context.Context, bucketName string, upload *types.UploadSession, chunk types.UploadChunk) (types.UploadChunkInfo, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetFilesList", ctx, bucketName)
-	ret0, _ := ret[0].([]*types.Key)
+	ret := m.ctrl.Call(m, "PutChunk", ctx, bucketName, upload, chunk)
+	ret0, _ := ret[0].(types.UploadChunkInfo)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetFilesList indicates an expected call of GetFilesList.
-func (mr *MockStorageMockRecorder) GetFilesList(ctx, bucketName interface{}) *gomock.Call {
+// PutChunk indicates an expected call of PutChunk.
+func (mr *MockStorageMockRecorder) PutChunk(ctx, bucketName, upload, chunk interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFilesList", reflect.TypeOf((*MockStorage)(nil).GetFilesList), ctx, bucketName)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutChunk", reflect.TypeOf((*MockStorage)(nil).PutChunk), ctx, bucketName, upload, chunk)
 }
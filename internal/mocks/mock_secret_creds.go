@@ -8,6 +8,7 @@ import (
 	context "context"
 	types "keeper-project/types"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -49,6 +50,20 @@ func (mr *MockSecretCredsMockRecorder) Create(arg0, arg1, arg2, arg3 interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockCredsSecret[types.Credentials])(nil).Create), arg0, arg1, arg2, arg3)
 }
 
+// CreateMany mocks base method.
+func (m *MockCredsSecret[T]) CreateMany(arg0 context.Context, arg1 string, arg2 []types.Record[types.Credentials]) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMany", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateMany indicates an expected call of CreateMany.
+func (mr *MockSecretCredsMockRecorder) CreateMany(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMany", reflect.TypeOf((*MockCredsSecret[types.Credentials])(nil).CreateMany), arg0, arg1, arg2)
+}
+
 // Delete mocks base method.
 func (m *MockCredsSecret[T]) Delete(arg0 context.Context, arg1, arg2 string) error {
 	m.ctrl.T.Helper()
@@ -63,6 +78,34 @@ func (mr *MockSecretCredsMockRecorder) Delete(arg0, arg1, arg2 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockCredsSecret[types.Credentials])(nil).Delete), arg0, arg1, arg2)
 }
 
+// DeleteMany mocks base method.
+func (m *MockCredsSecret[T]) DeleteMany(arg0 context.Context, arg1 string, arg2 []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMany", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMany indicates an expected call of DeleteMany.
+func (mr *MockSecretCredsMockRecorder) DeleteMany(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMany", reflect.TypeOf((*MockCredsSecret[types.Credentials])(nil).DeleteMany), arg0, arg1, arg2)
+}
+
+// EmptyTrash mocks base method.
+func (m *MockCredsSecret[T]) EmptyTrash(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EmptyTrash", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EmptyTrash indicates an expected call of EmptyTrash.
+func (mr *MockSecretCredsMockRecorder) EmptyTrash(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EmptyTrash", reflect.TypeOf((*MockCredsSecret[types.Credentials])(nil).EmptyTrash), arg0, arg1)
+}
+
 // Get mocks base method.
 func (m *MockCredsSecret[T]) Get(arg0 context.Context, arg1, arg2 string) (*types.Credentials, error) {
 	m.ctrl.T.Helper()
@@ -78,19 +121,63 @@ func (mr *MockSecretCredsMockRecorder) Get(arg0, arg1, arg2 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockCredsSecret[types.Credentials])(nil).Get), arg0, arg1, arg2)
 }
 
+// GetDeletedList mocks base method.
+func (m *MockCredsSecret[T]) GetDeletedList(arg0 context.Context, arg1 string) ([]types.TrashItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeletedList", arg0, arg1)
+	ret0, _ := ret[0].([]types.TrashItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeletedList indicates an expected call of GetDeletedList.
+func (mr *MockSecretCredsMockRecorder) GetDeletedList(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeletedList", reflect.TypeOf((*MockCredsSecret[types.Credentials])(nil).GetDeletedList), arg0, arg1)
+}
+
 // GetKeysList mocks base method.
-func (m *MockCredsSecret[T]) GetKeysList(arg0 context.Context, arg1 string) ([]types.Key, error) {
+func (m *MockCredsSecret[T]) GetKeysList(arg0 context.Context, arg1 string, arg2 types.ListOptions) (*types.Page[types.Key], error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetKeysList", arg0, arg1)
-	ret0, _ := ret[0].([]types.Key)
+	ret := m.ctrl.Call(m, "GetKeysList", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*types.Page[types.Key])
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetKeysList indicates an expected call of GetKeysList.
-func (mr *MockSecretCredsMockRecorder) GetKeysList(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockSecretCredsMockRecorder) GetKeysList(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKeysList", reflect.TypeOf((*MockCredsSecret[types.Credentials])(nil).GetKeysList), arg0, arg1, arg2)
+}
+
+// PurgeDeleted mocks base method.
+func (m *MockCredsSecret[T]) PurgeDeleted(arg0 context.Context, arg1 time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeleted", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeleted indicates an expected call of PurgeDeleted.
+func (mr *MockSecretCredsMockRecorder) PurgeDeleted(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeleted", reflect.TypeOf((*MockCredsSecret[types.Credentials])(nil).PurgeDeleted), arg0, arg1)
+}
+
+// Restore mocks base method.
+func (m *MockCredsSecret[T]) Restore(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockSecretCredsMockRecorder) Restore(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKeysList", reflect.TypeOf((*MockCredsSecret[types.Credentials])(nil).GetKeysList), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockCredsSecret[types.Credentials])(nil).Restore), arg0, arg1, arg2)
 }
 
 // Update mocks base method.
@@ -106,3 +193,17 @@ func (mr *MockSecretCredsMockRecorder) Update(arg0, arg1, arg2, arg3 interface{}
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockCredsSecret[types.Credentials])(nil).Update), arg0, arg1, arg2, arg3)
 }
+
+// UpdateMany mocks base method.
+func (m *MockCredsSecret[T]) UpdateMany(arg0 context.Context, arg1 string, arg2 []types.Record[types.Credentials]) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateMany", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateMany indicates an expected call of UpdateMany.
+func (mr *MockSecretCredsMockRecorder) UpdateMany(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMany", reflect.TypeOf((*MockCredsSecret[types.Credentials])(nil).UpdateMany), arg0, arg1, arg2)
+}
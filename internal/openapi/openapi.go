@@ -0,0 +1,255 @@
+// Package openapi holds the OpenAPI 3 document of the HTTP API and checks
+// requests and responses against it.
+//
+// The validator understands the subset of the specification the document
+// uses: path, query and header parameters, JSON bodies, the media types of
+// the other bodies, required response headers and the schema keywords listed
+// in Schema.
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+//go:embed openapi.json
+var document []byte
+
+// ServeSpec responds with the OpenAPI document.
+func ServeSpec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(document)
+}
+
+type Spec struct {
+	Paths      map[string]*PathItem  `json:"paths"`
+	Security   []map[string][]string `json:"security"`
+	Components struct {
+		Schemas   map[string]*Schema   `json:"schemas"`
+		Responses map[string]*Response `json:"responses"`
+	} `json:"components"`
+
+	templates []template
+}
+
+// PathItem holds the operations of a path keyed by the lower case method.
+type PathItem struct {
+	Parameters []*Parameter
+	Operations map[string]*Operation
+}
+
+func (p *PathItem) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	err := json.Unmarshal(data, &fields)
+	if err != nil {
+		return err
+	}
+
+	p.Operations = make(map[string]*Operation)
+	for name, raw := range fields {
+		if name == "parameters" {
+			err = json.Unmarshal(raw, &p.Parameters)
+		} else {
+			var op Operation
+			err = json.Unmarshal(raw, &op)
+			p.Operations[name] = &op
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+type Operation struct {
+	ID          string               `json:"operationId"`
+	Parameters  []*Parameter         `json:"parameters"`
+	RequestBody *RequestBody         `json:"requestBody"`
+	Responses   map[string]*Response `json:"responses"`
+	// Security overrides the security of the document, an empty list
+	// makes the operation public.
+	Security *[]map[string][]string `json:"security"`
+
+	// Method and Path are the method and the path template of the operation.
+	Method string `json:"-"`
+	Path   string `json:"-"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Ref     string                `json:"$ref"`
+	Headers map[string]*Header    `json:"headers"`
+	Content map[string]*MediaType `json:"content"`
+}
+
+type Header struct {
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// template is a path of the document split into segments, the parameters
+// are the segments in braces.
+type template struct {
+	path     string
+	segments []string
+	literals int
+}
+
+// Load parses the embedded document.
+func Load() (*Spec, error) {
+	var s Spec
+	err := json.Unmarshal(document, &s)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse openapi.json: %w", err)
+	}
+
+	for path, item := range s.Paths {
+		t := template{path: path, segments: strings.Split(strings.Trim(path, "/"), "/")}
+		for _, seg := range t.segments {
+			if !isParam(seg) {
+				t.literals++
+			}
+		}
+		s.templates = append(s.templates, t)
+
+		for method, op := range item.Operations {
+			op.Method, op.Path = strings.ToUpper(method), path
+			op.Parameters = mergeParameters(item.Parameters, op.Parameters)
+			for status, res := range op.Responses {
+				if res.Ref == "" {
+					continue
+				}
+				name := strings.TrimPrefix(res.Ref, "#/components/responses/")
+				shared, ok := s.Components.Responses[name]
+				if !ok {
+					return nil, fmt.Errorf("%s %s: unknown response %s", op.Method, path, res.Ref)
+				}
+				op.Responses[status] = shared
+			}
+		}
+	}
+	// the paths with more literal segments win, e.g. /file/link over /file/{id}
+	sort.Slice(s.templates, func(i, j int) bool {
+		return s.templates[i].literals > s.templates[j].literals
+	})
+	return &s, nil
+}
+
+// mergeParameters adds the parameters of the path the operation doesn't override.
+func mergeParameters(path, op []*Parameter) []*Parameter {
+	merged := append([]*Parameter(nil), op...)
+	for _, p := range path {
+		overridden := false
+		for _, o := range op {
+			if o.Name == p.Name && o.In == p.In {
+				overridden = true
+			}
+		}
+		if !overridden {
+			merged = append(merged, p)
+		}
+	}
+	return merged
+}
+
+func isParam(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
+
+// Operations returns every operation of the document.
+func (s *Spec) Operations() []*Operation {
+	var ops []*Operation
+	for _, item := range s.Paths {
+		for _, op := range item.Operations {
+			ops = append(ops, op)
+		}
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		if ops[i].Path != ops[j].Path {
+			return ops[i].Path < ops[j].Path
+		}
+		return ops[i].Method < ops[j].Method
+	})
+	return ops
+}
+
+// FindOperation returns the operation serving the request path along with
+// the values of its path parameters.
+func (s *Spec) FindOperation(method, path string) (*Operation, map[string]string, error) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	pathFound := false
+	for _, t := range s.templates {
+		params, ok := t.match(segments)
+		if !ok {
+			continue
+		}
+		pathFound = true
+		if op, ok := s.Paths[t.path].Operations[strings.ToLower(method)]; ok {
+			return op, params, nil
+		}
+	}
+	if pathFound {
+		return nil, nil, fmt.Errorf("%w: method %s", ErrNoOperation, method)
+	}
+	return nil, nil, fmt.Errorf("%w: path %s", ErrNoOperation, path)
+}
+
+func (t template) match(segments []string) (map[string]string, bool) {
+	if len(segments) != len(t.segments) {
+		return nil, false
+	}
+	params := make(map[string]string)
+	for i, seg := range t.segments {
+		switch {
+		case isParam(seg):
+			if segments[i] == "" {
+				return nil, false
+			}
+			params[seg[1:len(seg)-1]] = segments[i]
+		case seg != segments[i]:
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// secured tells whether the operation requires the bearer token.
+func (s *Spec) secured(op *Operation) bool {
+	if op.Security != nil {
+		return len(*op.Security) > 0
+	}
+	return len(s.Security) > 0
+}
+
+// schema resolves a reference to the schemas of the components.
+func (s *Spec) schema(schema *Schema) (*Schema, error) {
+	for schema != nil && schema.Ref != "" {
+		name := strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+		resolved, ok := s.Components.Schemas[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown schema %s", schema.Ref)
+		}
+		schema = resolved
+	}
+	return schema, nil
+}
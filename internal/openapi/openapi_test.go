@@ -0,0 +1,123 @@
+package openapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	s, err := Load()
+	require.NoError(t, err)
+
+	ids := make(map[string]bool)
+	for _, op := range s.Operations() {
+		assert.NotEmpty(t, op.ID, "%s %s", op.Method, op.Path)
+		assert.False(t, ids[op.ID], "duplicate operationId %s", op.ID)
+		ids[op.ID] = true
+
+		for status, res := range op.Responses {
+			assert.Empty(t, res.Ref, "%s %s %s", op.Method, op.Path, status)
+		}
+		for _, p := range op.Parameters {
+			if p.In == "path" {
+				assert.True(t, p.Required, "%s %s: path parameter %s", op.Method, op.Path, p.Name)
+			}
+		}
+	}
+}
+
+func TestLoad_refs(t *testing.T) {
+	s, err := Load()
+	require.NoError(t, err)
+
+	var walk func(schema *Schema, where string)
+	walk = func(schema *Schema, where string) {
+		if schema == nil {
+			return
+		}
+		if schema.Ref != "" {
+			_, err := s.schema(schema)
+			assert.NoError(t, err, where)
+			return
+		}
+		for name, prop := range schema.Properties {
+			walk(prop, where+"."+name)
+		}
+		walk(schema.Items, where+"[]")
+	}
+
+	for name, schema := range s.Components.Schemas {
+		walk(schema, name)
+	}
+	for _, op := range s.Operations() {
+		where := op.Method + " " + op.Path
+		for _, p := range op.Parameters {
+			walk(p.Schema, where+" "+p.Name)
+		}
+		if op.RequestBody != nil {
+			for mediaType, content := range op.RequestBody.Content {
+				walk(content.Schema, where+" "+mediaType)
+			}
+		}
+		for status, res := range op.Responses {
+			for name, h := range res.Headers {
+				walk(h.Schema, where+" "+status+" "+name)
+			}
+			for mediaType, content := range res.Content {
+				walk(content.Schema, where+" "+status+" "+mediaType)
+			}
+		}
+	}
+}
+
+func TestSpec_FindOperation(t *testing.T) {
+	s, err := Load()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   string
+		params map[string]string
+		err    bool
+	}{
+		{name: "literal", method: http.MethodPost, path: "/api/secret/text", want: "createNote", params: map[string]string{}},
+		{name: "param", method: http.MethodGet, path: "/api/secret/text/1", want: "getNote", params: map[string]string{"id": "1"}},
+		{name: "literal over param", method: http.MethodPost, path: "/api/secret/file/link", want: "linkFile", params: map[string]string{}},
+		{name: "two params", method: http.MethodPut, path: "/api/search/card/2", want: "setSearchTokens", params: map[string]string{"kind": "card", "id": "2"}},
+		{name: "trailing slash", method: http.MethodGet, path: "/api/trash/", want: "getTrash", params: map[string]string{}},
+		{name: "unknown path", method: http.MethodGet, path: "/api/secret/unknown", err: true},
+		{name: "empty param", method: http.MethodGet, path: "/api/secret/text/", err: true},
+		{name: "unknown method", method: http.MethodPatch, path: "/api/secret/text/1", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op, params, err := s.FindOperation(tt.method, tt.path)
+			if tt.err {
+				assert.True(t, errors.Is(err, ErrNoOperation), err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, op.ID)
+			assert.Equal(t, tt.params, params)
+		})
+	}
+}
+
+func TestServeSpec(t *testing.T) {
+	rec := httptest.NewRecorder()
+	ServeSpec(rec, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	assert.Equal(t, "3.0.3", doc["openapi"])
+}
@@ -0,0 +1,193 @@
+package openapi
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"time"
+)
+
+// Schema is the subset of the JSON schema of OpenAPI 3.0 the validator
+// supports. The values are checked as decoded by encoding/json into any.
+type Schema struct {
+	Ref      string `json:"$ref"`
+	Type     string `json:"type"`
+	Format   string `json:"format"`
+	Enum     []any  `json:"enum"`
+	Nullable bool   `json:"nullable"`
+
+	Properties map[string]*Schema `json:"properties"`
+	Required   []string           `json:"required"`
+	// AdditionalProperties is only supported as a boolean, properties not
+	// listed in Properties are rejected when it is false.
+	AdditionalProperties *bool `json:"additionalProperties"`
+
+	Items    *Schema `json:"items"`
+	MinItems *int    `json:"minItems"`
+	MaxItems *int    `json:"maxItems"`
+
+	MinLength *int     `json:"minLength"`
+	MaxLength *int     `json:"maxLength"`
+	Pattern   string   `json:"pattern"`
+	Minimum   *float64 `json:"minimum"`
+	Maximum   *float64 `json:"maximum"`
+}
+
+// validate checks the value against the schema, where names the value in
+// the error, e.g. body.ops[0].kind.
+func (s *Spec) validate(schema *Schema, value any, where string) error {
+	schema, err := s.schema(schema)
+	if err != nil {
+		return err
+	}
+	// the empty schema allows any value
+	if schema == nil {
+		return nil
+	}
+
+	if value == nil {
+		if schema.Nullable || schema.Type == "" {
+			return nil
+		}
+		return fmt.Errorf("%s: null is not allowed", where)
+	}
+	if len(schema.Enum) > 0 && !inEnum(schema.Enum, value) {
+		return fmt.Errorf("%s: %v is not one of %v", where, value, schema.Enum)
+	}
+
+	switch schema.Type {
+	case "":
+		return nil
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return typeError(where, schema.Type, value)
+		}
+		return s.validateObject(schema, obj, where)
+	case "array":
+		arr, ok := value.([]any)
+		if !ok {
+			return typeError(where, schema.Type, value)
+		}
+		if schema.MinItems != nil && len(arr) < *schema.MinItems {
+			return fmt.Errorf("%s: fewer than %d items", where, *schema.MinItems)
+		}
+		if schema.MaxItems != nil && len(arr) > *schema.MaxItems {
+			return fmt.Errorf("%s: more than %d items", where, *schema.MaxItems)
+		}
+		for i, item := range arr {
+			err = s.validate(schema.Items, item, fmt.Sprintf("%s[%d]", where, i))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return typeError(where, schema.Type, value)
+		}
+		return validateString(schema, str, where)
+	case "integer", "number":
+		num, ok := value.(float64)
+		if !ok || schema.Type == "integer" && num != math.Trunc(num) {
+			return typeError(where, schema.Type, value)
+		}
+		if schema.Minimum != nil && num < *schema.Minimum {
+			return fmt.Errorf("%s: %v is less than %v", where, num, *schema.Minimum)
+		}
+		if schema.Maximum != nil && num > *schema.Maximum {
+			return fmt.Errorf("%s: %v is greater than %v", where, num, *schema.Maximum)
+		}
+		return nil
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return typeError(where, schema.Type, value)
+		}
+		return nil
+	}
+	return fmt.Errorf("%s: unsupported type %s", where, schema.Type)
+}
+
+func (s *Spec) validateObject(schema *Schema, obj map[string]any, where string) error {
+	for _, name := range schema.Required {
+		if _, ok := obj[name]; !ok {
+			return fmt.Errorf("%s: property %s is required", where, name)
+		}
+	}
+
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	// sorted, so the same value always fails with the same error
+	sort.Strings(names)
+
+	for _, name := range names {
+		prop, ok := schema.Properties[name]
+		if !ok {
+			if schema.AdditionalProperties != nil && !*schema.AdditionalProperties {
+				return fmt.Errorf("%s: property %s is not allowed", where, name)
+			}
+			continue
+		}
+		err := s.validate(prop, obj[name], where+"."+name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateString(schema *Schema, str, where string) error {
+	length := len([]rune(str))
+	if schema.MinLength != nil && length < *schema.MinLength {
+		return fmt.Errorf("%s: shorter than %d characters", where, *schema.MinLength)
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		return fmt.Errorf("%s: longer than %d characters", where, *schema.MaxLength)
+	}
+	if schema.Pattern != "" {
+		re, err := regexp.Compile(schema.Pattern)
+		if err != nil {
+			return fmt.Errorf("%s: invalid pattern %q: %w", where, schema.Pattern, err)
+		}
+		if !re.MatchString(str) {
+			return fmt.Errorf("%s: %q doesn't match %s", where, str, schema.Pattern)
+		}
+	}
+	if schema.Format == "date-time" {
+		if _, err := time.Parse(time.RFC3339Nano, str); err != nil {
+			return fmt.Errorf("%s: %q is not a date-time", where, str)
+		}
+	}
+	return nil
+}
+
+// inEnum compares the scalars only, objects and arrays can't be compared with ==.
+func inEnum(enum []any, value any) bool {
+	switch value.(type) {
+	case map[string]any, []any:
+		return false
+	}
+	return slices.Contains(enum, value)
+}
+
+func typeError(where, want string, value any) error {
+	got := fmt.Sprintf("%T", value)
+	switch value.(type) {
+	case map[string]any:
+		got = "an object"
+	case []any:
+		got = "an array"
+	case float64:
+		got = "a number"
+	case string:
+		got = "a string"
+	case bool:
+		got = "a boolean"
+	}
+	return fmt.Errorf("%s: %s expected, got %s", where, want, got)
+}
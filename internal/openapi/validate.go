@@ -0,0 +1,306 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrNoOperation is matched by the errors of the requests the document has
+// no operation for.
+var ErrNoOperation = errors.New("no such operation")
+
+// RequestError is a request breaking the contract of the document.
+type RequestError struct {
+	Method, Path string
+	Err          error
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("request %s %s: %s", e.Method, e.Path, e.Err)
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// ResponseError is a response breaking the contract of the document.
+type ResponseError struct {
+	Method, Path string
+	Status       int
+	Err          error
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("response %d to %s %s: %s", e.Status, e.Method, e.Path, e.Err)
+}
+
+func (e *ResponseError) Unwrap() error {
+	return e.Err
+}
+
+// ValidateRequest checks the parameters and the body of the request, a
+// *RequestError is returned when they don't match the operation. A JSON
+// body is read and replaced, so the request can still be served.
+func (s *Spec) ValidateRequest(r *http.Request) error {
+	err := s.validateRequest(r)
+	if err != nil {
+		return &RequestError{Method: r.Method, Path: r.URL.Path, Err: err}
+	}
+	return nil
+}
+
+func (s *Spec) validateRequest(r *http.Request) error {
+	op, pathParams, err := s.FindOperation(r.Method, r.URL.Path)
+	if err != nil {
+		return err
+	}
+
+	if s.secured(op) && r.Header.Get("Authorization") == "" {
+		return errors.New("the Authorization header is required")
+	}
+
+	query := r.URL.Query()
+	for _, p := range op.Parameters {
+		var (
+			value string
+			ok    bool
+		)
+		switch p.In {
+		case "path":
+			value, ok = pathParams[p.Name]
+		case "query":
+			ok = query.Has(p.Name)
+			value = query.Get(p.Name)
+		case "header":
+			value = r.Header.Get(p.Name)
+			ok = value != ""
+		default:
+			return fmt.Errorf("parameter %s: unsupported location %s", p.Name, p.In)
+		}
+		if !ok {
+			if p.Required {
+				return fmt.Errorf("%s parameter %s is required", p.In, p.Name)
+			}
+			continue
+		}
+		err = s.validateParam(p.Schema, value, p.In+"."+p.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	if op.RequestBody == nil {
+		return nil
+	}
+	if r.ContentLength == 0 || r.Body == nil || r.Body == http.NoBody {
+		if op.RequestBody.Required {
+			return errors.New("the body is required")
+		}
+		return nil
+	}
+
+	mediaType, content, err := findContent(op.RequestBody.Content, r.Header.Get("Content-Type"))
+	if err != nil {
+		return err
+	}
+	if mediaType != "application/json" {
+		return nil
+	}
+
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("unable to read the body: %w", err)
+	}
+	return s.validateJSON(content.Schema, body)
+}
+
+// validateParam converts the value of a parameter to the type of its schema
+// before checking it.
+func (s *Spec) validateParam(schema *Schema, value, where string) error {
+	resolved, err := s.schema(schema)
+	if err != nil || resolved == nil {
+		return err
+	}
+
+	var v any = value
+	switch resolved.Type {
+	case "integer", "number":
+		num, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%s: %s expected, got %q", where, resolved.Type, value)
+		}
+		v = num
+	case "boolean":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s: boolean expected, got %q", where, value)
+		}
+		v = b
+	}
+	return s.validate(resolved, v, where)
+}
+
+// ValidateResponse checks the status, the headers and the body of the
+// response to the request, a *ResponseError is returned when they don't
+// match the operation.
+func (s *Spec) ValidateResponse(r *http.Request, status int, header http.Header, body []byte) error {
+	err := s.validateResponse(r, status, header, body)
+	if err != nil {
+		return &ResponseError{Method: r.Method, Path: r.URL.Path, Status: status, Err: err}
+	}
+	return nil
+}
+
+func (s *Spec) validateResponse(r *http.Request, status int, header http.Header, body []byte) error {
+	op, _, err := s.FindOperation(r.Method, r.URL.Path)
+	if err != nil {
+		return err
+	}
+
+	res, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		res, ok = op.Responses[strconv.Itoa(status/100)+"XX"]
+	}
+	if !ok {
+		return errors.New("the status is not documented")
+	}
+
+	for name, h := range res.Headers {
+		value := header.Get(name)
+		if _, set := header[http.CanonicalHeaderKey(name)]; !set {
+			if h.Required {
+				return fmt.Errorf("the %s header is required", name)
+			}
+			continue
+		}
+		err = s.validateParam(h.Schema, value, "header."+name)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the responses to HEAD have no body
+	if r.Method == http.MethodHead {
+		return nil
+	}
+	if len(res.Content) == 0 {
+		if len(body) > 0 {
+			return errors.New("the body is not documented")
+		}
+		return nil
+	}
+	if len(body) == 0 {
+		return errors.New("the body is missing")
+	}
+
+	mediaType, content, err := findContent(res.Content, header.Get("Content-Type"))
+	if err != nil {
+		return err
+	}
+	if mediaType != "application/json" {
+		return nil
+	}
+	return s.validateJSON(content.Schema, body)
+}
+
+func (s *Spec) validateJSON(schema *Schema, body []byte) error {
+	var v any
+	err := json.Unmarshal(body, &v)
+	if err != nil {
+		return fmt.Errorf("the body is not JSON: %w", err)
+	}
+	return s.validate(schema, v, "body")
+}
+
+// findContent returns the documented media type of the Content-Type header.
+func findContent(content map[string]*MediaType, contentType string) (string, *MediaType, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", nil, fmt.Errorf("incorrect Content-Type %q", contentType)
+	}
+	mt, ok := content[mediaType]
+	if !ok {
+		return "", nil, fmt.Errorf("the Content-Type %s is not documented", mediaType)
+	}
+	return mediaType, mt, nil
+}
+
+// Middleware checks every request and its response, the errors are passed
+// to report and the request is served anyway. The JSON bodies of the
+// responses are kept in memory until they are checked, so it is meant for
+// tests and debugging.
+func (s *Spec) Middleware(report func(r *http.Request, err error)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			err := s.ValidateRequest(r)
+			if err != nil {
+				report(r, err)
+			}
+			if errors.Is(err, ErrNoOperation) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rec := &recorder{ResponseWriter: w}
+			next.ServeHTTP(rec, r)
+			if !rec.wroteHeader {
+				rec.WriteHeader(http.StatusOK)
+			}
+
+			err = s.ValidateResponse(r, rec.status, rec.header, rec.body.Bytes())
+			if err != nil {
+				report(r, err)
+			}
+		})
+	}
+}
+
+// recorder keeps the status and the headers of a response, and its body
+// unless it is a file.
+type recorder struct {
+	http.ResponseWriter
+	wroteHeader bool
+	status      int
+	header      http.Header
+	keepBody    bool
+	body        bytes.Buffer
+}
+
+func (rec *recorder) WriteHeader(status int) {
+	if rec.wroteHeader {
+		return
+	}
+	rec.wroteHeader = true
+	rec.status = status
+	rec.header = rec.Header().Clone()
+	rec.keepBody = !strings.HasPrefix(rec.header.Get("Content-Type"), "application/octet-stream")
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *recorder) Write(p []byte) (int, error) {
+	if !rec.wroteHeader {
+		rec.WriteHeader(http.StatusOK)
+	}
+	if rec.keepBody {
+		rec.body.Write(p)
+	} else if rec.body.Len() == 0 {
+		// a byte is enough to tell the body isn't empty
+		rec.body.WriteByte(0)
+	}
+	return rec.ResponseWriter.Write(p)
+}
+
+func (rec *recorder) Flush() {
+	if f, ok := rec.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
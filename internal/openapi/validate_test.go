@@ -0,0 +1,250 @@
+package openapi
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const token = "Bearer token"
+
+func newRequest(method, target, body string, header map[string]string) *http.Request {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range header {
+		r.Header.Set(name, value)
+	}
+	return r
+}
+
+func TestSpec_ValidateRequest(t *testing.T) {
+	s, err := Load()
+	require.NoError(t, err)
+
+	auth := map[string]string{"Authorization": token}
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		header map[string]string
+		err    string
+	}{
+		{name: "valid", method: http.MethodPost, target: "/api/secret/text", body: `{"key":"key","data":"text"}`, header: auth},
+		{name: "public", method: http.MethodPost, target: "/api/user/login", body: `{"login":"bob","password":"secret"}`},
+		{name: "query", method: http.MethodGet, target: "/api/secret/texts?limit=10&order=asc", header: auth},
+		{
+			name: "no token", method: http.MethodGet, target: "/api/secret/text/1",
+			err: "the Authorization header is required",
+		},
+		{
+			name: "missing property", method: http.MethodPost, target: "/api/secret/text", body: `{"data":"text"}`, header: auth,
+			err: "body: property key is required",
+		},
+		{
+			name: "unknown property", method: http.MethodPost, target: "/api/secret/text", body: `{"key":"key","text":"text"}`, header: auth,
+			err: "body: property text is not allowed",
+		},
+		{
+			name: "wrong type", method: http.MethodPost, target: "/api/secret/text", body: `{"key":1}`, header: auth,
+			err: "body.key: string expected, got a number",
+		},
+		{
+			name: "enum", method: http.MethodPost, target: "/api/secret/batch", body: `{"ops":[{"op":"move","kind":"text"}]}`, header: auth,
+			err: "body.ops[0].op: move is not one of",
+		},
+		{
+			name: "not an integer", method: http.MethodGet, target: "/api/secret/texts?limit=ten", header: auth,
+			err: "query.limit: integer expected",
+		},
+		{
+			name: "missing header", method: http.MethodPut, target: "/api/secret/upload/u1/1", body: "data",
+			header: map[string]string{"Authorization": token, "Content-Type": "application/octet-stream"},
+			err:    "header parameter Content-MD5 is required",
+		},
+		{
+			name: "missing body", method: http.MethodPost, target: "/api/secret/text", header: auth,
+			err: "the body is required",
+		},
+		{
+			name: "undocumented content type", method: http.MethodPost, target: "/api/secret/text", body: "key",
+			header: map[string]string{"Authorization": token, "Content-Type": "text/plain"},
+			err:    "the Content-Type text/plain is not documented",
+		},
+		{
+			name: "not JSON", method: http.MethodPost, target: "/api/secret/text", body: "{", header: auth,
+			err: "the body is not JSON",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRequest(tt.method, tt.target, tt.body, tt.header)
+			err := s.ValidateRequest(r)
+			if tt.err == "" {
+				assert.NoError(t, err)
+				// the body is still there for the handler
+				body, _ := io.ReadAll(r.Body)
+				assert.Equal(t, tt.body, string(body))
+				return
+			}
+
+			var reqErr *RequestError
+			require.True(t, errors.As(err, &reqErr), err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestSpec_ValidateResponse(t *testing.T) {
+	s, err := Load()
+	require.NoError(t, err)
+
+	jsonHeader := http.Header{"Content-Type": {"application/json"}}
+	tests := []struct {
+		name   string
+		method string
+		target string
+		status int
+		header http.Header
+		body   string
+		err    string
+	}{
+		{name: "no content", method: http.MethodPost, target: "/api/secret/text", status: http.StatusAccepted},
+		{
+			name: "json", method: http.MethodGet, target: "/api/secret/text/1", status: http.StatusOK,
+			header: jsonHeader, body: `{"key":"key","text":"text","metadata":""}`,
+		},
+		{
+			name: "text error", method: http.MethodGet, target: "/api/secret/text/1", status: http.StatusNotFound,
+			header: http.Header{"Content-Type": {"text/plain; charset=utf-8"}}, body: "not found\n",
+		},
+		{
+			name: "list", method: http.MethodGet, target: "/api/secret/texts", status: http.StatusOK,
+			header: http.Header{"Content-Type": {"application/json"}, "X-Total-Count": {"1"}},
+			body:   `[{"id":"1","key":"key","created_at":"2024-05-01T10:00:00Z"}]`,
+		},
+		{
+			name: "undocumented status", method: http.MethodPost, target: "/api/secret/text", status: http.StatusCreated,
+			err: "the status is not documented",
+		},
+		{
+			name: "missing header", method: http.MethodGet, target: "/api/secret/texts", status: http.StatusOK,
+			header: jsonHeader, body: `[]`,
+			err: "the X-Total-Count header is required",
+		},
+		{
+			name: "incorrect header", method: http.MethodGet, target: "/api/secret/texts", status: http.StatusOK,
+			header: http.Header{"Content-Type": {"application/json"}, "X-Total-Count": {"-1"}}, body: `[]`,
+			err: "header.X-Total-Count: -1 is less than 0",
+		},
+		{
+			name: "extra property", method: http.MethodGet, target: "/api/secret/text/1", status: http.StatusOK,
+			header: jsonHeader, body: `{"data":"text","key":"key","metadata":"","text":"text"}`,
+			err: "body: property data is not allowed",
+		},
+		{
+			name: "not a date-time", method: http.MethodGet, target: "/api/secret/texts", status: http.StatusOK,
+			header: http.Header{"Content-Type": {"application/json"}, "X-Total-Count": {"1"}},
+			body:   `[{"id":"1","key":"key","created_at":"yesterday"}]`,
+			err:    `body[0].created_at: "yesterday" is not a date-time`,
+		},
+		{
+			name: "null list", method: http.MethodGet, target: "/api/secret/texts", status: http.StatusOK,
+			header: http.Header{"Content-Type": {"application/json"}, "X-Total-Count": {"0"}}, body: `null`,
+			err: "body: null is not allowed",
+		},
+		{
+			name: "wrong content type", method: http.MethodGet, target: "/api/secret/text/1", status: http.StatusOK,
+			header: http.Header{"Content-Type": {"text/plain"}}, body: `{"key":"key"}`,
+			err: "the Content-Type text/plain is not documented",
+		},
+		{
+			name: "undocumented body", method: http.MethodDelete, target: "/api/secret/text/1", status: http.StatusNoContent,
+			header: jsonHeader, body: `{}`,
+			err: "the body is not documented",
+		},
+		{
+			name: "missing body", method: http.MethodGet, target: "/api/secret/text/1", status: http.StatusOK,
+			header: jsonHeader,
+			err:    "the body is missing",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRequest(tt.method, tt.target, "", nil)
+			header := tt.header
+			if header == nil {
+				header = http.Header{}
+			}
+			err := s.ValidateResponse(r, tt.status, header, []byte(tt.body))
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			var resErr *ResponseError
+			require.True(t, errors.As(err, &resErr), err)
+			assert.Equal(t, tt.status, resErr.Status)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestSpec_Middleware(t *testing.T) {
+	s, err := Load()
+	require.NoError(t, err)
+
+	var reported []error
+	handler := s.Middleware(func(r *http.Request, err error) {
+		reported = append(reported, err)
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/secret/text":
+			// the client expects 202
+			w.WriteHeader(http.StatusCreated)
+		case "/api/secret/text/1":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"key":"key","text":"text","metadata":""}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	serve := func(r *http.Request) *httptest.ResponseRecorder {
+		reported = nil
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		return rec
+	}
+
+	rec := serve(newRequest(http.MethodGet, "/api/secret/text/1", "", map[string]string{"Authorization": token}))
+	assert.Equal(t, `{"key":"key","text":"text","metadata":""}`, rec.Body.String())
+	assert.Empty(t, reported)
+
+	rec = serve(newRequest(http.MethodPost, "/api/secret/text", `{"key":"key"}`, map[string]string{"Authorization": token}))
+	// the response isn't changed
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	require.Len(t, reported, 1)
+	var resErr *ResponseError
+	assert.True(t, errors.As(reported[0], &resErr))
+
+	// the request is served even though it breaks the contract
+	rec = serve(newRequest(http.MethodGet, "/api/secret/text/1", "", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	require.Len(t, reported, 1)
+	var reqErr *RequestError
+	assert.True(t, errors.As(reported[0], &reqErr))
+
+	rec = serve(newRequest(http.MethodGet, "/unknown", "", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	require.Len(t, reported, 1)
+	assert.True(t, errors.Is(reported[0], ErrNoOperation))
+}
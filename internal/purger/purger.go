@@ -0,0 +1,113 @@
+package purger
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"keeper-project/internal/store"
+)
+
+// Purger periodically removes records that stayed in the trash longer than the retention window.
+type Purger struct {
+	logger    *zap.Logger
+	trashes   map[string]store.Trash
+	retention time.Duration
+	interval  time.Duration
+	uploads   UploadsPurger
+	uploadTTL time.Duration
+	search    SearchPurger
+}
+
+// UploadsPurger aborts resumable upload sessions created before the given moment.
+type UploadsPurger interface {
+	PurgeUploads(ctx context.Context, before time.Time) (int64, error)
+}
+
+// SearchPurger removes search tokens of records that no longer exist.
+type SearchPurger interface {
+	PurgeOrphans(ctx context.Context) (int64, error)
+}
+
+// Option configures optional purger behaviour.
+type Option func(*Purger)
+
+// WithUploads makes the purger abort upload sessions older than ttl.
+func WithUploads(uploads UploadsPurger, ttl time.Duration) Option {
+	return func(p *Purger) {
+		p.uploads = uploads
+		p.uploadTTL = ttl
+	}
+}
+
+// WithSearch makes the purger drop search tokens of purged records.
+func WithSearch(search SearchPurger) Option {
+	return func(p *Purger) {
+		p.search = search
+	}
+}
+
+func New(logger *zap.Logger, retention, interval time.Duration, trashes map[string]store.Trash, opts ...Option) *Purger {
+	p := &Purger{
+		logger:    logger,
+		trashes:   trashes,
+		retention: retention,
+		interval:  interval,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Run purges the trash once and then on every interval tick until ctx is cancelled.
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.Purge(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge removes everything deleted before now minus the retention window
+// and aborts abandoned uploads, then drops the search tokens left without records.
+func (p *Purger) Purge(ctx context.Context, now time.Time) {
+	before := now.Add(-p.retention)
+
+	for kind, trash := range p.trashes {
+		n, err := trash.PurgeDeleted(ctx, before)
+		if err != nil {
+			p.logger.Error("failed to purge trash", zap.String("kind", kind), zap.Error(err))
+			continue
+		}
+		if n > 0 {
+			p.logger.Info("trash purged", zap.String("kind", kind), zap.Int64("count", n))
+		}
+	}
+
+	if p.uploads != nil {
+		n, err := p.uploads.PurgeUploads(ctx, now.Add(-p.uploadTTL))
+		if err != nil {
+			p.logger.Error("failed to purge uploads", zap.Error(err))
+		} else if n > 0 {
+			p.logger.Info("abandoned uploads purged", zap.Int64("count", n))
+		}
+	}
+
+	if p.search != nil {
+		n, err := p.search.PurgeOrphans(ctx)
+		if err != nil {
+			p.logger.Error("failed to purge search tokens", zap.Error(err))
+		} else if n > 0 {
+			p.logger.Info("search tokens purged", zap.Int64("count", n))
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package purger
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"go.uber.org/zap"
+
+	"keeper-project/internal/mocks"
+	"keeper-project/internal/store"
+	"keeper-project/types"
+)
+
+func TestPurger_Purge(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	notes := mocks.NewMockNotesSecret(mockCtrl)
+	files := mocks.NewMockFileService(mockCtrl)
+
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	before := now.Add(-24 * time.Hour)
+
+	notes.EXPECT().PurgeDeleted(gomock.Any(), before).Return(int64(2), nil).Times(1)
+	files.EXPECT().PurgeDeleted(gomock.Any(), before).Return(int64(0), errors.New("test error")).Times(1)
+
+	p := New(zap.L(), 24*time.Hour, time.Hour, map[string]store.Trash{
+		types.KindNote: notes,
+		types.KindFile: files,
+	})
+
+	p.Purge(context.Background(), now)
+}
+
+func TestPurger_PurgeUploads(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	files := mocks.NewMockFileService(mockCtrl)
+
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	files.EXPECT().PurgeDeleted(gomock.Any(), now.Add(-24*time.Hour)).Return(int64(0), nil).Times(1)
+	files.EXPECT().PurgeUploads(gomock.Any(), now.Add(-time.Hour)).Return(int64(1), nil).Times(1)
+
+	p := New(zap.L(), 24*time.Hour, time.Hour, map[string]store.Trash{
+		types.KindFile: files,
+	}, WithUploads(files, time.Hour))
+
+	p.Purge(context.Background(), now)
+}
+
+func TestPurger_RunStopsOnCancel(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	notes := mocks.NewMockNotesSecret(mockCtrl)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	notes.EXPECT().PurgeDeleted(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(context.Context, time.Time) (int64, error) {
+			cancel()
+			return 0, nil
+		}).Times(1)
+
+	p := New(zap.L(), time.Hour, time.Hour, map[string]store.Trash{types.KindNote: notes})
+
+	done := make(chan struct{})
+	go func() {
+		p.Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("purger did not stop after cancel")
+	}
+}
+
+func TestPurger_PurgeSearch(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	notes := mocks.NewMockNotesSecret(mockCtrl)
+	search := mocks.NewMockSearch(mockCtrl)
+
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	notes.EXPECT().PurgeDeleted(gomock.Any(), now.Add(-24*time.Hour)).Return(int64(2), nil).Times(1)
+	search.EXPECT().PurgeOrphans(gomock.Any()).Return(int64(5), nil).Times(1)
+
+	p := New(zap.L(), 24*time.Hour, time.Hour, map[string]store.Trash{
+		types.KindNote: notes,
+	}, WithSearch(search))
+
+	p.Purge(context.Background(), now)
+}
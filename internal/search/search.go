@@ -0,0 +1,155 @@
+// Package search builds the blind index of the records on the client.
+//
+// The words of a record are lower cased and split on anything but letters and
+// digits. Every word and every trigram of the words of three and more letters
+// is turned into a token, the HMAC-SHA256 of the kind of the term and the term
+// with a key derived from the password, truncated to 16 bytes and base64url
+// encoded. The server stores the tokens next to the encrypted records and
+// matches them without learning the words, it only learns which records share
+// terms. Results are decrypted and ranked on the client with Score.
+package search
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/crypto/hkdf"
+
+	"keeper-project/types"
+)
+
+const tokenSize = 16
+
+// Index makes the tokens of one user.
+type Index struct {
+	key []byte
+}
+
+// NewIndex derives the index key from the password, the login salts it so
+// that users with the same password get different tokens.
+func NewIndex(login, password string) *Index {
+	key := make([]byte, sha256.Size)
+	_, err := io.ReadFull(hkdf.New(sha256.New, []byte(password), []byte(login), []byte("keeper search")), key)
+	if err != nil {
+		// hkdf only fails when more than 255 blocks are read
+		panic(err)
+	}
+	return &Index{key: key}
+}
+
+// Tokens returns the tokens of the texts of a record, at most
+// types.MaxSearchTokens of them taken in the order of the words.
+func (ix *Index) Tokens(texts ...string) []string {
+	seen := make(map[string]struct{})
+	tokens := make([]string, 0)
+
+	add := func(kind byte, term string) bool {
+		token := ix.token(kind, term)
+		if _, ok := seen[token]; ok {
+			return true
+		}
+		if len(tokens) == types.MaxSearchTokens {
+			return false
+		}
+		seen[token] = struct{}{}
+		tokens = append(tokens, token)
+		return true
+	}
+
+	for _, text := range texts {
+		for _, word := range Words(text) {
+			if !add('w', word) {
+				return tokens
+			}
+			for _, tri := range trigrams(word) {
+				if !add('t', tri) {
+					return tokens
+				}
+			}
+		}
+	}
+	return tokens
+}
+
+// QueryTokens returns the tokens a record has to hold to contain every word
+// of the query: the trigrams of the words, short words have to match whole.
+func (ix *Index) QueryTokens(query string) []string {
+	var tokens []string
+	for _, word := range Words(query) {
+		tris := trigrams(word)
+		if len(tris) == 0 {
+			tokens = append(tokens, ix.token('w', word))
+			continue
+		}
+		for _, tri := range tris {
+			tokens = append(tokens, ix.token('t', tri))
+		}
+	}
+	slices.Sort(tokens)
+	return slices.Compact(tokens)
+}
+
+func (ix *Index) token(kind byte, term string) string {
+	mac := hmac.New(sha256.New, ix.key)
+	mac.Write([]byte{kind, 0})
+	mac.Write([]byte(term))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:tokenSize])
+}
+
+// Words returns the normalized words of the text.
+func Words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func trigrams(word string) []string {
+	runes := []rune(word)
+	if len(runes) < 3 {
+		return nil
+	}
+
+	tris := make([]string, 0, len(runes)-2)
+	for i := 0; i+3 <= len(runes); i++ {
+		tris = append(tris, string(runes[i:i+3]))
+	}
+	return tris
+}
+
+// Score ranks the decrypted texts of a record against the query: every query
+// word scores 3 when a word matches it, 2 when a word starts with it and 1
+// when it is found inside a word. Zero means the record does not match.
+func Score(query string, texts ...string) int {
+	var words []string
+	for _, text := range texts {
+		words = append(words, Words(text)...)
+	}
+
+	var score int
+	for _, q := range Words(query) {
+		best := 0
+		for _, w := range words {
+			switch {
+			case w == q:
+				best = 3
+			case strings.HasPrefix(w, q):
+				best = max(best, 2)
+			case strings.Contains(w, q):
+				best = max(best, 1)
+			}
+			if best == 3 {
+				break
+			}
+		}
+		if best == 0 {
+			return 0
+		}
+		score += best
+	}
+	return score
+}
@@ -0,0 +1,86 @@
+package search
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"keeper-project/types"
+)
+
+func TestWords(t *testing.T) {
+	assert.Equal(t, []string{"github", "com", "мой", "логин", "42"}, Words("GitHub.com: Мой_логин (42)"))
+	assert.Empty(t, Words(" -- "))
+}
+
+func TestIndex_Tokens(t *testing.T) {
+	ix := NewIndex("user", "pass")
+
+	tokens := ix.Tokens("GitHub", "github work")
+	require.Equal(t, ix.Tokens("github work"), tokens)
+	// github and its 4 trigrams, work and its 2 trigrams
+	assert.Len(t, tokens, 8)
+	assert.NoError(t, types.ValidateSearchTokens(tokens))
+
+	assert.NotEqual(t, tokens, NewIndex("other", "pass").Tokens("github work"))
+	assert.NotEqual(t, tokens, NewIndex("user", "pass2").Tokens("github work"))
+	assert.Empty(t, ix.Tokens(""))
+}
+
+func TestIndex_TokensLimit(t *testing.T) {
+	ix := NewIndex("user", "pass")
+
+	text := make([]byte, 0, 3*types.MaxSearchTokens)
+	for i := 0; i < types.MaxSearchTokens; i++ {
+		text = append(text, byte('a'+i%26), byte('a'+i/26%26), ' ')
+	}
+	assert.Len(t, ix.Tokens(string(text)), 26*26)
+
+	long := make([]rune, 0, types.MaxSearchTokens*2)
+	for i := 0; i < cap(long); i++ {
+		long = append(long, rune(0x4e00+i))
+	}
+	assert.Len(t, ix.Tokens(string(long)), types.MaxSearchTokens)
+}
+
+func TestIndex_QueryTokens(t *testing.T) {
+	ix := NewIndex("user", "pass")
+	tokens := ix.Tokens("mail.example.org", "my login")
+
+	tests := []struct {
+		name  string
+		query string
+		found bool
+	}{
+		{name: "word", query: "example", found: true},
+		{name: "substring", query: "xamp", found: true},
+		{name: "case", query: "MAIL", found: true},
+		{name: "short word", query: "my", found: true},
+		{name: "two words", query: "exam log", found: true},
+		{name: "short substring", query: "ex", found: false},
+		{name: "missing word", query: "example github", found: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := ix.QueryTokens(tt.query)
+			require.NotEmpty(t, query)
+
+			found := true
+			for _, token := range query {
+				found = found && slices.Contains(tokens, token)
+			}
+			assert.Equal(t, tt.found, found)
+		})
+	}
+}
+
+func TestScore(t *testing.T) {
+	assert.Equal(t, 3, Score("github", "GitHub"))
+	assert.Equal(t, 2, Score("git", "GitHub"))
+	assert.Equal(t, 1, Score("hub", "GitHub"))
+	assert.Equal(t, 5, Score("github work", "github", "my workplace"))
+	assert.Equal(t, 0, Score("github work", "github"))
+	assert.Greater(t, Score("mail", "mail"), Score("mail", "gmail"))
+}
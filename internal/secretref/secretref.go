@@ -0,0 +1,173 @@
+// Package secretref finds references to the records of the vault in text.
+//
+// A reference is keeper://<kind>/<name>[/<field>], kind is note, card or cred
+// and name is the id of the record or its title, card number or site, escaped
+// as a URL path segment. Without a field a note gives its text, a card its
+// number and credentials their password.
+package secretref
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Scheme starts every reference.
+const Scheme = "keeper://"
+
+// kinds of records a reference points to
+const (
+	KindNote = "note"
+	KindCard = "card"
+	KindCred = "cred"
+)
+
+// Fields are the fields of the records of each kind, the first one is the
+// field of a reference without one.
+var Fields = map[string][]string{
+	KindNote: {"text", "title", "metadata"},
+	KindCard: {"number", "expiration", "cvv", "metadata"},
+	KindCred: {"password", "login", "site", "metadata"},
+}
+
+var refPattern = regexp.MustCompile(regexp.QuoteMeta(Scheme) + `[A-Za-z0-9._~%+=-]+(?:/[A-Za-z0-9._~%+=-]+)*`)
+
+var envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Ref is a parsed reference.
+type Ref struct {
+	Kind  string
+	Name  string
+	Field string
+}
+
+func (r Ref) String() string {
+	return Scheme + r.Kind + "/" + url.PathEscape(r.Name) + "/" + r.Field
+}
+
+// Parse parses a whole string as a reference.
+func Parse(s string) (Ref, error) {
+	rest, ok := strings.CutPrefix(s, Scheme)
+	if !ok {
+		return Ref{}, fmt.Errorf("%q is not a %s reference", s, Scheme)
+	}
+
+	parts := strings.Split(rest, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return Ref{}, fmt.Errorf("%q is not %s<kind>/<name>[/<field>]", s, Scheme)
+	}
+	for i, p := range parts {
+		unescaped, err := url.PathUnescape(p)
+		if err != nil || unescaped == "" {
+			return Ref{}, fmt.Errorf("%q has a bad path segment %q", s, p)
+		}
+		parts[i] = unescaped
+	}
+
+	fields, ok := Fields[parts[0]]
+	if !ok {
+		return Ref{}, fmt.Errorf("%q has unknown kind %q, use note, card or cred", s, parts[0])
+	}
+	ref := Ref{Kind: parts[0], Name: parts[1], Field: fields[0]}
+	if len(parts) == 3 {
+		if !slices.Contains(fields, parts[2]) {
+			return Ref{}, fmt.Errorf("%q has unknown field %q, use %s", s, parts[2], strings.Join(fields, ", "))
+		}
+		ref.Field = parts[2]
+	}
+	return ref, nil
+}
+
+// IsRef tells whether the string starts like a reference.
+func IsRef(s string) bool {
+	return strings.HasPrefix(s, Scheme)
+}
+
+// Expand replaces every reference in the text with what resolve returns.
+// The dots ending a reference are taken as punctuation of the text.
+func Expand(text string, resolve func(Ref) (string, error)) (string, error) {
+	var err error
+	out := refPattern.ReplaceAllStringFunc(text, func(match string) string {
+		if err != nil {
+			return match
+		}
+		s := strings.TrimRight(match, ".")
+		dots := match[len(s):]
+
+		var ref Ref
+		ref, err = Parse(s)
+		if err != nil {
+			return s
+		}
+		var value string
+		value, err = resolve(ref)
+		if err != nil {
+			err = fmt.Errorf("%s: %w", s, err)
+		}
+		return value + dots
+	})
+	if err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+// Var is a variable of an env file.
+type Var struct {
+	Name  string
+	Value string
+}
+
+// ParseEnv reads NAME=VALUE lines of a .env file. Empty lines and lines
+// starting with # are skipped and an export in front of a name is allowed.
+// Values in double quotes are unquoted with Go escapes, values in single
+// quotes are taken as is and a # after a space starts a comment in an
+// unquoted value.
+func ParseEnv(r io.Reader) ([]Var, error) {
+	var vars []Var
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		v, err := ParseVar(strings.TrimPrefix(line, "export "))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		vars = append(vars, v)
+	}
+	return vars, sc.Err()
+}
+
+// ParseVar parses a single NAME=VALUE assignment.
+func ParseVar(s string) (Var, error) {
+	name, value, ok := strings.Cut(s, "=")
+	name = strings.TrimSpace(name)
+	if !ok || !envName.MatchString(name) {
+		return Var{}, fmt.Errorf("%q is not NAME=VALUE", s)
+	}
+
+	value = strings.TrimSpace(value)
+	switch {
+	case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return Var{}, fmt.Errorf("bad quoted value of %s", name)
+		}
+		value = unquoted
+	case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+		value = value[1 : len(value)-1]
+	default:
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+	}
+	return Var{Name: name, Value: value}, nil
+}
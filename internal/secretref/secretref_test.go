@@ -0,0 +1,106 @@
+package secretref
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    Ref
+		wantErr bool
+	}{
+		{
+			name: "default field",
+			s:    "keeper://cred/40d3289b-cc0c-4e2d-81b1-51ec81aa2e83",
+			want: Ref{Kind: KindCred, Name: "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", Field: "password"},
+		},
+		{
+			name: "field",
+			s:    "keeper://card/42/cvv",
+			want: Ref{Kind: KindCard, Name: "42", Field: "cvv"},
+		},
+		{
+			name: "escaped name",
+			s:    "keeper://note/deploy%20key%2Fprod",
+			want: Ref{Kind: KindNote, Name: "deploy key/prod", Field: "text"},
+		},
+		{name: "scheme", s: "vault://note/key", wantErr: true},
+		{name: "no name", s: "keeper://note", wantErr: true},
+		{name: "empty name", s: "keeper://note//text", wantErr: true},
+		{name: "too long", s: "keeper://note/key/text/more", wantErr: true},
+		{name: "kind", s: "keeper://file/key", wantErr: true},
+		{name: "unknown field", s: "keeper://note/key/cvv", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.s)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			again, err := Parse(got.String())
+			require.NoError(t, err)
+			assert.Equal(t, got, again)
+		})
+	}
+}
+
+func TestExpand(t *testing.T) {
+	resolve := func(ref Ref) (string, error) {
+		if ref.Name == "missing" {
+			return "", errors.New("not found")
+		}
+		return ref.Kind + ":" + ref.Name + ":" + ref.Field, nil
+	}
+
+	out, err := Expand(`url: "postgres://app:keeper://cred/db@localhost/app"
+key: keeper://note/deploy%20key/text.`, resolve)
+	require.NoError(t, err)
+	assert.Equal(t, `url: "postgres://app:cred:db:password@localhost/app"
+key: note:deploy key:text.`, out)
+
+	_, err = Expand("token=keeper://note/missing", resolve)
+	assert.EqualError(t, err, "keeper://note/missing: not found")
+
+	_, err = Expand("token=keeper://file/x", resolve)
+	assert.Error(t, err)
+
+	out, err = Expand("no references", resolve)
+	require.NoError(t, err)
+	assert.Equal(t, "no references", out)
+}
+
+func TestParseEnv(t *testing.T) {
+	vars, err := ParseEnv(strings.NewReader(`
+# database
+export DB_PASSWORD=keeper://cred/db
+API_KEY = "line\nbreak"
+RAW='a "quoted" $value'
+PLAIN=value # comment
+EMPTY=
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []Var{
+		{Name: "DB_PASSWORD", Value: "keeper://cred/db"},
+		{Name: "API_KEY", Value: "line\nbreak"},
+		{Name: "RAW", Value: `a "quoted" $value`},
+		{Name: "PLAIN", Value: "value"},
+		{Name: "EMPTY", Value: ""},
+	}, vars)
+
+	_, err = ParseEnv(strings.NewReader("OK=1\n2BAD=x\n"))
+	assert.EqualError(t, err, `line 2: "2BAD=x" is not NAME=VALUE`)
+
+	_, err = ParseEnv(strings.NewReader(`BAD="\q"`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,385 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	uuid "github.com/satori/go.uuid"
+
+	"keeper-project/internal/auth"
+	"keeper-project/internal/store"
+	"keeper-project/types"
+)
+
+// batchOp is a validated operation of a batch, data is the record to store
+// and tokens are its search tokens.
+type batchOp struct {
+	index  int
+	op     string
+	kind   string
+	id     string
+	data   any
+	tokens []string
+}
+
+func (ro *router) batch(w http.ResponseWriter, r *http.Request) {
+	if ro.tx == nil {
+		http.Error(w, "batches are not supported", http.StatusNotImplemented)
+		return
+	}
+
+	var req types.BatchRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Unable to decode json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userID, err := auth.GetUserID(r)
+	if err != nil {
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	err = req.Validate()
+	if err != nil {
+		http.Error(w, "incorrect data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp := types.BatchResponse{Mode: req.Mode, Results: make([]types.BatchResult, len(req.Ops))}
+	ops := make([]batchOp, 0, len(req.Ops))
+	for i, op := range req.Ops {
+		bop, err := parseBatchOp(i, op)
+		if err != nil {
+			resp.Results[i] = types.BatchResult{ID: op.ID, Status: http.StatusBadRequest, Error: "incorrect data: " + err.Error()}
+			continue
+		}
+		if op.Op != types.OpCreate {
+			resp.Results[i].ID = bop.id
+		}
+		ops = append(ops, bop)
+	}
+
+	if req.Mode == types.BatchPerItem {
+		ro.batchPerItem(w, r, userID, ops, resp)
+		return
+	}
+	ro.batchAtomic(w, r, userID, ops, resp)
+}
+
+// batchAtomic applies all the operations in one transaction, the first failed
+// operation rolls it back and sets the status of the response.
+func (ro *router) batchAtomic(w http.ResponseWriter, r *http.Request, userID string, ops []batchOp, resp types.BatchResponse) {
+	failed := len(ops) != len(resp.Results)
+
+	if !failed {
+		failedAt := -1
+		err := ro.tx.InTx(r.Context(), func(ctx context.Context) error {
+			usage, err := ro.lockUsage(ctx, userID)
+			if err != nil {
+				return err
+			}
+			for _, op := range ops {
+				if op.op != types.OpCreate {
+					continue
+				}
+				err = reserveRecord(usage, op.kind)
+				if err != nil {
+					failedAt = op.index
+					return err
+				}
+			}
+
+			failedAt, err = ro.applyBatch(ctx, userID, ops)
+			return err
+		})
+		if err != nil && failedAt < 0 {
+			http.Error(w, "failed to apply batch: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err != nil {
+			resp.Results[failedAt].Status, resp.Results[failedAt].Error = batchStatus(err)
+			failed = true
+		}
+	}
+
+	if !failed {
+		for _, op := range ops {
+			resp.Results[op.index] = types.BatchResult{ID: op.id, Status: batchSuccess(op.op)}
+		}
+		writeBatch(w, http.StatusOK, resp)
+		return
+	}
+
+	code := http.StatusInternalServerError
+	for i, res := range resp.Results {
+		if res.Status == 0 {
+			resp.Results[i].Status = http.StatusFailedDependency
+			resp.Results[i].Error = "batch rolled back"
+		} else {
+			code = res.Status
+		}
+	}
+	writeBatch(w, code, resp)
+}
+
+// batchPerItem applies every operation in a savepoint of one transaction, so
+// that a failed operation does not undo the others. The response is 207 when
+// some of them failed.
+func (ro *router) batchPerItem(w http.ResponseWriter, r *http.Request, userID string, ops []batchOp, resp types.BatchResponse) {
+	err := ro.tx.InTx(r.Context(), func(ctx context.Context) error {
+		usage, err := ro.lockUsage(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		for _, op := range ops {
+			res := &resp.Results[op.index]
+
+			if op.op == types.OpCreate {
+				err := reserveRecord(usage, op.kind)
+				if err != nil {
+					res.Status, res.Error = batchStatus(err)
+					continue
+				}
+			}
+
+			err := ro.tx.Savepoint(ctx, func(ctx context.Context) error {
+				_, err := ro.applyBatch(ctx, userID, []batchOp{op})
+				return err
+			})
+			if err != nil {
+				if op.op == types.OpCreate {
+					releaseRecord(usage, op.kind)
+				}
+				res.Status, res.Error = batchStatus(err)
+				continue
+			}
+			res.ID, res.Status = op.id, batchSuccess(op.op)
+		}
+		return nil
+	})
+	if err != nil {
+		http.Error(w, "failed to apply batch: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	code := http.StatusOK
+	for _, res := range resp.Results {
+		if res.Status >= http.StatusBadRequest {
+			code = http.StatusMultiStatus
+		}
+	}
+	writeBatch(w, code, resp)
+}
+
+// applyBatch passes the runs of operations of the same kind and type to the
+// slice methods of the stores. It returns the index of the failed operation
+// with the error, the index is -1 if it is not known.
+func (ro *router) applyBatch(ctx context.Context, userID string, ops []batchOp) (int, error) {
+	for start := 0; start < len(ops); {
+		end := start + 1
+		for end < len(ops) && ops[end].kind == ops[start].kind && ops[end].op == ops[start].op {
+			end++
+		}
+		group := ops[start:end]
+
+		var err error
+		switch group[0].kind {
+		case types.KindNote:
+			err = applyOps(ctx, ro.notesRepo, userID, group)
+		case types.KindCard:
+			err = applyOps(ctx, ro.cardsRepo, userID, group)
+		case types.KindCredentials:
+			err = applyOps(ctx, ro.credsRepo, userID, group)
+		}
+		if err != nil {
+			var itemErr *types.ItemError
+			if errors.As(err, &itemErr) && itemErr.Index < len(group) {
+				return group[itemErr.Index].index, itemErr.Err
+			}
+			return group[0].index, err
+		}
+
+		if ro.searchRepo != nil && group[0].op != types.OpDelete {
+			for _, op := range group {
+				if op.tokens == nil {
+					continue
+				}
+				err = ro.searchRepo.SetTokens(ctx, userID, op.kind, op.id, op.tokens)
+				if err != nil {
+					return op.index, err
+				}
+			}
+		}
+
+		start = end
+	}
+	return -1, nil
+}
+
+func applyOps[T any](ctx context.Context, repo store.Secrets[T], userID string, ops []batchOp) error {
+	if ops[0].op == types.OpDelete {
+		ids := make([]string, 0, len(ops))
+		for _, op := range ops {
+			ids = append(ids, op.id)
+		}
+		return repo.DeleteMany(ctx, userID, ids)
+	}
+
+	records := make([]types.Record[T], 0, len(ops))
+	for _, op := range ops {
+		records = append(records, types.Record[T]{ID: op.id, Data: op.data.(*T)})
+	}
+	if ops[0].op == types.OpCreate {
+		return repo.CreateMany(ctx, userID, records)
+	}
+	return repo.UpdateMany(ctx, userID, records)
+}
+
+// parseBatchOp validates the operation as the single record endpoints do and
+// assigns the ID of a new record.
+func parseBatchOp(index int, op types.BatchOp) (batchOp, error) {
+	bop := batchOp{index: index, op: op.Op, kind: op.Kind}
+
+	switch op.Op {
+	case types.OpCreate:
+		bop.id = uuid.NewV4().String()
+	case types.OpUpdate, types.OpDelete:
+		id, err := uuid.FromString(op.ID)
+		if err != nil {
+			return bop, errors.New("incorrect id")
+		}
+		bop.id = id.String()
+	default:
+		return bop, fmt.Errorf("unknown op %q", op.Op)
+	}
+
+	if op.Op == types.OpDelete {
+		switch op.Kind {
+		case types.KindNote, types.KindCard, types.KindCredentials:
+			return bop, nil
+		}
+		return bop, fmt.Errorf("unknown kind %q", op.Kind)
+	}
+
+	if len(op.Data) == 0 {
+		return bop, errors.New("no data")
+	}
+
+	var err error
+	switch op.Kind {
+	case types.KindNote:
+		var req types.CreateNoteRequest
+		if err = json.Unmarshal(op.Data, &req); err == nil {
+			bop.data, err = req.Validate()
+			bop.tokens = req.SearchTokens
+		}
+	case types.KindCard:
+		var req types.CreateCardRequest
+		if err = json.Unmarshal(op.Data, &req); err == nil {
+			bop.data, err = req.Validate()
+			bop.tokens = req.SearchTokens
+		}
+	case types.KindCredentials:
+		var req types.CreateCredentialsRequest
+		if err = json.Unmarshal(op.Data, &req); err == nil {
+			bop.data, err = req.Validate()
+			bop.tokens = req.SearchTokens
+		}
+	default:
+		err = fmt.Errorf("unknown kind %q", op.Kind)
+	}
+	return bop, err
+}
+
+// lockUsage locks the quota of the user for the transaction of ctx, the usage
+// is nil when quotas are off.
+func (ro *router) lockUsage(ctx context.Context, userID string) (*types.Usage, error) {
+	if ro.quotas == nil {
+		return nil, nil
+	}
+	usage, err := ro.quotas.LockUsage(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check quota: %w", err)
+	}
+	return usage, nil
+}
+
+// reserveRecord counts one more record of the kind or returns the quota
+// error, usage is nil when quotas are off.
+func reserveRecord(usage *types.Usage, kind string) error {
+	if usage == nil {
+		return nil
+	}
+
+	err := usage.Check(kind, 0)
+	if err != nil {
+		return err
+	}
+	if count := recordCount(usage, kind); count != nil {
+		*count++
+	}
+	return nil
+}
+
+// releaseRecord gives back a record reserved for a create that was not applied.
+func releaseRecord(usage *types.Usage, kind string) {
+	if usage == nil {
+		return
+	}
+	if count := recordCount(usage, kind); count != nil {
+		*count--
+	}
+}
+
+// recordCount returns the usage counter of the kind.
+func recordCount(usage *types.Usage, kind string) *int64 {
+	switch kind {
+	case types.KindNote:
+		return &usage.Notes
+	case types.KindCard:
+		return &usage.Cards
+	case types.KindCredentials:
+		return &usage.Credentials
+	}
+	return nil
+}
+
+// batchSuccess returns the status of the single record endpoint for the operation.
+func batchSuccess(op string) int {
+	switch op {
+	case types.OpCreate:
+		return http.StatusAccepted
+	case types.OpDelete:
+		return http.StatusNoContent
+	}
+	return http.StatusOK
+}
+
+func batchStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound, "no such record"
+	case errors.Is(err, types.ErrRecordAlreadyExists):
+		return http.StatusConflict, err.Error()
+	case errors.Is(err, types.ErrQuotaExceeded):
+		return http.StatusForbidden, err.Error()
+	}
+	return http.StatusInternalServerError, "failed to apply: " + err.Error()
+}
+
+func writeBatch(w http.ResponseWriter, code int, resp types.BatchResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	err := json.NewEncoder(w).Encode(resp)
+	if err != nil {
+		http.Error(w, "Can't marshal data: "+err.Error(), http.StatusInternalServerError)
+	}
+}
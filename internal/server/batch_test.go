@@ -0,0 +1,208 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"keeper-project/internal/mocks"
+	"keeper-project/types"
+)
+
+// fakeTx runs the functions without a database, rolled back savepoints are counted.
+type fakeTx struct {
+	committed  bool
+	rolledBack int
+}
+
+func (tx *fakeTx) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	err := fn(ctx)
+	tx.committed = err == nil
+	return err
+}
+
+func (tx *fakeTx) Savepoint(ctx context.Context, fn func(ctx context.Context) error) error {
+	err := fn(ctx)
+	if err != nil {
+		tx.rolledBack++
+	}
+	return err
+}
+
+const (
+	batchNoteID = "11111111-1111-1111-1111-111111111111"
+	batchCardID = "22222222-2222-2222-2222-222222222222"
+)
+
+func batchStatuses(t *testing.T, body string) []int {
+	t.Helper()
+
+	var resp types.BatchResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &resp))
+
+	var statuses []int
+	for _, res := range resp.Results {
+		statuses = append(statuses, res.Status)
+	}
+	return statuses
+}
+
+func Test_router_batchAtomic(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	notes := mocks.NewMockNotesSecret(mockCtrl)
+	cards := mocks.NewMockCardSecret(mockCtrl)
+	tx := &fakeTx{}
+
+	ts := httptest.NewServer(SetupRouter(logger, nil, notes, nil, cards, nil, WithTx(tx)))
+	defer ts.Close()
+
+	body := `{"ops":[
+		{"op":"create","kind":"text","data":{"key":"a","data":"1"}},
+		{"op":"create","kind":"text","data":{"key":"b","data":"2"}},
+		{"op":"update","kind":"card","id":"` + batchCardID + `","data":{"number":"4111","expiration":"12/30","cvv":"123"}},
+		{"op":"delete","kind":"text","id":"` + batchNoteID + `"}
+	]}`
+
+	t.Run("positive test #1", func(t *testing.T) {
+		notes.EXPECT().CreateMany(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", gomock.Len(2)).Return(nil)
+		cards.EXPECT().UpdateMany(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", []types.Record[types.CardInfo]{
+			{ID: batchCardID, Data: &types.CardInfo{Number: "4111", Expiration: "12/30", CVV: "123"}},
+		}).Return(nil)
+		notes.EXPECT().DeleteMany(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", []string{batchNoteID}).Return(nil)
+
+		res, resBody := testAuthorizedRequest(t, ts, http.MethodPost, "/api/secret/batch", validToken, []byte(body))
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, []int{202, 202, 200, 204}, batchStatuses(t, resBody))
+		assert.True(t, tx.committed)
+	})
+
+	t.Run("failed test #1 missing record rolls back", func(t *testing.T) {
+		notes.EXPECT().CreateMany(gomock.Any(), gomock.Any(), gomock.Len(2)).Return(nil)
+		cards.EXPECT().UpdateMany(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(&types.ItemError{Index: 0, Err: sql.ErrNoRows})
+
+		res, resBody := testAuthorizedRequest(t, ts, http.MethodPost, "/api/secret/batch", validToken, []byte(body))
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+		assert.Equal(t, []int{424, 424, 404, 424}, batchStatuses(t, resBody))
+		assert.False(t, tx.committed)
+	})
+
+	t.Run("failed test #2 invalid operation", func(t *testing.T) {
+		invalid := `{"ops":[{"op":"create","kind":"text","data":{"key":"a"}},{"op":"update","kind":"text","id":"1","data":{"key":"b"}}]}`
+
+		res, resBody := testAuthorizedRequest(t, ts, http.MethodPost, "/api/secret/batch", validToken, []byte(invalid))
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		assert.Equal(t, []int{424, 400}, batchStatuses(t, resBody))
+	})
+
+	t.Run("failed test #3 empty batch", func(t *testing.T) {
+		res, resBody := testAuthorizedRequest(t, ts, http.MethodPost, "/api/secret/batch", validToken, []byte(`{"ops":[]}`))
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		assert.Equal(t, "incorrect data: no operations\n", resBody)
+	})
+
+	t.Run("failed test #4 same record twice", func(t *testing.T) {
+		// ids are compared the way they are parsed, regardless of the case
+		twice := `{"ops":[{"op":"update","kind":"text","id":"aaaaaaaa-1111-1111-1111-111111111111","data":{"key":"a","data":"a"}},` +
+			`{"op":"delete","kind":"text","id":"AAAAAAAA-1111-1111-1111-111111111111"}]}`
+
+		res, resBody := testAuthorizedRequest(t, ts, http.MethodPost, "/api/secret/batch", validToken, []byte(twice))
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		assert.Equal(t, "incorrect data: operations 0 and 1 change the same record AAAAAAAA-1111-1111-1111-111111111111\n", resBody)
+	})
+
+	t.Run("failed test #5 invalid token", func(t *testing.T) {
+		res, resBody := testAuthorizedRequest(t, ts, http.MethodPost, "/api/secret/batch", invalidToken, []byte(body))
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		assert.Equal(t, "Unauthorized: invalid token\n", resBody)
+	})
+}
+
+func Test_router_batchPerItem(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	notes := mocks.NewMockNotesSecret(mockCtrl)
+	quotas := mocks.NewMockQuotas(mockCtrl)
+	tx := &fakeTx{}
+
+	ts := httptest.NewServer(SetupRouter(logger, nil, notes, nil, nil, nil, WithTx(tx), WithQuotas(quotas)))
+	defer ts.Close()
+
+	body := `{"mode":"per_item","ops":[
+		{"op":"create","kind":"text","data":{"key":"a"}},
+		{"op":"create","kind":"text","data":{"key":"b"}},
+		{"op":"delete","kind":"text","id":"` + batchNoteID + `"},
+		{"op":"update","kind":"text","id":"33333333-3333-3333-3333-333333333333","data":{"key":"c"}}
+	]}`
+
+	quotas.EXPECT().LockUsage(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83").
+		Return(&types.Usage{Notes: 1, Quota: types.Quota{Notes: 2}}, nil)
+	notes.EXPECT().CreateMany(gomock.Any(), gomock.Any(), gomock.Len(1)).Return(nil)
+	notes.EXPECT().DeleteMany(gomock.Any(), gomock.Any(), []string{batchNoteID}).Return(nil)
+	notes.EXPECT().UpdateMany(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("sql error"))
+
+	res, resBody := testAuthorizedRequest(t, ts, http.MethodPost, "/api/secret/batch", validToken, []byte(body))
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusMultiStatus, res.StatusCode)
+	assert.Equal(t, []int{202, 403, 204, 500}, batchStatuses(t, resBody))
+	assert.True(t, tx.committed)
+	assert.Equal(t, 1, tx.rolledBack)
+}
+
+func Test_router_batchPerItemFailedCreate(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	notes := mocks.NewMockNotesSecret(mockCtrl)
+	quotas := mocks.NewMockQuotas(mockCtrl)
+	tx := &fakeTx{}
+
+	ts := httptest.NewServer(SetupRouter(logger, nil, notes, nil, nil, nil, WithTx(tx), WithQuotas(quotas)))
+	defer ts.Close()
+
+	body := `{"mode":"per_item","ops":[
+		{"op":"create","kind":"text","data":{"key":"a"}},
+		{"op":"create","kind":"text","data":{"key":"b"}}
+	]}`
+
+	quotas.EXPECT().LockUsage(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83").
+		Return(&types.Usage{Notes: 1, Quota: types.Quota{Notes: 2}}, nil)
+	gomock.InOrder(
+		notes.EXPECT().CreateMany(gomock.Any(), gomock.Any(), gomock.Len(1)).Return(errors.New("sql error")),
+		notes.EXPECT().CreateMany(gomock.Any(), gomock.Any(), gomock.Len(1)).Return(nil),
+	)
+
+	res, resBody := testAuthorizedRequest(t, ts, http.MethodPost, "/api/secret/batch", validToken, []byte(body))
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusMultiStatus, res.StatusCode)
+	assert.Equal(t, []int{500, 202}, batchStatuses(t, resBody))
+	assert.True(t, tx.committed)
+	assert.Equal(t, 1, tx.rolledBack)
+}
+
+func Test_router_batchNotSupported(t *testing.T) {
+	ts := httptest.NewServer(SetupRouter(logger, nil, nil, nil, nil, nil))
+	defer ts.Close()
+
+	res, resBody := testAuthorizedRequest(t, ts, http.MethodPost, "/api/secret/batch", validToken, []byte(`{}`))
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusNotImplemented, res.StatusCode)
+	assert.Equal(t, "batches are not supported\n", resBody)
+}
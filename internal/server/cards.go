@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
@@ -36,8 +37,15 @@ func (ro *router) createCard(w http.ResponseWriter, r *http.Request) {
 
 	id := uuid.NewV4().String()
 
-	err = ro.cardsRepo.Create(r.Context(), userID, id, cardInfo)
+	err = ro.createInQuota(r.Context(), userID, types.KindCard, func(ctx context.Context) error {
+		return ro.saveIndexed(ctx, userID, types.KindCard, id, req.SearchTokens, func(ctx context.Context) error {
+			return ro.cardsRepo.Create(ctx, userID, id, cardInfo)
+		})
+	})
 	if err != nil {
+		if quotaExceeded(w, err) {
+			return
+		}
 		http.Error(w, "failed to create: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -81,23 +89,23 @@ func (ro *router) getCardsList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cardsIDs, err := ro.cardsRepo.GetKeysList(r.Context(), userID)
+	opts, err := listOptions(r, secretSorts...)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			http.Error(w, "no cards info", http.StatusNotFound)
-			return
-		}
-		http.Error(w, "failed to get from db: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "incorrect list parameters: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	err = json.NewEncoder(w).Encode(cardsIDs)
+	page, err := ro.cardsRepo.GetKeysList(r.Context(), userID, opts)
 	if err != nil {
-		http.Error(w, "Can't marshal data: "+err.Error(), http.StatusInternalServerError)
+		if errors.Is(err, types.ErrInvalidCursor) {
+			http.Error(w, "incorrect list parameters: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "failed to get from db: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+
+	writePage(w, page)
 }
 
 func (ro *router) updateCard(w http.ResponseWriter, r *http.Request) {
@@ -126,7 +134,9 @@ func (ro *router) updateCard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = ro.cardsRepo.Update(r.Context(), userID, cardInfo.ID, cardInfo)
+	err = ro.saveIndexed(r.Context(), userID, types.KindCard, cardInfo.ID, req.SearchTokens, func(ctx context.Context) error {
+		return ro.cardsRepo.Update(ctx, userID, cardInfo.ID, cardInfo)
+	})
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			http.Error(w, "nothing to update", http.StatusNotFound)
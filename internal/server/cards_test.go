@@ -264,9 +264,9 @@ func Test_router_card_list(t *testing.T) {
 		Key: "test_key"},
 	}
 
-	mocksSecret.EXPECT().GetKeysList(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83").Return(cardsList, nil).Times(1)
-	mocksSecret.EXPECT().GetKeysList(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83").Return(nil, sql.ErrNoRows).Times(1)
-	mocksSecret.EXPECT().GetKeysList(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83").Return(nil, sql.ErrConnDone).Times(1)
+	mocksSecret.EXPECT().GetKeysList(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", defaultList).Return(&types.Page[types.Key]{Items: cardsList, Total: 1}, nil).Times(1)
+	mocksSecret.EXPECT().GetKeysList(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", defaultList).Return(&types.Page[types.Key]{Items: []types.Key{}}, nil).Times(1)
+	mocksSecret.EXPECT().GetKeysList(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", defaultList).Return(nil, sql.ErrConnDone).Times(1)
 
 	ts := httptest.NewServer(SetupRouter(logger, nil, nil, nil, mocksSecret, nil))
 	defer ts.Close()
@@ -303,15 +303,15 @@ func Test_router_card_list(t *testing.T) {
 			},
 		},
 		{
-			name:   "failed test #2 not found",
+			name:   "positive test #2 empty list",
 			method: http.MethodGet,
 			target: "/api/secret/cards",
 			token:  validToken,
 			want: want{
-				code:          404,
+				code:          200,
 				emptyResponse: false,
-				response:      "no cards info\n",
-				contentType:   "text/plain; charset=utf-8",
+				response:      "[]\n",
+				contentType:   "application/json",
 			},
 		},
 		{
@@ -0,0 +1,636 @@
+package server
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"keeper-project/internal/mocks"
+	"keeper-project/internal/openapi"
+	"keeper-project/types"
+)
+
+type contractMocks struct {
+	users  *mocks.MockUser
+	notes  *mocks.MockNotesSecret[types.Note]
+	cards  *mocks.MockCardSecret[types.CardInfo]
+	creds  *mocks.MockCredsSecret[types.Credentials]
+	files  *mocks.MockFileService
+	quotas *mocks.MockQuotas
+	search *mocks.MockSearch
+}
+
+type contractCase struct {
+	name   string
+	method string
+	target string
+	token  string
+	header map[string]string
+	body   string
+	setup  func(m contractMocks)
+	code   int
+	// invalid requests break the contract on purpose, the response to them
+	// must be documented anyway
+	invalid bool
+}
+
+// Test_router_contract runs the router behind the validation middleware, so
+// every request and response is checked against the OpenAPI document.
+func Test_router_contract(t *testing.T) {
+	spec, err := openapi.Load()
+	require.NoError(t, err)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	m := contractMocks{
+		users:  mocks.NewMockUser(mockCtrl),
+		notes:  mocks.NewMockNotesSecret(mockCtrl),
+		cards:  mocks.NewMockCardSecret(mockCtrl),
+		creds:  mocks.NewMockCredsSecret(mockCtrl),
+		files:  mocks.NewMockFileService(mockCtrl),
+		quotas: mocks.NewMockQuotas(mockCtrl),
+		search: mocks.NewMockSearch(mockCtrl),
+	}
+
+	var reqErrs, resErrs []error
+	report := func(r *http.Request, err error) {
+		var reqErr *openapi.RequestError
+		if errors.As(err, &reqErr) {
+			reqErrs = append(reqErrs, err)
+		} else {
+			resErrs = append(resErrs, err)
+		}
+	}
+	handler := spec.Middleware(report)(SetupRouter(logger, m.users, m.notes, m.creds, m.cards, m.files,
+		WithQuotas(m.quotas), WithTx(&fakeTx{}), WithSearch(m.search)))
+
+	covered := make(map[*openapi.Operation]bool)
+	for _, tt := range contractCases() {
+		t.Run(tt.name, func(t *testing.T) {
+			reqErrs, resErrs = nil, nil
+			if tt.setup != nil {
+				tt.setup(m)
+			}
+
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			if tt.token != "" {
+				req.Header.Set("Authorization", tt.token)
+			}
+			for name, value := range tt.header {
+				req.Header.Set(name, value)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.code, rec.Code, rec.Body.String())
+			assert.Empty(t, resErrs)
+			if tt.invalid {
+				assert.NotEmpty(t, reqErrs, "the request is expected to break the contract")
+			} else {
+				assert.Empty(t, reqErrs)
+			}
+
+			op, _, err := spec.FindOperation(tt.method, req.URL.Path)
+			require.NoError(t, err)
+			if rec.Code < http.StatusMultipleChoices {
+				covered[op] = true
+			}
+		})
+	}
+
+	for _, op := range spec.Operations() {
+		assert.True(t, covered[op], "no successful request to %s %s", op.Method, op.Path)
+	}
+}
+
+// Test_router_contract_routes checks the router and the document describe
+// the same operations.
+func Test_router_contract_routes(t *testing.T) {
+	spec, err := openapi.Load()
+	require.NoError(t, err)
+
+	routes, ok := SetupRouter(logger, nil, nil, nil, nil, nil).(chi.Routes)
+	require.True(t, ok)
+
+	served := make(map[string]bool)
+	err = chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		// the static files of the clients aren't a part of the API
+		if strings.HasPrefix(route, "/clients/") {
+			return nil
+		}
+		served[method+" "+route] = true
+
+		op, _, err := spec.FindOperation(method, route)
+		if assert.NoError(t, err) {
+			assert.Equal(t, route, op.Path)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	for _, op := range spec.Operations() {
+		assert.True(t, served[op.Method+" "+op.Path], "%s %s is not served", op.Method, op.Path)
+	}
+}
+
+func contractCases() []contractCase {
+	const (
+		userID = "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83"
+		noteID = "8c7ec1b4-2c0b-4bd1-9f3e-6f6d3c1f6a10"
+		cardID = "0e5b3a4c-91d2-4c8e-a4a5-2f1f7d0c9b21"
+		hash   = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	)
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	user := (&types.User{Login: "bob", Password: "secret"}).ToDB()
+	keys := &types.Page[types.Key]{Items: []types.Key{{Id: "1", Key: "key", CreatedAt: &now, UpdatedAt: &now}}, Total: 3, Next: "next"}
+	upload := &types.UploadSession{ID: "u1", Name: "name", Size: 10, ChunkSize: types.UploadChunkSize, CreatedAt: now}
+	file := func(content string) *types.File {
+		return &types.File{ID: "1", Name: "name", Size: int64(len(content)), Metadata: "meta", ModTime: now,
+			Content: readSeekNopCloser{strings.NewReader(content)}}
+	}
+
+	return []contractCase{
+		{name: "spec", method: http.MethodGet, target: "/api/openapi.json", code: http.StatusOK},
+
+		// users
+		{
+			name: "register", method: http.MethodPost, target: "/api/user/register", body: `{"login":"bob","password":"secret"}`,
+			setup: func(m contractMocks) { m.users.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(nil) },
+			code:  http.StatusOK,
+		},
+		{
+			name: "register without password", method: http.MethodPost, target: "/api/user/register", body: `{"login":"bob"}`,
+			code: http.StatusBadRequest, invalid: true,
+		},
+		{
+			name: "register taken login", method: http.MethodPost, target: "/api/user/register", body: `{"login":"bob","password":"secret"}`,
+			setup: func(m contractMocks) {
+				m.users.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(types.ErrUserAlreadyExists)
+			},
+			code: http.StatusConflict,
+		},
+		{
+			name: "login", method: http.MethodPost, target: "/api/user/login", body: `{"login":"bob","password":"secret"}`,
+			setup: func(m contractMocks) { m.users.EXPECT().GetByLogin(gomock.Any(), "bob").Return(user, nil) },
+			code:  http.StatusOK,
+		},
+		{
+			name: "login wrong password", method: http.MethodPost, target: "/api/user/login", body: `{"login":"bob","password":"wrong"}`,
+			setup: func(m contractMocks) { m.users.EXPECT().GetByLogin(gomock.Any(), "bob").Return(user, nil) },
+			code:  http.StatusUnauthorized,
+		},
+		{
+			name: "usage", method: http.MethodGet, target: "/api/user/usage", token: validToken,
+			setup: func(m contractMocks) {
+				m.quotas.EXPECT().GetUsage(gomock.Any(), userID).Return(&types.Usage{Notes: 1, Quota: types.Quota{Notes: 10}}, nil)
+			},
+			code: http.StatusOK,
+		},
+		{name: "usage without token", method: http.MethodGet, target: "/api/user/usage", code: http.StatusUnauthorized, invalid: true},
+
+		// notes
+		{
+			name: "create note", method: http.MethodPost, target: "/api/secret/text", token: validToken,
+			body: `{"key":"key","data":"text","metadata":"meta","search_tokens":["abc"]}`,
+			setup: func(m contractMocks) {
+				m.quotas.EXPECT().LockUsage(gomock.Any(), userID).Return(&types.Usage{}, nil)
+				m.notes.EXPECT().Create(gomock.Any(), userID, gomock.Any(), gomock.Any()).Return(nil)
+				m.search.EXPECT().SetTokens(gomock.Any(), userID, types.KindNote, gomock.Any(), []string{"abc"}).Return(nil)
+			},
+			code: http.StatusAccepted,
+		},
+		{
+			name: "create note without key", method: http.MethodPost, target: "/api/secret/text", token: validToken, body: `{"data":"text"}`,
+			code: http.StatusBadRequest, invalid: true,
+		},
+		{
+			name: "create note over quota", method: http.MethodPost, target: "/api/secret/text", token: validToken, body: `{"key":"key"}`,
+			setup: func(m contractMocks) {
+				m.quotas.EXPECT().LockUsage(gomock.Any(), userID).Return(&types.Usage{Notes: 1, Quota: types.Quota{Notes: 1}}, nil)
+			},
+			code: http.StatusForbidden,
+		},
+		{
+			name: "create note failed", method: http.MethodPost, target: "/api/secret/text", token: validToken, body: `{"key":"key"}`,
+			setup: func(m contractMocks) {
+				m.quotas.EXPECT().LockUsage(gomock.Any(), userID).Return(&types.Usage{}, nil)
+				m.notes.EXPECT().Create(gomock.Any(), userID, gomock.Any(), gomock.Any()).Return(sql.ErrConnDone)
+			},
+			code: http.StatusInternalServerError,
+		},
+		{
+			name: "get note", method: http.MethodGet, target: "/api/secret/text/1", token: validToken,
+			setup: func(m contractMocks) {
+				m.notes.EXPECT().Get(gomock.Any(), userID, "1").Return(&types.Note{Key: "key", Text: "text"}, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name: "get missing note", method: http.MethodGet, target: "/api/secret/text/1", token: validToken,
+			setup: func(m contractMocks) { m.notes.EXPECT().Get(gomock.Any(), userID, "1").Return(nil, sql.ErrNoRows) },
+			code:  http.StatusNotFound,
+		},
+		{
+			name: "get note with invalid token", method: http.MethodGet, target: "/api/secret/text/1", token: invalidToken,
+			code: http.StatusUnauthorized,
+		},
+		{
+			name: "list notes", method: http.MethodGet, target: "/api/secret/texts?sort=updated&order=desc&limit=1", token: validToken,
+			setup: func(m contractMocks) {
+				m.notes.EXPECT().GetKeysList(gomock.Any(), userID, gomock.Any()).Return(keys, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name: "list no notes", method: http.MethodGet, target: "/api/secret/texts", token: validToken,
+			setup: func(m contractMocks) {
+				m.notes.EXPECT().GetKeysList(gomock.Any(), userID, gomock.Any()).Return(&types.Page[types.Key]{}, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name: "list notes by size", method: http.MethodGet, target: "/api/secret/texts?sort=size", token: validToken,
+			code: http.StatusBadRequest, invalid: true,
+		},
+		{
+			name: "update note", method: http.MethodPut, target: "/api/secret/text", token: validToken, body: `{"id":"1","key":"key","data":"text"}`,
+			setup: func(m contractMocks) { m.notes.EXPECT().Update(gomock.Any(), userID, "1", gomock.Any()).Return(nil) },
+			code:  http.StatusOK,
+		},
+		{
+			name: "update missing note", method: http.MethodPut, target: "/api/secret/text", token: validToken, body: `{"id":"1","key":"key"}`,
+			setup: func(m contractMocks) {
+				m.notes.EXPECT().Update(gomock.Any(), userID, "1", gomock.Any()).Return(sql.ErrNoRows)
+			},
+			code: http.StatusNotFound,
+		},
+		{
+			name: "delete note", method: http.MethodDelete, target: "/api/secret/text/1", token: validToken,
+			setup: func(m contractMocks) { m.notes.EXPECT().Delete(gomock.Any(), userID, "1").Return(nil) },
+			code:  http.StatusNoContent,
+		},
+
+		// cards
+		{
+			name: "create card", method: http.MethodPost, target: "/api/secret/card", token: validToken,
+			body: `{"number":"4111","expiration":"12/30","cvv":"123"}`,
+			setup: func(m contractMocks) {
+				m.quotas.EXPECT().LockUsage(gomock.Any(), userID).Return(&types.Usage{}, nil)
+				m.cards.EXPECT().Create(gomock.Any(), userID, gomock.Any(), gomock.Any()).Return(nil)
+			},
+			code: http.StatusAccepted,
+		},
+		{
+			name: "get card", method: http.MethodGet, target: "/api/secret/card/1", token: validToken,
+			setup: func(m contractMocks) {
+				m.cards.EXPECT().Get(gomock.Any(), userID, "1").Return(&types.CardInfo{ID: "1", Number: "4111", Expiration: "12/30", CVV: "123"}, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name: "list cards", method: http.MethodGet, target: "/api/secret/cards", token: validToken,
+			setup: func(m contractMocks) {
+				m.cards.EXPECT().GetKeysList(gomock.Any(), userID, gomock.Any()).Return(keys, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name: "update card", method: http.MethodPut, target: "/api/secret/card", token: validToken,
+			body:  `{"id":"1","number":"4111","expiration":"12/30","cvv":"123"}`,
+			setup: func(m contractMocks) { m.cards.EXPECT().Update(gomock.Any(), userID, "1", gomock.Any()).Return(nil) },
+			code:  http.StatusOK,
+		},
+		{
+			name: "update card without cvv", method: http.MethodPut, target: "/api/secret/card", token: validToken,
+			body: `{"id":"1","number":"4111","expiration":"12/30"}`,
+			code: http.StatusBadRequest, invalid: true,
+		},
+		{
+			name: "delete card", method: http.MethodDelete, target: "/api/secret/card/1", token: validToken,
+			setup: func(m contractMocks) { m.cards.EXPECT().Delete(gomock.Any(), userID, "1").Return(nil) },
+			code:  http.StatusNoContent,
+		},
+
+		// credentials
+		{
+			name: "create credentials", method: http.MethodPost, target: "/api/secret/cred", token: validToken,
+			body: `{"site":"site","login":"login","password":"secret"}`,
+			setup: func(m contractMocks) {
+				m.quotas.EXPECT().LockUsage(gomock.Any(), userID).Return(&types.Usage{}, nil)
+				m.creds.EXPECT().Create(gomock.Any(), userID, gomock.Any(), gomock.Any()).Return(nil)
+			},
+			code: http.StatusAccepted,
+		},
+		{
+			name: "get credentials", method: http.MethodGet, target: "/api/secret/cred/1", token: validToken,
+			setup: func(m contractMocks) {
+				m.creds.EXPECT().Get(gomock.Any(), userID, "1").Return(&types.Credentials{Site: "site", Login: "login"}, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name: "list credentials", method: http.MethodGet, target: "/api/secret/creds?since=2024-05-01", token: validToken,
+			setup: func(m contractMocks) {
+				m.creds.EXPECT().GetKeysList(gomock.Any(), userID, gomock.Any()).Return(keys, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name: "update credentials", method: http.MethodPut, target: "/api/secret/cred", token: validToken,
+			body:  `{"id":"1","site":"site","login":"login"}`,
+			setup: func(m contractMocks) { m.creds.EXPECT().Update(gomock.Any(), userID, "1", gomock.Any()).Return(nil) },
+			code:  http.StatusOK,
+		},
+		{
+			name: "delete credentials", method: http.MethodDelete, target: "/api/secret/cred/1", token: validToken,
+			setup: func(m contractMocks) { m.creds.EXPECT().Delete(gomock.Any(), userID, "1").Return(nil) },
+			code:  http.StatusNoContent,
+		},
+
+		// batch
+		{
+			name: "batch", method: http.MethodPost, target: "/api/secret/batch", token: validToken,
+			body: `{"ops":[{"op":"delete","kind":"text","id":"` + noteID + `"}]}`,
+			setup: func(m contractMocks) {
+				m.quotas.EXPECT().LockUsage(gomock.Any(), userID).Return(&types.Usage{}, nil)
+				m.notes.EXPECT().DeleteMany(gomock.Any(), userID, []string{noteID}).Return(nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name: "batch per item", method: http.MethodPost, target: "/api/secret/batch", token: validToken,
+			body: `{"mode":"per_item","ops":[{"op":"delete","kind":"text","id":"` + noteID + `"},{"op":"delete","kind":"card","id":"` + cardID + `"}]}`,
+			setup: func(m contractMocks) {
+				m.quotas.EXPECT().LockUsage(gomock.Any(), userID).Return(&types.Usage{}, nil)
+				m.notes.EXPECT().DeleteMany(gomock.Any(), userID, []string{noteID}).Return(nil)
+				m.cards.EXPECT().DeleteMany(gomock.Any(), userID, []string{cardID}).Return(&types.ItemError{Index: 0, Err: sql.ErrNoRows})
+			},
+			code: http.StatusMultiStatus,
+		},
+		{
+			name: "batch rolled back", method: http.MethodPost, target: "/api/secret/batch", token: validToken,
+			body: `{"ops":[{"op":"delete","kind":"text","id":"` + noteID + `"}]}`,
+			setup: func(m contractMocks) {
+				m.quotas.EXPECT().LockUsage(gomock.Any(), userID).Return(&types.Usage{}, nil)
+				m.notes.EXPECT().DeleteMany(gomock.Any(), userID, []string{noteID}).Return(&types.ItemError{Index: 0, Err: sql.ErrNoRows})
+			},
+			code: http.StatusNotFound,
+		},
+		{
+			name: "empty batch", method: http.MethodPost, target: "/api/secret/batch", token: validToken, body: `{"ops":[]}`,
+			code: http.StatusBadRequest, invalid: true,
+		},
+
+		// files
+		{
+			name: "create file", method: http.MethodPost, target: "/api/secret/file", token: validToken,
+			header: map[string]string{"Content-Type": fileForm.FormDataContentType()}, body: fileFormBody,
+			setup: func(m contractMocks) { m.files.EXPECT().Create(gomock.Any(), userID, gomock.Any()).Return(nil) },
+			code:  http.StatusCreated,
+		},
+		{
+			name: "create file without file", method: http.MethodPost, target: "/api/secret/file", token: validToken,
+			header: map[string]string{"Content-Type": emptyForm.FormDataContentType()}, body: emptyFormBody,
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "link file", method: http.MethodPost, target: "/api/secret/file/link", token: validToken,
+			body: `{"name":"name","hash":"` + hash + `","metadata":"meta"}`,
+			setup: func(m contractMocks) {
+				m.files.EXPECT().Link(gomock.Any(), userID, gomock.Any()).Return(&types.FileInfo{ID: "1", Name: "name", Size: 4, Hash: hash, CreatedAt: now}, nil)
+			},
+			code: http.StatusCreated,
+		},
+		{
+			name: "link file without contents", method: http.MethodPost, target: "/api/secret/file/link", token: validToken,
+			body: `{"name":"name","hash":"` + hash + `"}`,
+			setup: func(m contractMocks) {
+				m.files.EXPECT().Link(gomock.Any(), userID, gomock.Any()).Return(nil, types.ErrNotFound)
+			},
+			code: http.StatusNotFound,
+		},
+		{
+			name: "link file with incorrect hash", method: http.MethodPost, target: "/api/secret/file/link", token: validToken,
+			body: `{"name":"name","hash":"xyz"}`,
+			code: http.StatusBadRequest, invalid: true,
+		},
+		{
+			name: "stored blob", method: http.MethodHead, target: "/api/secret/blob/" + hash, token: validToken,
+			setup: func(m contractMocks) { m.files.EXPECT().HasBlob(gomock.Any(), userID, hash).Return(true, nil) },
+			code:  http.StatusOK,
+		},
+		{
+			name: "missing blob", method: http.MethodHead, target: "/api/secret/blob/" + hash, token: validToken,
+			setup: func(m contractMocks) { m.files.EXPECT().HasBlob(gomock.Any(), userID, hash).Return(false, nil) },
+			code:  http.StatusNotFound,
+		},
+		{
+			name: "blob with incorrect hash", method: http.MethodHead, target: "/api/secret/blob/xyz", token: validToken,
+			code: http.StatusBadRequest, invalid: true,
+		},
+		{
+			name: "get file", method: http.MethodGet, target: "/api/secret/file/1", token: validToken,
+			setup: func(m contractMocks) {
+				m.files.EXPECT().GetFile(gomock.Any(), userID, "1").Return(file("0123456789"), nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name: "get file range", method: http.MethodGet, target: "/api/secret/file/1", token: validToken,
+			header: map[string]string{"Range": "bytes=4-6"},
+			setup: func(m contractMocks) {
+				m.files.EXPECT().GetFile(gomock.Any(), userID, "1").Return(file("0123456789"), nil)
+			},
+			code: http.StatusPartialContent,
+		},
+		{
+			name: "get file after the end", method: http.MethodGet, target: "/api/secret/file/1", token: validToken,
+			header: map[string]string{"Range": "bytes=10-"},
+			setup: func(m contractMocks) {
+				m.files.EXPECT().GetFile(gomock.Any(), userID, "1").Return(file("0123456789"), nil)
+			},
+			code: http.StatusRequestedRangeNotSatisfiable,
+		},
+		{
+			name: "get missing file", method: http.MethodGet, target: "/api/secret/file/1", token: validToken,
+			setup: func(m contractMocks) {
+				m.files.EXPECT().GetFile(gomock.Any(), userID, "1").Return(nil, types.ErrNotFound)
+			},
+			code: http.StatusNotFound,
+		},
+		{
+			name: "list files", method: http.MethodGet, target: "/api/secret/files?sort=size", token: validToken,
+			setup: func(m contractMocks) {
+				m.files.EXPECT().GetFilesList(gomock.Any(), userID, gomock.Any()).Return(&types.Page[*types.FileInfo]{
+					Items: []*types.FileInfo{{ID: "1", Name: "name", Size: 4, Hash: hash, CreatedAt: now}}, Total: 1,
+				}, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name: "delete file", method: http.MethodDelete, target: "/api/secret/file/1", token: validToken,
+			setup: func(m contractMocks) { m.files.EXPECT().Delete(gomock.Any(), userID, "1").Return(nil) },
+			code:  http.StatusNoContent,
+		},
+		{
+			name: "delete missing file", method: http.MethodDelete, target: "/api/secret/file/1", token: validToken,
+			setup: func(m contractMocks) { m.files.EXPECT().Delete(gomock.Any(), userID, "1").Return(types.ErrNotFound) },
+			code:  http.StatusNotFound,
+		},
+
+		// uploads
+		{
+			name: "create upload", method: http.MethodPost, target: "/api/secret/upload", token: validToken,
+			body: `{"name":"name","size":10,"metadata":"meta"}`,
+			setup: func(m contractMocks) {
+				m.files.EXPECT().CreateUpload(gomock.Any(), userID, gomock.Any()).Return(upload, nil)
+			},
+			code: http.StatusCreated,
+		},
+		{
+			name: "create empty upload", method: http.MethodPost, target: "/api/secret/upload", token: validToken,
+			body: `{"name":"name","size":0}`,
+			code: http.StatusBadRequest, invalid: true,
+		},
+		{
+			name: "get upload", method: http.MethodGet, target: "/api/secret/upload/u1", token: validToken,
+			setup: func(m contractMocks) {
+				m.files.EXPECT().GetUpload(gomock.Any(), userID, "u1").Return(&types.UploadSession{
+					ID: "u1", Name: "name", Size: 10, ChunkSize: types.UploadChunkSize, CreatedAt: now,
+					Chunks: []types.UploadChunkInfo{{Number: 1, Size: 10, ETag: "etag"}}, Offset: 10,
+				}, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name: "get missing upload", method: http.MethodGet, target: "/api/secret/upload/u1", token: validToken,
+			setup: func(m contractMocks) {
+				m.files.EXPECT().GetUpload(gomock.Any(), userID, "u1").Return(nil, types.ErrNotFound)
+			},
+			code: http.StatusNotFound,
+		},
+		{
+			name: "put chunk", method: http.MethodPut, target: "/api/secret/upload/u1/1", token: validToken,
+			header: map[string]string{"Content-Type": "application/octet-stream", "Content-MD5": "4vxxTEcn7pOV8yTNLn8zHw=="},
+			body:   "0123456789",
+			setup: func(m contractMocks) {
+				m.files.EXPECT().UploadChunk(gomock.Any(), userID, "u1", gomock.Any()).Return(&types.UploadChunkInfo{Number: 1, Size: 10, ETag: "etag"}, nil)
+			},
+			code: http.StatusNoContent,
+		},
+		{
+			name: "put chunk without checksum", method: http.MethodPut, target: "/api/secret/upload/u1/1", token: validToken,
+			header: map[string]string{"Content-Type": "application/octet-stream"}, body: "0123456789",
+			code: http.StatusBadRequest, invalid: true,
+		},
+		{
+			name: "complete upload", method: http.MethodPost, target: "/api/secret/upload/u1/complete", token: validToken,
+			setup: func(m contractMocks) { m.files.EXPECT().CompleteUpload(gomock.Any(), userID, "u1").Return(upload, nil) },
+			code:  http.StatusCreated,
+		},
+		{
+			name: "complete incomplete upload", method: http.MethodPost, target: "/api/secret/upload/u1/complete", token: validToken,
+			setup: func(m contractMocks) {
+				m.files.EXPECT().CompleteUpload(gomock.Any(), userID, "u1").Return(nil, types.ErrUploadIncomplete)
+			},
+			code: http.StatusConflict,
+		},
+		{
+			name: "abort upload", method: http.MethodDelete, target: "/api/secret/upload/u1", token: validToken,
+			setup: func(m contractMocks) { m.files.EXPECT().AbortUpload(gomock.Any(), userID, "u1").Return(nil) },
+			code:  http.StatusNoContent,
+		},
+
+		// search
+		{
+			name: "search", method: http.MethodPost, target: "/api/search/", token: validToken, body: `{"tokens":["abc","def"],"min_match":1}`,
+			setup: func(m contractMocks) {
+				m.search.EXPECT().Search(gomock.Any(), userID, gomock.Any()).Return([]types.SearchHit{{Kind: types.KindNote, ID: "1", Matched: 1}}, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name: "search without tokens", method: http.MethodPost, target: "/api/search/", token: validToken, body: `{"tokens":[]}`,
+			code: http.StatusBadRequest, invalid: true,
+		},
+		{
+			name: "set search tokens", method: http.MethodPut, target: "/api/search/text/1", token: validToken, body: `{"tokens":["abc"]}`,
+			setup: func(m contractMocks) {
+				m.notes.EXPECT().Get(gomock.Any(), userID, "1").Return(&types.Note{}, nil)
+				m.search.EXPECT().SetTokens(gomock.Any(), userID, types.KindNote, "1", []string{"abc"}).Return(nil)
+			},
+			code: http.StatusNoContent,
+		},
+		{
+			name: "set search tokens of a file", method: http.MethodPut, target: "/api/search/file/1", token: validToken, body: `{"tokens":["abc"]}`,
+			code: http.StatusNotFound, invalid: true,
+		},
+
+		// trash
+		{
+			name: "get trash", method: http.MethodGet, target: "/api/trash/", token: validToken,
+			setup: func(m contractMocks) {
+				m.notes.EXPECT().GetDeletedList(gomock.Any(), userID).Return([]types.TrashItem{{Id: "1", Key: "key", DeletedAt: now}}, nil)
+				m.cards.EXPECT().GetDeletedList(gomock.Any(), userID).Return(nil, nil)
+				m.creds.EXPECT().GetDeletedList(gomock.Any(), userID).Return(nil, nil)
+				m.files.EXPECT().GetDeletedList(gomock.Any(), userID).Return([]types.TrashItem{{Id: "2", Key: "name", DeletedAt: now}}, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name: "restore", method: http.MethodPost, target: "/api/trash/text/1/restore", token: validToken,
+			setup: func(m contractMocks) { m.notes.EXPECT().Restore(gomock.Any(), userID, "1").Return(nil) },
+			code:  http.StatusOK,
+		},
+		{
+			name: "restore missing", method: http.MethodPost, target: "/api/trash/file/1/restore", token: validToken,
+			setup: func(m contractMocks) { m.files.EXPECT().Restore(gomock.Any(), userID, "1").Return(types.ErrNotFound) },
+			code:  http.StatusNotFound,
+		},
+		{
+			name: "empty trash", method: http.MethodDelete, target: "/api/trash/", token: validToken,
+			setup: func(m contractMocks) {
+				m.notes.EXPECT().EmptyTrash(gomock.Any(), userID).Return(nil)
+				m.cards.EXPECT().EmptyTrash(gomock.Any(), userID).Return(nil)
+				m.creds.EXPECT().EmptyTrash(gomock.Any(), userID).Return(nil)
+				m.files.EXPECT().EmptyTrash(gomock.Any(), userID).Return(nil)
+			},
+			code: http.StatusNoContent,
+		},
+	}
+}
+
+var (
+	fileForm, fileFormBody   = contractForm(true)
+	emptyForm, emptyFormBody = contractForm(false)
+)
+
+// contractForm builds the form of a file upload, with the file part or without it.
+func contractForm(withFile bool) (*multipart.Writer, string) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	_ = mw.WriteField("Metadata", "meta")
+	_ = mw.WriteField("Size", "4")
+	if withFile {
+		part, _ := mw.CreateFormFile("file", "name")
+		_, _ = part.Write([]byte("data"))
+	}
+	_ = mw.Close()
+	return mw, buf.String()
+}
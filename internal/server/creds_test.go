@@ -259,9 +259,9 @@ func Test_router_creds_list(t *testing.T) {
 		Key: "test_key"},
 	}
 
-	mocksSecret.EXPECT().GetKeysList(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83").Return(credsList, nil).Times(1)
-	mocksSecret.EXPECT().GetKeysList(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83").Return(nil, sql.ErrNoRows).Times(1)
-	mocksSecret.EXPECT().GetKeysList(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83").Return(nil, sql.ErrConnDone).Times(1)
+	mocksSecret.EXPECT().GetKeysList(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", defaultList).Return(&types.Page[types.Key]{Items: credsList, Total: 1}, nil).Times(1)
+	mocksSecret.EXPECT().GetKeysList(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", defaultList).Return(&types.Page[types.Key]{Items: []types.Key{}}, nil).Times(1)
+	mocksSecret.EXPECT().GetKeysList(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", defaultList).Return(nil, sql.ErrConnDone).Times(1)
 
 	ts := httptest.NewServer(SetupRouter(logger, nil, nil, mocksSecret, nil, nil))
 	defer ts.Close()
@@ -298,15 +298,15 @@ func Test_router_creds_list(t *testing.T) {
 			},
 		},
 		{
-			name:   "failed test #2 not found",
+			name:   "positive test #2 empty list",
 			method: http.MethodGet,
 			target: "/api/secret/creds",
 			token:  validToken,
 			want: want{
-				code:          404,
+				code:          200,
 				emptyResponse: false,
-				response:      "no credentials info\n",
-				contentType:   "text/plain; charset=utf-8",
+				response:      "[]\n",
+				contentType:   "application/json",
 			},
 		},
 		{
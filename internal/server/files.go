@@ -2,16 +2,23 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 
 	"github.com/docker/go-units"
 	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
 
 	"keeper-project/internal/auth"
 	"keeper-project/types"
 )
 
+// maxFormValueSize limits the non-file fields of an upload form.
+const maxFormValueSize = 64 * units.KiB
+
 func (ro *router) getFile(w http.ResponseWriter, r *http.Request) {
 	fileId := chi.URLParam(r, "id")
 	if fileId == "" {
@@ -27,15 +34,30 @@ func (ro *router) getFile(w http.ResponseWriter, r *http.Request) {
 
 	f, err := ro.fileService.GetFile(r.Context(), userID, fileId)
 	if err != nil {
+		if errors.Is(err, types.ErrNotFound) {
+			http.Error(w, "no such file", http.StatusNotFound)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	defer f.Content.Close()
 
 	w.Header().Set("Meta", f.Metadata)
 	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", f.Name))
-	w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+	w.Header().Set("Content-Type", "application/octet-stream")
 
-	w.Write(f.Bytes)
+	// ServeContent handles Range and If-Range headers, so interrupted downloads can be resumed.
+	if rs, ok := f.Content.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, f.Name, f.ModTime, rs)
+		return
+	}
+
+	w.Header().Set("Content-Length", strconv.FormatInt(f.Size, 10))
+	_, err = io.Copy(w, f.Content)
+	if err != nil {
+		ro.logger.Error("failed to send file", zap.String("id", fileId), zap.Error(err))
+	}
 }
 
 func (ro *router) getFiles(w http.ResponseWriter, r *http.Request) {
@@ -45,61 +67,114 @@ func (ro *router) getFiles(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	list, err := ro.fileService.GetFilesList(r.Context(), userID)
+	opts, err := listOptions(r, types.SortCreated, types.SortUpdated, types.SortSize)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	if len(list) == 0 {
-		http.Error(w, "no files", http.StatusNotFound)
+		http.Error(w, "incorrect list parameters: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	err = json.NewEncoder(w).Encode(list)
+	page, err := ro.fileService.GetFilesList(r.Context(), userID, opts)
 	if err != nil {
-		http.Error(w, "Can't marshal data: "+err.Error(), http.StatusInternalServerError)
+		if errors.Is(err, types.ErrInvalidCursor) {
+			http.Error(w, "incorrect list parameters: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+
+	writePage(w, page)
 }
 
+// createFile streams a multipart upload straight to the file service without buffering it.
+// Form fields (Metadata and optional Size) must precede the file part.
 func (ro *router) createFile(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "form/json")
 
-	err := r.ParseMultipartForm(32 * units.MiB)
+	userID, err := auth.GetUserID(r)
 	if err != nil {
-		http.Error(w, "file size limit exceeded", http.StatusBadRequest)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
 		return
 	}
 
-	userID, err := auth.GetUserID(r)
+	mr, err := r.MultipartReader()
 	if err != nil {
-		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		http.Error(w, "multipart form expected: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	files, ok := r.MultipartForm.File["file"]
-	if !ok || len(files) == 0 {
-		http.Error(w, "file required", http.StatusBadRequest)
-		return
+	dto := types.CreateFileDTO{Size: -1}
+
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			http.Error(w, "unable to read form: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		switch part.FormName() {
+		case "Metadata":
+			dto.Metadata, err = readFormValue(part)
+		case "Size":
+			var size string
+			size, err = readFormValue(part)
+			if err == nil {
+				// a hint, the file service checks the contents match it
+				dto.Size, err = strconv.ParseInt(size, 10, 64)
+			}
+			if err == nil && dto.Size < 0 {
+				err = errors.New("negative size")
+			}
+		case "file":
+			dto.Name = part.FileName()
+			dto.Reader = part
+
+			err = ro.fileService.Create(r.Context(), userID, dto)
+			if err != nil {
+				if quotaExceeded(w, err) || fileTooLarge(w, err) {
+					return
+				}
+				if errors.Is(err, types.ErrSizeMismatch) {
+					http.Error(w, "incorrect form value: "+err.Error(), http.StatusBadRequest)
+					return
+				}
+				http.Error(w, "unable to store file: "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		if err != nil {
+			http.Error(w, "incorrect form value: "+err.Error(), http.StatusBadRequest)
+			return
+		}
 	}
-	fileInfo := files[0]
-	fileReader, err := fileInfo.Open()
-	dto := types.CreateFileDTO{
-		Name:     fileInfo.Filename,
-		Size:     fileInfo.Size,
-		Reader:   fileReader,
-		Metadata: r.Form.Get("Metadata"),
+
+	http.Error(w, "file required", http.StatusBadRequest)
+}
+
+// fileTooLarge reports whether err is a file size limit error and responds with
+// it, the body starts with the file_too_large code.
+func fileTooLarge(w http.ResponseWriter, err error) bool {
+	if !errors.Is(err, types.ErrFileTooLarge) {
+		return false
 	}
+	http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+	return true
+}
 
-	err = ro.fileService.Create(r.Context(), userID, dto)
+func readFormValue(part io.Reader) (string, error) {
+	value, err := io.ReadAll(io.LimitReader(part, maxFormValueSize+1))
 	if err != nil {
-		http.Error(w, "unable to store file: "+err.Error(), http.StatusInternalServerError)
-		return
+		return "", err
 	}
-	w.WriteHeader(http.StatusCreated)
+	if len(value) > maxFormValueSize {
+		return "", errors.New("value is too long")
+	}
+	return string(value), nil
 }
 
 func (ro *router) deleteFile(w http.ResponseWriter, r *http.Request) {
@@ -112,8 +187,78 @@ func (ro *router) deleteFile(w http.ResponseWriter, r *http.Request) {
 
 	err = ro.fileService.Delete(r.Context(), userID, fileId)
 	if err != nil {
+		if errors.Is(err, types.ErrNotFound) {
+			http.Error(w, "nothing to delete", http.StatusNotFound)
+			return
+		}
 		http.Error(w, "unable to delete: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// hasBlob lets the client ask whether contents with the hash are already stored,
+// so linkFile can add the file without sending them again.
+func (ro *router) hasBlob(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserID(r)
+	if err != nil {
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	hash := chi.URLParam(r, "hash")
+	if !types.ValidHash(hash) {
+		http.Error(w, "incorrect hash", http.StatusBadRequest)
+		return
+	}
+
+	ok, err := ro.fileService.HasBlob(r.Context(), userID, hash)
+	if err != nil {
+		http.Error(w, "unable to check contents: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (ro *router) linkFile(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserID(r)
+	if err != nil {
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var req types.LinkFileRequest
+
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Unable to decode json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = req.Validate()
+	if err != nil {
+		http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	info, err := ro.fileService.Link(r.Context(), userID, req)
+	if err != nil {
+		if errors.Is(err, types.ErrNotFound) {
+			http.Error(w, "no stored contents with such hash", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "unable to store file: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(info)
+	if err != nil {
+		ro.logger.Error("failed to send file info", zap.Error(err))
+	}
+}
@@ -2,14 +2,18 @@ package server
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/minio/minio-go/v7"
@@ -145,14 +149,15 @@ func Test_router_file_get(t *testing.T) {
 
 	fileTest := &types.File{
 		Name:     "123321",
-		Size:     1000,
-		Bytes:    []byte{1},
+		Size:     1,
 		Metadata: "test_meta",
+		Content:  readSeekNopCloser{bytes.NewReader([]byte{1})},
 	}
 
 	mockFileService.EXPECT().GetFile(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", "test").Return(fileTest, nil).Times(1)
 	mockFileService.EXPECT().GetFile(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", "test").Return(nil, errors.New("not found")).Times(1)
 	mockFileService.EXPECT().GetFile(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", "test").Return(nil, minio.ToErrorResponse(errors.New("failed request"))).Times(1)
+	mockFileService.EXPECT().GetFile(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", "deleted").Return(nil, fmt.Errorf("%w: sql: no rows in result set", types.ErrNotFound)).Times(1)
 
 	ts := httptest.NewServer(SetupRouter(logger, nil, nil, nil, nil, mockFileService))
 	defer ts.Close()
@@ -173,7 +178,7 @@ func Test_router_file_get(t *testing.T) {
 				code:          200,
 				emptyResponse: false,
 				response:      "\x01",
-				contentType:   "",
+				contentType:   "application/octet-stream",
 			},
 		},
 		{
@@ -224,6 +229,42 @@ func Test_router_file_get(t *testing.T) {
 				contentType:   "text/plain; charset=utf-8",
 			},
 		},
+		{
+			name:   "failed test #5 no record",
+			method: http.MethodGet,
+			target: "/api/secret/file/deleted",
+			token:  validToken,
+			want: want{
+				code:          404,
+				emptyResponse: false,
+				response:      "no such file\n",
+				contentType:   "text/plain; charset=utf-8",
+			},
+		},
+		{
+			name:   "failed test #4 unknown sort",
+			method: http.MethodGet,
+			target: "/api/secret/files?sort=owner",
+			token:  validToken,
+			want: want{
+				code:          400,
+				emptyResponse: false,
+				response:      "incorrect list parameters: unknown sort field \"owner\"\n",
+				contentType:   "text/plain; charset=utf-8",
+			},
+		},
+		{
+			name:   "failed test #5 incorrect limit",
+			method: http.MethodGet,
+			target: "/api/secret/files?limit=ten",
+			token:  validToken,
+			want: want{
+				code:          400,
+				emptyResponse: false,
+				response:      "incorrect list parameters: incorrect limit \"ten\"\n",
+				contentType:   "text/plain; charset=utf-8",
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -255,14 +296,19 @@ func Test_router_file_list(t *testing.T) {
 
 	mockFileService := mocks.NewMockFileService(mockCtrl)
 
-	filesList := []*types.Key{{
-		Id:  "test",
-		Key: "test_key"},
+	filesList := []*types.FileInfo{{
+		ID:        "test",
+		Name:      "test_key",
+		Size:      10,
+		Hash:      "hash",
+		CreatedAt: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)},
 	}
 
-	mockFileService.EXPECT().GetFilesList(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83").Return(filesList, nil).Times(1)
-	mockFileService.EXPECT().GetFilesList(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83").Return(nil, nil).Times(1)
-	mockFileService.EXPECT().GetFilesList(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83").Return(nil, minio.ToErrorResponse(errors.New("failed request"))).Times(1)
+	mockFileService.EXPECT().GetFilesList(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", defaultList).Return(&types.Page[*types.FileInfo]{Items: filesList, Total: 1}, nil).Times(1)
+	mockFileService.EXPECT().GetFilesList(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83",
+		types.ListOptions{Sort: types.SortSize, Desc: true, Limit: 5, Offset: 10}).Return(&types.Page[*types.FileInfo]{Items: filesList, Total: 1}, nil).Times(1)
+	mockFileService.EXPECT().GetFilesList(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", defaultList).Return(&types.Page[*types.FileInfo]{Items: []*types.FileInfo{}}, nil).Times(1)
+	mockFileService.EXPECT().GetFilesList(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", defaultList).Return(nil, minio.ToErrorResponse(errors.New("failed request"))).Times(1)
 
 	ts := httptest.NewServer(SetupRouter(logger, nil, nil, nil, nil, mockFileService))
 	defer ts.Close()
@@ -282,7 +328,19 @@ func Test_router_file_list(t *testing.T) {
 			want: want{
 				code:          200,
 				emptyResponse: false,
-				response:      "[{\"id\":\"test\",\"key\":\"test_key\"}]\n",
+				response:      "[{\"id\":\"test\",\"key\":\"test_key\",\"size\":10,\"hash\":\"hash\",\"created_at\":\"2024-06-01T12:00:00Z\"}]\n",
+				contentType:   "application/json",
+			},
+		},
+		{
+			name:   "positive test #2 sorted page",
+			method: http.MethodGet,
+			target: "/api/secret/files?sort=size&order=desc&limit=5&offset=10",
+			token:  validToken,
+			want: want{
+				code:          200,
+				emptyResponse: false,
+				response:      "[{\"id\":\"test\",\"key\":\"test_key\",\"size\":10,\"hash\":\"hash\",\"created_at\":\"2024-06-01T12:00:00Z\"}]\n",
 				contentType:   "application/json",
 			},
 		},
@@ -299,15 +357,15 @@ func Test_router_file_list(t *testing.T) {
 			},
 		},
 		{
-			name:   "failed test #2 not found",
+			name:   "positive test #3 empty list",
 			method: http.MethodGet,
 			target: "/api/secret/files",
 			token:  validToken,
 			want: want{
-				code:          404,
+				code:          200,
 				emptyResponse: false,
-				response:      "no files\n",
-				contentType:   "text/plain; charset=utf-8",
+				response:      "[]\n",
+				contentType:   "application/json",
 			},
 		},
 		{
@@ -430,12 +488,137 @@ func Test_router_file_delete(t *testing.T) {
 	}
 }
 
+func Test_router_file_get_range(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockFileService := mocks.NewMockFileService(mockCtrl)
+
+	mockFileService.EXPECT().GetFile(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", "test").Return(&types.File{
+		Name:    "123321",
+		Size:    10,
+		Content: readSeekNopCloser{strings.NewReader("0123456789")},
+	}, nil).Times(1)
+
+	ts := httptest.NewServer(SetupRouter(logger, nil, nil, nil, nil, mockFileService))
+	defer ts.Close()
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL+"/api/secret/file/test", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", validToken)
+	req.Header.Set("Range", "bytes=4-")
+
+	res, err := ts.Client().Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusPartialContent, res.StatusCode)
+	assert.Equal(t, "bytes 4-9/10", res.Header.Get("Content-Range"))
+	assert.Equal(t, "456789", string(body))
+}
+
+func Test_router_files_create_limit(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockFileService := mocks.NewMockFileService(mockCtrl)
+
+	var stored types.CreateFileDTO
+	mockFileService.EXPECT().Create(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, dto types.CreateFileDTO) error {
+			stored = dto
+			_, err := io.ReadAll(dto.Reader)
+			return err
+		}).Times(1)
+	// the limit of the user is checked by the file service
+	mockFileService.EXPECT().Create(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", gomock.Any()).
+		Return(fmt.Errorf("%w: 512 bytes allowed", types.ErrFileTooLarge)).Times(1)
+	mockFileService.EXPECT().Create(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", gomock.Any()).
+		Return(fmt.Errorf("%w: 20 bytes declared", types.ErrSizeMismatch)).Times(1)
+
+	ts := httptest.NewServer(SetupRouter(logger, nil, nil, nil, nil, mockFileService))
+	defer ts.Close()
+
+	res, body := testAuthorizedRequestMultipartForm(t, ts, http.MethodPost, "/api/secret/file", validToken,
+		map[string]io.Reader{
+			"file":     namedReader{Reader: strings.NewReader("small file"), name: "small.txt"},
+			"Metadata": strings.NewReader("some_test_meta"),
+			"Size":     strings.NewReader("10"),
+		})
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusCreated, res.StatusCode, body)
+	assert.Equal(t, "small.txt", stored.Name)
+	assert.Equal(t, "some_test_meta", stored.Metadata)
+	assert.Equal(t, int64(10), stored.Size)
+
+	res, body = testAuthorizedRequestMultipartForm(t, ts, http.MethodPost, "/api/secret/file", validToken,
+		map[string]io.Reader{
+			"file": namedReader{Reader: strings.NewReader(strings.Repeat("a", 1024)), name: "big.txt"},
+		})
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusRequestEntityTooLarge, res.StatusCode)
+	assert.Equal(t, "file_too_large: 512 bytes allowed\n", body)
+
+	res, body = testAuthorizedRequestMultipartForm(t, ts, http.MethodPost, "/api/secret/file", validToken,
+		map[string]io.Reader{
+			"file": namedReader{Reader: strings.NewReader("small file"), name: "small.txt"},
+			"Size": strings.NewReader("20"),
+		})
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, "incorrect form value: size mismatch: 20 bytes declared\n", body)
+
+	res, body = testAuthorizedRequestMultipartForm(t, ts, http.MethodPost, "/api/secret/file", validToken,
+		map[string]io.Reader{
+			"file": namedReader{Reader: strings.NewReader("small file"), name: "small.txt"},
+			"Size": strings.NewReader("-5"),
+		})
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, "incorrect form value: negative size\n", body)
+}
+
+type readSeekNopCloser struct {
+	io.ReadSeeker
+}
+
+func (readSeekNopCloser) Close() error { return nil }
+
+type namedReader struct {
+	io.Reader
+	name string
+}
+
+// testAuthorizedRequestMultipartForm writes form fields before files,
+// as the upload handler streams the file part and expects metadata first.
 func testAuthorizedRequestMultipartForm(t *testing.T, ts *httptest.Server,
 	method, path, token string, values map[string]io.Reader) (*http.Response, string) {
 	var b bytes.Buffer
 	var err error
 	w := multipart.NewWriter(&b)
-	for key, r := range values {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	isFile := func(r io.Reader) bool {
+		switch r.(type) {
+		case *os.File, namedReader:
+			return true
+		}
+		return false
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		iFile, jFile := isFile(values[keys[i]]), isFile(values[keys[j]])
+		if iFile != jFile {
+			return jFile
+		}
+		return keys[i] < keys[j]
+	})
+	for _, key := range keys {
+		r := values[key]
 		var fw io.Writer
 		if x, ok := r.(io.Closer); ok {
 			defer x.Close()
@@ -443,6 +626,9 @@ func testAuthorizedRequestMultipartForm(t *testing.T, ts *httptest.Server,
 		if x, ok := r.(*os.File); ok {
 			if fw, _ = w.CreateFormFile(key, x.Name()); err != nil {
 			}
+		} else if x, ok := r.(namedReader); ok {
+			if fw, _ = w.CreateFormFile(key, x.name); err != nil {
+			}
 		} else {
 			if fw, _ = w.CreateFormField(key); err != nil {
 			}
@@ -466,3 +652,76 @@ func testAuthorizedRequestMultipartForm(t *testing.T, ts *httptest.Server,
 
 	return resp, string(respBody)
 }
+
+func Test_router_blob_dedup(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockFileService := mocks.NewMockFileService(mockCtrl)
+
+	userID := "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83"
+	stored := "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	missing := "0000000000000000000000000000000000000000000000000000000000000000"
+
+	mockFileService.EXPECT().HasBlob(gomock.Any(), userID, stored).Return(true, nil).AnyTimes()
+	mockFileService.EXPECT().HasBlob(gomock.Any(), userID, missing).Return(false, nil).AnyTimes()
+	mockFileService.EXPECT().Link(gomock.Any(), userID, types.LinkFileRequest{Name: "name", Hash: stored, Metadata: "meta"}).
+		Return(&types.FileInfo{ID: "id", Name: "name", Size: 10, Hash: stored, CreatedAt: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)}, nil).Times(1)
+	mockFileService.EXPECT().Link(gomock.Any(), userID, types.LinkFileRequest{Name: "name", Hash: missing}).
+		Return(nil, types.ErrNotFound).Times(1)
+
+	ts := httptest.NewServer(SetupRouter(logger, nil, nil, nil, nil, mockFileService))
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		token    string
+		body     string
+		code     int
+		response string
+	}{
+		{name: "stored contents", method: http.MethodHead, target: "/api/secret/blob/" + stored, token: validToken, code: 200},
+		{name: "missing contents", method: http.MethodHead, target: "/api/secret/blob/" + missing, token: validToken, code: 404},
+		{name: "incorrect hash", method: http.MethodHead, target: "/api/secret/blob/abc", token: validToken, code: 400},
+		{name: "invalid token", method: http.MethodHead, target: "/api/secret/blob/" + stored, token: invalidToken, code: 401},
+		{
+			name:     "link stored contents",
+			method:   http.MethodPost,
+			target:   "/api/secret/file/link",
+			token:    validToken,
+			body:     `{"name":"name","hash":"` + stored + `","metadata":"meta"}`,
+			code:     201,
+			response: `{"id":"id","key":"name","size":10,"hash":"` + stored + `","created_at":"2024-06-01T12:00:00Z"}` + "\n",
+		},
+		{
+			name:     "link missing contents",
+			method:   http.MethodPost,
+			target:   "/api/secret/file/link",
+			token:    validToken,
+			body:     `{"name":"name","hash":"` + missing + `"}`,
+			code:     404,
+			response: "no stored contents with such hash\n",
+		},
+		{
+			name:     "link without name",
+			method:   http.MethodPost,
+			target:   "/api/secret/file/link",
+			token:    validToken,
+			body:     `{"hash":"` + stored + `"}`,
+			code:     400,
+			response: "Bad request: incorrect name or hash\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, body := testAuthorizedRequest(t, ts, tt.method, tt.target, tt.token, []byte(tt.body))
+			defer res.Body.Close()
+			assert.Equal(t, tt.code, res.StatusCode)
+			if tt.response != "" {
+				assert.Equal(t, tt.response, body)
+			}
+		})
+	}
+}
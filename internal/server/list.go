@@ -0,0 +1,79 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"keeper-project/types"
+)
+
+// secretSorts are the sort fields of the notes, cards and credentials lists.
+var secretSorts = []string{types.SortCreated, types.SortUpdated}
+
+// listOptions reads the sort, order, limit, cursor, offset and since query
+// parameters. The limit defaults to types.DefaultPageSize, since is an
+// RFC 3339 time or a date.
+func listOptions(r *http.Request, sorts ...string) (types.ListOptions, error) {
+	q := r.URL.Query()
+	opts := types.ListOptions{Sort: q.Get("sort"), Cursor: q.Get("cursor")}
+
+	switch q.Get("order") {
+	case "", "asc":
+	case "desc":
+		opts.Desc = true
+	default:
+		return opts, fmt.Errorf("unknown order %q", q.Get("order"))
+	}
+
+	var err error
+	if limit := q.Get("limit"); limit != "" {
+		opts.Limit, err = strconv.Atoi(limit)
+		if err != nil {
+			return opts, fmt.Errorf("incorrect limit %q", limit)
+		}
+	}
+	if opts.Limit == 0 {
+		opts.Limit = types.DefaultPageSize
+	}
+	if offset := q.Get("offset"); offset != "" {
+		opts.Offset, err = strconv.Atoi(offset)
+		if err != nil {
+			return opts, fmt.Errorf("incorrect offset %q", offset)
+		}
+	}
+	if since := q.Get("since"); since != "" {
+		opts.Since, err = time.Parse(time.RFC3339, since)
+		if err != nil {
+			opts.Since, err = time.Parse(time.DateOnly, since)
+		}
+		if err != nil {
+			return opts, fmt.Errorf("incorrect since %q", since)
+		}
+	}
+
+	return opts, opts.Validate(sorts...)
+}
+
+// writePage responds with the items of the page, an empty list included, the
+// total count and the next cursor are sent in headers.
+func writePage[T any](w http.ResponseWriter, page *types.Page[T]) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(types.HeaderTotalCount, strconv.FormatInt(page.Total, 10))
+	if page.Next != "" {
+		w.Header().Set(types.HeaderNextCursor, page.Next)
+	}
+	w.WriteHeader(http.StatusOK)
+
+	items := page.Items
+	if items == nil {
+		items = []T{}
+	}
+	err := json.NewEncoder(w).Encode(items)
+	if err != nil {
+		http.Error(w, "Can't marshal data: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"keeper-project/internal/mocks"
+	"keeper-project/types"
+)
+
+var defaultList = types.ListOptions{Limit: types.DefaultPageSize}
+
+func Test_listOptions(t *testing.T) {
+	cursor := types.Cursor{Sort: types.SortUpdated, Desc: true, Value: []byte(`"2024-05-02T00:00:00Z"`), ID: "id"}.Encode()
+
+	tests := []struct {
+		name    string
+		query   string
+		want    types.ListOptions
+		wantErr bool
+	}{
+		{
+			name:  "defaults",
+			query: "",
+			want:  defaultList,
+		},
+		{
+			name:  "cursor page",
+			query: "?sort=updated&order=desc&limit=10&cursor=" + cursor + "&since=2024-05-01",
+			want: types.ListOptions{Sort: types.SortUpdated, Desc: true, Limit: 10, Cursor: cursor,
+				Since: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:  "since time",
+			query: "?since=2024-05-01T10:00:00Z",
+			want:  types.ListOptions{Limit: types.DefaultPageSize, Since: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:    "cursor of another sort",
+			query:   "?sort=created&cursor=" + cursor,
+			wantErr: true,
+		},
+		{
+			name:    "cursor with offset",
+			query:   "?sort=updated&order=desc&offset=5&cursor=" + cursor,
+			wantErr: true,
+		},
+		{
+			name:    "unknown sort",
+			query:   "?sort=size",
+			wantErr: true,
+		},
+		{
+			name:    "names are encrypted",
+			query:   "?sort=name",
+			wantErr: true,
+		},
+		{
+			name:    "limit too large",
+			query:   "?limit=100000",
+			wantErr: true,
+		},
+		{
+			name:    "incorrect since",
+			query:   "?since=yesterday",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/secret/texts"+tt.query, nil)
+			opts, err := listOptions(r, secretSorts...)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, opts)
+		})
+	}
+}
+
+func Test_router_listHeaders(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mocksSecret := mocks.NewMockNotesSecret(mockCtrl)
+	mocksSecret.EXPECT().GetKeysList(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83",
+		types.ListOptions{Sort: types.SortUpdated, Limit: 1}).
+		Return(&types.Page[types.Key]{Items: []types.Key{{Id: "a", Key: "b"}}, Total: 3, Next: "next"}, nil)
+
+	ts := httptest.NewServer(SetupRouter(logger, nil, mocksSecret, nil, nil, nil))
+	defer ts.Close()
+
+	res, body := testAuthorizedRequest(t, ts, http.MethodGet, "/api/secret/texts?sort=updated&limit=1", validToken, nil)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "[{\"id\":\"a\",\"key\":\"b\"}]\n", body)
+	assert.Equal(t, "3", res.Header.Get(types.HeaderTotalCount))
+	assert.Equal(t, "next", res.Header.Get(types.HeaderNextCursor))
+}
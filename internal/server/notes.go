@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
@@ -36,8 +37,15 @@ func (ro *router) createNote(w http.ResponseWriter, r *http.Request) {
 
 	id := uuid.NewV4().String()
 
-	err = ro.notesRepo.Create(r.Context(), userID, id, note)
+	err = ro.createInQuota(r.Context(), userID, types.KindNote, func(ctx context.Context) error {
+		return ro.saveIndexed(ctx, userID, types.KindNote, id, req.SearchTokens, func(ctx context.Context) error {
+			return ro.notesRepo.Create(ctx, userID, id, note)
+		})
+	})
 	if err != nil {
+		if quotaExceeded(w, err) {
+			return
+		}
 		http.Error(w, "failed to create: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -81,23 +89,23 @@ func (ro *router) getNotesKeys(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	noteKeys, err := ro.notesRepo.GetKeysList(r.Context(), userID)
+	opts, err := listOptions(r, secretSorts...)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			http.Error(w, "no recorded notes found", http.StatusNotFound)
-			return
-		}
-		http.Error(w, "failed to get from db: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "incorrect list parameters: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	err = json.NewEncoder(w).Encode(noteKeys)
+	page, err := ro.notesRepo.GetKeysList(r.Context(), userID, opts)
 	if err != nil {
-		http.Error(w, "Can't marshal data: "+err.Error(), http.StatusInternalServerError)
+		if errors.Is(err, types.ErrInvalidCursor) {
+			http.Error(w, "incorrect list parameters: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "failed to get from db: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+
+	writePage(w, page)
 }
 
 func (ro *router) updateNote(w http.ResponseWriter, r *http.Request) {
@@ -121,7 +129,9 @@ func (ro *router) updateNote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = ro.notesRepo.Update(r.Context(), userID, req.ID, note)
+	err = ro.saveIndexed(r.Context(), userID, types.KindNote, req.ID, req.SearchTokens, func(ctx context.Context) error {
+		return ro.notesRepo.Update(ctx, userID, req.ID, note)
+	})
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			http.Error(w, "nothing to update", http.StatusNotFound)
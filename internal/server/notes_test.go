@@ -257,9 +257,9 @@ func Test_router_notes_list(t *testing.T) {
 		Key: "test_key"},
 	}
 
-	mocksSecret.EXPECT().GetKeysList(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83").Return(notesList, nil).Times(1)
-	mocksSecret.EXPECT().GetKeysList(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83").Return(nil, sql.ErrNoRows).Times(1)
-	mocksSecret.EXPECT().GetKeysList(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83").Return(nil, sql.ErrConnDone).Times(1)
+	mocksSecret.EXPECT().GetKeysList(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", defaultList).Return(&types.Page[types.Key]{Items: notesList, Total: 1}, nil).Times(1)
+	mocksSecret.EXPECT().GetKeysList(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", defaultList).Return(&types.Page[types.Key]{Items: []types.Key{}}, nil).Times(1)
+	mocksSecret.EXPECT().GetKeysList(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", defaultList).Return(nil, sql.ErrConnDone).Times(1)
 
 	ts := httptest.NewServer(SetupRouter(logger, nil, mocksSecret, nil, nil, nil))
 	defer ts.Close()
@@ -296,15 +296,15 @@ func Test_router_notes_list(t *testing.T) {
 			},
 		},
 		{
-			name:   "failed test #2 not found",
+			name:   "positive test #2 empty list",
 			method: http.MethodGet,
 			target: "/api/secret/texts",
 			token:  validToken,
 			want: want{
-				code:          404,
+				code:          200,
 				emptyResponse: false,
-				response:      "no recorded notes found\n",
-				contentType:   "text/plain; charset=utf-8",
+				response:      "[]\n",
+				contentType:   "application/json",
 			},
 		},
 		{
@@ -17,10 +17,15 @@ import (
 	"golang.org/x/crypto/sha3"
 
 	"keeper-project/internal/auth"
+	"keeper-project/internal/openapi"
 	"keeper-project/internal/store"
 	"keeper-project/types"
 )
 
+// maxSecretSize limits JSON bodies of the text, card, credentials and file
+// metadata endpoints.
+const maxSecretSize = 32 * units.MiB
+
 type router struct {
 	logger      *zap.Logger
 	userRepo    store.User
@@ -28,6 +33,33 @@ type router struct {
 	credsRepo   store.Secrets[types.Credentials]
 	cardsRepo   store.Secrets[types.CardInfo]
 	fileService store.FileService
+	quotas      store.Quotas
+	tx          store.Tx
+	searchRepo  store.Search
+}
+
+// Option configures optional router behaviour.
+type Option func(*router)
+
+// WithQuotas limits what users can store and enables the usage endpoint.
+func WithQuotas(quotas store.Quotas) Option {
+	return func(ro *router) {
+		ro.quotas = quotas
+	}
+}
+
+// WithTx enables the batch endpoint, its operations run in transactions of tx.
+func WithTx(tx store.Tx) Option {
+	return func(ro *router) {
+		ro.tx = tx
+	}
+}
+
+// WithSearch enables the search endpoints and keeps the search tokens sent with the records.
+func WithSearch(search store.Search) Option {
+	return func(ro *router) {
+		ro.searchRepo = search
+	}
 }
 
 func SetupRouter(logger *zap.Logger,
@@ -35,7 +67,8 @@ func SetupRouter(logger *zap.Logger,
 	notesRepo store.Secrets[types.Note],
 	credsRepo store.Secrets[types.Credentials],
 	cardsRepo store.Secrets[types.CardInfo],
-	fileService store.FileService) http.Handler {
+	fileService store.FileService,
+	opts ...Option) http.Handler {
 	ro := &router{
 		logger:      logger,
 		userRepo:    user,
@@ -44,6 +77,9 @@ func SetupRouter(logger *zap.Logger,
 		credsRepo:   credsRepo,
 		fileService: fileService,
 	}
+	for _, opt := range opts {
+		opt(ro)
+	}
 	return ro.Handler()
 }
 
@@ -58,31 +94,61 @@ func (ro *router) Handler() http.Handler {
 		fs := http.StripPrefix(pathPrefix, http.FileServer(filesDir))
 		fs.ServeHTTP(w, r)
 	})
+	rtr.Get("/api/openapi.json", openapi.ServeSpec)
 	rtr.Post("/api/user/register", ro.register)
 	rtr.Post("/api/user/login", ro.auth)
+	rtr.Group(func(r chi.Router) {
+		r.Use(jwtauth.Verifier(auth.TokenAuth))
+		r.Use(jwtauth.Authenticator)
+		r.Get("/api/user/usage", ro.getUsage)
+	})
 	rtr.Route("/api/secret", func(r chi.Router) {
 		r.Use(jwtauth.Verifier(auth.TokenAuth))
 		r.Use(jwtauth.Authenticator)
-		r.Use(middleware.RequestSize(32 * units.MiB))
-		r.Post("/text", ro.createNote)
-		r.Get("/text/{id}", ro.getNote)
-		r.Get("/texts", ro.getNotesKeys)
-		r.Put("/text", ro.updateNote)
-		r.Delete("/text/{id}", ro.deleteNote)
-		r.Post("/card", ro.createCard)
-		r.Get("/card/{id}", ro.getCardInfo)
-		r.Get("/cards", ro.getCardsList)
-		r.Put("/card", ro.updateCard)
-		r.Delete("/card/{id}", ro.deleteCard)
-		r.Post("/cred", ro.createCredentials)
-		r.Get("/cred/{id}", ro.getCredentials)
-		r.Get("/creds", ro.getSites)
-		r.Put("/cred", ro.updateCredentials)
-		r.Delete("/cred/{id}", ro.deleteCredentials)
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequestSize(maxSecretSize))
+			r.Post("/text", ro.createNote)
+			r.Get("/text/{id}", ro.getNote)
+			r.Get("/texts", ro.getNotesKeys)
+			r.Put("/text", ro.updateNote)
+			r.Delete("/text/{id}", ro.deleteNote)
+			r.Post("/card", ro.createCard)
+			r.Get("/card/{id}", ro.getCardInfo)
+			r.Get("/cards", ro.getCardsList)
+			r.Put("/card", ro.updateCard)
+			r.Delete("/card/{id}", ro.deleteCard)
+			r.Post("/cred", ro.createCredentials)
+			r.Get("/cred/{id}", ro.getCredentials)
+			r.Get("/creds", ro.getSites)
+			r.Put("/cred", ro.updateCredentials)
+			r.Delete("/cred/{id}", ro.deleteCredentials)
+			r.Post("/batch", ro.batch)
+			r.Post("/file/link", ro.linkFile)
+			r.Post("/upload", ro.createUpload)
+			r.Post("/upload/{id}/complete", ro.completeUpload)
+		})
 		r.Post("/file", ro.createFile)
+		r.Head("/blob/{hash}", ro.hasBlob)
 		r.Get("/file/{id}", ro.getFile)
 		r.Get("/files", ro.getFiles)
 		r.Delete("/file/{id}", ro.deleteFile)
+		r.Get("/upload/{id}", ro.getUpload)
+		r.Put("/upload/{id}/{number}", ro.putChunk)
+		r.Delete("/upload/{id}", ro.abortUpload)
+	})
+	rtr.Route("/api/search", func(r chi.Router) {
+		r.Use(jwtauth.Verifier(auth.TokenAuth))
+		r.Use(jwtauth.Authenticator)
+		r.Use(middleware.RequestSize(maxSecretSize))
+		r.Post("/", ro.searchSecrets)
+		r.Put("/{kind}/{id}", ro.setSearchTokens)
+	})
+	rtr.Route("/api/trash", func(r chi.Router) {
+		r.Use(jwtauth.Verifier(auth.TokenAuth))
+		r.Use(jwtauth.Authenticator)
+		r.Get("/", ro.getTrash)
+		r.Post("/{kind}/{id}/restore", ro.restoreFromTrash)
+		r.Delete("/", ro.emptyTrash)
 	})
 	return rtr
 }
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"keeper-project/internal/auth"
+	"keeper-project/types"
+)
+
+func (ro *router) searchSecrets(w http.ResponseWriter, r *http.Request) {
+	if ro.searchRepo == nil {
+		http.Error(w, "search is not supported", http.StatusNotImplemented)
+		return
+	}
+
+	var req types.SearchRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Unable to decode json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userID, err := auth.GetUserID(r)
+	if err != nil {
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	err = req.Validate()
+	if err != nil {
+		http.Error(w, "incorrect data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	hits, err := ro.searchRepo.Search(r.Context(), userID, req)
+	if err != nil {
+		http.Error(w, "failed to search: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if hits == nil {
+		hits = []types.SearchHit{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(w).Encode(hits)
+	if err != nil {
+		http.Error(w, "Can't marshal data: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// setSearchTokens replaces the tokens of a stored record, clients use it to
+// index the records saved before they sent tokens.
+func (ro *router) setSearchTokens(w http.ResponseWriter, r *http.Request) {
+	if ro.searchRepo == nil {
+		http.Error(w, "search is not supported", http.StatusNotImplemented)
+		return
+	}
+
+	var req types.SearchTokensRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Unable to decode json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userID, err := auth.GetUserID(r)
+	if err != nil {
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	err = types.ValidateSearchTokens(req.Tokens)
+	if err != nil {
+		http.Error(w, "incorrect data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	kind, id := chi.URLParam(r, "kind"), chi.URLParam(r, "id")
+	switch kind {
+	case types.KindNote:
+		_, err = ro.notesRepo.Get(r.Context(), userID, id)
+	case types.KindCard:
+		_, err = ro.cardsRepo.Get(r.Context(), userID, id)
+	case types.KindCredentials:
+		_, err = ro.credsRepo.Get(r.Context(), userID, id)
+	default:
+		http.Error(w, "unknown kind", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "no such record", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to get from db: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = ro.searchRepo.SetTokens(r.Context(), userID, kind, id, req.Tokens)
+	if err != nil {
+		http.Error(w, "failed to save search tokens: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// saveIndexed runs save and replaces the search tokens of the record, in one
+// transaction when the router has one. The tokens are kept as they are when
+// the request has none.
+func (ro *router) saveIndexed(ctx context.Context, userID, kind, id string, tokens []string, save func(ctx context.Context) error) error {
+	if tokens == nil || ro.searchRepo == nil {
+		return save(ctx)
+	}
+
+	index := func(ctx context.Context) error {
+		err := save(ctx)
+		if err != nil {
+			return err
+		}
+		return ro.searchRepo.SetTokens(ctx, userID, kind, id, tokens)
+	}
+	if ro.tx == nil {
+		return index(ctx)
+	}
+	return ro.tx.InTx(ctx, index)
+}
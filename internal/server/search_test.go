@@ -0,0 +1,168 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"keeper-project/internal/mocks"
+	"keeper-project/types"
+)
+
+func Test_router_searchSecrets(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	search := mocks.NewMockSearch(mockCtrl)
+
+	ts := httptest.NewServer(SetupRouter(logger, nil, nil, nil, nil, nil, WithSearch(search)))
+	defer ts.Close()
+
+	t.Run("positive test #1", func(t *testing.T) {
+		search.EXPECT().Search(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", types.SearchRequest{
+			Tokens:   []string{"aaa", "bbb"},
+			MinMatch: 2,
+			Kinds:    []string{types.KindNote},
+			Limit:    types.DefaultPageSize,
+		}).Return([]types.SearchHit{{Kind: types.KindNote, ID: batchNoteID, Matched: 2}}, nil)
+
+		res, body := testAuthorizedRequest(t, ts, http.MethodPost, "/api/search", validToken,
+			[]byte(`{"tokens":["aaa","bbb"],"kinds":["text"]}`))
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.JSONEq(t, `[{"kind":"text","id":"`+batchNoteID+`","matched":2}]`, body)
+	})
+
+	t.Run("positive test #2 no hits", func(t *testing.T) {
+		search.EXPECT().Search(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
+
+		res, body := testAuthorizedRequest(t, ts, http.MethodPost, "/api/search", validToken, []byte(`{"tokens":["aaa"]}`))
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.JSONEq(t, `[]`, body)
+	})
+
+	t.Run("failed test #1 invalid token", func(t *testing.T) {
+		res, _ := testAuthorizedRequest(t, ts, http.MethodPost, "/api/search", invalidToken, []byte(`{"tokens":["aaa"]}`))
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("failed test #2 invalid data", func(t *testing.T) {
+		res, body := testAuthorizedRequest(t, ts, http.MethodPost, "/api/search", validToken, []byte(`{"tokens":["a b"]}`))
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		assert.Equal(t, "incorrect data: incorrect search token\n", body)
+	})
+
+	t.Run("failed test #3 unknown kind", func(t *testing.T) {
+		res, body := testAuthorizedRequest(t, ts, http.MethodPost, "/api/search", validToken,
+			[]byte(`{"tokens":["aaa"],"kinds":["file"]}`))
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		assert.Equal(t, "incorrect data: unknown kind \"file\"\n", body)
+	})
+
+	t.Run("failed test #4 sql error", func(t *testing.T) {
+		search.EXPECT().Search(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, sql.ErrConnDone)
+
+		res, body := testAuthorizedRequest(t, ts, http.MethodPost, "/api/search", validToken, []byte(`{"tokens":["aaa"]}`))
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+		assert.Equal(t, "failed to search: sql: connection is already closed\n", body)
+	})
+}
+
+func Test_router_setSearchTokens(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	notes := mocks.NewMockNotesSecret(mockCtrl)
+	search := mocks.NewMockSearch(mockCtrl)
+
+	ts := httptest.NewServer(SetupRouter(logger, nil, notes, nil, nil, nil, WithSearch(search)))
+	defer ts.Close()
+
+	t.Run("positive test #1", func(t *testing.T) {
+		notes.EXPECT().Get(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", batchNoteID).Return(&types.Note{}, nil)
+		search.EXPECT().SetTokens(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", types.KindNote, batchNoteID, []string{"aaa"}).Return(nil)
+
+		res, body := testAuthorizedRequest(t, ts, http.MethodPut, "/api/search/text/"+batchNoteID, validToken, []byte(`{"tokens":["aaa"]}`))
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+		require.Empty(t, body)
+	})
+
+	t.Run("failed test #1 no such record", func(t *testing.T) {
+		notes.EXPECT().Get(gomock.Any(), gomock.Any(), batchNoteID).Return(nil, sql.ErrNoRows)
+
+		res, body := testAuthorizedRequest(t, ts, http.MethodPut, "/api/search/text/"+batchNoteID, validToken, []byte(`{"tokens":["aaa"]}`))
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+		assert.Equal(t, "no such record\n", body)
+	})
+
+	t.Run("failed test #2 unknown kind", func(t *testing.T) {
+		res, _ := testAuthorizedRequest(t, ts, http.MethodPut, "/api/search/file/"+batchNoteID, validToken, []byte(`{"tokens":["aaa"]}`))
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+}
+
+func Test_router_searchNotSupported(t *testing.T) {
+	ts := httptest.NewServer(SetupRouter(logger, nil, nil, nil, nil, nil))
+	defer ts.Close()
+
+	res, body := testAuthorizedRequest(t, ts, http.MethodPost, "/api/search", validToken, []byte(`{"tokens":["aaa"]}`))
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusNotImplemented, res.StatusCode)
+	assert.Equal(t, "search is not supported\n", body)
+}
+
+func Test_router_notes_createIndexed(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	notes := mocks.NewMockNotesSecret(mockCtrl)
+	search := mocks.NewMockSearch(mockCtrl)
+	tx := &fakeTx{}
+
+	ts := httptest.NewServer(SetupRouter(logger, nil, notes, nil, nil, nil, WithTx(tx), WithSearch(search)))
+	defer ts.Close()
+
+	t.Run("positive test #1", func(t *testing.T) {
+		notes.EXPECT().Create(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", gomock.Any(), gomock.Any()).Return(nil)
+		search.EXPECT().SetTokens(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", types.KindNote, gomock.Any(), []string{"aaa", "bbb"}).Return(nil)
+
+		res, _ := testAuthorizedRequest(t, ts, http.MethodPost, "/api/secret/text", validToken,
+			[]byte(`{"key":"a","data":"1","search_tokens":["aaa","bbb"]}`))
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusAccepted, res.StatusCode)
+		assert.True(t, tx.committed)
+	})
+
+	t.Run("positive test #2 no tokens", func(t *testing.T) {
+		notes.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+		res, _ := testAuthorizedRequest(t, ts, http.MethodPost, "/api/secret/text", validToken, []byte(`{"key":"a","data":"1"}`))
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusAccepted, res.StatusCode)
+	})
+
+	t.Run("failed test #1 tokens are rolled back with the record", func(t *testing.T) {
+		notes.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+		search.EXPECT().SetTokens(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("sql error"))
+
+		res, _ := testAuthorizedRequest(t, ts, http.MethodPost, "/api/secret/text", validToken,
+			[]byte(`{"key":"a","data":"1","search_tokens":["aaa"]}`))
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+		assert.False(t, tx.committed)
+	})
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/go-chi/chi/v5"
+
+	"keeper-project/internal/auth"
+	"keeper-project/internal/store"
+	"keeper-project/types"
+)
+
+// trashes returns every configured store that supports soft delete keyed by its kind.
+func (ro *router) trashes() map[string]store.Trash {
+	ret := make(map[string]store.Trash)
+	if ro.notesRepo != nil {
+		ret[types.KindNote] = ro.notesRepo
+	}
+	if ro.cardsRepo != nil {
+		ret[types.KindCard] = ro.cardsRepo
+	}
+	if ro.credsRepo != nil {
+		ret[types.KindCredentials] = ro.credsRepo
+	}
+	if ro.fileService != nil {
+		ret[types.KindFile] = ro.fileService
+	}
+	return ret
+}
+
+func (ro *router) getTrash(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserID(r)
+	if err != nil {
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	items := make([]types.TrashItem, 0)
+	for kind, trash := range ro.trashes() {
+		deleted, err := trash.GetDeletedList(r.Context(), userID)
+		if err != nil {
+			http.Error(w, "failed to get trash: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for _, item := range deleted {
+			item.Kind = kind
+			items = append(items, item)
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+
+	// the trash is emptied by the purger, so it is sent in a single page
+	writePage(w, &types.Page[types.TrashItem]{Items: items, Total: int64(len(items))})
+}
+
+func (ro *router) restoreFromTrash(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserID(r)
+	if err != nil {
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	trash, ok := ro.trashes()[chi.URLParam(r, "kind")]
+	if !ok {
+		http.Error(w, "unknown record kind", http.StatusNotFound)
+		return
+	}
+
+	err = trash.Restore(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, types.ErrNotFound) {
+			http.Error(w, "nothing to restore", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to restore: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (ro *router) emptyTrash(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserID(r)
+	if err != nil {
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	for _, trash := range ro.trashes() {
+		err = trash.EmptyTrash(r.Context(), userID)
+		if err != nil {
+			http.Error(w, "failed to empty trash: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,292 @@
+package server
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"keeper-project/internal/mocks"
+	"keeper-project/types"
+)
+
+func Test_router_trash_list(t *testing.T) {
+	type want struct {
+		code          int
+		emptyResponse bool
+		response      string
+		contentType   string
+	}
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockNotes := mocks.NewMockNotesSecret(mockCtrl)
+	mockFileService := mocks.NewMockFileService(mockCtrl)
+
+	noteDeleted := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	fileDeleted := time.Date(2024, 6, 2, 12, 0, 0, 0, time.UTC)
+
+	mockNotes.EXPECT().GetDeletedList(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83").Return(
+		[]types.TrashItem{{Id: "note", Key: "title", DeletedAt: noteDeleted}}, nil).Times(1)
+	mockFileService.EXPECT().GetDeletedList(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83").Return(
+		[]types.TrashItem{{Id: "file", Key: "name", DeletedAt: fileDeleted}}, nil).Times(1)
+	mockNotes.EXPECT().GetDeletedList(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83").Return(
+		nil, sql.ErrConnDone).Times(1)
+	mockFileService.EXPECT().GetDeletedList(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83").Return(
+		nil, nil).MaxTimes(1)
+
+	ts := httptest.NewServer(SetupRouter(logger, nil, mockNotes, nil, nil, mockFileService))
+	defer ts.Close()
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		token  string
+		want   want
+	}{
+		{
+			name:   "positive test #1",
+			method: http.MethodGet,
+			target: "/api/trash",
+			token:  validToken,
+			want: want{
+				code: 200,
+				response: `[{"kind":"file","id":"file","key":"name","deleted_at":"2024-06-02T12:00:00Z"},` +
+					`{"kind":"text","id":"note","key":"title","deleted_at":"2024-06-01T12:00:00Z"}]` + "\n",
+				contentType: "application/json",
+			},
+		},
+		{
+			name:   "failed test #1 invalid token",
+			method: http.MethodGet,
+			target: "/api/trash",
+			token:  invalidToken,
+			want: want{
+				code:        401,
+				response:    "Unauthorized: invalid token\n",
+				contentType: "text/plain; charset=utf-8",
+			},
+		},
+		{
+			name:   "failed test #2 sql error",
+			method: http.MethodGet,
+			target: "/api/trash",
+			token:  validToken,
+			want: want{
+				code:        500,
+				response:    "failed to get trash: sql: connection is already closed\n",
+				contentType: "text/plain; charset=utf-8",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, body := testAuthorizedRequest(t, ts, tt.method, tt.target, tt.token, nil)
+			defer res.Body.Close()
+			assert.Equal(t, tt.want.code, res.StatusCode)
+
+			if tt.want.emptyResponse {
+				require.Empty(t, body)
+			} else {
+				assert.Equal(t, tt.want.response, body)
+			}
+
+			assert.Equal(t, tt.want.contentType, res.Header.Get("Content-Type"))
+		})
+	}
+}
+
+func Test_router_trash_restore(t *testing.T) {
+	type want struct {
+		code          int
+		emptyResponse bool
+		response      string
+		contentType   string
+	}
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockCreds := mocks.NewMockCredsSecret(mockCtrl)
+	mockFileService := mocks.NewMockFileService(mockCtrl)
+
+	mockCreds.EXPECT().Restore(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", "test").Return(nil).Times(1)
+	mockCreds.EXPECT().Restore(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", "test").Return(sql.ErrNoRows).Times(1)
+	mockFileService.EXPECT().Restore(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", "test").Return(types.ErrNotFound).Times(1)
+	mockFileService.EXPECT().Restore(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", "test").Return(sql.ErrConnDone).Times(1)
+
+	ts := httptest.NewServer(SetupRouter(logger, nil, nil, mockCreds, nil, mockFileService))
+	defer ts.Close()
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		token  string
+		want   want
+	}{
+		{
+			name:   "positive test #1",
+			method: http.MethodPost,
+			target: "/api/trash/cred/test/restore",
+			token:  validToken,
+			want: want{
+				code:          200,
+				emptyResponse: true,
+			},
+		},
+		{
+			name:   "failed test #1 invalid token",
+			method: http.MethodPost,
+			target: "/api/trash/cred/test/restore",
+			token:  invalidToken,
+			want: want{
+				code:        401,
+				response:    "Unauthorized: invalid token\n",
+				contentType: "text/plain; charset=utf-8",
+			},
+		},
+		{
+			name:   "failed test #2 unknown kind",
+			method: http.MethodPost,
+			target: "/api/trash/card/test/restore",
+			token:  validToken,
+			want: want{
+				code:        404,
+				response:    "unknown record kind\n",
+				contentType: "text/plain; charset=utf-8",
+			},
+		},
+		{
+			name:   "failed test #3 not in trash",
+			method: http.MethodPost,
+			target: "/api/trash/cred/test/restore",
+			token:  validToken,
+			want: want{
+				code:        404,
+				response:    "nothing to restore\n",
+				contentType: "text/plain; charset=utf-8",
+			},
+		},
+		{
+			name:   "failed test #4 file not in trash",
+			method: http.MethodPost,
+			target: "/api/trash/file/test/restore",
+			token:  validToken,
+			want: want{
+				code:        404,
+				response:    "nothing to restore\n",
+				contentType: "text/plain; charset=utf-8",
+			},
+		},
+		{
+			name:   "failed test #5 storage error",
+			method: http.MethodPost,
+			target: "/api/trash/file/test/restore",
+			token:  validToken,
+			want: want{
+				code:        500,
+				response:    "failed to restore: sql: connection is already closed\n",
+				contentType: "text/plain; charset=utf-8",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, body := testAuthorizedRequest(t, ts, tt.method, tt.target, tt.token, nil)
+			defer res.Body.Close()
+			assert.Equal(t, tt.want.code, res.StatusCode)
+
+			if tt.want.emptyResponse {
+				require.Empty(t, body)
+			} else {
+				assert.Equal(t, tt.want.response, body)
+			}
+
+			assert.Equal(t, tt.want.contentType, res.Header.Get("Content-Type"))
+		})
+	}
+}
+
+func Test_router_trash_empty(t *testing.T) {
+	type want struct {
+		code          int
+		emptyResponse bool
+		response      string
+		contentType   string
+	}
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockCards := mocks.NewMockCardSecret(mockCtrl)
+
+	mockCards.EXPECT().EmptyTrash(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83").Return(nil).Times(1)
+	mockCards.EXPECT().EmptyTrash(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83").Return(sql.ErrConnDone).Times(1)
+
+	ts := httptest.NewServer(SetupRouter(logger, nil, nil, nil, mockCards, nil))
+	defer ts.Close()
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		token  string
+		want   want
+	}{
+		{
+			name:   "positive test #1",
+			method: http.MethodDelete,
+			target: "/api/trash",
+			token:  validToken,
+			want: want{
+				code:          204,
+				emptyResponse: true,
+			},
+		},
+		{
+			name:   "failed test #1 invalid token",
+			method: http.MethodDelete,
+			target: "/api/trash",
+			token:  invalidToken,
+			want: want{
+				code:        401,
+				response:    "Unauthorized: invalid token\n",
+				contentType: "text/plain; charset=utf-8",
+			},
+		},
+		{
+			name:   "failed test #2 sql error",
+			method: http.MethodDelete,
+			target: "/api/trash",
+			token:  validToken,
+			want: want{
+				code:        500,
+				response:    "failed to empty trash: sql: connection is already closed\n",
+				contentType: "text/plain; charset=utf-8",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, body := testAuthorizedRequest(t, ts, tt.method, tt.target, tt.token, nil)
+			defer res.Body.Close()
+			assert.Equal(t, tt.want.code, res.StatusCode)
+
+			if tt.want.emptyResponse {
+				require.Empty(t, body)
+			} else {
+				assert.Equal(t, tt.want.response, body)
+			}
+
+			assert.Equal(t, tt.want.contentType, res.Header.Get("Content-Type"))
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"keeper-project/internal/auth"
+	"keeper-project/types"
+)
+
+// Resumable uploads: a session is created with the file name and size, numbered
+// chunks are PUT with a Content-MD5 header in any order and retried at will,
+// GET reports the chunks received so far and complete assembles the file.
+
+func (ro *router) createUpload(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserID(r)
+	if err != nil {
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var req types.CreateUploadRequest
+
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Unable to decode json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = req.Validate()
+	if err != nil {
+		http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	upload, err := ro.fileService.CreateUpload(r.Context(), userID, req)
+	if err != nil {
+		if quotaExceeded(w, err) || fileTooLarge(w, err) {
+			return
+		}
+		http.Error(w, "unable to create upload: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeUpload(w, http.StatusCreated, upload)
+}
+
+func (ro *router) getUpload(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserID(r)
+	if err != nil {
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	upload, err := ro.fileService.GetUpload(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, types.ErrNotFound) {
+			http.Error(w, "upload not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "unable to get upload: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeUpload(w, http.StatusOK, upload)
+}
+
+func (ro *router) putChunk(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserID(r)
+	if err != nil {
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	number, err := strconv.Atoi(chi.URLParam(r, "number"))
+	if err != nil || number < 1 {
+		http.Error(w, "incorrect chunk number", http.StatusBadRequest)
+		return
+	}
+
+	chunk := types.UploadChunk{
+		Number: number,
+		Size:   r.ContentLength,
+		MD5:    r.Header.Get("Content-MD5"),
+		Reader: http.MaxBytesReader(w, r.Body, types.UploadChunkSize),
+	}
+	if chunk.MD5 == "" {
+		http.Error(w, "Content-MD5 header required", http.StatusBadRequest)
+		return
+	}
+	if chunk.Size < 0 {
+		http.Error(w, "Content-Length header required", http.StatusLengthRequired)
+		return
+	}
+
+	info, err := ro.fileService.UploadChunk(r.Context(), userID, chi.URLParam(r, "id"), chunk)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case errors.Is(err, types.ErrNotFound):
+			http.Error(w, "upload not found", http.StatusNotFound)
+		case errors.Is(err, types.ErrInvalidChunk), errors.Is(err, types.ErrChecksumMismatch):
+			http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+		case errors.As(err, &maxBytesErr):
+			http.Error(w, "chunk size limit exceeded", http.StatusRequestEntityTooLarge)
+		default:
+			http.Error(w, "unable to store chunk: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("ETag", info.ETag)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ro *router) completeUpload(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserID(r)
+	if err != nil {
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	upload, err := ro.fileService.CompleteUpload(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, types.ErrNotFound):
+			http.Error(w, "upload not found", http.StatusNotFound)
+		case errors.Is(err, types.ErrUploadIncomplete):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, types.ErrQuotaExceeded):
+			quotaExceeded(w, err)
+		case errors.Is(err, types.ErrFileTooLarge):
+			fileTooLarge(w, err)
+		default:
+			http.Error(w, "unable to complete upload: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeUpload(w, http.StatusCreated, upload)
+}
+
+func (ro *router) abortUpload(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserID(r)
+	if err != nil {
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	err = ro.fileService.AbortUpload(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, types.ErrNotFound) {
+			http.Error(w, "upload not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "unable to abort upload: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeUpload(w http.ResponseWriter, code int, upload *types.UploadSession) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(upload)
+}
@@ -0,0 +1,310 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"keeper-project/internal/mocks"
+	"keeper-project/types"
+)
+
+func Test_router_upload_create(t *testing.T) {
+	type want struct {
+		code        int
+		response    string
+		contentType string
+	}
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockFileService := mocks.NewMockFileService(mockCtrl)
+
+	created := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	mockFileService.EXPECT().CreateUpload(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83",
+		types.CreateUploadRequest{Name: "big.bin", Size: 100, Metadata: "meta"}).Return(&types.UploadSession{
+		ID: "upload", Name: "big.bin", Size: 100, ChunkSize: 50, Metadata: "meta", CreatedAt: created,
+		Chunks: []types.UploadChunkInfo{},
+	}, nil).Times(1)
+	mockFileService.EXPECT().CreateUpload(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83",
+		types.CreateUploadRequest{Name: "big.bin", Size: 1001}).Return(nil, fmt.Errorf("%w: 1000 bytes allowed", types.ErrFileTooLarge)).Times(1)
+	mockFileService.EXPECT().CreateUpload(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83",
+		gomock.Any()).Return(nil, errors.New("storage is down")).Times(1)
+
+	ts := httptest.NewServer(SetupRouter(logger, nil, nil, nil, nil, mockFileService))
+	defer ts.Close()
+
+	tests := []struct {
+		name   string
+		target string
+		token  string
+		body   string
+		want   want
+	}{
+		{
+			name:   "positive test #1",
+			target: "/api/secret/upload",
+			token:  validToken,
+			body:   `{"name":"big.bin","size":100,"metadata":"meta"}`,
+			want: want{
+				code: 201,
+				response: `{"id":"upload","name":"big.bin","size":100,"chunk_size":50,"metadata":"meta",` +
+					`"created_at":"2024-06-01T12:00:00Z","chunks":[],"offset":0}` + "\n",
+				contentType: "application/json",
+			},
+		},
+		{
+			name:   "failed test #1 invalid token",
+			target: "/api/secret/upload",
+			token:  invalidToken,
+			body:   `{"name":"big.bin","size":100}`,
+			want: want{
+				code:        401,
+				response:    "Unauthorized: invalid token\n",
+				contentType: "text/plain; charset=utf-8",
+			},
+		},
+		{
+			name:   "failed test #2 empty size",
+			target: "/api/secret/upload",
+			token:  validToken,
+			body:   `{"name":"big.bin"}`,
+			want: want{
+				code:        400,
+				response:    "Bad request: incorrect name or size\n",
+				contentType: "text/plain; charset=utf-8",
+			},
+		},
+		{
+			name:   "failed test #3 too large",
+			target: "/api/secret/upload",
+			token:  validToken,
+			body:   `{"name":"big.bin","size":1001}`,
+			want: want{
+				code:        413,
+				response:    "file_too_large: 1000 bytes allowed\n",
+				contentType: "text/plain; charset=utf-8",
+			},
+		},
+		{
+			name:   "failed test #4 storage error",
+			target: "/api/secret/upload",
+			token:  validToken,
+			body:   `{"name":"big.bin","size":10}`,
+			want: want{
+				code:        500,
+				response:    "unable to create upload: storage is down\n",
+				contentType: "text/plain; charset=utf-8",
+			},
+		},
+		{
+			name:   "failed test #5 body too large",
+			target: "/api/secret/upload",
+			token:  validToken,
+			body:   `{"name":"big.bin","size":10,"metadata":"` + strings.Repeat("a", maxSecretSize) + `"}`,
+			want: want{
+				code:        400,
+				response:    "Unable to decode json: http: request body too large\n",
+				contentType: "text/plain; charset=utf-8",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, body := testAuthorizedRequest(t, ts, http.MethodPost, tt.target, tt.token, []byte(tt.body))
+			defer res.Body.Close()
+			assert.Equal(t, tt.want.code, res.StatusCode)
+			assert.Equal(t, tt.want.response, body)
+			assert.Equal(t, tt.want.contentType, res.Header.Get("Content-Type"))
+		})
+	}
+}
+
+func Test_router_upload_chunk(t *testing.T) {
+	type want struct {
+		code     int
+		response string
+		etag     string
+	}
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockFileService := mocks.NewMockFileService(mockCtrl)
+
+	mockFileService.EXPECT().UploadChunk(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", "upload", gomock.Any()).DoAndReturn(
+		func(_ any, _, _ string, chunk types.UploadChunk) (*types.UploadChunkInfo, error) {
+			data, err := io.ReadAll(chunk.Reader)
+			require.NoError(t, err)
+			assert.Equal(t, "chunk", string(data))
+			assert.Equal(t, types.UploadChunk{Number: 2, Size: 5, MD5: "md5", Reader: chunk.Reader}, chunk)
+			return &types.UploadChunkInfo{Number: 2, Size: 5, ETag: "etag"}, nil
+		}).Times(1)
+	mockFileService.EXPECT().UploadChunk(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", "upload", gomock.Any()).Return(
+		nil, types.ErrChecksumMismatch).Times(1)
+	mockFileService.EXPECT().UploadChunk(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", "unknown", gomock.Any()).Return(
+		nil, types.ErrNotFound).Times(1)
+
+	ts := httptest.NewServer(SetupRouter(logger, nil, nil, nil, nil, mockFileService))
+	defer ts.Close()
+
+	tests := []struct {
+		name   string
+		target string
+		md5    string
+		want   want
+	}{
+		{
+			name:   "positive test #1",
+			target: "/api/secret/upload/upload/2",
+			md5:    "md5",
+			want: want{
+				code: 204,
+				etag: "etag",
+			},
+		},
+		{
+			name:   "failed test #1 checksum mismatch",
+			target: "/api/secret/upload/upload/2",
+			md5:    "md5",
+			want: want{
+				code:     400,
+				response: "Bad request: chunk checksum mismatch\n",
+			},
+		},
+		{
+			name:   "failed test #2 unknown upload",
+			target: "/api/secret/upload/unknown/1",
+			md5:    "md5",
+			want: want{
+				code:     404,
+				response: "upload not found\n",
+			},
+		},
+		{
+			name:   "failed test #3 no checksum",
+			target: "/api/secret/upload/upload/1",
+			want: want{
+				code:     400,
+				response: "Content-MD5 header required\n",
+			},
+		},
+		{
+			name:   "failed test #4 incorrect number",
+			target: "/api/secret/upload/upload/0",
+			md5:    "md5",
+			want: want{
+				code:     400,
+				response: "incorrect chunk number\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPut, ts.URL+tt.target, bytes.NewReader([]byte("chunk")))
+			require.NoError(t, err)
+			req.Header.Set("Authorization", validToken)
+			if tt.md5 != "" {
+				req.Header.Set("Content-MD5", tt.md5)
+			}
+
+			res, err := ts.Client().Do(req)
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			body, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want.code, res.StatusCode)
+			assert.Equal(t, tt.want.response, string(body))
+			assert.Equal(t, tt.want.etag, res.Header.Get("ETag"))
+		})
+	}
+}
+
+func Test_router_upload_complete(t *testing.T) {
+	type want struct {
+		code     int
+		response string
+	}
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockFileService := mocks.NewMockFileService(mockCtrl)
+
+	mockFileService.EXPECT().CompleteUpload(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", "upload").Return(
+		&types.UploadSession{ID: "upload", Name: "big.bin", Size: 5, ChunkSize: 5, CreatedAt: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+			Chunks: []types.UploadChunkInfo{{Number: 1, Size: 5, ETag: "etag"}}, Offset: 5}, nil).Times(1)
+	mockFileService.EXPECT().CompleteUpload(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", "partial").Return(
+		nil, types.ErrUploadIncomplete).Times(1)
+	mockFileService.EXPECT().AbortUpload(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", "upload").Return(nil).Times(1)
+	mockFileService.EXPECT().AbortUpload(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", "unknown").Return(types.ErrNotFound).Times(1)
+
+	ts := httptest.NewServer(SetupRouter(logger, nil, nil, nil, nil, mockFileService))
+	defer ts.Close()
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		want   want
+	}{
+		{
+			name:   "positive test #1 complete",
+			method: http.MethodPost,
+			target: "/api/secret/upload/upload/complete",
+			want: want{
+				code: 201,
+				response: `{"id":"upload","name":"big.bin","size":5,"chunk_size":5,"metadata":"",` +
+					`"created_at":"2024-06-01T12:00:00Z","chunks":[{"number":1,"size":5,"etag":"etag"}],"offset":5}` + "\n",
+			},
+		},
+		{
+			name:   "positive test #2 abort",
+			method: http.MethodDelete,
+			target: "/api/secret/upload/upload",
+			want: want{
+				code: 204,
+			},
+		},
+		{
+			name:   "failed test #1 incomplete",
+			method: http.MethodPost,
+			target: "/api/secret/upload/partial/complete",
+			want: want{
+				code:     409,
+				response: "upload is incomplete\n",
+			},
+		},
+		{
+			name:   "failed test #2 abort unknown",
+			method: http.MethodDelete,
+			target: "/api/secret/upload/unknown",
+			want: want{
+				code:     404,
+				response: "upload not found\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, body := testAuthorizedRequest(t, ts, tt.method, tt.target, validToken, nil)
+			defer res.Body.Close()
+			assert.Equal(t, tt.want.code, res.StatusCode)
+			assert.Equal(t, tt.want.response, body)
+		})
+	}
+}
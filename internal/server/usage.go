@@ -0,0 +1,76 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"keeper-project/internal/auth"
+	"keeper-project/types"
+)
+
+func (ro *router) getUsage(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserID(r)
+	if err != nil {
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if ro.quotas == nil {
+		http.Error(w, "usage is not tracked", http.StatusNotFound)
+		return
+	}
+
+	usage, err := ro.quotas.GetUsage(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "failed to get usage: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(w).Encode(usage)
+	if err != nil {
+		http.Error(w, "Can't marshal data: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// createInQuota runs create when one more record of the kind fits the quota of
+// the user. With a transaction the quota stays locked until create is done, so
+// concurrent requests can't exceed it together.
+func (ro *router) createInQuota(ctx context.Context, userID, kind string, create func(ctx context.Context) error) error {
+	if ro.quotas == nil {
+		return create(ctx)
+	}
+
+	check := func(ctx context.Context, getUsage func(ctx context.Context, userID string) (*types.Usage, error)) error {
+		usage, err := getUsage(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to check quota: %w", err)
+		}
+		err = usage.Check(kind, 0)
+		if err != nil {
+			return err
+		}
+		return create(ctx)
+	}
+	if ro.tx == nil {
+		return check(ctx, ro.quotas.GetUsage)
+	}
+	return ro.tx.InTx(ctx, func(ctx context.Context) error {
+		return check(ctx, ro.quotas.LockUsage)
+	})
+}
+
+// quotaExceeded reports whether err is a quota error and responds with it,
+// the body starts with the quota_exceeded code.
+func quotaExceeded(w http.ResponseWriter, err error) bool {
+	if !errors.Is(err, types.ErrQuotaExceeded) {
+		return false
+	}
+	http.Error(w, err.Error(), http.StatusForbidden)
+	return true
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"keeper-project/internal/mocks"
+	"keeper-project/types"
+)
+
+func Test_router_usage(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockQuotas := mocks.NewMockQuotas(mockCtrl)
+
+	usage := &types.Usage{Files: 1, FileBytes: 10, Notes: 2, Quota: types.Quota{FileBytes: 100}}
+
+	mockQuotas.EXPECT().GetUsage(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83").Return(usage, nil).Times(1)
+	mockQuotas.EXPECT().GetUsage(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83").Return(nil, errors.New("sql error")).Times(1)
+
+	ts := httptest.NewServer(SetupRouter(logger, nil, nil, nil, nil, nil, WithQuotas(mockQuotas)))
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		token    string
+		code     int
+		response string
+	}{
+		{
+			name:     "positive test #1",
+			token:    validToken,
+			code:     200,
+			response: `{"files":1,"file_bytes":10,"notes":2,"cards":0,"credentials":0,"quota":{"file_bytes":100,"notes":0,"cards":0,"credentials":0,"max_file_size":0}}` + "\n",
+		},
+		{
+			name:     "failed test #1 invalid token",
+			token:    invalidToken,
+			code:     401,
+			response: "Unauthorized: invalid token\n",
+		},
+		{
+			name:     "failed test #2 sql error",
+			token:    validToken,
+			code:     500,
+			response: "failed to get usage: sql error\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, body := testAuthorizedRequest(t, ts, http.MethodGet, "/api/user/usage", tt.token, nil)
+			defer res.Body.Close()
+			assert.Equal(t, tt.code, res.StatusCode)
+			assert.Equal(t, tt.response, body)
+		})
+	}
+}
+
+func Test_router_quota_exceeded(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockQuotas := mocks.NewMockQuotas(mockCtrl)
+	mocksSecret := mocks.NewMockNotesSecret(mockCtrl)
+
+	mockQuotas.EXPECT().GetUsage(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83").Return(
+		&types.Usage{Notes: 10, Quota: types.Quota{Notes: 10}}, nil).Times(1)
+
+	ts := httptest.NewServer(SetupRouter(logger, nil, mocksSecret, nil, nil, nil, WithQuotas(mockQuotas)))
+	defer ts.Close()
+
+	res, body := testAuthorizedRequest(t, ts, http.MethodPost, "/api/secret/text", validToken,
+		[]byte(`{"key":"123321","text":"test","metadata":"test_meta"}`))
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	assert.Equal(t, "quota_exceeded: 10 notes allowed\n", body)
+}
+
+func Test_router_usage_not_tracked(t *testing.T) {
+	ts := httptest.NewServer(SetupRouter(logger, nil, nil, nil, nil, nil))
+	defer ts.Close()
+
+	res, _ := testAuthorizedRequest(t, ts, http.MethodGet, "/api/user/usage", validToken, nil)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func Test_router_quota_locked(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockQuotas := mocks.NewMockQuotas(mockCtrl)
+	mocksSecret := mocks.NewMockNotesSecret(mockCtrl)
+	tx := &fakeTx{}
+
+	// the check and the insert run in one transaction holding the lock of the quota
+	gomock.InOrder(
+		mockQuotas.EXPECT().LockUsage(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83").Return(
+			&types.Usage{Notes: 9, Quota: types.Quota{Notes: 10}}, nil),
+		mocksSecret.EXPECT().Create(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", gomock.Any(), gomock.Any()).Return(nil),
+	)
+
+	ts := httptest.NewServer(SetupRouter(logger, nil, mocksSecret, nil, nil, nil, WithQuotas(mockQuotas), WithTx(tx)))
+	defer ts.Close()
+
+	res, _ := testAuthorizedRequest(t, ts, http.MethodPost, "/api/secret/text", validToken,
+		[]byte(`{"key":"123321","text":"test","metadata":"test_meta"}`))
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusAccepted, res.StatusCode)
+	assert.True(t, tx.committed)
+
+	mockQuotas.EXPECT().LockUsage(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83").Return(
+		&types.Usage{Notes: 10, Quota: types.Quota{Notes: 10}}, nil)
+
+	res, body := testAuthorizedRequest(t, ts, http.MethodPost, "/api/secret/text", validToken,
+		[]byte(`{"key":"123321","text":"test","metadata":"test_meta"}`))
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	assert.Equal(t, "quota_exceeded: 10 notes allowed\n", body)
+	assert.False(t, tx.committed)
+}
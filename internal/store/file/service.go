@@ -2,6 +2,16 @@ package file
 
 import (
 	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
 
 	"go.uber.org/zap"
 
@@ -11,51 +21,501 @@ import (
 
 var _ store.FileService = &service{}
 
+// service keeps file records in store.Files and their contents in Storage.
+// Buckets are named after the user ids.
 type service struct {
-	storage Storage
-	logger  *zap.Logger
+	storage   Storage
+	files     store.Files
+	quotas    store.Quotas
+	tx        store.Tx
+	retention time.Duration
+	logger    *zap.Logger
+}
+
+// Option configures optional service behaviour.
+type Option func(*service)
+
+// WithQuotas rejects files that don't fit the quota of the user.
+func WithQuotas(quotas store.Quotas) Option {
+	return func(s *service) {
+		s.quotas = quotas
+	}
+}
+
+// WithTx adds the records of the files in transactions of tx, holding the lock
+// of the quota of the user, so concurrent uploads can't exceed it together.
+func WithTx(tx store.Tx) Option {
+	return func(s *service) {
+		s.tx = tx
+	}
+}
+
+// WithRetention makes a Retainer storage keep the contents of deleted files for
+// the retention window of the trash.
+func WithRetention(retention time.Duration) Option {
+	return func(s *service) {
+		s.retention = retention
+	}
 }
 
-func NewService(fileStorage Storage, logger *zap.Logger) (store.FileService, error) {
-	return &service{
+func NewService(fileStorage Storage, files store.Files, logger *zap.Logger, opts ...Option) (store.FileService, error) {
+	s := &service{
 		storage: fileStorage,
+		files:   files,
 		logger:  logger,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
 }
 
 func (s *service) GetFile(ctx context.Context, bucketName, fileId string) (*types.File, error) {
-	ret, err := s.storage.GetFile(ctx, bucketName, fileId)
+	info, err := s.files.Get(ctx, bucketName, fileId)
+	if err != nil {
+		return nil, wrapNoRows(err)
+	}
+
+	ret, err := s.storage.GetFile(ctx, bucketName, info.StorageKey)
 	if err != nil {
 		return nil, err
 	}
+	ret.ID = info.ID
+	ret.Name = info.Name
+	ret.Metadata = info.Metadata
+	ret.ModTime = info.CreatedAt
 	return ret, nil
 }
 
-func (s *service) GetFilesList(ctx context.Context, bucketName string) ([]*types.Key, error) {
-	list, err := s.storage.GetFilesList(ctx, bucketName)
+func (s *service) GetFilesList(ctx context.Context, bucketName string, opts types.ListOptions) (*types.Page[*types.FileInfo], error) {
+	err := opts.Validate(types.SortCreated, types.SortUpdated, types.SortSize)
 	if err != nil {
-		return list, err
+		return nil, err
 	}
-	return list, nil
+	return s.files.List(ctx, bucketName, opts)
 }
 
+// Create stores the contents first and adds the record once they are safe,
+// the hash and size are counted on the fly.
 func (s *service) Create(ctx context.Context, bucketName string, dto types.CreateFileDTO) error {
 	dto.NormalizeName()
 	file, err := types.NewFile(dto)
 	if err != nil {
 		return err
 	}
+
+	left, maxSize, err := s.fileLimits(ctx, bucketName, dto.Size)
+	if err != nil {
+		return err
+	}
+
+	h := sha256.New()
+	counter := &countingReader{r: io.TeeReader(file.Content, h), left: left, maxSize: maxSize}
+	file.Content = io.NopCloser(counter)
+
 	err = s.storage.CreateFile(ctx, bucketName, file)
+	if counter.err == nil && dto.Size >= 0 && (err == nil || counter.eof) {
+		// the declared size is only a hint for the storage, the contents must match it
+		counter.err = counter.checkSize(dto.Size)
+	}
+	if counter.err != nil {
+		// storages may have kept what was read before the limit was hit
+		if delErr := s.storage.DeleteFile(ctx, bucketName, file.ID); delErr != nil {
+			s.logger.Error("failed to remove orphan file", zap.String("key", file.ID), zap.Error(delErr))
+		}
+		return counter.err
+	}
 	if err != nil {
 		return err
 	}
-	return nil
+
+	return s.addRecord(ctx, bucketName, &types.FileInfo{
+		ID:         file.ID,
+		UserID:     bucketName,
+		Name:       file.Name,
+		Size:       counter.n,
+		Hash:       hex.EncodeToString(h.Sum(nil)),
+		Metadata:   file.Metadata,
+		StorageKey: file.ID,
+	})
+}
+
+// addRecord removes the stored contents when the record can't be added or the
+// user already has the same contents stored, so no orphan blobs are left.
+func (s *service) addRecord(ctx context.Context, bucketName string, info *types.FileInfo) error {
+	key := info.StorageKey
+	err := s.inQuota(ctx, bucketName, func(ctx context.Context) error {
+		return s.files.Create(ctx, info)
+	})
+	if err == nil && info.StorageKey == key {
+		return nil
+	}
+
+	if delErr := s.storage.DeleteFile(ctx, bucketName, key); delErr != nil {
+		s.logger.Error("failed to remove orphan file", zap.String("key", key), zap.Error(delErr))
+	}
+	return err
+}
+
+func (s *service) HasBlob(ctx context.Context, bucketName, hash string) (bool, error) {
+	if !types.ValidHash(hash) {
+		return false, fmt.Errorf("incorrect hash %q", hash)
+	}
+	return s.files.HasBlob(ctx, bucketName, hash)
 }
 
+func (s *service) Link(ctx context.Context, bucketName string, req types.LinkFileRequest) (*types.FileInfo, error) {
+	err := req.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	info := &types.FileInfo{
+		ID:       uuid.New().String(),
+		UserID:   bucketName,
+		Name:     req.Name,
+		Hash:     req.Hash,
+		Metadata: req.Metadata,
+	}
+	err = s.files.Link(ctx, info)
+	if err != nil {
+		return nil, wrapNoRows(err)
+	}
+	return info, nil
+}
+
+// Delete moves the file to the trash, it is removed for good by EmptyTrash or PurgeDeleted.
+// The contents are put under retention when the storage supports it.
 func (s *service) Delete(ctx context.Context, bucketName, fileName string) error {
-	err := s.storage.DeleteFile(ctx, bucketName, fileName)
+	retainer, ok := s.storage.(Retainer)
+	if !ok || s.retention <= 0 {
+		return wrapNoRows(s.files.Delete(ctx, bucketName, fileName))
+	}
+
+	info, err := s.files.Get(ctx, bucketName, fileName)
+	if err != nil {
+		return wrapNoRows(err)
+	}
+	err = s.files.Delete(ctx, bucketName, fileName)
+	if err != nil {
+		return wrapNoRows(err)
+	}
+
+	// the file is in the trash already, so a failure only loses the protection
+	err = retainer.RetainFile(ctx, bucketName, info.StorageKey, time.Now().Add(s.retention))
+	if err != nil {
+		s.logger.Error("failed to retain deleted file", zap.String("key", info.StorageKey), zap.Error(err))
+	}
+	return nil
+}
+
+func (s *service) GetDeletedList(ctx context.Context, bucketName string) ([]types.TrashItem, error) {
+	return s.files.GetDeletedList(ctx, bucketName)
+}
+
+func (s *service) Restore(ctx context.Context, bucketName, fileName string) error {
+	return wrapNoRows(s.files.Restore(ctx, bucketName, fileName))
+}
+
+func (s *service) EmptyTrash(ctx context.Context, bucketName string) error {
+	deleted, err := s.files.GetDeleted(ctx, bucketName)
+	if err != nil {
+		return err
+	}
+	_, err = s.remove(ctx, deleted)
 	if err != nil {
 		return err
 	}
+	return s.collectGarbage(ctx)
+}
+
+func (s *service) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	expired, err := s.files.GetExpired(ctx, before)
+	if err != nil {
+		return 0, err
+	}
+	removed, err := s.remove(ctx, expired)
+	if err != nil {
+		return removed, err
+	}
+	// blobs left after failed deletions are collected on every run.
+	return removed, s.collectGarbage(ctx)
+}
+
+// remove deletes the records, the contents are deleted by collectGarbage once no file refers to them.
+func (s *service) remove(ctx context.Context, files []*types.FileInfo) (int64, error) {
+	var removed int64
+	for _, f := range files {
+		err := s.files.Remove(ctx, f.UserID, f.ID)
+		if err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+func (s *service) collectGarbage(ctx context.Context) error {
+	blobs, err := s.files.GetUnreferenced(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, blob := range blobs {
+		err = s.files.DeleteBlob(ctx, blob, func(key string) error {
+			return s.storage.DeleteFile(ctx, blob.UserID, key)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if len(blobs) > 0 {
+		s.logger.Info("unreferenced files removed", zap.Int("count", len(blobs)))
+	}
 	return nil
 }
+
+func (s *service) CreateUpload(ctx context.Context, bucketName string, req types.CreateUploadRequest) (*types.UploadSession, error) {
+	err := req.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	_, _, err = s.fileLimits(ctx, bucketName, req.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	upload := &types.UploadSession{
+		ID:        uuid.New().String(),
+		Name:      req.Name,
+		Size:      req.Size,
+		ChunkSize: types.UploadChunkSize,
+		Metadata:  req.Metadata,
+		CreatedAt: time.Now().UTC(),
+		Chunks:    []types.UploadChunkInfo{},
+	}
+
+	err = s.storage.CreateUpload(ctx, bucketName, upload)
+	if err != nil {
+		return nil, err
+	}
+	return upload, nil
+}
+
+func (s *service) GetUpload(ctx context.Context, bucketName, uploadID string) (*types.UploadSession, error) {
+	upload, err := s.storage.GetUpload(ctx, bucketName, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	upload.UpdateOffset()
+	return upload, nil
+}
+
+func (s *service) UploadChunk(ctx context.Context, bucketName, uploadID string, chunk types.UploadChunk) (*types.UploadChunkInfo, error) {
+	upload, err := s.storage.GetUpload(ctx, bucketName, uploadID)
+	if err != nil {
+		return nil, err
+	}
+
+	if expected := upload.ChunkLength(chunk.Number); expected < 0 || expected != chunk.Size {
+		return nil, fmt.Errorf("%w: chunk %d of %d bytes doesn't fit the upload", types.ErrInvalidChunk, chunk.Number, chunk.Size)
+	}
+
+	info, err := s.storage.PutChunk(ctx, bucketName, upload, chunk)
+	if err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// CompleteUpload assembles the received chunks into the file, the upload id becomes the file id.
+func (s *service) CompleteUpload(ctx context.Context, bucketName, uploadID string) (*types.UploadSession, error) {
+	upload, err := s.storage.GetUpload(ctx, bucketName, uploadID)
+	if err != nil {
+		return nil, err
+	}
+
+	upload.UpdateOffset()
+	if upload.Offset != upload.Size {
+		return upload, fmt.Errorf("%w: %d of %d bytes received", types.ErrUploadIncomplete, upload.Offset, upload.Size)
+	}
+
+	// other files may have been added since the upload was created
+	_, _, err = s.fileLimits(ctx, bucketName, upload.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(upload.Chunks, func(i, j int) bool { return upload.Chunks[i].Number < upload.Chunks[j].Number })
+
+	err = s.storage.CompleteUpload(ctx, bucketName, upload)
+	if err != nil {
+		return nil, err
+	}
+
+	// chunks arrive in any order, so the hash is counted over the assembled file.
+	hash, err := s.hashFile(ctx, bucketName, upload.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.addRecord(ctx, bucketName, &types.FileInfo{
+		ID:         upload.ID,
+		UserID:     bucketName,
+		Name:       upload.Name,
+		Size:       upload.Size,
+		Hash:       hash,
+		Metadata:   upload.Metadata,
+		StorageKey: upload.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return upload, nil
+}
+
+func (s *service) AbortUpload(ctx context.Context, bucketName, uploadID string) error {
+	upload, err := s.storage.GetUpload(ctx, bucketName, uploadID)
+	if err != nil {
+		return err
+	}
+	return s.storage.AbortUpload(ctx, bucketName, upload)
+}
+
+func (s *service) PurgeUploads(ctx context.Context, before time.Time) (int64, error) {
+	buckets, err := s.storage.GetBuckets(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, bucket := range buckets {
+		n, err := s.storage.AbortStaleUploads(ctx, bucket, before)
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// hashFile reads the stored contents back to count their hash.
+func (s *service) hashFile(ctx context.Context, bucketName, key string) (string, error) {
+	f, err := s.storage.GetFile(ctx, bucketName, key)
+	if err != nil {
+		return "", err
+	}
+	defer f.Content.Close()
+
+	h := sha256.New()
+	_, err = io.Copy(h, f.Content)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// fileLimits checks that a file of the size fits the quota of the user and
+// returns how many bytes are left and how large a single file can be, -1 means
+// no limit. A negative size is not known yet.
+func (s *service) fileLimits(ctx context.Context, bucketName string, size int64) (int64, int64, error) {
+	if s.quotas == nil {
+		return -1, -1, nil
+	}
+
+	usage, err := s.quotas.GetUsage(ctx, bucketName)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to check quota. err: %w", err)
+	}
+	err = usage.Check(types.KindFile, max(size, 0))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	maxSize := usage.Quota.MaxFileSize
+	if maxSize <= 0 {
+		maxSize = -1
+	}
+	return usage.FileBytesLeft(), maxSize, nil
+}
+
+// inQuota runs add with the quota of the user locked and rolls it back when the
+// files no longer fit the quota. Identical contents are stored once, so the
+// usage is checked after the file is added. Without a transaction only the
+// check of fileLimits before the upload applies.
+func (s *service) inQuota(ctx context.Context, bucketName string, add func(ctx context.Context) error) error {
+	if s.quotas == nil || s.tx == nil {
+		return add(ctx)
+	}
+
+	return s.tx.InTx(ctx, func(ctx context.Context) error {
+		_, err := s.quotas.LockUsage(ctx, bucketName)
+		if err != nil {
+			return fmt.Errorf("failed to check quota. err: %w", err)
+		}
+
+		err = add(ctx)
+		if err != nil {
+			return err
+		}
+
+		usage, err := s.quotas.GetUsage(ctx, bucketName)
+		if err != nil {
+			return fmt.Errorf("failed to check quota. err: %w", err)
+		}
+		return usage.Check(types.KindFile, 0)
+	})
+}
+
+// wrapNoRows maps missing records to types.ErrNotFound.
+func wrapNoRows(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %s", types.ErrNotFound, err.Error())
+	}
+	return err
+}
+
+// countingReader counts the bytes read and fails once more than left bytes of the
+// quota or more than maxSize bytes of a single file are read, negative limits
+// mean no limit.
+type countingReader struct {
+	r       io.Reader
+	n       int64
+	left    int64
+	maxSize int64
+	// err is the limit hit
+	err error
+	eof bool
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	switch {
+	case c.maxSize >= 0 && c.n > c.maxSize:
+		c.err = fmt.Errorf("%w: %d bytes allowed", types.ErrFileTooLarge, c.maxSize)
+		return n, c.err
+	case c.left >= 0 && c.n > c.left:
+		c.err = fmt.Errorf("%w: the file doesn't fit", types.ErrQuotaExceeded)
+		return n, c.err
+	}
+	c.eof = errors.Is(err, io.EOF)
+	return n, err
+}
+
+// checkSize fails unless exactly size bytes were sent, storages may stop reading
+// once they got the declared size.
+func (c *countingReader) checkSize(size int64) error {
+	if c.n == size {
+		var b [1]byte
+		n, _ := c.Read(b[:])
+		if n == 0 && c.err == nil {
+			return nil
+		}
+	}
+	if c.err != nil {
+		return c.err
+	}
+	return fmt.Errorf("%w: %d bytes declared", types.ErrSizeMismatch, size)
+}
@@ -1,10 +1,14 @@
 package file
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
 	"errors"
+	"io"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -20,8 +24,9 @@ func TestService_Create(t *testing.T) {
 	defer mockCtrl.Finish()
 
 	mockFileStorage := mocks.NewMockStorage(mockCtrl)
+	mockFiles := mocks.NewMockFiles(mockCtrl)
 
-	fs, err := NewService(mockFileStorage, zap.L())
+	fs, err := NewService(mockFileStorage, mockFiles, zap.L())
 	require.NoError(t, err)
 
 	filepath := t.TempDir() + "/test.txt"
@@ -29,7 +34,31 @@ func TestService_Create(t *testing.T) {
 	require.NoError(t, err)
 
 	mockFileStorage.EXPECT().CreateFile(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", gomock.Any()).Return(nil).Times(1)
+	mockFileStorage.EXPECT().CreateFile(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, f *types.File) error {
+			_, err := io.ReadAll(f.Content)
+			return err
+		}).Times(1)
 	mockFileStorage.EXPECT().CreateFile(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", gomock.Any()).Return(testErr).Times(1)
+	mockFileStorage.EXPECT().CreateFile(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", gomock.Any()).Return(nil).Times(1)
+	mockFileStorage.EXPECT().DeleteFile(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", gomock.Any()).Return(nil).Times(1)
+	mockFiles.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, info *types.FileInfo) error {
+			assert.Equal(t, "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", info.UserID)
+			assert.Equal(t, info.ID, info.StorageKey)
+			assert.Equal(t, "123321", info.Name)
+			assert.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", info.Hash)
+			return nil
+		}).Times(1)
+	mockFiles.EXPECT().Create(gomock.Any(), gomock.Any()).Return(testErr).Times(1)
+	// the same contents are stored already, the new copy is removed
+	mockFileStorage.EXPECT().CreateFile(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", gomock.Any()).Return(nil).Times(1)
+	mockFiles.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, info *types.FileInfo) error {
+			info.StorageKey = "existing"
+			return nil
+		}).Times(1)
+	mockFileStorage.EXPECT().DeleteFile(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", gomock.Not("existing")).Return(nil).Times(1)
 
 	tests := []struct {
 		name    string
@@ -43,14 +72,14 @@ func TestService_Create(t *testing.T) {
 			bucket: "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83",
 			fileDTO: types.CreateFileDTO{
 				Name:     "123321",
-				Size:     1000,
+				Size:     0,
 				Metadata: "test_meta",
 				Reader:   file,
 			},
 			wantErr: false,
 		},
 		{
-			name:   "Failed test #1 Failed to create new file",
+			name:   "Failed test #1 Failed to read file",
 			bucket: "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83",
 			fileDTO: types.CreateFileDTO{
 				Name:     "123321",
@@ -66,13 +95,36 @@ func TestService_Create(t *testing.T) {
 			bucket: "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83",
 			fileDTO: types.CreateFileDTO{
 				Name:     "123321",
-				Size:     1000,
+				Size:     0,
+				Metadata: "test_meta",
+				Reader:   file,
+			},
+			wantErr: true,
+			err:     testErr,
+		},
+		{
+			name:   "Failed test #3 Record err",
+			bucket: "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83",
+			fileDTO: types.CreateFileDTO{
+				Name:     "123321",
+				Size:     0,
 				Metadata: "test_meta",
 				Reader:   file,
 			},
 			wantErr: true,
 			err:     testErr,
 		},
+		{
+			name:   "Positive test #2 Duplicate contents",
+			bucket: "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83",
+			fileDTO: types.CreateFileDTO{
+				Name:     "123321",
+				Size:     0,
+				Metadata: "test_meta",
+				Reader:   file,
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -88,24 +140,202 @@ func TestService_Create(t *testing.T) {
 	}
 }
 
+func TestService_CreateQuota(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockFileStorage := mocks.NewMockStorage(mockCtrl)
+	mockFiles := mocks.NewMockFiles(mockCtrl)
+	mockQuotas := mocks.NewMockQuotas(mockCtrl)
+
+	fs, err := NewService(mockFileStorage, mockFiles, zap.L(), WithQuotas(mockQuotas))
+	require.NoError(t, err)
+
+	bucket := "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83"
+	usage := &types.Usage{FileBytes: 90, Quota: types.Quota{FileBytes: 100}}
+	mockQuotas.EXPECT().GetUsage(gomock.Any(), bucket).Return(usage, nil).AnyTimes()
+
+	// the declared size doesn't fit
+	err = fs.Create(context.Background(), bucket, types.CreateFileDTO{Name: "big", Size: 20, Reader: bytes.NewReader(make([]byte, 20))})
+	assert.ErrorIs(t, err, types.ErrQuotaExceeded)
+
+	// the size is not known in advance, the stored part is removed
+	mockFileStorage.EXPECT().CreateFile(gomock.Any(), bucket, gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, f *types.File) error {
+			_, err := io.ReadAll(f.Content)
+			return err
+		}).Times(1)
+	mockFileStorage.EXPECT().DeleteFile(gomock.Any(), bucket, gomock.Any()).Return(nil).Times(1)
+
+	err = fs.Create(context.Background(), bucket, types.CreateFileDTO{Name: "big", Size: -1, Reader: bytes.NewReader(make([]byte, 20))})
+	assert.ErrorIs(t, err, types.ErrQuotaExceeded)
+
+	// a file that fits
+	mockFileStorage.EXPECT().CreateFile(gomock.Any(), bucket, gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, f *types.File) error {
+			_, err := io.ReadAll(f.Content)
+			return err
+		}).Times(1)
+	mockFiles.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+
+	err = fs.Create(context.Background(), bucket, types.CreateFileDTO{Name: "small", Size: -1, Reader: bytes.NewReader(make([]byte, 10))})
+	assert.NoError(t, err)
+
+	_, err = fs.CreateUpload(context.Background(), bucket, types.CreateUploadRequest{Name: "big", Size: 20})
+	assert.ErrorIs(t, err, types.ErrQuotaExceeded)
+}
+
+// fakeTx runs the functions without a database and records the outcome.
+type fakeTx struct {
+	committed bool
+}
+
+func (tx *fakeTx) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	err := fn(ctx)
+	tx.committed = err == nil
+	return err
+}
+
+func (tx *fakeTx) Savepoint(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func TestService_CreateQuotaTx(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockFileStorage := mocks.NewMockStorage(mockCtrl)
+	mockFiles := mocks.NewMockFiles(mockCtrl)
+	mockQuotas := mocks.NewMockQuotas(mockCtrl)
+	tx := &fakeTx{}
+
+	fs, err := NewService(mockFileStorage, mockFiles, zap.L(), WithQuotas(mockQuotas), WithTx(tx))
+	require.NoError(t, err)
+
+	bucket := "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83"
+	quota := types.Quota{FileBytes: 100}
+	mockFileStorage.EXPECT().CreateFile(gomock.Any(), bucket, gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, f *types.File) error {
+			_, err := io.ReadAll(f.Content)
+			return err
+		}).Times(2)
+
+	// a concurrent upload took the rest of the quota while this one was stored
+	gomock.InOrder(
+		mockQuotas.EXPECT().GetUsage(gomock.Any(), bucket).Return(&types.Usage{FileBytes: 50, Quota: quota}, nil),
+		mockQuotas.EXPECT().LockUsage(gomock.Any(), bucket).Return(&types.Usage{FileBytes: 90, Quota: quota}, nil),
+		mockFiles.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil),
+		mockQuotas.EXPECT().GetUsage(gomock.Any(), bucket).Return(&types.Usage{FileBytes: 130, Quota: quota}, nil),
+		mockFileStorage.EXPECT().DeleteFile(gomock.Any(), bucket, gomock.Any()).Return(nil),
+	)
+
+	err = fs.Create(context.Background(), bucket, types.CreateFileDTO{Name: "file", Size: 40, Reader: bytes.NewReader(make([]byte, 40))})
+	assert.ErrorIs(t, err, types.ErrQuotaExceeded)
+	assert.False(t, tx.committed)
+
+	// the same contents are stored already, so they don't count twice
+	gomock.InOrder(
+		mockQuotas.EXPECT().GetUsage(gomock.Any(), bucket).Return(&types.Usage{FileBytes: 90, Quota: quota}, nil),
+		mockQuotas.EXPECT().LockUsage(gomock.Any(), bucket).Return(&types.Usage{FileBytes: 90, Quota: quota}, nil),
+		mockFiles.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil),
+		mockQuotas.EXPECT().GetUsage(gomock.Any(), bucket).Return(&types.Usage{FileBytes: 90, Quota: quota}, nil),
+	)
+
+	err = fs.Create(context.Background(), bucket, types.CreateFileDTO{Name: "copy", Size: 10, Reader: bytes.NewReader(make([]byte, 10))})
+	assert.NoError(t, err)
+	assert.True(t, tx.committed)
+}
+
+func TestService_CreateSize(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockFileStorage := mocks.NewMockStorage(mockCtrl)
+	mockFiles := mocks.NewMockFiles(mockCtrl)
+	mockQuotas := mocks.NewMockQuotas(mockCtrl)
+
+	fs, err := NewService(mockFileStorage, mockFiles, zap.L(), WithQuotas(mockQuotas))
+	require.NoError(t, err)
+
+	bucket := "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83"
+	usage := &types.Usage{Quota: types.Quota{MaxFileSize: 10}}
+	mockQuotas.EXPECT().GetUsage(gomock.Any(), bucket).Return(usage, nil).AnyTimes()
+
+	// the declared size is over the limit of the user
+	err = fs.Create(context.Background(), bucket, types.CreateFileDTO{Name: "big", Size: 11, Reader: bytes.NewReader(make([]byte, 11))})
+	assert.ErrorIs(t, err, types.ErrFileTooLarge)
+
+	// the storage reads only the declared size, so the rest is checked after it
+	readDeclared := func(_ context.Context, _ string, f *types.File) error {
+		_, err := io.CopyN(io.Discard, f.Content, f.Size)
+		return err
+	}
+	mockFileStorage.EXPECT().CreateFile(gomock.Any(), bucket, gomock.Any()).DoAndReturn(readDeclared).Times(2)
+	mockFileStorage.EXPECT().DeleteFile(gomock.Any(), bucket, gomock.Any()).Return(nil).Times(3)
+
+	err = fs.Create(context.Background(), bucket, types.CreateFileDTO{Name: "lie", Size: 5, Reader: bytes.NewReader(make([]byte, 20))})
+	assert.ErrorIs(t, err, types.ErrSizeMismatch)
+	err = fs.Create(context.Background(), bucket, types.CreateFileDTO{Name: "lie", Size: 5, Reader: bytes.NewReader(make([]byte, 8))})
+	assert.ErrorIs(t, err, types.ErrSizeMismatch)
+
+	// fewer bytes than declared
+	mockFileStorage.EXPECT().CreateFile(gomock.Any(), bucket, gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, f *types.File) error {
+			_, err := io.CopyN(io.Discard, f.Content, f.Size)
+			return err
+		}).Times(1)
+	err = fs.Create(context.Background(), bucket, types.CreateFileDTO{Name: "short", Size: 8, Reader: bytes.NewReader(make([]byte, 5))})
+	assert.ErrorIs(t, err, types.ErrSizeMismatch)
+
+	// the size is not known in advance
+	mockFileStorage.EXPECT().CreateFile(gomock.Any(), bucket, gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, f *types.File) error {
+			_, err := io.ReadAll(f.Content)
+			return err
+		}).Times(2)
+	mockFileStorage.EXPECT().DeleteFile(gomock.Any(), bucket, gomock.Any()).Return(nil).Times(1)
+	mockFiles.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, info *types.FileInfo) error {
+			assert.Equal(t, int64(10), info.Size)
+			return nil
+		}).Times(1)
+
+	err = fs.Create(context.Background(), bucket, types.CreateFileDTO{Name: "big", Size: -1, Reader: bytes.NewReader(make([]byte, 11))})
+	assert.ErrorIs(t, err, types.ErrFileTooLarge)
+	err = fs.Create(context.Background(), bucket, types.CreateFileDTO{Name: "fits", Size: -1, Reader: bytes.NewReader(make([]byte, 10))})
+	assert.NoError(t, err)
+
+	_, err = fs.CreateUpload(context.Background(), bucket, types.CreateUploadRequest{Name: "big", Size: 11})
+	assert.ErrorIs(t, err, types.ErrFileTooLarge)
+}
+
 func TestService_Get(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 
 	mockFileStorage := mocks.NewMockStorage(mockCtrl)
+	mockFiles := mocks.NewMockFiles(mockCtrl)
 
-	fs, err := NewService(mockFileStorage, zap.L())
+	fs, err := NewService(mockFileStorage, mockFiles, zap.L())
 	require.NoError(t, err)
 
+	info := &types.FileInfo{
+		ID:         "test",
+		Name:       "123321",
+		Size:       1,
+		Metadata:   "test_meta",
+		StorageKey: "key",
+	}
 	fileTest := &types.File{
-		Name:     "123321",
-		Size:     1000,
-		Bytes:    []byte{1},
-		Metadata: "test_meta",
+		ID:      "key",
+		Size:    1,
+		Content: io.NopCloser(bytes.NewReader([]byte{1})),
 	}
 
-	mockFileStorage.EXPECT().GetFile(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", "test").Return(fileTest, nil).Times(1)
-	mockFileStorage.EXPECT().GetFile(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", "test").Return(nil, notFound).Times(1)
+	mockFiles.EXPECT().Get(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", "test").Return(info, nil).Times(2)
+	mockFiles.EXPECT().Get(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", "missing").Return(nil, sql.ErrNoRows).Times(1)
+	mockFileStorage.EXPECT().GetFile(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", "key").Return(fileTest, nil).Times(1)
+	mockFileStorage.EXPECT().GetFile(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", "key").Return(nil, notFound).Times(1)
 
 	tests := []struct {
 		name     string
@@ -127,16 +357,26 @@ func TestService_Get(t *testing.T) {
 			wantErr:  true,
 			err:      notFound,
 		},
+		{
+			name:     "Failed test #2 No record",
+			bucket:   "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83",
+			fileName: "missing",
+			wantErr:  true,
+			err:      types.ErrNotFound,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := fs.GetFile(context.Background(), tt.bucket, tt.fileName)
+			f, err := fs.GetFile(context.Background(), tt.bucket, tt.fileName)
 			if tt.wantErr {
 				assert.Error(t, err)
 				assert.ErrorIs(t, err, tt.err)
 			} else {
 				assert.NoError(t, err)
+				assert.Equal(t, "test", f.ID)
+				assert.Equal(t, "123321", f.Name)
+				assert.Equal(t, "test_meta", f.Metadata)
 			}
 		})
 	}
@@ -147,43 +387,56 @@ func TestService_GetFilesList(t *testing.T) {
 	defer mockCtrl.Finish()
 
 	mockFileStorage := mocks.NewMockStorage(mockCtrl)
+	mockFiles := mocks.NewMockFiles(mockCtrl)
 
-	fs, err := NewService(mockFileStorage, zap.L())
+	fs, err := NewService(mockFileStorage, mockFiles, zap.L())
 	require.NoError(t, err)
 
-	filesList := []*types.Key{{
-		Id:  "test",
-		Key: "test_key"},
+	filesList := []*types.FileInfo{{
+		ID:   "test",
+		Name: "test_key"},
 	}
+	opts := types.ListOptions{Sort: types.SortSize, Desc: true, Limit: 10}
 
-	mockFileStorage.EXPECT().GetFilesList(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83").Return(filesList, nil).Times(1)
-	mockFileStorage.EXPECT().GetFilesList(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83").Return(nil, notFound).Times(1)
+	mockFiles.EXPECT().List(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", opts).Return(&types.Page[*types.FileInfo]{Items: filesList}, nil).Times(1)
+	mockFiles.EXPECT().List(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", opts).Return(nil, notFound).Times(1)
 
 	tests := []struct {
 		name    string
 		bucket  string
+		opts    types.ListOptions
 		wantErr bool
 		err     error
 	}{
 		{
 			name:    "Positive test Get",
 			bucket:  "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83",
+			opts:    opts,
 			wantErr: false,
 		},
 		{
 			name:    "Failed test #1 Client err",
 			bucket:  "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83",
+			opts:    opts,
 			wantErr: true,
 			err:     notFound,
 		},
+		{
+			name:    "Failed test #2 Unknown sort",
+			bucket:  "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83",
+			opts:    types.ListOptions{Sort: "owner"},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := fs.GetFilesList(context.Background(), tt.bucket)
+			_, err := fs.GetFilesList(context.Background(), tt.bucket, tt.opts)
 			if tt.wantErr {
 				assert.Error(t, err)
-				assert.ErrorIs(t, err, tt.err)
+				if tt.err != nil {
+					assert.ErrorIs(t, err, tt.err)
+				}
 			} else {
 				assert.NoError(t, err)
 			}
@@ -196,12 +449,13 @@ func TestService_Delete(t *testing.T) {
 	defer mockCtrl.Finish()
 
 	mockFileStorage := mocks.NewMockStorage(mockCtrl)
+	mockFiles := mocks.NewMockFiles(mockCtrl)
 
-	fs, err := NewService(mockFileStorage, zap.L())
+	fs, err := NewService(mockFileStorage, mockFiles, zap.L())
 	require.NoError(t, err)
 
-	mockFileStorage.EXPECT().DeleteFile(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", "test").Return(nil).Times(1)
-	mockFileStorage.EXPECT().DeleteFile(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", "test").Return(testErr).Times(1)
+	mockFiles.EXPECT().Delete(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", "test").Return(nil).Times(1)
+	mockFiles.EXPECT().Delete(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", "test").Return(testErr).Times(1)
 
 	tests := []struct {
 		name     string
@@ -238,6 +492,317 @@ func TestService_Delete(t *testing.T) {
 	}
 }
 
+// retainingStorage is a storage with object retention.
+type retainingStorage struct {
+	*mocks.MockStorage
+	retained map[string]time.Time
+}
+
+func (s *retainingStorage) RetainFile(_ context.Context, _, key string, until time.Time) error {
+	s.retained[key] = until
+	return nil
+}
+
+func TestService_DeleteRetain(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	storage := &retainingStorage{MockStorage: mocks.NewMockStorage(mockCtrl), retained: make(map[string]time.Time)}
+	mockFiles := mocks.NewMockFiles(mockCtrl)
+
+	fs, err := NewService(storage, mockFiles, zap.L(), WithRetention(time.Hour))
+	require.NoError(t, err)
+
+	bucket := "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83"
+	gomock.InOrder(
+		mockFiles.EXPECT().Get(gomock.Any(), bucket, "test").Return(&types.FileInfo{ID: "test", StorageKey: "blob"}, nil),
+		mockFiles.EXPECT().Delete(gomock.Any(), bucket, "test").Return(nil),
+	)
+	require.NoError(t, fs.Delete(context.Background(), bucket, "test"))
+	assert.WithinDuration(t, time.Now().Add(time.Hour), storage.retained["blob"], time.Minute)
+
+	mockFiles.EXPECT().Get(gomock.Any(), bucket, "missing").Return(nil, sql.ErrNoRows)
+	err = fs.Delete(context.Background(), bucket, "missing")
+	assert.ErrorIs(t, err, types.ErrNotFound)
+	assert.Len(t, storage.retained, 1)
+}
+
+func TestService_Restore(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockFileStorage := mocks.NewMockStorage(mockCtrl)
+	mockFiles := mocks.NewMockFiles(mockCtrl)
+
+	fs, err := NewService(mockFileStorage, mockFiles, zap.L())
+	require.NoError(t, err)
+
+	mockFiles.EXPECT().Restore(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", "test").Return(nil).Times(1)
+	mockFiles.EXPECT().Restore(gomock.Any(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", "test").Return(sql.ErrNoRows).Times(1)
+
+	err = fs.Restore(context.Background(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", "test")
+	assert.NoError(t, err)
+
+	err = fs.Restore(context.Background(), "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", "test")
+	assert.ErrorIs(t, err, types.ErrNotFound)
+}
+
+func TestService_EmptyTrash(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockFileStorage := mocks.NewMockStorage(mockCtrl)
+	mockFiles := mocks.NewMockFiles(mockCtrl)
+
+	fs, err := NewService(mockFileStorage, mockFiles, zap.L())
+	require.NoError(t, err)
+
+	bucket := "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83"
+	trashed := []*types.FileInfo{
+		{ID: "first", UserID: bucket, StorageKey: "first_key"},
+		{ID: "second", UserID: bucket, StorageKey: "second_key"},
+	}
+
+	mockFiles.EXPECT().GetDeleted(gomock.Any(), bucket).Return(trashed, nil).Times(1)
+	mockFiles.EXPECT().Remove(gomock.Any(), bucket, "first").Return(nil).Times(1)
+	mockFiles.EXPECT().Remove(gomock.Any(), bucket, "second").Return(nil).Times(1)
+	// the second file shares its contents with a file that is kept
+	mockFiles.EXPECT().GetUnreferenced(gomock.Any()).Return([]types.Blob{
+		{UserID: bucket, Hash: "first_hash", StorageKey: "first_key"},
+	}, nil).Times(1)
+	mockFiles.EXPECT().DeleteBlob(gomock.Any(), types.Blob{UserID: bucket, Hash: "first_hash", StorageKey: "first_key"}, gomock.Any()).DoAndReturn(
+		func(_ context.Context, blob types.Blob, deleteContent func(string) error) error {
+			return deleteContent(blob.StorageKey)
+		}).Times(1)
+	mockFileStorage.EXPECT().DeleteFile(gomock.Any(), bucket, "first_key").Return(nil).Times(1)
+
+	err = fs.EmptyTrash(context.Background(), bucket)
+	assert.NoError(t, err)
+}
+
+func TestService_PurgeDeleted(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockFileStorage := mocks.NewMockStorage(mockCtrl)
+	mockFiles := mocks.NewMockFiles(mockCtrl)
+
+	fs, err := NewService(mockFileStorage, mockFiles, zap.L())
+	require.NoError(t, err)
+
+	before := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	expired := []*types.FileInfo{
+		{ID: "expired", UserID: "first", StorageKey: "expired"},
+		{ID: "stale", UserID: "second", StorageKey: "stale"},
+	}
+
+	blobs := []types.Blob{
+		{UserID: "first", Hash: "expired_hash", StorageKey: "expired"},
+		{UserID: "second", Hash: "stale_hash", StorageKey: "stale"},
+	}
+	deleteBlob := func(_ context.Context, blob types.Blob, deleteContent func(string) error) error {
+		return deleteContent(blob.StorageKey)
+	}
+
+	mockFiles.EXPECT().GetExpired(gomock.Any(), before).Return(expired, nil).Times(1)
+	mockFiles.EXPECT().Remove(gomock.Any(), "first", "expired").Return(nil).Times(1)
+	mockFiles.EXPECT().Remove(gomock.Any(), "second", "stale").Return(nil).Times(1)
+	mockFiles.EXPECT().GetUnreferenced(gomock.Any()).Return(blobs, nil).Times(1)
+	mockFiles.EXPECT().DeleteBlob(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(deleteBlob).Times(2)
+	mockFileStorage.EXPECT().DeleteFile(gomock.Any(), "first", "expired").Return(nil).Times(1)
+	mockFileStorage.EXPECT().DeleteFile(gomock.Any(), "second", "stale").Return(nil).Times(1)
+
+	n, err := fs.PurgeDeleted(context.Background(), before)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	// the blob is kept when the contents can't be removed, so the next purge retries
+	mockFiles.EXPECT().GetExpired(gomock.Any(), before).Return(nil, nil).Times(1)
+	mockFiles.EXPECT().GetUnreferenced(gomock.Any()).Return(blobs[:1], nil).Times(1)
+	mockFiles.EXPECT().DeleteBlob(gomock.Any(), blobs[0], gomock.Any()).DoAndReturn(deleteBlob).Times(1)
+	mockFileStorage.EXPECT().DeleteFile(gomock.Any(), "first", "expired").Return(testErr).Times(1)
+
+	n, err = fs.PurgeDeleted(context.Background(), before)
+	assert.ErrorIs(t, err, testErr)
+	assert.Equal(t, int64(0), n)
+
+	mockFiles.EXPECT().GetExpired(gomock.Any(), before).Return(nil, testErr).Times(1)
+
+	_, err = fs.PurgeDeleted(context.Background(), before)
+	assert.ErrorIs(t, err, testErr)
+}
+
+func TestService_Link(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockFileStorage := mocks.NewMockStorage(mockCtrl)
+	mockFiles := mocks.NewMockFiles(mockCtrl)
+
+	fs, err := NewService(mockFileStorage, mockFiles, zap.L())
+	require.NoError(t, err)
+
+	bucket := "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83"
+	hash := "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+	mockFiles.EXPECT().Link(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, info *types.FileInfo) error {
+			assert.Equal(t, bucket, info.UserID)
+			assert.Equal(t, hash, info.Hash)
+			info.StorageKey = "key"
+			info.Size = 10
+			return nil
+		}).Times(1)
+	mockFiles.EXPECT().Link(gomock.Any(), gomock.Any()).Return(sql.ErrNoRows).Times(1)
+
+	info, err := fs.Link(context.Background(), bucket, types.LinkFileRequest{Name: "name", Hash: hash, Metadata: "meta"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, info.ID)
+	assert.Equal(t, int64(10), info.Size)
+
+	_, err = fs.Link(context.Background(), bucket, types.LinkFileRequest{Name: "name", Hash: hash})
+	assert.ErrorIs(t, err, types.ErrNotFound)
+
+	_, err = fs.Link(context.Background(), bucket, types.LinkFileRequest{Name: "name", Hash: "../etc"})
+	assert.Error(t, err)
+}
+
+func TestService_CreateUpload(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockFileStorage := mocks.NewMockStorage(mockCtrl)
+	mockFiles := mocks.NewMockFiles(mockCtrl)
+
+	fs, err := NewService(mockFileStorage, mockFiles, zap.L())
+	require.NoError(t, err)
+
+	bucket := "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83"
+
+	mockFileStorage.EXPECT().CreateUpload(gomock.Any(), bucket, gomock.Any()).Return(nil).Times(1)
+
+	upload, err := fs.CreateUpload(context.Background(), bucket, types.CreateUploadRequest{Name: "big.bin", Size: 100, Metadata: "meta"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, upload.ID)
+	assert.Equal(t, int64(types.UploadChunkSize), upload.ChunkSize)
+	assert.Equal(t, "meta", upload.Metadata)
+
+	_, err = fs.CreateUpload(context.Background(), bucket, types.CreateUploadRequest{Name: "big.bin"})
+	assert.Error(t, err)
+}
+
+func TestService_UploadChunk(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockFileStorage := mocks.NewMockStorage(mockCtrl)
+	mockFiles := mocks.NewMockFiles(mockCtrl)
+
+	fs, err := NewService(mockFileStorage, mockFiles, zap.L())
+	require.NoError(t, err)
+
+	bucket := "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83"
+	upload := &types.UploadSession{ID: "upload", Size: 25, ChunkSize: 10}
+
+	mockFileStorage.EXPECT().GetUpload(gomock.Any(), bucket, "upload").Return(upload, nil).Times(4)
+	mockFileStorage.EXPECT().PutChunk(gomock.Any(), bucket, upload, gomock.Any()).Return(
+		types.UploadChunkInfo{Number: 3, Size: 5, ETag: "etag"}, nil).Times(1)
+	mockFileStorage.EXPECT().GetUpload(gomock.Any(), bucket, "unknown").Return(nil, types.ErrNotFound).Times(1)
+
+	tests := []struct {
+		name   string
+		id     string
+		number int
+		size   int64
+		err    error
+	}{
+		{name: "Positive test last chunk", id: "upload", number: 3, size: 5},
+		{name: "Negative test short chunk", id: "upload", number: 1, size: 5, err: types.ErrInvalidChunk},
+		{name: "Negative test long last chunk", id: "upload", number: 3, size: 10, err: types.ErrInvalidChunk},
+		{name: "Negative test out of range", id: "upload", number: 4, size: 10, err: types.ErrInvalidChunk},
+		{name: "Negative test unknown upload", id: "unknown", number: 1, size: 10, err: types.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := fs.UploadChunk(context.Background(), bucket, tt.id, types.UploadChunk{Number: tt.number, Size: tt.size})
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "etag", info.ETag)
+		})
+	}
+}
+
+func TestService_CompleteUpload(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockFileStorage := mocks.NewMockStorage(mockCtrl)
+	mockFiles := mocks.NewMockFiles(mockCtrl)
+
+	fs, err := NewService(mockFileStorage, mockFiles, zap.L())
+	require.NoError(t, err)
+
+	bucket := "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83"
+
+	mockFileStorage.EXPECT().GetUpload(gomock.Any(), bucket, "partial").Return(&types.UploadSession{
+		ID: "partial", Size: 25, ChunkSize: 10,
+		Chunks: []types.UploadChunkInfo{{Number: 1, Size: 10}, {Number: 3, Size: 5}},
+	}, nil).Times(1)
+	mockFileStorage.EXPECT().GetUpload(gomock.Any(), bucket, "upload").Return(&types.UploadSession{
+		ID: "upload", Size: 25, ChunkSize: 10,
+		Chunks: []types.UploadChunkInfo{{Number: 3, Size: 5}, {Number: 1, Size: 10}, {Number: 2, Size: 10}},
+	}, nil).Times(1)
+	mockFileStorage.EXPECT().CompleteUpload(gomock.Any(), bucket, gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, upload *types.UploadSession) error {
+			assert.Equal(t, []types.UploadChunkInfo{{Number: 1, Size: 10}, {Number: 2, Size: 10}, {Number: 3, Size: 5}}, upload.Chunks)
+			return nil
+		}).Times(1)
+	mockFileStorage.EXPECT().GetFile(gomock.Any(), bucket, "upload").Return(&types.File{
+		ID: "upload", Size: 25, Content: io.NopCloser(bytes.NewReader(make([]byte, 25))),
+	}, nil).Times(1)
+	mockFiles.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, info *types.FileInfo) error {
+			assert.Equal(t, "upload", info.ID)
+			assert.Equal(t, "upload", info.StorageKey)
+			assert.Equal(t, int64(25), info.Size)
+			assert.Len(t, info.Hash, 64)
+			return nil
+		}).Times(1)
+
+	upload, err := fs.CompleteUpload(context.Background(), bucket, "partial")
+	assert.ErrorIs(t, err, types.ErrUploadIncomplete)
+	assert.Equal(t, int64(10), upload.Offset)
+
+	upload, err = fs.CompleteUpload(context.Background(), bucket, "upload")
+	require.NoError(t, err)
+	assert.Equal(t, int64(25), upload.Offset)
+}
+
+func TestService_PurgeUploads(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockFileStorage := mocks.NewMockStorage(mockCtrl)
+	mockFiles := mocks.NewMockFiles(mockCtrl)
+
+	fs, err := NewService(mockFileStorage, mockFiles, zap.L())
+	require.NoError(t, err)
+
+	before := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	mockFileStorage.EXPECT().GetBuckets(gomock.Any()).Return([]string{"first", "second"}, nil).Times(1)
+	mockFileStorage.EXPECT().AbortStaleUploads(gomock.Any(), "first", before).Return(int64(2), nil).Times(1)
+	mockFileStorage.EXPECT().AbortStaleUploads(gomock.Any(), "second", before).Return(int64(0), testErr).Times(1)
+
+	n, err := fs.PurgeUploads(context.Background(), before)
+	assert.ErrorIs(t, err, testErr)
+	assert.Equal(t, int64(2), n)
+}
+
 type errReader int
 
 var testErr = errors.New("test error")
@@ -2,13 +2,30 @@ package file
 
 import (
 	"context"
+	"time"
 
 	"keeper-project/types"
 )
 
+// Storage is a blob store of file contents keyed by the storage key of a file,
+// names and metadata are kept in store.Files.
 type Storage interface {
-	GetFile(ctx context.Context, bucketName, fileName string) (*types.File, error)
-	GetFilesList(ctx context.Context, bucketName string) ([]*types.Key, error)
+	GetFile(ctx context.Context, bucketName, key string) (*types.File, error)
 	CreateFile(ctx context.Context, bucketName string, file *types.File) error
-	DeleteFile(ctx context.Context, bucketName, fileName string) error
+	DeleteFile(ctx context.Context, bucketName, key string) error
+	GetBuckets(ctx context.Context) ([]string, error)
+
+	CreateUpload(ctx context.Context, bucketName string, upload *types.UploadSession) error
+	GetUpload(ctx context.Context, bucketName, uploadID string) (*types.UploadSession, error)
+	PutChunk(ctx context.Context, bucketName string, upload *types.UploadSession, chunk types.UploadChunk) (types.UploadChunkInfo, error)
+	CompleteUpload(ctx context.Context, bucketName string, upload *types.UploadSession) error
+	AbortUpload(ctx context.Context, bucketName string, upload *types.UploadSession) error
+	// AbortStaleUploads drops the sessions of the bucket created before the given moment.
+	AbortStaleUploads(ctx context.Context, bucketName string, before time.Time) (int64, error)
+}
+
+// Retainer is implemented by storages that can protect the contents of the files
+// in the trash from being deleted until the end of the retention window.
+type Retainer interface {
+	RetainFile(ctx context.Context, bucketName, key string, until time.Time) error
 }
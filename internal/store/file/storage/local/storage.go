@@ -0,0 +1,379 @@
+package local
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"keeper-project/internal/store/file"
+	"keeper-project/types"
+)
+
+// Layout of the root directory:
+//
+//	<bucket>/files/<shard>/<id>        file content
+//	<bucket>/uploads/<id>/upload.json  resumable upload manifest
+//	<bucket>/uploads/<id>/<n>[.json]   received chunks and their checksums
+//	.tmp/                              files being written
+//
+// Everything is written to .tmp first and renamed into place, so a crash never
+// leaves a partially written file behind.
+
+const (
+	filesDir   = "files"
+	uploadsDir = "uploads"
+	tmpDir     = ".tmp"
+
+	sidecarExt   = ".json"
+	manifestName = "upload.json"
+)
+
+// validName guards against ids and bucket names escaping the root directory.
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+type manifest struct {
+	types.UploadSession
+	UploadID string `json:"upload_id"`
+}
+
+type localStorage struct {
+	root string
+}
+
+func NewStorage(root string) (file.Storage, error) {
+	err := os.MkdirAll(filepath.Join(root, tmpDir), 0700)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage directory. err: %w", err)
+	}
+	return &localStorage{root: root}, nil
+}
+
+func (l *localStorage) GetFile(_ context.Context, bucketName, fileID string) (*types.File, error) {
+	path, err := l.path(bucketName, filesDir, fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, wrapNotFound(err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &types.File{
+		ID:      fileID,
+		Size:    info.Size(),
+		ModTime: info.ModTime().UTC(),
+		Content: f,
+	}, nil
+}
+
+func (l *localStorage) CreateFile(_ context.Context, bucketName string, f *types.File) error {
+	path, err := l.path(bucketName, filesDir, f.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = l.writeAtomic(path, func(w io.Writer) error {
+		_, err := io.Copy(w, f.Content)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write file. err: %w", err)
+	}
+	return nil
+}
+
+func (l *localStorage) DeleteFile(_ context.Context, bucketName, fileID string) error {
+	path, err := l.path(bucketName, filesDir, fileID)
+	if err != nil {
+		return err
+	}
+	return removeFile(path)
+}
+
+func (l *localStorage) GetBuckets(_ context.Context) ([]string, error) {
+	entries, err := os.ReadDir(l.root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list buckets. err: %w", err)
+	}
+
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() && validName.MatchString(e.Name()) {
+			names = append(names, e.Name())
+		}
+	}
+	return names, nil
+}
+
+func (l *localStorage) CreateUpload(_ context.Context, bucketName string, upload *types.UploadSession) error {
+	dir, err := l.uploadDir(bucketName, upload.ID)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		return fmt.Errorf("failed to create upload. err: %w", err)
+	}
+
+	upload.UploadID = uuid.New().String()
+	_, err = l.writeAtomic(filepath.Join(dir, manifestName), func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(manifest{UploadSession: *upload, UploadID: upload.UploadID})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create upload. err: %w", err)
+	}
+	return nil
+}
+
+func (l *localStorage) GetUpload(_ context.Context, bucketName, uploadID string) (*types.UploadSession, error) {
+	dir, err := l.uploadDir(bucketName, uploadID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, manifestName))
+	if err != nil {
+		return nil, wrapNotFound(err)
+	}
+
+	var m manifest
+	err = json.Unmarshal(data, &m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode upload manifest. err: %w", err)
+	}
+	upload := m.UploadSession
+	upload.UploadID = m.UploadID
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, wrapNotFound(err)
+	}
+
+	upload.Chunks = []types.UploadChunkInfo{}
+	for _, e := range entries {
+		if e.Name() == manifestName || filepath.Ext(e.Name()) != sidecarExt {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var info types.UploadChunkInfo
+		err = json.Unmarshal(data, &info)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode chunk info. err: %w", err)
+		}
+		upload.Chunks = append(upload.Chunks, info)
+	}
+	sort.Slice(upload.Chunks, func(i, j int) bool { return upload.Chunks[i].Number < upload.Chunks[j].Number })
+
+	return &upload, nil
+}
+
+func (l *localStorage) PutChunk(_ context.Context, bucketName string, upload *types.UploadSession,
+	chunk types.UploadChunk) (types.UploadChunkInfo, error) {
+	dir, err := l.uploadDir(bucketName, upload.ID)
+	if err != nil {
+		return types.UploadChunkInfo{}, err
+	}
+
+	_, err = os.Stat(filepath.Join(dir, manifestName))
+	if err != nil {
+		return types.UploadChunkInfo{}, wrapNotFound(err)
+	}
+
+	h := md5.New()
+	size, err := l.writeAtomic(filepath.Join(dir, strconv.Itoa(chunk.Number)), func(w io.Writer) error {
+		n, err := io.Copy(io.MultiWriter(w, h), chunk.Reader)
+		if err != nil {
+			return err
+		}
+		if n != chunk.Size {
+			return fmt.Errorf("%w: %d bytes received instead of %d", types.ErrInvalidChunk, n, chunk.Size)
+		}
+		if base64.StdEncoding.EncodeToString(h.Sum(nil)) != chunk.MD5 {
+			return types.ErrChecksumMismatch
+		}
+		return nil
+	})
+	if err != nil {
+		return types.UploadChunkInfo{}, err
+	}
+
+	// the checksum is kept next to the chunk, so listing chunks doesn't read them.
+	sum := h.Sum(nil)
+	info := types.UploadChunkInfo{Number: chunk.Number, Size: size, ETag: hex.EncodeToString(sum),
+		MD5: base64.StdEncoding.EncodeToString(sum)}
+	_, err = l.writeAtomic(filepath.Join(dir, strconv.Itoa(chunk.Number))+sidecarExt, func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(info)
+	})
+	if err != nil {
+		return types.UploadChunkInfo{}, err
+	}
+	return info, nil
+}
+
+func (l *localStorage) CompleteUpload(_ context.Context, bucketName string, upload *types.UploadSession) error {
+	dir, err := l.uploadDir(bucketName, upload.ID)
+	if err != nil {
+		return err
+	}
+	path, err := l.path(bucketName, filesDir, upload.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = l.writeAtomic(path, func(w io.Writer) error {
+		for _, c := range upload.Chunks {
+			err := appendFile(w, filepath.Join(dir, strconv.Itoa(c.Number)))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to complete upload. err: %w", wrapNotFound(err))
+	}
+
+	return os.RemoveAll(dir)
+}
+
+func (l *localStorage) AbortUpload(_ context.Context, bucketName string, upload *types.UploadSession) error {
+	dir, err := l.uploadDir(bucketName, upload.ID)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+func (l *localStorage) AbortStaleUploads(ctx context.Context, bucketName string, before time.Time) (int64, error) {
+	entries, err := os.ReadDir(filepath.Join(l.root, bucketName, uploadsDir))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to list uploads. err: %w", err)
+	}
+
+	var aborted int64
+	for _, e := range entries {
+		upload, err := l.GetUpload(ctx, bucketName, e.Name())
+		if err != nil {
+			// a directory without a manifest is a leftover of a failed CreateUpload
+			if !errors.Is(err, types.ErrNotFound) {
+				continue
+			}
+			info, err := e.Info()
+			if err != nil || !info.ModTime().Before(before) {
+				continue
+			}
+			upload = &types.UploadSession{ID: e.Name()}
+		} else if !upload.CreatedAt.Before(before) {
+			continue
+		}
+
+		err = l.AbortUpload(ctx, bucketName, upload)
+		if err != nil {
+			return aborted, err
+		}
+		aborted++
+	}
+	return aborted, nil
+}
+
+// path returns the content path of the file, files are spread over 256 shard
+// directories to keep directory listings short.
+func (l *localStorage) path(bucketName, area, fileID string) (string, error) {
+	if !validName.MatchString(bucketName) || !validName.MatchString(fileID) {
+		return "", fmt.Errorf("%w: incorrect file id %q", types.ErrNotFound, fileID)
+	}
+	sum := sha256.Sum256([]byte(fileID))
+	return filepath.Join(l.root, bucketName, area, hex.EncodeToString(sum[:1]), fileID), nil
+}
+
+func (l *localStorage) uploadDir(bucketName, uploadID string) (string, error) {
+	if !validName.MatchString(bucketName) || !validName.MatchString(uploadID) {
+		return "", fmt.Errorf("%w: incorrect upload id %q", types.ErrNotFound, uploadID)
+	}
+	return filepath.Join(l.root, bucketName, uploadsDir, uploadID), nil
+}
+
+// writeAtomic writes to a temporary file and renames it to path once write succeeds.
+func (l *localStorage) writeAtomic(path string, write func(w io.Writer) error) (int64, error) {
+	tmp, err := os.CreateTemp(filepath.Join(l.root, tmpDir), "write-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	err = write(tmp)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	info, err := os.Stat(tmp.Name())
+	if err != nil {
+		return 0, err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), os.Rename(tmp.Name(), path)
+}
+
+func removeFile(path string) error {
+	err := os.Remove(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func appendFile(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(w, f)
+	return err
+}
+
+func wrapNotFound(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s", types.ErrNotFound, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,52 @@
+package local
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"keeper-project/internal/store/file"
+	"keeper-project/internal/store/file/storagetest"
+	"keeper-project/types"
+)
+
+func TestStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) file.Storage {
+		s, err := NewStorage(t.TempDir())
+		require.NoError(t, err)
+		return s
+	})
+}
+
+func TestStorage_PathTraversal(t *testing.T) {
+	root := t.TempDir()
+	s, err := NewStorage(filepath.Join(root, "files"))
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "secret"), []byte("secret"), 0600))
+
+	_, err = s.GetFile(context.Background(), "..", "secret")
+	assert.ErrorIs(t, err, types.ErrNotFound)
+
+	_, err = s.GetFile(context.Background(), "bucket", "../../../secret")
+	assert.ErrorIs(t, err, types.ErrNotFound)
+
+	_, err = s.GetUpload(context.Background(), "bucket", "..")
+	assert.ErrorIs(t, err, types.ErrNotFound)
+}
+
+func TestStorage_NoLeftovers(t *testing.T) {
+	root := t.TempDir()
+	s, err := NewStorage(root)
+	require.NoError(t, err)
+
+	storagetest.Run(t, func(t *testing.T) file.Storage { return s })
+
+	tmp, err := os.ReadDir(filepath.Join(root, tmpDir))
+	require.NoError(t, err)
+	assert.Empty(t, tmp, "temporary files must be renamed or removed")
+}
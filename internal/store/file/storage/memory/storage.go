@@ -0,0 +1,228 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"keeper-project/internal/store/file"
+	"keeper-project/types"
+)
+
+type object struct {
+	data    []byte
+	modTime time.Time
+}
+
+type upload struct {
+	session types.UploadSession
+	parts   map[int][]byte
+}
+
+type bucket struct {
+	files   map[string]*object
+	uploads map[string]*upload
+}
+
+// memoryStorage keeps everything in process memory, it is meant for tests and local runs.
+type memoryStorage struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+func NewStorage() file.Storage {
+	return &memoryStorage{buckets: make(map[string]*bucket)}
+}
+
+// lookup doesn't register unknown buckets, it returns an empty one instead.
+func (m *memoryStorage) lookup(name string) *bucket {
+	if b, ok := m.buckets[name]; ok {
+		return b
+	}
+	return &bucket{}
+}
+
+func (m *memoryStorage) bucket(name string) *bucket {
+	b, ok := m.buckets[name]
+	if !ok {
+		b = &bucket{
+			files:   make(map[string]*object),
+			uploads: make(map[string]*upload),
+		}
+		m.buckets[name] = b
+	}
+	return b
+}
+
+func (m *memoryStorage) GetFile(_ context.Context, bucketName, fileID string) (*types.File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	obj, ok := m.lookup(bucketName).files[fileID]
+	if !ok {
+		return nil, fmt.Errorf("%w: file %s", types.ErrNotFound, fileID)
+	}
+
+	return &types.File{
+		ID:      fileID,
+		Size:    int64(len(obj.data)),
+		ModTime: obj.modTime,
+		Content: readSeekNopCloser{bytes.NewReader(obj.data)},
+	}, nil
+}
+
+func (m *memoryStorage) CreateFile(_ context.Context, bucketName string, f *types.File) error {
+	data, err := io.ReadAll(f.Content)
+	if err != nil {
+		return fmt.Errorf("failed to read file. err: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.bucket(bucketName).files[f.ID] = &object{data: data, modTime: time.Now().UTC()}
+	return nil
+}
+
+func (m *memoryStorage) DeleteFile(_ context.Context, bucketName, fileID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.lookup(bucketName).files, fileID)
+	return nil
+}
+
+func (m *memoryStorage) GetBuckets(_ context.Context) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.buckets))
+	for name := range m.buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func (m *memoryStorage) CreateUpload(_ context.Context, bucketName string, u *types.UploadSession) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u.UploadID = uuid.New().String()
+	m.bucket(bucketName).uploads[u.ID] = &upload{session: *u, parts: make(map[int][]byte)}
+	return nil
+}
+
+func (m *memoryStorage) GetUpload(_ context.Context, bucketName, uploadID string) (*types.UploadSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.lookup(bucketName).uploads[uploadID]
+	if !ok {
+		return nil, fmt.Errorf("%w: upload %s", types.ErrNotFound, uploadID)
+	}
+
+	session := u.session
+	session.Chunks = make([]types.UploadChunkInfo, 0, len(u.parts))
+	for n, data := range u.parts {
+		session.Chunks = append(session.Chunks, chunkInfo(n, data))
+	}
+	sort.Slice(session.Chunks, func(i, j int) bool { return session.Chunks[i].Number < session.Chunks[j].Number })
+
+	return &session, nil
+}
+
+func (m *memoryStorage) PutChunk(_ context.Context, bucketName string, u *types.UploadSession,
+	chunk types.UploadChunk) (types.UploadChunkInfo, error) {
+	data, err := io.ReadAll(chunk.Reader)
+	if err != nil {
+		return types.UploadChunkInfo{}, fmt.Errorf("failed to read chunk. err: %w", err)
+	}
+	if int64(len(data)) != chunk.Size {
+		return types.UploadChunkInfo{}, fmt.Errorf("%w: %d bytes received instead of %d", types.ErrInvalidChunk, len(data), chunk.Size)
+	}
+	sum := md5.Sum(data)
+	if base64.StdEncoding.EncodeToString(sum[:]) != chunk.MD5 {
+		return types.UploadChunkInfo{}, types.ErrChecksumMismatch
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.lookup(bucketName).uploads[u.ID]
+	if !ok {
+		return types.UploadChunkInfo{}, fmt.Errorf("%w: upload %s", types.ErrNotFound, u.ID)
+	}
+	stored.parts[chunk.Number] = data
+
+	return chunkInfo(chunk.Number, data), nil
+}
+
+func (m *memoryStorage) CompleteUpload(_ context.Context, bucketName string, u *types.UploadSession) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b := m.lookup(bucketName)
+	stored, ok := b.uploads[u.ID]
+	if !ok {
+		return fmt.Errorf("%w: upload %s", types.ErrNotFound, u.ID)
+	}
+
+	var data []byte
+	for _, c := range u.Chunks {
+		part, ok := stored.parts[c.Number]
+		if !ok {
+			return fmt.Errorf("%w: chunk %d is missing", types.ErrUploadIncomplete, c.Number)
+		}
+		data = append(data, part...)
+	}
+
+	b.files[u.ID] = &object{data: data, modTime: time.Now().UTC()}
+	delete(b.uploads, u.ID)
+	return nil
+}
+
+func (m *memoryStorage) AbortUpload(_ context.Context, bucketName string, u *types.UploadSession) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.lookup(bucketName).uploads, u.ID)
+	return nil
+}
+
+func (m *memoryStorage) AbortStaleUploads(_ context.Context, bucketName string, before time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var aborted int64
+	uploads := m.lookup(bucketName).uploads
+	for id, u := range uploads {
+		if u.session.CreatedAt.Before(before) {
+			delete(uploads, id)
+			aborted++
+		}
+	}
+	return aborted, nil
+}
+
+func chunkInfo(number int, data []byte) types.UploadChunkInfo {
+	sum := md5.Sum(data)
+	return types.UploadChunkInfo{Number: number, Size: int64(len(data)), ETag: hex.EncodeToString(sum[:]),
+		MD5: base64.StdEncoding.EncodeToString(sum[:])}
+}
+
+type readSeekNopCloser struct {
+	io.ReadSeeker
+}
+
+func (readSeekNopCloser) Close() error { return nil }
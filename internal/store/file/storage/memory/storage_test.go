@@ -0,0 +1,14 @@
+package memory
+
+import (
+	"testing"
+
+	"keeper-project/internal/store/file"
+	"keeper-project/internal/store/file/storagetest"
+)
+
+func TestStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) file.Storage {
+		return NewStorage()
+	})
+}
@@ -0,0 +1,279 @@
+package minio
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"keeper-project/internal/store"
+	"keeper-project/pkg/minio"
+	"keeper-project/types"
+)
+
+// MigrateBuckets moves the objects of the legacy per-user buckets, named after
+// the user ID, into the shared bucket under the "<user_id>/" prefix and removes
+// the emptied buckets. Unfinished multipart uploads cannot be moved, they are
+// aborted together with their manifests and have to be restarted by the clients.
+// It is safe to run again after a failure, the moved objects are already gone
+// from the source bucket. Returns the number of moved objects.
+func MigrateBuckets(ctx context.Context, logger *zap.Logger, cfg Config) (int64, error) {
+	client, _, err := newClient(logger, cfg)
+	if err != nil {
+		return 0, err
+	}
+
+	err = client.EnsureBucket(ctx, cfg.Bucket)
+	if err != nil {
+		return 0, err
+	}
+
+	buckets, err := client.GetBuckets(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var moved int64
+	for _, bucket := range buckets {
+		if bucket == cfg.Bucket {
+			continue
+		}
+		if _, err := uuid.Parse(bucket); err != nil {
+			logger.Info("skipping bucket not named after a user", zap.String("bucket", bucket))
+			continue
+		}
+
+		n, err := migrateBucket(ctx, client, bucket, cfg.Bucket)
+		moved += n
+		if err != nil {
+			return moved, fmt.Errorf("failed to migrate bucket %s. err: %w", bucket, err)
+		}
+		logger.Info("bucket migrated", zap.String("bucket", bucket), zap.Int64("objects", n))
+	}
+
+	return moved, nil
+}
+
+func migrateBucket(ctx context.Context, client *minio.Client, bucket, dstBucket string) (int64, error) {
+	uploads, err := client.GetIncompleteUploads(ctx, bucket, "")
+	if err != nil {
+		return 0, err
+	}
+	for _, u := range uploads {
+		err = client.AbortMultipartUpload(ctx, bucket, u.Key, u.UploadID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	objects, err := client.ListObjects(ctx, bucket, "")
+	if err != nil {
+		return 0, err
+	}
+
+	var moved int64
+	for _, obj := range objects {
+		if !strings.HasPrefix(obj.ID, minio.UploadsPrefix) {
+			err = client.CopyObject(ctx, bucket, obj.ID, dstBucket, bucket+"/"+obj.ID)
+			if err != nil {
+				return moved, err
+			}
+			moved++
+		}
+
+		err = client.DeleteFile(ctx, bucket, obj.ID)
+		if err != nil {
+			return moved, err
+		}
+	}
+
+	return moved, client.RemoveBucket(ctx, bucket)
+}
+
+// RotateKeys rewraps the data keys with the current master key and encrypts
+// the objects stored before encryption was enabled. Returns the numbers of
+// rewrapped keys and encrypted objects.
+func RotateKeys(ctx context.Context, logger *zap.Logger, cfg Config) (int64, int64, error) {
+	client, encryption, err := newClient(logger, cfg)
+	if err != nil {
+		return 0, 0, err
+	}
+	if encryption == nil {
+		return 0, 0, errors.New("no master keys configured")
+	}
+
+	rotated, err := encryption.Rotate(ctx)
+	if err != nil {
+		return rotated, 0, err
+	}
+	logger.Info("data keys rewrapped", zap.Int64("keys", rotated), zap.String("master key", cfg.MasterKeys.CurrentID()))
+
+	owners, err := client.ListPrefixes(ctx, cfg.Bucket)
+	if err != nil {
+		return rotated, 0, err
+	}
+
+	var encrypted int64
+	for _, owner := range owners {
+		objects, err := client.ListObjects(ctx, cfg.Bucket, owner+"/")
+		if err != nil {
+			return rotated, encrypted, err
+		}
+		for _, obj := range objects {
+			ok, err := client.Encrypt(ctx, cfg.Bucket, owner+"/"+obj.ID)
+			if err != nil {
+				return rotated, encrypted, err
+			}
+			if ok {
+				encrypted++
+			}
+		}
+	}
+
+	return rotated, encrypted, nil
+}
+
+// legacyTrashPrefix is the key prefix the files in the trash were moved under
+// before the file records were kept in Postgres.
+const legacyTrashPrefix = "trash/"
+
+// BackfillFiles adds the records of the files stored before they were kept in
+// Postgres, when the name and metadata were the user metadata of the objects.
+// The files in the trash are moved back to their keys and stay in the trash.
+// It has to run after MigrateBuckets and before RotateKeys, and it is safe to run
+// again, the files with records already are skipped. Returns the number of added records.
+func BackfillFiles(ctx context.Context, logger *zap.Logger, cfg Config, files store.Files) (int64, error) {
+	client, _, err := newClient(logger, cfg)
+	if err != nil {
+		return 0, err
+	}
+
+	owners, err := client.ListPrefixes(ctx, cfg.Bucket)
+	if err != nil {
+		return 0, err
+	}
+
+	var added int64
+	for _, owner := range owners {
+		if _, err := uuid.Parse(owner); err != nil {
+			logger.Info("skipping prefix not named after a user", zap.String("prefix", owner))
+			continue
+		}
+
+		n, err := backfillOwner(ctx, client, cfg.Bucket, owner, files)
+		added += n
+		if err != nil {
+			return added, fmt.Errorf("failed to backfill files of user %s. err: %w", owner, err)
+		}
+		logger.Info("files backfilled", zap.String("user", owner), zap.Int64("files", n))
+	}
+
+	return added, nil
+}
+
+func backfillOwner(ctx context.Context, client *minio.Client, bucket, owner string, files store.Files) (int64, error) {
+	objects, err := client.ListObjectsWithMetadata(ctx, bucket, owner+"/")
+	if err != nil {
+		return 0, err
+	}
+
+	var added int64
+	for _, obj := range objects {
+		info, deletedAt, ok := legacyFile(owner, obj)
+		if !ok {
+			continue
+		}
+
+		key := owner + "/" + info.StorageKey
+		if deletedAt != nil {
+			err = client.CopyObject(ctx, bucket, owner+"/"+obj.ID, bucket, key)
+			if err != nil {
+				return added, err
+			}
+		}
+
+		info.Hash, info.Size, err = hashObject(ctx, client, bucket, key)
+		if err != nil {
+			return added, err
+		}
+
+		storageKey := info.StorageKey
+		err = files.Import(ctx, info, deletedAt)
+		switch {
+		case errors.Is(err, types.ErrRecordAlreadyExists):
+			// backfilled by a previous run
+		case err != nil:
+			return added, err
+		default:
+			added++
+			// the user has the same contents stored already
+			if info.StorageKey != storageKey {
+				err = client.DeleteFile(ctx, bucket, key)
+				if err != nil {
+					return added, err
+				}
+			}
+		}
+
+		if deletedAt != nil {
+			err = client.DeleteFile(ctx, bucket, owner+"/"+obj.ID)
+			if err != nil {
+				return added, err
+			}
+		}
+	}
+
+	return added, nil
+}
+
+// legacyFile returns the record of an object stored with the name in its user
+// metadata and the deletion time of the files in the trash, which is the time
+// they were moved there. ok is false for the objects stored without a name.
+func legacyFile(owner string, obj *minio.Object) (info *types.FileInfo, deletedAt *time.Time, ok bool) {
+	name, ok := obj.Metadata["Name"]
+	if !ok {
+		return nil, nil, false
+	}
+
+	id := obj.ID
+	if trashed, found := strings.CutPrefix(id, legacyTrashPrefix); found {
+		id = trashed
+		modified := obj.LastModified
+		deletedAt = &modified
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, nil, false
+	}
+
+	// the creation time of the files in the trash is lost, the deletion time is the closest
+	return &types.FileInfo{
+		ID:         id,
+		UserID:     owner,
+		Name:       name,
+		Metadata:   obj.Metadata["Metadata"],
+		StorageKey: id,
+		CreatedAt:  obj.LastModified,
+	}, deletedAt, true
+}
+
+func hashObject(ctx context.Context, client *minio.Client, bucket, key string) (string, int64, error) {
+	obj, err := client.GetFile(ctx, bucket, key)
+	if err != nil {
+		return "", 0, err
+	}
+	defer obj.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, obj)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read object %s. err: %w", key, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
@@ -1,10 +1,13 @@
 package minio
 
 import (
-	"bytes"
 	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"strings"
+	"time"
 
 	"go.uber.org/zap"
 
@@ -13,76 +16,346 @@ import (
 	"keeper-project/types"
 )
 
+type Config struct {
+	Endpoint        string
+	AccessKeyID     string
+	SecretAccessKey string
+	// Bucket is shared by all users, objects of a user are kept under the
+	// "<user_id>/" prefix.
+	Bucket string
+	// TLS enables HTTPS when not nil.
+	TLS *tls.Config
+	// MasterKeys enables SSE-C of the objects with per-user data keys kept
+	// in DataKeys, it requires TLS.
+	MasterKeys *minio.KeyRing
+	DataKeys   minio.KeyStore
+	// ObjectLock keeps the contents of the files in the trash under retention,
+	// the bucket must have been created with object locking.
+	ObjectLock bool
+}
+
+func newClient(logger *zap.Logger, cfg Config) (*minio.Client, *minio.Encryption, error) {
+	var (
+		opts       []minio.Option
+		encryption *minio.Encryption
+	)
+	if cfg.TLS != nil {
+		opts = append(opts, minio.WithTLS(cfg.TLS))
+	}
+	if cfg.MasterKeys != nil {
+		if cfg.TLS == nil {
+			return nil, nil, errors.New("server side encryption requires TLS")
+		}
+		if cfg.DataKeys == nil {
+			return nil, nil, errors.New("no store for data keys")
+		}
+		encryption = minio.NewEncryption(cfg.MasterKeys, cfg.DataKeys)
+		opts = append(opts, minio.WithEncryption(cfg.Bucket, encryption))
+	}
+
+	if cfg.ObjectLock {
+		opts = append(opts, minio.WithObjectLock(cfg.Bucket))
+	}
+
+	client, err := minio.NewClient(cfg.Endpoint, cfg.AccessKeyID, cfg.SecretAccessKey, logger, opts...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create minio client. err: %w", err)
+	}
+	return client, encryption, nil
+}
+
+// minioStorage keeps everything in a single bucket, the bucketName of
+// file.Storage is used as the key prefix.
 type minioStorage struct {
 	client *minio.Client
+	bucket string
 }
 
-func NewStorage(logger *zap.Logger, endpoint, accessKeyID, secretAccessKey string) (file.Storage, error) {
-	client, err := minio.NewClient(endpoint, accessKeyID, secretAccessKey, logger)
+// NewStorage creates the shared bucket unless it exists.
+func NewStorage(ctx context.Context, logger *zap.Logger, cfg Config) (file.Storage, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("minio bucket is not set")
+	}
+
+	client, _, err := newClient(logger, cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create minio client. err: %w", err)
+		return nil, err
 	}
+
+	err = client.EnsureBucket(ctx, cfg.Bucket)
+	if err != nil {
+		return nil, err
+	}
+
 	return &minioStorage{
 		client: client,
+		bucket: cfg.Bucket,
 	}, nil
 }
 
-func (m *minioStorage) GetFile(ctx context.Context, bucketName, fileID string) (*types.File, error) {
-	obj, err := m.client.GetFile(ctx, bucketName, fileID)
+// objectKey returns the key of an object of the user, both parts are IDs
+// generated by the server and never contain a slash.
+func objectKey(bucketName, key string) (string, error) {
+	if !validName(bucketName) || !validName(key) {
+		return "", fmt.Errorf("%w: invalid object key %q", types.ErrNotFound, bucketName+"/"+key)
+	}
+	return bucketName + "/" + key, nil
+}
+
+func validName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.Contains(name, "/")
+}
+
+func (m *minioStorage) GetFile(ctx context.Context, bucketName, key string) (*types.File, error) {
+	objKey, err := objectKey(bucketName, key)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get file. err: %w", err)
+		return nil, err
+	}
+
+	obj, err := m.client.GetFile(ctx, m.bucket, objKey)
+	if err != nil {
+		return nil, wrapNotFound(fmt.Errorf("failed to get file. err: %w", err))
 	}
-	defer obj.Close()
 	objectInfo, err := obj.Stat()
 	if err != nil {
+		obj.Close()
 		return nil, fmt.Errorf("failed to get file. err: %w", err)
 	}
-	buffer := make([]byte, objectInfo.Size)
-	_, err = obj.Read(buffer)
-	if err != nil && err != io.EOF {
-		return nil, fmt.Errorf("failed to get objects. err: %w", err)
-	}
+	// minio.Object implements io.ReadSeekCloser, so it is handed out as is
+	// and read lazily by the consumer.
 	f := types.File{
-		ID:       objectInfo.Key,
-		Name:     objectInfo.UserMetadata["Name"],
-		Size:     objectInfo.Size,
-		Bytes:    buffer,
-		Metadata: objectInfo.UserMetadata["Metadata"],
+		ID:      key,
+		Size:    objectInfo.Size,
+		ModTime: objectInfo.LastModified,
+		Content: obj,
 	}
 
 	return &f, nil
 }
 
-func (m *minioStorage) GetFilesList(ctx context.Context, bucketName string) ([]*types.Key, error) {
-	objs, err := m.client.GetFilesList(ctx, bucketName)
+func (m *minioStorage) CreateFile(ctx context.Context, bucketName string, file *types.File) error {
+	objKey, err := objectKey(bucketName, file.ID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get file. err: %w", err)
+		return err
 	}
 
-	var keys []*types.Key
+	err = m.client.UploadFile(ctx, objKey, m.bucket, file.Size, file.Content)
+	if err != nil {
+		return err
+	}
+	return nil
+}
 
-	for _, obj := range objs {
-		keys = append(keys, &types.Key{
-			Id:  obj.ID,
-			Key: obj.Name,
-		})
+func (m *minioStorage) DeleteFile(ctx context.Context, bucketName, key string) error {
+	objKey, err := objectKey(bucketName, key)
+	if err != nil {
+		return err
 	}
 
-	return keys, nil
+	err = m.client.DeleteFile(ctx, m.bucket, objKey)
+	if err != nil {
+		return err
+	}
+	return nil
 }
 
-func (m *minioStorage) CreateFile(ctx context.Context, bucketName string, file *types.File) error {
-	err := m.client.UploadFile(ctx, file.ID, file.Name, bucketName, file.Metadata, file.Size, bytes.NewBuffer(file.Bytes))
+// RetainFile keeps the contents from being deleted until the given moment when
+// the bucket has object locking.
+func (m *minioStorage) RetainFile(ctx context.Context, bucketName, key string, until time.Time) error {
+	objKey, err := objectKey(bucketName, key)
 	if err != nil {
 		return err
 	}
-	return nil
+	return wrapNotFound(m.client.Retain(ctx, m.bucket, objKey, until))
+}
+
+// GetBuckets returns the user prefixes of the shared bucket.
+func (m *minioStorage) GetBuckets(ctx context.Context) ([]string, error) {
+	return m.client.ListPrefixes(ctx, m.bucket)
+}
+
+// manifestKey returns the key of the upload manifest, kept under the user prefix
+// so that AbortStaleUploads lists only the sessions of one user.
+func manifestKey(bucketName, uploadID string) (string, error) {
+	if !validName(bucketName) || !validName(uploadID) {
+		return "", fmt.Errorf("%w: invalid upload id %q", types.ErrNotFound, uploadID)
+	}
+	return bucketName + "/" + minio.UploadsPrefix + uploadID, nil
+}
+
+// uploadManifest is the upload session as persisted next to the multipart upload.
+type uploadManifest struct {
+	types.UploadSession
+	UploadID string `json:"upload_id"`
 }
 
-func (m *minioStorage) DeleteFile(ctx context.Context, bucketName, fileId string) error {
-	err := m.client.DeleteFile(ctx, bucketName, fileId)
+func (m *minioStorage) CreateUpload(ctx context.Context, bucketName string, upload *types.UploadSession) error {
+	objKey, err := objectKey(bucketName, upload.ID)
 	if err != nil {
 		return err
 	}
+	manifest, err := manifestKey(bucketName, upload.ID)
+	if err != nil {
+		return err
+	}
+
+	uploadID, err := m.client.NewMultipartUpload(ctx, m.bucket, objKey)
+	if err != nil {
+		return err
+	}
+	upload.UploadID = uploadID
+
+	data, err := json.Marshal(uploadManifest{UploadSession: *upload, UploadID: uploadID})
+	if err != nil {
+		return fmt.Errorf("failed to encode upload manifest. err: %w", err)
+	}
+
+	err = m.client.PutObject(ctx, m.bucket, manifest, data)
+	if err != nil {
+		_ = m.client.AbortMultipartUpload(ctx, m.bucket, objKey, uploadID)
+		return err
+	}
 	return nil
 }
+
+func (m *minioStorage) GetUpload(ctx context.Context, bucketName, uploadID string) (*types.UploadSession, error) {
+	manifestObj, err := manifestKey(bucketName, uploadID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := m.client.ReadObject(ctx, m.bucket, manifestObj)
+	if err != nil {
+		return nil, wrapNotFound(err)
+	}
+
+	var manifest uploadManifest
+	err = json.Unmarshal(data, &manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode upload manifest. err: %w", err)
+	}
+	upload := manifest.UploadSession
+	upload.UploadID = manifest.UploadID
+
+	objKey, err := objectKey(bucketName, upload.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	parts, err := m.client.ListParts(ctx, m.bucket, objKey, upload.UploadID)
+	if err != nil {
+		return nil, wrapNotFound(err)
+	}
+
+	upload.Chunks = make([]types.UploadChunkInfo, 0, len(parts))
+	for _, p := range parts {
+		upload.Chunks = append(upload.Chunks, types.UploadChunkInfo{Number: p.Number, Size: p.Size, ETag: p.ETag, MD5: p.MD5})
+	}
+
+	return &upload, nil
+}
+
+func (m *minioStorage) PutChunk(ctx context.Context, bucketName string, upload *types.UploadSession,
+	chunk types.UploadChunk) (types.UploadChunkInfo, error) {
+	objKey, err := objectKey(bucketName, upload.ID)
+	if err != nil {
+		return types.UploadChunkInfo{}, err
+	}
+
+	part, err := m.client.PutPart(ctx, m.bucket, objKey, upload.UploadID, chunk.Number, chunk.Reader, chunk.Size, chunk.MD5)
+	if err != nil {
+		if errors.Is(err, minio.ErrBadDigest) {
+			return types.UploadChunkInfo{}, fmt.Errorf("%w: %s", types.ErrChecksumMismatch, err.Error())
+		}
+		return types.UploadChunkInfo{}, wrapNotFound(err)
+	}
+	return types.UploadChunkInfo{Number: part.Number, Size: part.Size, ETag: part.ETag, MD5: part.MD5}, nil
+}
+
+func (m *minioStorage) CompleteUpload(ctx context.Context, bucketName string, upload *types.UploadSession) error {
+	objKey, err := objectKey(bucketName, upload.ID)
+	if err != nil {
+		return err
+	}
+	manifest, err := manifestKey(bucketName, upload.ID)
+	if err != nil {
+		return err
+	}
+
+	parts := make([]minio.Part, 0, len(upload.Chunks))
+	for _, c := range upload.Chunks {
+		parts = append(parts, minio.Part{Number: c.Number, Size: c.Size, ETag: c.ETag})
+	}
+
+	err = m.client.CompleteMultipartUpload(ctx, m.bucket, objKey, upload.UploadID, parts)
+	if err != nil {
+		return err
+	}
+	return m.client.DeleteFile(ctx, m.bucket, manifest)
+}
+
+func (m *minioStorage) AbortUpload(ctx context.Context, bucketName string, upload *types.UploadSession) error {
+	objKey, err := objectKey(bucketName, upload.ID)
+	if err != nil {
+		return err
+	}
+	manifest, err := manifestKey(bucketName, upload.ID)
+	if err != nil {
+		return err
+	}
+
+	err = m.client.AbortMultipartUpload(ctx, m.bucket, objKey, upload.UploadID)
+	if err != nil {
+		return err
+	}
+	return m.client.DeleteFile(ctx, m.bucket, manifest)
+}
+
+func (m *minioStorage) AbortStaleUploads(ctx context.Context, bucketName string, before time.Time) (int64, error) {
+	if !validName(bucketName) {
+		return 0, fmt.Errorf("%w: invalid prefix %q", types.ErrNotFound, bucketName)
+	}
+	prefix := bucketName + "/"
+
+	manifests, err := m.client.GetUploadManifests(ctx, m.bucket, prefix)
+	if err != nil {
+		return 0, err
+	}
+
+	var aborted int64
+	for _, obj := range manifests {
+		if !obj.LastModified.Before(before) {
+			continue
+		}
+		err = m.client.DeleteFile(ctx, m.bucket, prefix+minio.UploadsPrefix+obj.ID)
+		if err != nil {
+			return aborted, err
+		}
+		aborted++
+	}
+
+	// multipart uploads are aborted even without a manifest, e.g. when the
+	// session creation failed halfway.
+	uploads, err := m.client.GetIncompleteUploads(ctx, m.bucket, prefix)
+	if err != nil {
+		return aborted, err
+	}
+	for _, u := range uploads {
+		if !u.Initiated.Before(before) {
+			continue
+		}
+		err = m.client.AbortMultipartUpload(ctx, m.bucket, u.Key, u.UploadID)
+		if err != nil {
+			return aborted, err
+		}
+	}
+
+	return aborted, nil
+}
+
+func wrapNotFound(err error) error {
+	if errors.Is(err, minio.ErrNotFound) {
+		return fmt.Errorf("%w: %s", types.ErrNotFound, err.Error())
+	}
+	return err
+}
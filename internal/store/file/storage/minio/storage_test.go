@@ -0,0 +1,166 @@
+package minio
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	miniogo "github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"keeper-project/internal/mocks"
+	"keeper-project/internal/store/file"
+	"keeper-project/internal/store/file/storagetest"
+	"keeper-project/pkg/minio"
+	"keeper-project/types"
+)
+
+// TestStorage needs a running MinIO, e.g. the one from docker-compose:
+// MINIO_TEST_URL=localhost:9000 MINIO_TEST_ACCESS_KEY=minio MINIO_TEST_SECRET_KEY=minio123 go test ./...
+func TestStorage(t *testing.T) {
+	url := os.Getenv("MINIO_TEST_URL")
+	if url == "" {
+		t.Skip("MINIO_TEST_URL is not set")
+	}
+
+	s, err := NewStorage(context.Background(), zap.L(), testConfig(url))
+	require.NoError(t, err)
+
+	storagetest.Run(t, func(t *testing.T) file.Storage { return s })
+}
+
+func TestMigrateBuckets(t *testing.T) {
+	url := os.Getenv("MINIO_TEST_URL")
+	if url == "" {
+		t.Skip("MINIO_TEST_URL is not set")
+	}
+	ctx := context.Background()
+	cfg := testConfig(url)
+
+	client, err := minio.NewClient(cfg.Endpoint, cfg.AccessKeyID, cfg.SecretAccessKey, zap.L())
+	require.NoError(t, err)
+
+	userID := uuid.NewString()
+	fileID := uuid.NewString()
+	require.NoError(t, client.EnsureBucket(ctx, userID))
+	require.NoError(t, client.PutObject(ctx, userID, fileID, []byte("legacy")))
+	require.NoError(t, client.PutObject(ctx, userID, minio.UploadsPrefix+uuid.NewString(), []byte("{}")))
+
+	moved, err := MigrateBuckets(ctx, zap.L(), cfg)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, moved, int64(1))
+
+	s, err := NewStorage(ctx, zap.L(), cfg)
+	require.NoError(t, err)
+	f, err := s.GetFile(ctx, userID, fileID)
+	require.NoError(t, err)
+	defer f.Content.Close()
+	data, err := io.ReadAll(f.Content)
+	require.NoError(t, err)
+	assert.Equal(t, "legacy", string(data))
+
+	buckets, err := client.GetBuckets(ctx)
+	require.NoError(t, err)
+	assert.NotContains(t, buckets, userID)
+}
+
+func TestBackfillFiles(t *testing.T) {
+	url := os.Getenv("MINIO_TEST_URL")
+	if url == "" {
+		t.Skip("MINIO_TEST_URL is not set")
+	}
+	ctx := context.Background()
+	cfg := testConfig(url)
+
+	client, err := miniogo.New(cfg.Endpoint, &miniogo.Options{
+		Creds: credentials.NewStaticV4(cfg.AccessKeyID, cfg.SecretAccessKey, ""),
+	})
+	require.NoError(t, err)
+	s, err := NewStorage(ctx, zap.L(), cfg)
+	require.NoError(t, err)
+
+	userID := uuid.NewString()
+	fileID, trashedID, blobID := uuid.NewString(), uuid.NewString(), uuid.NewString()
+	put := func(key, content string, meta map[string]string) {
+		_, err := client.PutObject(ctx, cfg.Bucket, userID+"/"+key, strings.NewReader(content), int64(len(content)),
+			miniogo.PutObjectOptions{UserMetadata: meta})
+		require.NoError(t, err)
+	}
+	put(fileID, "legacy", map[string]string{"Name": "file.txt", "Metadata": "meta"})
+	put(legacyTrashPrefix+trashedID, "trashed", map[string]string{"Name": "old.txt"})
+	put(blobID, "blob", nil)
+
+	files := mocks.NewMockFiles(gomock.NewController(t))
+	files.EXPECT().Import(gomock.Any(), gomock.Any(), nil).DoAndReturn(
+		func(_ context.Context, info *types.FileInfo, _ *time.Time) error {
+			assert.Equal(t, fileID, info.ID)
+			assert.Equal(t, "file.txt", info.Name)
+			assert.Equal(t, "meta", info.Metadata)
+			assert.Equal(t, int64(len("legacy")), info.Size)
+			return nil
+		})
+	files.EXPECT().Import(gomock.Any(), gomock.Any(), gomock.Not(gomock.Nil())).DoAndReturn(
+		func(_ context.Context, info *types.FileInfo, _ *time.Time) error {
+			assert.Equal(t, trashedID, info.ID)
+			assert.Equal(t, "old.txt", info.Name)
+			return nil
+		})
+
+	added, err := BackfillFiles(ctx, zap.L(), cfg, files)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), added)
+
+	// the trashed file is back at its key
+	f, err := s.GetFile(ctx, userID, trashedID)
+	require.NoError(t, err)
+	defer f.Content.Close()
+	data, err := io.ReadAll(f.Content)
+	require.NoError(t, err)
+	assert.Equal(t, "trashed", string(data))
+
+	_, err = client.StatObject(ctx, cfg.Bucket, userID+"/"+legacyTrashPrefix+trashedID, miniogo.StatObjectOptions{})
+	assert.Error(t, err)
+}
+
+func TestLegacyFile(t *testing.T) {
+	owner := uuid.NewString()
+	id := uuid.NewString()
+	modified := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	info, deletedAt, ok := legacyFile(owner, &minio.Object{ID: id, LastModified: modified,
+		Metadata: map[string]string{"Name": "file.txt", "Metadata": "meta"}})
+	require.True(t, ok)
+	assert.Nil(t, deletedAt)
+	assert.Equal(t, &types.FileInfo{ID: id, UserID: owner, Name: "file.txt", Metadata: "meta", StorageKey: id,
+		CreatedAt: modified}, info)
+
+	info, deletedAt, ok = legacyFile(owner, &minio.Object{ID: legacyTrashPrefix + id, LastModified: modified,
+		Metadata: map[string]string{"Name": "file.txt"}})
+	require.True(t, ok)
+	assert.Equal(t, &modified, deletedAt)
+	assert.Equal(t, id, info.StorageKey)
+
+	// blobs stored with the records in Postgres have no name
+	_, _, ok = legacyFile(owner, &minio.Object{ID: id, Metadata: map[string]string{}})
+	assert.False(t, ok)
+
+	_, _, ok = legacyFile(owner, &minio.Object{ID: minio.UploadsPrefix + id, Metadata: map[string]string{"Name": "x"}})
+	assert.False(t, ok)
+}
+
+func testConfig(url string) Config {
+	return Config{
+		Endpoint:        url,
+		AccessKeyID:     os.Getenv("MINIO_TEST_ACCESS_KEY"),
+		SecretAccessKey: os.Getenv("MINIO_TEST_SECRET_KEY"),
+		Bucket:          "keeper-test",
+	}
+}
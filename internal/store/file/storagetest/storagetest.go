@@ -0,0 +1,232 @@
+// Package storagetest is the conformance suite every file.Storage implementation must pass.
+package storagetest
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"keeper-project/internal/store/file"
+	"keeper-project/types"
+)
+
+// Run runs the suite, newStorage is called for every test. Every test works in
+// its own random bucket, so the returned storages may share state.
+func Run(t *testing.T, newStorage func(t *testing.T) file.Storage) {
+	t.Run("CreateAndGet", func(t *testing.T) { testCreateAndGet(t, newStorage(t)) })
+	t.Run("Delete", func(t *testing.T) { testDelete(t, newStorage(t)) })
+	t.Run("Buckets", func(t *testing.T) { testBuckets(t, newStorage(t)) })
+	t.Run("Upload", func(t *testing.T) { testUpload(t, newStorage(t)) })
+	t.Run("UploadChecksum", func(t *testing.T) { testUploadChecksum(t, newStorage(t)) })
+	t.Run("UploadAbort", func(t *testing.T) { testUploadAbort(t, newStorage(t)) })
+}
+
+func newBucket() string {
+	return uuid.New().String()
+}
+
+func createFile(t *testing.T, s file.Storage, bucket string, data []byte) string {
+	t.Helper()
+
+	id := uuid.New().String()
+	err := s.CreateFile(context.Background(), bucket, &types.File{
+		ID:      id,
+		Size:    int64(len(data)),
+		Content: io.NopCloser(bytes.NewReader(data)),
+	})
+	require.NoError(t, err)
+	return id
+}
+
+func readFile(t *testing.T, s file.Storage, bucket, id string) (*types.File, []byte) {
+	t.Helper()
+
+	f, err := s.GetFile(context.Background(), bucket, id)
+	require.NoError(t, err)
+	defer f.Content.Close()
+
+	data, err := io.ReadAll(f.Content)
+	require.NoError(t, err)
+	return f, data
+}
+
+func testCreateAndGet(t *testing.T, s file.Storage) {
+	bucket := newBucket()
+	data := bytes.Repeat([]byte("keeper"), 1000)
+
+	id := createFile(t, s, bucket, data)
+
+	f, content := readFile(t, s, bucket, id)
+	assert.Equal(t, id, f.ID)
+	assert.Equal(t, int64(len(data)), f.Size)
+	assert.False(t, f.ModTime.IsZero())
+	assert.Equal(t, data, content)
+
+	_, err := s.GetFile(context.Background(), bucket, uuid.New().String())
+	assert.ErrorIs(t, err, types.ErrNotFound)
+}
+
+func testDelete(t *testing.T, s file.Storage) {
+	bucket := newBucket()
+	id := createFile(t, s, bucket, []byte("data"))
+
+	require.NoError(t, s.DeleteFile(context.Background(), bucket, id))
+
+	_, err := s.GetFile(context.Background(), bucket, id)
+	assert.ErrorIs(t, err, types.ErrNotFound)
+
+	// deleting a missing file is not an error, purges are retried after failures
+	require.NoError(t, s.DeleteFile(context.Background(), bucket, id))
+}
+
+func testBuckets(t *testing.T, s file.Storage) {
+	bucket := newBucket()
+	createFile(t, s, bucket, []byte("data"))
+
+	buckets, err := s.GetBuckets(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, buckets, bucket)
+}
+
+// uploadChunks returns chunks large enough for every backend, S3 requires all
+// parts but the last one to be at least 5 MiB.
+func uploadChunks() [][]byte {
+	return [][]byte{
+		bytes.Repeat([]byte{1}, types.UploadChunkSize),
+		[]byte("tail"),
+	}
+}
+
+func newUpload(t *testing.T, s file.Storage, bucket string, chunks [][]byte) *types.UploadSession {
+	t.Helper()
+
+	var size int64
+	for _, c := range chunks {
+		size += int64(len(c))
+	}
+
+	upload := &types.UploadSession{
+		ID:        uuid.New().String(),
+		Name:      "upload",
+		Size:      size,
+		ChunkSize: types.UploadChunkSize,
+		Metadata:  "meta",
+		CreatedAt: time.Now().UTC(),
+	}
+	require.NoError(t, s.CreateUpload(context.Background(), bucket, upload))
+	assert.NotEmpty(t, upload.UploadID)
+	return upload
+}
+
+func putChunk(s file.Storage, bucket string, upload *types.UploadSession, n int, data []byte) (types.UploadChunkInfo, error) {
+	sum := md5.Sum(data)
+	return s.PutChunk(context.Background(), bucket, upload, types.UploadChunk{
+		Number: n,
+		Size:   int64(len(data)),
+		MD5:    base64.StdEncoding.EncodeToString(sum[:]),
+		Reader: bytes.NewReader(data),
+	})
+}
+
+func testUpload(t *testing.T, s file.Storage) {
+	ctx := context.Background()
+	bucket := newBucket()
+	chunks := uploadChunks()
+	upload := newUpload(t, s, bucket, chunks)
+
+	_, err := s.GetUpload(ctx, bucket, uuid.New().String())
+	assert.ErrorIs(t, err, types.ErrNotFound)
+
+	// chunks may arrive in any order and be sent again
+	for _, n := range []int{2, 1, 2} {
+		info, err := putChunk(s, bucket, upload, n, chunks[n-1])
+		require.NoError(t, err)
+		sum := md5.Sum(chunks[n-1])
+		assert.Equal(t, n, info.Number)
+		assert.Equal(t, int64(len(chunks[n-1])), info.Size)
+		assert.NotEmpty(t, info.ETag)
+		assert.Equal(t, base64.StdEncoding.EncodeToString(sum[:]), info.MD5)
+	}
+
+	stored, err := s.GetUpload(ctx, bucket, upload.ID)
+	require.NoError(t, err)
+	assert.Equal(t, upload.Name, stored.Name)
+	assert.Equal(t, upload.Size, stored.Size)
+	assert.Equal(t, upload.Metadata, stored.Metadata)
+	assert.Equal(t, upload.UploadID, stored.UploadID)
+	require.Len(t, stored.Chunks, 2)
+	assert.Equal(t, 1, stored.Chunks[0].Number)
+	assert.Equal(t, 2, stored.Chunks[1].Number)
+	for _, c := range stored.Chunks {
+		// the digest may be unknown, e.g. for encrypted parts, but never wrong
+		if c.MD5 != "" {
+			sum := md5.Sum(chunks[c.Number-1])
+			assert.Equal(t, base64.StdEncoding.EncodeToString(sum[:]), c.MD5)
+		}
+	}
+
+	_, err = s.GetFile(ctx, bucket, upload.ID)
+	assert.ErrorIs(t, err, types.ErrNotFound, "unfinished uploads must not be readable")
+
+	require.NoError(t, s.CompleteUpload(ctx, bucket, stored))
+
+	f, content := readFile(t, s, bucket, upload.ID)
+	assert.Equal(t, upload.Size, f.Size)
+	assert.Equal(t, bytes.Join(chunks, nil), content)
+
+	_, err = s.GetUpload(ctx, bucket, upload.ID)
+	assert.ErrorIs(t, err, types.ErrNotFound)
+}
+
+func testUploadChecksum(t *testing.T, s file.Storage) {
+	bucket := newBucket()
+	upload := newUpload(t, s, bucket, uploadChunks())
+
+	sum := md5.Sum([]byte("other"))
+	_, err := s.PutChunk(context.Background(), bucket, upload, types.UploadChunk{
+		Number: 2,
+		Size:   4,
+		MD5:    base64.StdEncoding.EncodeToString(sum[:]),
+		Reader: bytes.NewReader([]byte("tail")),
+	})
+	assert.ErrorIs(t, err, types.ErrChecksumMismatch)
+
+	stored, err := s.GetUpload(context.Background(), bucket, upload.ID)
+	require.NoError(t, err)
+	assert.Empty(t, stored.Chunks)
+}
+
+func testUploadAbort(t *testing.T, s file.Storage) {
+	ctx := context.Background()
+	bucket := newBucket()
+	chunks := uploadChunks()
+
+	aborted := newUpload(t, s, bucket, chunks)
+	_, err := putChunk(s, bucket, aborted, 2, chunks[1])
+	require.NoError(t, err)
+
+	require.NoError(t, s.AbortUpload(ctx, bucket, aborted))
+	_, err = s.GetUpload(ctx, bucket, aborted.ID)
+	assert.ErrorIs(t, err, types.ErrNotFound)
+
+	stale := newUpload(t, s, bucket, chunks)
+
+	n, err := s.AbortStaleUploads(ctx, bucket, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+
+	n, err = s.AbortStaleUploads(ctx, bucket, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	_, err = s.GetUpload(ctx, bucket, stale.ID)
+	assert.ErrorIs(t, err, types.ErrNotFound)
+}
@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"keeper-project/types"
+)
+
+// Values returns the placeholders of rows of cols values each numbered from
+// first, e.g. "($2, $3), ($4, $5)" for Values(2, 2, 2).
+func Values(rows, cols, first int) string {
+	var sb strings.Builder
+	n := first
+	for i := 0; i < rows; i++ {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString("(")
+		for j := 0; j < cols; j++ {
+			if j > 0 {
+				sb.WriteString(", ")
+			}
+			fmt.Fprintf(&sb, "$%d", n)
+			n++
+		}
+		sb.WriteString(")")
+	}
+	return sb.String()
+}
+
+// CheckReturned reads the IDs returned by a statement changing the records ids
+// and returns *types.ItemError with sql.ErrNoRows for the first ID missing.
+func CheckReturned(rows *sql.Rows, ids []string) error {
+	defer rows.Close()
+
+	returned := make(map[string]struct{}, len(ids))
+	for rows.Next() {
+		var id string
+		err := rows.Scan(&id)
+		if err != nil {
+			return err
+		}
+		returned[id] = struct{}{}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i, id := range ids {
+		if _, ok := returned[id]; !ok {
+			return &types.ItemError{Index: i, Err: sql.ErrNoRows}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package datakeys
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"keeper-project/pkg/minio"
+)
+
+type repo struct {
+	db *sql.DB
+}
+
+// NewRepository keeps the wrapped data keys of the MinIO server side encryption.
+func NewRepository(db *sql.DB) minio.KeyStore {
+	return &repo{db: db}
+}
+
+func (repo *repo) GetDataKey(ctx context.Context, owner string) (*minio.DataKey, error) {
+	key := minio.DataKey{Owner: owner}
+
+	row := repo.db.QueryRowContext(ctx,
+		"SELECT master_key_id, wrapped FROM data_keys WHERE user_id=$1", owner)
+	err := row.Scan(&key.MasterKeyID, &key.Wrapped)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: data key of %s", minio.ErrNotFound, owner)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (repo *repo) CreateDataKey(ctx context.Context, key *minio.DataKey) (*minio.DataKey, error) {
+	if key == nil || key.Owner == "" {
+		return nil, errors.New("repository: incorrect parameters")
+	}
+
+	// the key created first wins, the caller gets it back either way.
+	_, err := repo.db.ExecContext(ctx,
+		"INSERT INTO data_keys(user_id, master_key_id, wrapped) VALUES ($1, $2, $3) ON CONFLICT (user_id) DO NOTHING",
+		key.Owner, key.MasterKeyID, key.Wrapped)
+	if err != nil {
+		return nil, err
+	}
+	return repo.GetDataKey(ctx, key.Owner)
+}
+
+func (repo *repo) ListDataKeys(ctx context.Context) ([]*minio.DataKey, error) {
+	rows, err := repo.db.QueryContext(ctx, "SELECT user_id, master_key_id, wrapped FROM data_keys")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*minio.DataKey
+	for rows.Next() {
+		var key minio.DataKey
+		err = rows.Scan(&key.Owner, &key.MasterKeyID, &key.Wrapped)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, &key)
+	}
+	return keys, rows.Err()
+}
+
+func (repo *repo) UpdateDataKey(ctx context.Context, key *minio.DataKey, prevMasterKeyID string) error {
+	if key == nil || key.Owner == "" {
+		return errors.New("repository: incorrect parameters")
+	}
+
+	_, err := repo.db.ExecContext(ctx,
+		"UPDATE data_keys SET master_key_id=$2, wrapped=$3, rotated_at=now() WHERE user_id=$1 and master_key_id=$4",
+		key.Owner, key.MasterKeyID, key.Wrapped, prevMasterKeyID)
+	return err
+}
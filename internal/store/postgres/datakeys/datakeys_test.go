@@ -0,0 +1,115 @@
+package datakeys
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"keeper-project/pkg/minio"
+)
+
+func TestGetDataKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("^SELECT master_key_id, wrapped FROM data_keys").WithArgs("test").
+		WillReturnRows(sqlmock.NewRows([]string{"master_key_id", "wrapped"}).AddRow("k1", []byte("wrapped")))
+
+	store := NewRepository(db)
+
+	key, err := store.GetDataKey(context.Background(), "test")
+	require.NoError(t, err)
+	require.Equal(t, &minio.DataKey{Owner: "test", MasterKeyID: "k1", Wrapped: []byte("wrapped")}, key)
+}
+
+func TestGetDataKey_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("^SELECT master_key_id, wrapped FROM data_keys").WithArgs("test").
+		WillReturnRows(sqlmock.NewRows([]string{"master_key_id", "wrapped"}))
+
+	store := NewRepository(db)
+
+	_, err = store.GetDataKey(context.Background(), "test")
+	require.ErrorIs(t, err, minio.ErrNotFound)
+}
+
+func TestCreateDataKey_Conflict(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("^INSERT INTO data_keys(.+) ON CONFLICT").WithArgs("test", "k2", []byte("new")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("^SELECT master_key_id, wrapped FROM data_keys").WithArgs("test").
+		WillReturnRows(sqlmock.NewRows([]string{"master_key_id", "wrapped"}).AddRow("k1", []byte("stored")))
+
+	store := NewRepository(db)
+
+	key, err := store.CreateDataKey(context.Background(), &minio.DataKey{Owner: "test", MasterKeyID: "k2", Wrapped: []byte("new")})
+	require.NoError(t, err)
+	require.Equal(t, &minio.DataKey{Owner: "test", MasterKeyID: "k1", Wrapped: []byte("stored")}, key)
+}
+
+func TestCreateDataKey_IncorrectParams(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewRepository(db)
+
+	_, err = store.CreateDataKey(context.Background(), &minio.DataKey{})
+	require.Error(t, err)
+}
+
+func TestListDataKeys(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("^SELECT user_id, master_key_id, wrapped FROM data_keys").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "master_key_id", "wrapped"}).
+			AddRow("u1", "k1", []byte("a")).
+			AddRow("u2", "k2", []byte("b")))
+
+	store := NewRepository(db)
+
+	keys, err := store.ListDataKeys(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []*minio.DataKey{
+		{Owner: "u1", MasterKeyID: "k1", Wrapped: []byte("a")},
+		{Owner: "u2", MasterKeyID: "k2", Wrapped: []byte("b")},
+	}, keys)
+}
+
+func TestUpdateDataKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("^UPDATE data_keys SET").WithArgs("test", "k2", []byte("new"), "k1").
+		WillReturnError(errors.New("some error"))
+
+	store := NewRepository(db)
+
+	err = store.UpdateDataKey(context.Background(), &minio.DataKey{Owner: "test", MasterKeyID: "k2", Wrapped: []byte("new")}, "k1")
+	require.Error(t, err)
+}
@@ -0,0 +1,342 @@
+package files
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"keeper-project/internal/store"
+	"keeper-project/internal/store/postgres"
+	"keeper-project/types"
+)
+
+const fileColumns = "user_id, id, name, size, hash, metadata, storage_key, created_at"
+
+// files are never modified, the updated sort falls back to the creation time.
+var listQuery = postgres.ListQuery{
+	Select:  fileColumns,
+	From:    "files",
+	Where:   "user_id=$1 and deleted_at IS NULL",
+	Created: "created_at",
+	Sorts: map[string]postgres.Column{
+		"":                postgres.TimeColumn("created_at"),
+		types.SortCreated: postgres.TimeColumn("created_at"),
+		types.SortUpdated: postgres.TimeColumn("created_at"),
+		types.SortSize:    postgres.IntColumn("size"),
+	},
+}
+
+type repo struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) store.Files {
+	return &repo{db: db}
+}
+
+func (repo *repo) Create(ctx context.Context, info *types.FileInfo) error {
+	return repo.create(ctx, info, insertFile)
+}
+
+// Import keeps info.CreatedAt and marks the file deleted at deletedAt unless it is nil.
+func (repo *repo) Import(ctx context.Context, info *types.FileInfo, deletedAt *time.Time) error {
+	return repo.create(ctx, info, func(ctx context.Context, tx postgres.Executor, info *types.FileInfo) error {
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO files(user_id, id, name, size, hash, metadata, storage_key, created_at, deleted_at) "+
+				"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)",
+			info.UserID, info.ID, info.Name, info.Size, info.Hash, info.Metadata, info.StorageKey, info.CreatedAt, deletedAt)
+		return insertErr(err)
+	})
+}
+
+func (repo *repo) create(ctx context.Context, info *types.FileInfo,
+	insert func(ctx context.Context, tx postgres.Executor, info *types.FileInfo) error) error {
+	if info == nil || info.ID == "" {
+		return errors.New("repository: incorrect parameters")
+	}
+
+	return repo.inTx(ctx, func(tx postgres.Executor) error {
+		// a concurrent upload of the same contents waits here and references the blob stored first.
+		row := tx.QueryRowContext(ctx,
+			"INSERT INTO blobs(user_id, hash, storage_key, size, refs) VALUES ($1, $2, $3, $4, 1) "+
+				"ON CONFLICT (user_id, hash) DO UPDATE SET refs = blobs.refs + 1 RETURNING storage_key",
+			info.UserID, info.Hash, info.StorageKey, info.Size)
+		err := row.Scan(&info.StorageKey)
+		if err != nil {
+			return err
+		}
+		return insert(ctx, tx, info)
+	})
+}
+
+func (repo *repo) Link(ctx context.Context, info *types.FileInfo) error {
+	if info == nil || info.ID == "" {
+		return errors.New("repository: incorrect parameters")
+	}
+
+	return repo.inTx(ctx, func(tx postgres.Executor) error {
+		row := tx.QueryRowContext(ctx,
+			"UPDATE blobs SET refs = refs + 1 WHERE user_id=$1 and hash=$2 and refs > 0 RETURNING storage_key, size",
+			info.UserID, info.Hash)
+		err := row.Scan(&info.StorageKey, &info.Size)
+		if err != nil {
+			return err
+		}
+		return insertFile(ctx, tx, info)
+	})
+}
+
+func (repo *repo) Get(ctx context.Context, userID, id string) (*types.FileInfo, error) {
+	if id == "" {
+		return nil, errors.New("repository: incorrect parameters")
+	}
+
+	row := repo.db.QueryRowContext(ctx,
+		"SELECT "+fileColumns+" FROM files WHERE user_id=$1 and id=$2 and deleted_at IS NULL", userID, id)
+
+	return scanFile(row)
+}
+
+func (repo *repo) List(ctx context.Context, userID string, opts types.ListOptions) (*types.Page[*types.FileInfo], error) {
+	page := &types.Page[*types.FileInfo]{Items: make([]*types.FileInfo, 0)}
+
+	var err error
+	page.Next, page.Total, err = postgres.List(ctx, repo.db, listQuery, userID, opts, func(rows *sql.Rows, sortValue any) (string, error) {
+		info, err := scanFile(rows, sortValue)
+		if err != nil {
+			return "", err
+		}
+		page.Items = append(page.Items, info)
+		return info.ID, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+func (repo *repo) Delete(ctx context.Context, userID, id string) error {
+	if id == "" {
+		return errors.New("repository: incorrect parameters")
+	}
+
+	result, err := repo.db.ExecContext(ctx, "UPDATE files SET deleted_at=now() WHERE user_id=$1 and id =$2 and deleted_at IS NULL;",
+		userID, id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows != 1 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (repo *repo) GetDeletedList(ctx context.Context, userID string) ([]types.TrashItem, error) {
+	var ret []types.TrashItem
+
+	rows, err := repo.db.QueryContext(ctx,
+		"SELECT id, name, deleted_at FROM files WHERE user_id=$1 and deleted_at IS NOT NULL", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item types.TrashItem
+		err = rows.Scan(&item.Id, &item.Key, &item.DeletedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (repo *repo) Restore(ctx context.Context, userID, id string) error {
+	if id == "" {
+		return errors.New("repository: incorrect parameters")
+	}
+
+	result, err := repo.db.ExecContext(ctx, "UPDATE files SET deleted_at=NULL WHERE user_id=$1 and id =$2 and deleted_at IS NOT NULL;",
+		userID, id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows != 1 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (repo *repo) GetDeleted(ctx context.Context, userID string) ([]*types.FileInfo, error) {
+	return repo.queryFiles(ctx,
+		"SELECT "+fileColumns+" FROM files WHERE user_id=$1 and deleted_at IS NOT NULL", userID)
+}
+
+func (repo *repo) GetExpired(ctx context.Context, before time.Time) ([]*types.FileInfo, error) {
+	return repo.queryFiles(ctx,
+		"SELECT "+fileColumns+" FROM files WHERE deleted_at < $1", before)
+}
+
+func (repo *repo) Remove(ctx context.Context, userID, id string) error {
+	return repo.inTx(ctx, func(tx postgres.Executor) error {
+		var hash string
+		err := tx.QueryRowContext(ctx, "DELETE FROM files WHERE user_id=$1 and id=$2 RETURNING hash;", userID, id).Scan(&hash)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE blobs SET refs = refs - 1 WHERE user_id=$1 and hash=$2;", userID, hash)
+		return err
+	})
+}
+
+func (repo *repo) HasBlob(ctx context.Context, userID, hash string) (bool, error) {
+	var exists bool
+	err := repo.db.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM blobs WHERE user_id=$1 and hash=$2 and refs > 0)", userID, hash).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+func (repo *repo) GetUnreferenced(ctx context.Context) ([]types.Blob, error) {
+	var ret []types.Blob
+
+	rows, err := repo.db.QueryContext(ctx,
+		"SELECT user_id, hash, storage_key, size, refs FROM blobs WHERE refs <= 0")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var blob types.Blob
+		err = rows.Scan(&blob.UserID, &blob.Hash, &blob.StorageKey, &blob.Size, &blob.Refs)
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, blob)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// DeleteBlob holds the row lock while the contents are deleted, so a file
+// created meanwhile waits and then stores the contents again as a new blob.
+func (repo *repo) DeleteBlob(ctx context.Context, blob types.Blob, deleteContent func(storageKey string) error) error {
+	return repo.inTx(ctx, func(tx postgres.Executor) error {
+		var key string
+		err := tx.QueryRowContext(ctx,
+			"SELECT storage_key FROM blobs WHERE user_id=$1 and hash=$2 and refs <= 0 FOR UPDATE",
+			blob.UserID, blob.Hash).Scan(&key)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+			return err
+		}
+
+		err = deleteContent(key)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "DELETE FROM blobs WHERE user_id=$1 and hash=$2;", blob.UserID, blob.Hash)
+		return err
+	})
+}
+
+// inTx joins the transaction of ctx, e.g. the one the quota of the user is
+// locked in, or runs fn in a new one.
+func (repo *repo) inTx(ctx context.Context, fn func(tx postgres.Executor) error) error {
+	return postgres.NewTx(repo.db).InTx(ctx, func(ctx context.Context) error {
+		return fn(postgres.Conn(ctx, repo.db))
+	})
+}
+
+func insertFile(ctx context.Context, tx postgres.Executor, info *types.FileInfo) error {
+	_, err := tx.ExecContext(ctx,
+		"INSERT INTO files(user_id, id, name, size, hash, metadata, storage_key) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		info.UserID, info.ID, info.Name, info.Size, info.Hash, info.Metadata, info.StorageKey)
+	return insertErr(err)
+}
+
+func insertErr(err error) error {
+	if err != nil && strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
+		return types.ErrRecordAlreadyExists
+	}
+	return err
+}
+
+func (repo *repo) queryFiles(ctx context.Context, query string, args ...any) ([]*types.FileInfo, error) {
+	var ret []*types.FileInfo
+
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		info, err := scanFile(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, info)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanFile scans the fileColumns and then the extra destinations.
+func scanFile(row scanner, extra ...any) (*types.FileInfo, error) {
+	var (
+		info     types.FileInfo
+		metadata sql.NullString
+	)
+
+	dest := []any{&info.UserID, &info.ID, &info.Name, &info.Size, &info.Hash, &metadata, &info.StorageKey, &info.CreatedAt}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return nil, err
+	}
+	info.Metadata = metadata.String
+
+	return &info, nil
+}
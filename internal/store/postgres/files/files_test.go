@@ -0,0 +1,552 @@
+package files
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"keeper-project/types"
+)
+
+var columns = []string{"user_id", "id", "name", "size", "hash", "metadata", "storage_key", "created_at"}
+
+func TestCreate_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	info := &types.FileInfo{
+		ID:         "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83",
+		UserID:     "test",
+		Name:       "file.txt",
+		Size:       10,
+		Hash:       "hash",
+		Metadata:   "test_meta",
+		StorageKey: "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83",
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("^INSERT INTO blobs(.+) ON CONFLICT (.+) RETURNING storage_key").
+		WithArgs(info.UserID, info.Hash, info.StorageKey, info.Size).
+		WillReturnRows(sqlmock.NewRows([]string{"storage_key"}).AddRow(info.StorageKey))
+	mock.ExpectExec("^INSERT INTO files(.+)").WithArgs(info.UserID, info.ID, info.Name,
+		info.Size, info.Hash, info.Metadata, info.StorageKey).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	store := NewRepository(db)
+
+	err = store.Create(context.Background(), info)
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreate_ExistingBlob(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	info := &types.FileInfo{ID: "id", UserID: "test", Hash: "hash", StorageKey: "new"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("^INSERT INTO blobs(.+)").
+		WillReturnRows(sqlmock.NewRows([]string{"storage_key"}).AddRow("existing"))
+	mock.ExpectExec("^INSERT INTO files(.+)").WithArgs("test", "id", "", int64(0), "hash", "", "existing").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	store := NewRepository(db)
+
+	err = store.Create(context.Background(), info)
+	require.NoError(t, err)
+	require.Equal(t, "existing", info.StorageKey)
+}
+
+func TestCreate_NilInfo(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewRepository(db)
+
+	err = store.Create(context.Background(), nil)
+	require.Equal(t, err.Error(), "repository: incorrect parameters")
+}
+
+func TestCreate_DuplicateErr(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("^INSERT INTO blobs(.+)").
+		WillReturnRows(sqlmock.NewRows([]string{"storage_key"}).AddRow("key"))
+	mock.ExpectExec("^INSERT INTO files(.+)").
+		WillReturnError(errors.New("duplicate key value violates unique constraint"))
+	mock.ExpectRollback()
+
+	store := NewRepository(db)
+
+	err = store.Create(context.Background(), &types.FileInfo{ID: "test"})
+	require.Equal(t, err, types.ErrRecordAlreadyExists)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestImport_Deleted(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	deleted := created.Add(time.Hour)
+	info := &types.FileInfo{ID: "id", UserID: "test", Name: "file.txt", Size: 10, Hash: "hash",
+		Metadata: "meta", StorageKey: "test/id", CreatedAt: created}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("^INSERT INTO blobs(.+)").
+		WithArgs("test", "hash", "test/id", int64(10)).
+		WillReturnRows(sqlmock.NewRows([]string{"storage_key"}).AddRow("test/id"))
+	mock.ExpectExec("^INSERT INTO files(.+)created_at, deleted_at(.+)").
+		WithArgs("test", "id", "file.txt", int64(10), "hash", "meta", "test/id", created, &deleted).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	store := NewRepository(db)
+
+	err = store.Import(context.Background(), info, &deleted)
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestImport_DuplicateErr(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("^INSERT INTO blobs(.+)").
+		WillReturnRows(sqlmock.NewRows([]string{"storage_key"}).AddRow("key"))
+	mock.ExpectExec("^INSERT INTO files(.+)").
+		WillReturnError(errors.New("duplicate key value violates unique constraint"))
+	mock.ExpectRollback()
+
+	store := NewRepository(db)
+
+	err = store.Import(context.Background(), &types.FileInfo{ID: "test"}, nil)
+	require.Equal(t, types.ErrRecordAlreadyExists, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLink_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	info := &types.FileInfo{ID: "id", UserID: "test", Name: "file.txt", Hash: "hash"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("^UPDATE blobs SET refs = refs \\+ 1 (.+) RETURNING storage_key, size").WithArgs("test", "hash").
+		WillReturnRows(sqlmock.NewRows([]string{"storage_key", "size"}).AddRow("key", 10))
+	mock.ExpectExec("^INSERT INTO files(.+)").WithArgs("test", "id", "file.txt", int64(10), "hash", "", "key").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	store := NewRepository(db)
+
+	err = store.Link(context.Background(), info)
+	require.NoError(t, err)
+	require.Equal(t, "key", info.StorageKey)
+}
+
+func TestLink_NoBlob(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("^UPDATE blobs (.+)").WithArgs("test", "hash").
+		WillReturnRows(sqlmock.NewRows([]string{"storage_key", "size"}))
+	mock.ExpectRollback()
+
+	store := NewRepository(db)
+
+	err = store.Link(context.Background(), &types.FileInfo{ID: "id", UserID: "test", Hash: "hash"})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGet_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	created := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows(columns).
+		AddRow("test", "id", "file.txt", 10, "hash", nil, "key", created)
+
+	mock.ExpectQuery("^SELECT (.+) FROM files WHERE user_id=(.+) and id=(.+) and deleted_at IS NULL").
+		WithArgs("test", "id").WillReturnRows(rows)
+
+	store := NewRepository(db)
+
+	info, err := store.Get(context.Background(), "test", "id")
+	require.NoError(t, err)
+	require.Equal(t, &types.FileInfo{
+		ID:         "id",
+		UserID:     "test",
+		Name:       "file.txt",
+		Size:       10,
+		Hash:       "hash",
+		StorageKey: "key",
+		CreatedAt:  created,
+	}, info)
+}
+
+func TestGet_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("^SELECT (.+) FROM files (.+)").
+		WithArgs("test", "id").WillReturnRows(sqlmock.NewRows(columns))
+
+	store := NewRepository(db)
+
+	_, err = store.Get(context.Background(), "test", "id")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestList_Default(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	rows := sqlmock.NewRows(append(columns, "created_at")).
+		AddRow("test", "first", "a.txt", 10, "hash", "meta", "first", now, now).
+		AddRow("test", "second", "b.txt", 20, "hash", "meta", "second", now, now)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM files WHERE user_id=$1 and deleted_at IS NULL") + "$").
+		WithArgs("test").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM files WHERE user_id=$1 and deleted_at IS NULL ORDER BY created_at ASC, id ASC") + "$").
+		WithArgs("test").WillReturnRows(rows)
+
+	store := NewRepository(db)
+
+	page, err := store.List(context.Background(), "test", types.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 2)
+	require.Equal(t, "second", page.Items[1].ID)
+	require.Equal(t, "meta", page.Items[1].Metadata)
+	require.Equal(t, int64(2), page.Total)
+}
+
+func TestList_SortedPage(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM files")).
+		WithArgs("test").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(regexp.QuoteMeta("ORDER BY size DESC, id DESC LIMIT $2 OFFSET $3")).
+		WithArgs("test", 6, 10).WillReturnRows(sqlmock.NewRows(append(columns, "size")))
+
+	store := NewRepository(db)
+
+	page, err := store.List(context.Background(), "test", types.ListOptions{Sort: types.SortSize, Desc: true, Limit: 5, Offset: 10})
+	require.NoError(t, err)
+	require.Empty(t, page.Items)
+	require.NotNil(t, page.Items)
+}
+
+func TestList_Cursor(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	cursor := types.Cursor{Sort: types.SortSize, Value: []byte("20"), ID: "second"}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM files")).
+		WithArgs("test").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery(regexp.QuoteMeta("and (size, id) > ($2, $3) ORDER BY size ASC, id ASC LIMIT $4")).
+		WithArgs("test", 20, "second", 2).
+		WillReturnRows(sqlmock.NewRows(append(columns, "size")).
+			AddRow("test", "third", "c.txt", 30, "hash", "meta", "third", time.Now(), 30))
+
+	store := NewRepository(db)
+
+	page, err := store.List(context.Background(), "test", types.ListOptions{Sort: types.SortSize, Limit: 1, Cursor: cursor.Encode()})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	require.Empty(t, page.Next)
+}
+
+func TestList_InvalidCursor(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	cursor := types.Cursor{Sort: types.SortSize, Value: []byte(`"big"`), ID: "second"}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM files")).
+		WithArgs("test").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	store := NewRepository(db)
+
+	_, err = store.List(context.Background(), "test", types.ListOptions{Sort: types.SortSize, Cursor: cursor.Encode()})
+	require.ErrorIs(t, err, types.ErrInvalidCursor)
+}
+
+func TestList_CursorOfOtherSort(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	cursor := types.Cursor{Sort: types.SortSize, Value: []byte("20"), ID: "second"}
+
+	store := NewRepository(db)
+
+	_, err = store.List(context.Background(), "test", types.ListOptions{Sort: types.SortCreated, Cursor: cursor.Encode()})
+	require.ErrorIs(t, err, types.ErrInvalidCursor)
+	_, err = store.List(context.Background(), "test", types.ListOptions{Sort: types.SortSize, Desc: true, Cursor: cursor.Encode()})
+	require.ErrorIs(t, err, types.ErrInvalidCursor)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestList_UnknownSort(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewRepository(db)
+
+	_, err = store.List(context.Background(), "test", types.ListOptions{Sort: "name; DROP TABLE files"})
+	require.Equal(t, err.Error(), "repository: incorrect parameters")
+}
+
+func TestDelete_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("^UPDATE files SET deleted_at=now()(.+)").WithArgs("test", "id").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	store := NewRepository(db)
+
+	err = store.Delete(context.Background(), "test", "id")
+	require.NoError(t, err)
+}
+
+func TestDelete_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("^UPDATE files SET deleted_at=now()(.+)").WithArgs("test", "id").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	store := NewRepository(db)
+
+	err = store.Delete(context.Background(), "test", "id")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestRestore_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("^UPDATE files SET deleted_at=NULL(.+)").WithArgs("test", "id").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	store := NewRepository(db)
+
+	err = store.Restore(context.Background(), "test", "id")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestGetDeletedList_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	deleted := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("^SELECT id, name, deleted_at FROM files (.+)").WithArgs("test").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "deleted_at"}).AddRow("id", "file.txt", deleted))
+
+	store := NewRepository(db)
+
+	items, err := store.GetDeletedList(context.Background(), "test")
+	require.NoError(t, err)
+	require.Equal(t, []types.TrashItem{{Id: "id", Key: "file.txt", DeletedAt: deleted}}, items)
+}
+
+func TestGetExpired_SqlErr(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	before := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("^SELECT (.+) FROM files WHERE deleted_at < (.+)").WithArgs(before).
+		WillReturnError(errors.New("sql error"))
+
+	store := NewRepository(db)
+
+	_, err = store.GetExpired(context.Background(), before)
+	require.Error(t, err)
+}
+
+func TestRemove_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("^DELETE FROM files (.+) RETURNING hash").WithArgs("test", "id").
+		WillReturnRows(sqlmock.NewRows([]string{"hash"}).AddRow("hash"))
+	mock.ExpectExec("^UPDATE blobs SET refs = refs - 1 (.+)").WithArgs("test", "hash").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	store := NewRepository(db)
+
+	err = store.Remove(context.Background(), "test", "id")
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHasBlob_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("^SELECT EXISTS(.+)").WithArgs("test", "hash").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	store := NewRepository(db)
+
+	ok, err := store.HasBlob(context.Background(), "test", "hash")
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestDeleteBlob_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("^SELECT storage_key FROM blobs (.+) FOR UPDATE").WithArgs("test", "hash").
+		WillReturnRows(sqlmock.NewRows([]string{"storage_key"}).AddRow("key"))
+	mock.ExpectExec("^DELETE FROM blobs (.+)").WithArgs("test", "hash").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	store := NewRepository(db)
+
+	var deleted string
+	err = store.DeleteBlob(context.Background(), types.Blob{UserID: "test", Hash: "hash"}, func(key string) error {
+		deleted = key
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "key", deleted)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteBlob_ContentErr(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("^SELECT storage_key FROM blobs (.+)").WithArgs("test", "hash").
+		WillReturnRows(sqlmock.NewRows([]string{"storage_key"}).AddRow("key"))
+	mock.ExpectRollback()
+
+	store := NewRepository(db)
+
+	err = store.DeleteBlob(context.Background(), types.Blob{UserID: "test", Hash: "hash"}, func(string) error {
+		return errors.New("storage error")
+	})
+	require.Error(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteBlob_Referenced(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("^SELECT storage_key FROM blobs (.+)").WithArgs("test", "hash").
+		WillReturnRows(sqlmock.NewRows([]string{"storage_key"}))
+	mock.ExpectCommit()
+
+	store := NewRepository(db)
+
+	err = store.DeleteBlob(context.Background(), types.Blob{UserID: "test", Hash: "hash"}, func(string) error {
+		t.Fatal("referenced contents must not be deleted")
+		return nil
+	})
+	require.NoError(t, err)
+}
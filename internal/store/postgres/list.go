@@ -0,0 +1,137 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"keeper-project/types"
+)
+
+// Column is a column a list is sorted by, Value returns a new pointer to scan
+// the column into.
+type Column struct {
+	Name  string
+	Value func() any
+}
+
+func TimeColumn(name string) Column {
+	return Column{Name: name, Value: func() any { return new(time.Time) }}
+}
+
+func TextColumn(name string) Column {
+	return Column{Name: name, Value: func() any { return new(string) }}
+}
+
+func IntColumn(name string) Column {
+	return Column{Name: name, Value: func() any { return new(int64) }}
+}
+
+// ListQuery is a list of records of a user paged by keyset: the rows follow
+// the sort column and the id of the last row of the previous page.
+type ListQuery struct {
+	// Select lists the columns of the items, the sort column is selected after them.
+	Select string
+	From   string
+	// Where filters the rows of the user bound to $1.
+	Where string
+	// Created is the column ListOptions.Since filters by.
+	Created string
+	// Sorts maps the sort fields to the columns, "" is the default sort.
+	Sorts map[string]Column
+}
+
+// List runs the query for a page. scan is called for each row with a pointer
+// to scan the sort column into after the item columns and returns the item ID.
+func List(ctx context.Context, conn Executor, q ListQuery, userID string, opts types.ListOptions,
+	scan func(rows *sql.Rows, sortValue any) (string, error)) (next string, total int64, err error) {
+	column, ok := q.Sorts[opts.Sort]
+	if !ok || opts.Limit < 0 || opts.Offset < 0 {
+		return "", 0, errors.New("repository: incorrect parameters")
+	}
+
+	var cursor *types.Cursor
+	if opts.Cursor != "" {
+		cursor, err = types.DecodeCursor(opts.Cursor)
+		if err != nil {
+			return "", 0, err
+		}
+		// the value of the cursor is of the sort column, the position is lost
+		// in another sort or order
+		if cursor.Sort != opts.Sort || cursor.Desc != opts.Desc {
+			return "", 0, fmt.Errorf("%w: sort or order changed", types.ErrInvalidCursor)
+		}
+	}
+
+	where := q.Where
+	args := []any{userID}
+	if !opts.Since.IsZero() {
+		args = append(args, opts.Since)
+		where += fmt.Sprintf(" and %s >= $%d", q.Created, len(args))
+	}
+
+	err = conn.QueryRowContext(ctx, "SELECT count(*) FROM "+q.From+" WHERE "+where, args...).Scan(&total)
+	if err != nil {
+		return "", 0, err
+	}
+
+	order, cmp := "ASC", ">"
+	if opts.Desc {
+		order, cmp = "DESC", "<"
+	}
+
+	if cursor != nil {
+		value := column.Value()
+		if json.Unmarshal(cursor.Value, value) != nil {
+			return "", 0, types.ErrInvalidCursor
+		}
+		args = append(args, value, cursor.ID)
+		where += fmt.Sprintf(" and (%s, id) %s ($%d, $%d)", column.Name, cmp, len(args)-1, len(args))
+	}
+
+	query := fmt.Sprintf("SELECT %s, %s FROM %s WHERE %s ORDER BY %s %s, id %s",
+		q.Select, column.Name, q.From, where, column.Name, order, order)
+	if opts.Limit > 0 {
+		// one more row tells whether there is a next page
+		args = append(args, opts.Limit+1)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if opts.Offset > 0 {
+		args = append(args, opts.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return "", 0, err
+	}
+	defer rows.Close()
+
+	value := column.Value()
+	var n int
+	var lastID string
+	for rows.Next() {
+		if opts.Limit > 0 && n == opts.Limit {
+			data, err := json.Marshal(value)
+			if err != nil {
+				return "", 0, err
+			}
+			next = types.Cursor{Sort: opts.Sort, Desc: opts.Desc, Value: data, ID: lastID}.Encode()
+			break
+		}
+
+		lastID, err = scan(rows, value)
+		if err != nil {
+			return "", 0, err
+		}
+		n++
+	}
+	if err = rows.Err(); err != nil {
+		return "", 0, err
+	}
+
+	return next, total, nil
+}
@@ -0,0 +1,5 @@
+ALTER TABLE texts DROP COLUMN IF EXISTS deleted_at;
+
+ALTER TABLE cards DROP COLUMN IF EXISTS deleted_at;
+
+ALTER TABLE credentials DROP COLUMN IF EXISTS deleted_at;
\ No newline at end of file
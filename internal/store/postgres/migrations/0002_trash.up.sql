@@ -0,0 +1,5 @@
+ALTER TABLE texts ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+ALTER TABLE cards ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+ALTER TABLE credentials ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
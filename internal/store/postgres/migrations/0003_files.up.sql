@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS files
+(
+    user_id     uuid,
+    id          uuid,
+    name        varchar   NOT NULL,
+    size        bigint    NOT NULL,
+    hash        varchar   NOT NULL,
+    metadata    varchar,
+    storage_key varchar   NOT NULL,
+    created_at  TIMESTAMP NOT NULL DEFAULT now(),
+    deleted_at  TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users (id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+        DEFERRABLE INITIALLY DEFERRED
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS files_idx ON files (id);
+
+CREATE INDEX IF NOT EXISTS files_user_idx ON files (user_id, created_at);
@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS blobs
+(
+    user_id     uuid,
+    hash        varchar   NOT NULL,
+    storage_key varchar   NOT NULL,
+    size        bigint    NOT NULL,
+    refs        integer   NOT NULL DEFAULT 0,
+    created_at  TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, hash),
+    FOREIGN KEY (user_id) REFERENCES users (id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+        DEFERRABLE INITIALLY DEFERRED
+);
+
+CREATE INDEX IF NOT EXISTS blobs_unreferenced_idx ON blobs (user_id) WHERE refs <= 0;
+
+-- files stored before deduplication share a blob per hash.
+INSERT INTO blobs (user_id, hash, storage_key, size, refs)
+SELECT user_id, hash, min(storage_key), min(size), count(*)
+FROM files
+GROUP BY user_id, hash
+ON CONFLICT DO NOTHING;
+
+-- the other copies are kept as unreferenced blobs, so the garbage collection
+-- deletes them from the file storage. Their hash is made of the storage key
+-- to stay unique, no file can refer to it.
+INSERT INTO blobs (user_id, hash, storage_key, size, refs)
+SELECT f.user_id, 'orphan:' || f.storage_key, f.storage_key, f.size, 0
+FROM files f
+         JOIN blobs b ON b.user_id = f.user_id AND b.hash = f.hash
+WHERE f.storage_key <> b.storage_key
+ON CONFLICT DO NOTHING;
+
+UPDATE files f
+SET storage_key = b.storage_key
+FROM blobs b
+WHERE f.user_id = b.user_id
+  AND f.hash = b.hash;
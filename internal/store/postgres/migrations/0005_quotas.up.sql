@@ -0,0 +1,13 @@
+-- per-user overrides of the default quotas, NULL keeps the default and 0 means no limit.
+CREATE TABLE IF NOT EXISTS quotas
+(
+    user_id     uuid PRIMARY KEY,
+    file_bytes  bigint,
+    notes       bigint,
+    cards       bigint,
+    credentials bigint,
+    FOREIGN KEY (user_id) REFERENCES users (id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+        DEFERRABLE INITIALLY DEFERRED
+);
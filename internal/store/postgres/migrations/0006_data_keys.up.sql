@@ -0,0 +1,14 @@
+-- data keys of the SSE-C encryption of the file contents, wrapped by the
+-- master key master_key_id which never leaves the server config.
+CREATE TABLE IF NOT EXISTS data_keys
+(
+    user_id       uuid PRIMARY KEY,
+    master_key_id varchar   NOT NULL,
+    wrapped       bytea     NOT NULL,
+    created_at    TIMESTAMP NOT NULL DEFAULT now(),
+    rotated_at    TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users (id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+        DEFERRABLE INITIALLY DEFERRED
+);
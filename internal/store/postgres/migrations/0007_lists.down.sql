@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS texts_list_idx;
+DROP INDEX IF EXISTS cards_list_idx;
+DROP INDEX IF EXISTS creds_list_idx;
+
+ALTER TABLE texts DROP COLUMN IF EXISTS updated_at;
+
+ALTER TABLE cards DROP COLUMN IF EXISTS updated_at;
+
+ALTER TABLE credentials DROP COLUMN IF EXISTS updated_at;
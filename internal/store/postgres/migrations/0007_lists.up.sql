@@ -0,0 +1,16 @@
+ALTER TABLE texts ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP;
+UPDATE texts SET updated_at = uploaded_at WHERE updated_at IS NULL;
+ALTER TABLE texts ALTER COLUMN updated_at SET DEFAULT now(), ALTER COLUMN updated_at SET NOT NULL;
+
+ALTER TABLE cards ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP;
+UPDATE cards SET updated_at = uploaded_at WHERE updated_at IS NULL;
+ALTER TABLE cards ALTER COLUMN updated_at SET DEFAULT now(), ALTER COLUMN updated_at SET NOT NULL;
+
+ALTER TABLE credentials ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP;
+UPDATE credentials SET updated_at = uploaded_at WHERE updated_at IS NULL;
+ALTER TABLE credentials ALTER COLUMN updated_at SET DEFAULT now(), ALTER COLUMN updated_at SET NOT NULL;
+
+-- keyset pages of the default sort
+CREATE INDEX IF NOT EXISTS texts_list_idx ON texts (user_id, uploaded_at, id);
+CREATE INDEX IF NOT EXISTS cards_list_idx ON cards (user_id, uploaded_at, id);
+CREATE INDEX IF NOT EXISTS creds_list_idx ON credentials (user_id, uploaded_at, id);
@@ -0,0 +1,15 @@
+-- blind index tokens made by the clients, see package search.
+CREATE TABLE IF NOT EXISTS search_tokens
+(
+    user_id uuid        NOT NULL,
+    kind    varchar(8)  NOT NULL,
+    id      uuid        NOT NULL,
+    token   varchar(64) NOT NULL,
+    PRIMARY KEY (user_id, token, kind, id),
+    FOREIGN KEY (user_id) REFERENCES users (id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+        DEFERRABLE INITIALLY DEFERRED
+);
+
+CREATE INDEX IF NOT EXISTS search_tokens_record_idx ON search_tokens (user_id, kind, id);
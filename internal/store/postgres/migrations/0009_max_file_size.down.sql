@@ -0,0 +1 @@
+ALTER TABLE quotas DROP COLUMN IF EXISTS max_file_size;
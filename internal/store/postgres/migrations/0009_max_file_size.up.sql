@@ -0,0 +1,2 @@
+-- per-user override of the default size limit of a single file, NULL keeps the default and 0 means no limit.
+ALTER TABLE quotas ADD COLUMN IF NOT EXISTS max_file_size bigint;
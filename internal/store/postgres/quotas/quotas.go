@@ -0,0 +1,81 @@
+package quotas
+
+import (
+	"context"
+	"database/sql"
+
+	"keeper-project/internal/store"
+	"keeper-project/internal/store/postgres"
+	"keeper-project/types"
+)
+
+// usageQuery counts everything in one round trip, the quotas row is optional.
+const usageQuery = `SELECT
+	(SELECT count(*) FROM files WHERE user_id=$1),
+	(SELECT COALESCE(sum(size), 0) FROM blobs WHERE user_id=$1 and refs > 0),
+	(SELECT count(*) FROM texts WHERE user_id=$1),
+	(SELECT count(*) FROM cards WHERE user_id=$1),
+	(SELECT count(*) FROM credentials WHERE user_id=$1),
+	q.file_bytes, q.notes, q.cards, q.credentials, q.max_file_size
+FROM (SELECT $1::uuid AS user_id) u LEFT JOIN quotas q ON q.user_id = u.user_id`
+
+type repo struct {
+	db       *sql.DB
+	defaults types.Quota
+}
+
+// NewRepository returns quotas of the users, limits without a per-user override are taken from defaults.
+func NewRepository(db *sql.DB, defaults types.Quota) store.Quotas {
+	return &repo{db: db, defaults: defaults}
+}
+
+func (repo *repo) GetUsage(ctx context.Context, userID string) (*types.Usage, error) {
+	var (
+		usage                                       types.Usage
+		fileBytes, notes, cards, creds, maxFileSize sql.NullInt64
+	)
+
+	err := postgres.Conn(ctx, repo.db).QueryRowContext(ctx, usageQuery, userID).Scan(
+		&usage.Files, &usage.FileBytes, &usage.Notes, &usage.Cards, &usage.Credentials,
+		&fileBytes, &notes, &cards, &creds, &maxFileSize)
+	if err != nil {
+		return nil, err
+	}
+
+	usage.Quota = types.Quota{
+		FileBytes:   orDefault(fileBytes, repo.defaults.FileBytes),
+		Notes:       orDefault(notes, repo.defaults.Notes),
+		Cards:       orDefault(cards, repo.defaults.Cards),
+		Credentials: orDefault(creds, repo.defaults.Credentials),
+		MaxFileSize: orDefault(maxFileSize, repo.defaults.MaxFileSize),
+	}
+	return &usage, nil
+}
+
+// LockUsage locks the row of the user, the quotas row may not exist.
+func (repo *repo) LockUsage(ctx context.Context, userID string) (*types.Usage, error) {
+	var id string
+	err := postgres.Conn(ctx, repo.db).QueryRowContext(ctx,
+		"SELECT id FROM users WHERE id=$1 FOR NO KEY UPDATE", userID).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	return repo.GetUsage(ctx, userID)
+}
+
+func (repo *repo) SetQuota(ctx context.Context, userID string, quota types.QuotaOverride) error {
+	_, err := postgres.Conn(ctx, repo.db).ExecContext(ctx,
+		"INSERT INTO quotas (user_id, file_bytes, notes, cards, credentials, max_file_size) "+
+			"VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (user_id) DO UPDATE SET "+
+			"file_bytes = EXCLUDED.file_bytes, notes = EXCLUDED.notes, cards = EXCLUDED.cards, "+
+			"credentials = EXCLUDED.credentials, max_file_size = EXCLUDED.max_file_size",
+		userID, quota.FileBytes, quota.Notes, quota.Cards, quota.Credentials, quota.MaxFileSize)
+	return err
+}
+
+func orDefault(v sql.NullInt64, def int64) int64 {
+	if v.Valid {
+		return v.Int64
+	}
+	return def
+}
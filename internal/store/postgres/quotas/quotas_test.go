@@ -0,0 +1,127 @@
+package quotas
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"keeper-project/types"
+)
+
+var columns = []string{"files", "file_bytes", "notes", "cards", "credentials",
+	"quota_file_bytes", "quota_notes", "quota_cards", "quota_credentials", "quota_max_file_size"}
+
+func TestGetUsage_Defaults(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("^SELECT (.+) FROM (.+) LEFT JOIN quotas").WithArgs("test").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(2, 1024, 3, 4, 5, nil, nil, nil, nil, nil))
+
+	store := NewRepository(db, types.Quota{FileBytes: 1 << 20, Notes: 10, MaxFileSize: 1 << 10})
+
+	usage, err := store.GetUsage(context.Background(), "test")
+	require.NoError(t, err)
+	require.Equal(t, &types.Usage{
+		Files:       2,
+		FileBytes:   1024,
+		Notes:       3,
+		Cards:       4,
+		Credentials: 5,
+		Quota:       types.Quota{FileBytes: 1 << 20, Notes: 10, MaxFileSize: 1 << 10},
+	}, usage)
+}
+
+func TestGetUsage_Override(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("^SELECT (.+) FROM (.+) LEFT JOIN quotas").WithArgs("test").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(0, 0, 0, 0, 0, 5<<30, 0, nil, 100, 1<<30))
+
+	store := NewRepository(db, types.Quota{FileBytes: 1 << 20, Notes: 10, Cards: 10, Credentials: 10, MaxFileSize: 1 << 20})
+
+	usage, err := store.GetUsage(context.Background(), "test")
+	require.NoError(t, err)
+	require.Equal(t, types.Quota{FileBytes: 5 << 30, Notes: 0, Cards: 10, Credentials: 100, MaxFileSize: 1 << 30}, usage.Quota)
+}
+
+func TestGetUsage_SqlErr(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("^SELECT (.+)").WithArgs("test").WillReturnError(errors.New("sql error"))
+
+	store := NewRepository(db, types.Quota{})
+
+	_, err = store.GetUsage(context.Background(), "test")
+	require.Error(t, err)
+}
+
+func TestLockUsage_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("^SELECT id FROM users WHERE id=\\$1 FOR NO KEY UPDATE").WithArgs("test").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("test"))
+	mock.ExpectQuery("^SELECT (.+) FROM (.+) LEFT JOIN quotas").WithArgs("test").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(0, 0, 1, 0, 0, nil, 5, nil, nil, nil))
+
+	store := NewRepository(db, types.Quota{})
+
+	usage, err := store.LockUsage(context.Background(), "test")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), usage.Notes)
+	require.Equal(t, int64(5), usage.Quota.Notes)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLockUsage_NoUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("^SELECT id FROM users").WithArgs("test").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	store := NewRepository(db, types.Quota{})
+
+	_, err = store.LockUsage(context.Background(), "test")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestSetQuota_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	fileBytes, notes := int64(5<<30), int64(0)
+	mock.ExpectExec("^INSERT INTO quotas (.+) ON CONFLICT \\(user_id\\) DO UPDATE").
+		WithArgs("test", &fileBytes, &notes, nil, nil, nil).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	store := NewRepository(db, types.Quota{})
+
+	err = store.SetQuota(context.Background(), "test", types.QuotaOverride{FileBytes: &fileBytes, Notes: &notes})
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
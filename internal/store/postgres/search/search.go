@@ -0,0 +1,135 @@
+package search
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+
+	"keeper-project/internal/store"
+	"keeper-project/internal/store/postgres"
+	"keeper-project/types"
+)
+
+// tables maps the kinds with search tokens to the tables of the records.
+var tables = map[string]string{
+	types.KindNote:        "texts",
+	types.KindCard:        "cards",
+	types.KindCredentials: "credentials",
+}
+
+type repo struct {
+	db *sql.DB
+	tx store.Tx
+}
+
+func NewRepository(db *sql.DB) store.Search {
+	return &repo{db: db, tx: postgres.NewTx(db)}
+}
+
+func (repo *repo) SetTokens(ctx context.Context, userID, kind, id string, tokens []string) error {
+	if _, ok := tables[kind]; !ok || id == "" {
+		return errors.New("repository: incorrect parameters")
+	}
+
+	return repo.tx.InTx(ctx, func(ctx context.Context) error {
+		conn := postgres.Conn(ctx, repo.db)
+
+		_, err := conn.ExecContext(ctx, "DELETE FROM search_tokens WHERE user_id=$1 and kind=$2 and id=$3", userID, kind, id)
+		if err != nil {
+			return err
+		}
+		if len(tokens) == 0 {
+			return nil
+		}
+
+		args := []any{userID, kind, id}
+		for _, token := range tokens {
+			args = append(args, token)
+		}
+		_, err = conn.ExecContext(ctx,
+			"INSERT INTO search_tokens(user_id, kind, id, token) SELECT $1, $2, $3, v.token FROM (VALUES "+
+				postgres.Values(len(tokens), 1, 4)+") AS v(token) ON CONFLICT DO NOTHING",
+			args...)
+		return err
+	})
+}
+
+func (repo *repo) Search(ctx context.Context, userID string, req types.SearchRequest) ([]types.SearchHit, error) {
+	tokens := slices.Clone(req.Tokens)
+	slices.Sort(tokens)
+	tokens = slices.Compact(tokens)
+	if len(tokens) == 0 || req.MinMatch <= 0 || req.Limit <= 0 {
+		return nil, errors.New("repository: incorrect parameters")
+	}
+
+	kinds := req.Kinds
+	if len(kinds) == 0 {
+		kinds = types.SearchKinds
+	}
+
+	// only the records out of the trash are found, the tokens of the trashed
+	// ones are kept for when they are restored.
+	live := ""
+	for _, kind := range kinds {
+		table, ok := tables[kind]
+		if !ok {
+			return nil, errors.New("repository: incorrect parameters")
+		}
+		if live != "" {
+			live += " UNION ALL "
+		}
+		live += fmt.Sprintf("SELECT '%s', id FROM %s WHERE user_id=$1 and deleted_at IS NULL", kind, table)
+	}
+
+	args := []any{userID}
+	for _, token := range tokens {
+		args = append(args, token)
+	}
+	args = append(args, min(req.MinMatch, len(tokens)), req.Limit)
+
+	query := fmt.Sprintf("WITH live(kind, id) AS (%s) "+
+		"SELECT t.kind, t.id, count(*) FROM search_tokens t JOIN live l ON l.kind = t.kind and l.id = t.id "+
+		"WHERE t.user_id=$1 and t.token IN %s GROUP BY t.kind, t.id HAVING count(*) >= $%d "+
+		"ORDER BY count(*) DESC, t.id LIMIT $%d",
+		live, postgres.Values(1, len(tokens), 2), len(args)-1, len(args))
+
+	rows, err := postgres.Conn(ctx, repo.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hits := make([]types.SearchHit, 0)
+	for rows.Next() {
+		var hit types.SearchHit
+		err = rows.Scan(&hit.Kind, &hit.ID, &hit.Matched)
+		if err != nil {
+			return nil, err
+		}
+		hits = append(hits, hit)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return hits, nil
+}
+
+func (repo *repo) PurgeOrphans(ctx context.Context) (int64, error) {
+	query := "DELETE FROM search_tokens t WHERE"
+	for i, kind := range types.SearchKinds {
+		if i > 0 {
+			query += " or"
+		}
+		query += fmt.Sprintf(" (t.kind = '%s' and NOT EXISTS (SELECT 1 FROM %s r WHERE r.id = t.id))", kind, tables[kind])
+	}
+
+	result, err := repo.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
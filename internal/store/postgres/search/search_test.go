@@ -0,0 +1,128 @@
+package search
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"keeper-project/types"
+)
+
+const (
+	userID = "test"
+	id     = "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83"
+)
+
+func TestSetTokens_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("^DELETE FROM search_tokens WHERE").WithArgs(userID, types.KindNote, id).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO search_tokens(user_id, kind, id, token) SELECT $1, $2, $3, v.token FROM (VALUES ($4), ($5))")).
+		WithArgs(userID, types.KindNote, id, "a", "b").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	store := NewRepository(db)
+
+	err = store.SetTokens(context.Background(), userID, types.KindNote, id, []string{"a", "b"})
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetTokens_Clear(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("^DELETE FROM search_tokens WHERE").WithArgs(userID, types.KindCard, id).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	store := NewRepository(db)
+
+	err = store.SetTokens(context.Background(), userID, types.KindCard, id, nil)
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetTokens_UnknownKind(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewRepository(db)
+
+	err = store.SetTokens(context.Background(), userID, types.KindFile, id, []string{"a"})
+	require.Equal(t, err.Error(), "repository: incorrect parameters")
+}
+
+func TestSearch_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta("WITH live(kind, id) AS (SELECT 'cred', id FROM credentials WHERE user_id=$1 and deleted_at IS NULL) ")+
+		"(.+)"+regexp.QuoteMeta("t.token IN ($2, $3) GROUP BY t.kind, t.id HAVING count(*) >= $4 ORDER BY count(*) DESC, t.id LIMIT $5")).
+		WithArgs(userID, "a", "b", 2, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"kind", "id", "count"}).AddRow(types.KindCredentials, id, 2))
+
+	store := NewRepository(db)
+
+	hits, err := store.Search(context.Background(), userID, types.SearchRequest{
+		Tokens:   []string{"b", "a", "b"},
+		MinMatch: 3,
+		Kinds:    []string{types.KindCredentials},
+		Limit:    10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []types.SearchHit{{Kind: types.KindCredentials, ID: id, Matched: 2}}, hits)
+}
+
+func TestSearch_SqlErr(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("^WITH live").WillReturnError(sql.ErrConnDone)
+
+	store := NewRepository(db)
+
+	_, err = store.Search(context.Background(), userID, types.SearchRequest{Tokens: []string{"a"}, MinMatch: 1, Limit: 10})
+	require.Equal(t, err, sql.ErrConnDone)
+}
+
+func TestPurgeOrphans(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM search_tokens t WHERE (t.kind = 'text' and NOT EXISTS (SELECT 1 FROM texts r WHERE r.id = t.id)) or")).
+		WillReturnResult(sqlmock.NewResult(0, 5))
+
+	store := NewRepository(db)
+
+	n, err := store.PurgeOrphans(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(5), n)
+}
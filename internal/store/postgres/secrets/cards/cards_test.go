@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/require"
@@ -307,14 +308,14 @@ func TestGetKeysList_SqlErr(t *testing.T) {
 
 	userID := "test"
 
-	mock.ExpectQuery("^SELECT id, card FROM cards WHERE(.+)").WithArgs(userID).
+	mock.ExpectQuery("^SELECT count\\(\\*\\) FROM cards WHERE(.+)").WithArgs(userID).
 		WillReturnError(sql.ErrConnDone)
 
 	store := NewRepository(db)
 
 	ctx := context.Background()
 
-	_, err = store.GetKeysList(ctx, userID)
+	_, err = store.GetKeysList(ctx, userID, types.ListOptions{})
 	require.Equal(t, err, sql.ErrConnDone)
 }
 
@@ -328,17 +329,55 @@ func TestGetKeysList_Success(t *testing.T) {
 	userID := "test"
 	id := "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83"
 
-	mock.ExpectQuery("^SELECT id, card FROM cards WHERE(.+)").WithArgs(userID).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "card"}).AddRow(id, "123321"))
+	mock.ExpectQuery("^SELECT count\\(\\*\\) FROM cards WHERE(.+)").WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("^SELECT id, card, uploaded_at, updated_at, uploaded_at FROM cards WHERE (.+) ORDER BY uploaded_at ASC, id ASC$").WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "card", "uploaded_at", "updated_at", "uploaded_at"}).AddRow(id, "123321.com", time.Now(), time.Now(), time.Now()))
 
 	store := NewRepository(db)
 
 	ctx := context.Background()
 
-	keys, err := store.GetKeysList(ctx, userID)
+	page, err := store.GetKeysList(ctx, userID, types.ListOptions{})
 	require.NoError(t, err)
 
-	require.Equal(t, len(keys), 1)
+	require.Equal(t, len(page.Items), 1)
+	require.Equal(t, int64(1), page.Total)
+	require.Empty(t, page.Next)
+}
+
+func TestGetKeysList_Page(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	userID := "test"
+	since := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Now()
+	at := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+	cursor := types.Cursor{Sort: types.SortUpdated, Desc: true, Value: []byte(`"2024-06-03T00:00:00Z"`), ID: "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83"}
+
+	mock.ExpectQuery("^SELECT count\\(\\*\\) FROM cards WHERE (.+) and uploaded_at >= \\$2$").WithArgs(userID, since).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(10))
+	mock.ExpectQuery("^SELECT id, card, uploaded_at, updated_at, updated_at FROM cards WHERE (.+) and \\(updated_at, id\\) < \\(\\$3, \\$4\\) ORDER BY updated_at DESC, id DESC LIMIT \\$5$").
+		WithArgs(userID, since, at, cursor.ID, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "card", "uploaded_at", "updated_at", "updated_at"}).
+			AddRow("c", "l", now, now, at.Add(-time.Hour)).AddRow("b", "k", now, now, at.Add(-2*time.Hour)).AddRow("a", "j", now, now, at.Add(-3*time.Hour)))
+
+	store := NewRepository(db)
+
+	opts := types.ListOptions{Sort: types.SortUpdated, Desc: true, Limit: 2, Cursor: cursor.Encode(), Since: since}
+	page, err := store.GetKeysList(context.Background(), userID, opts)
+	require.NoError(t, err)
+	require.Len(t, page.Items, 2)
+	require.Equal(t, int64(10), page.Total)
+
+	next, err := types.DecodeCursor(page.Next)
+	require.NoError(t, err)
+	require.Equal(t, "b", next.ID)
+	require.JSONEq(t, `"2024-06-02T22:00:00Z"`, string(next.Value))
 }
 
 func TestGetKeysList_RowsErr(t *testing.T) {
@@ -351,14 +390,16 @@ func TestGetKeysList_RowsErr(t *testing.T) {
 	userID := "test"
 	id := "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83"
 
-	mock.ExpectQuery("^SELECT id, card FROM cards WHERE(.+)").WithArgs(userID).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "card"}).AddRow(id, "123321").RowError(0, sql.ErrConnDone))
+	mock.ExpectQuery("^SELECT count\\(\\*\\) FROM cards WHERE(.+)").WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("^SELECT id, card, uploaded_at, updated_at, uploaded_at FROM cards WHERE(.+)").WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "card", "uploaded_at", "updated_at", "uploaded_at"}).AddRow(id, "123321.com", time.Now(), time.Now(), time.Now()).RowError(0, sql.ErrConnDone))
 
 	store := NewRepository(db)
 
 	ctx := context.Background()
 
-	_, err = store.GetKeysList(ctx, userID)
+	_, err = store.GetKeysList(ctx, userID, types.ListOptions{})
 	require.Equal(t, err, sql.ErrConnDone)
 }
 
@@ -372,7 +413,7 @@ func TestDelete_Success(t *testing.T) {
 	userID := "test"
 	id := "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83"
 
-	mock.ExpectExec("^DELETE FROM cards WHERE (.+)").WithArgs(userID, id).
+	mock.ExpectExec("^UPDATE cards SET deleted_at=now\\(\\) WHERE (.+)").WithArgs(userID, id).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	store := NewRepository(db)
@@ -410,7 +451,7 @@ func TestDelete_NotFoundErr(t *testing.T) {
 	userID := "test"
 	id := "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83"
 
-	mock.ExpectExec("^DELETE FROM cards WHERE (.+)").WithArgs(userID, id).
+	mock.ExpectExec("^UPDATE cards SET deleted_at=now\\(\\) WHERE (.+)").WithArgs(userID, id).
 		WillReturnResult(sqlmock.NewResult(0, 0))
 
 	store := NewRepository(db)
@@ -431,7 +472,7 @@ func TestDelete_SqlErr(t *testing.T) {
 	userID := "test"
 	id := "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83"
 
-	mock.ExpectExec("^DELETE FROM cards WHERE (.+)").WithArgs(userID, id).
+	mock.ExpectExec("^UPDATE cards SET deleted_at=now\\(\\) WHERE (.+)").WithArgs(userID, id).
 		WillReturnError(sql.ErrConnDone)
 
 	store := NewRepository(db)
@@ -441,3 +482,238 @@ func TestDelete_SqlErr(t *testing.T) {
 	err = store.Delete(ctx, userID, id)
 	require.Equal(t, err, sql.ErrConnDone)
 }
+
+func TestGetDeletedList_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	userID := "test"
+	id := "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83"
+	deletedAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("^SELECT id, card, deleted_at FROM cards WHERE(.+)deleted_at IS NOT NULL").WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "card", "deleted_at"}).AddRow(id, "123321", deletedAt))
+
+	store := NewRepository(db)
+
+	ctx := context.Background()
+
+	items, err := store.GetDeletedList(ctx, userID)
+	require.NoError(t, err)
+
+	require.Equal(t, []types.TrashItem{{Id: id, Key: "123321", DeletedAt: deletedAt}}, items)
+}
+
+func TestGetDeletedList_SqlErr(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	userID := "test"
+
+	mock.ExpectQuery("^SELECT id, card, deleted_at FROM cards WHERE(.+)").WithArgs(userID).
+		WillReturnError(sql.ErrConnDone)
+
+	store := NewRepository(db)
+
+	ctx := context.Background()
+
+	_, err = store.GetDeletedList(ctx, userID)
+	require.Equal(t, err, sql.ErrConnDone)
+}
+
+func TestRestore_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	userID := "test"
+	id := "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83"
+
+	mock.ExpectExec("^UPDATE cards SET deleted_at=NULL WHERE (.+)").WithArgs(userID, id).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	store := NewRepository(db)
+
+	ctx := context.Background()
+
+	err = store.Restore(ctx, userID, id)
+	require.NoError(t, err)
+}
+
+func TestRestore_NilCardId(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	userID := "test"
+
+	store := NewRepository(db)
+
+	ctx := context.Background()
+
+	err = store.Restore(ctx, userID, "")
+	require.Equal(t, err.Error(), "repository: incorrect parameters")
+}
+
+func TestRestore_NotFoundErr(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	userID := "test"
+	id := "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83"
+
+	mock.ExpectExec("^UPDATE cards SET deleted_at=NULL WHERE (.+)").WithArgs(userID, id).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	store := NewRepository(db)
+
+	ctx := context.Background()
+
+	err = store.Restore(ctx, userID, id)
+	require.Equal(t, err, sql.ErrNoRows)
+}
+
+func TestEmptyTrash_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	userID := "test"
+
+	mock.ExpectExec("^DELETE FROM cards WHERE user_id=(.+)deleted_at IS NOT NULL").WithArgs(userID).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	store := NewRepository(db)
+
+	ctx := context.Background()
+
+	err = store.EmptyTrash(ctx, userID)
+	require.NoError(t, err)
+}
+
+func TestPurgeDeleted_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	before := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec("^DELETE FROM cards WHERE deleted_at <(.+)").WithArgs(before).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	store := NewRepository(db)
+
+	ctx := context.Background()
+
+	n, err := store.PurgeDeleted(ctx, before)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), n)
+}
+
+func TestPurgeDeleted_SqlErr(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	before := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec("^DELETE FROM cards WHERE deleted_at <(.+)").WithArgs(before).
+		WillReturnError(sql.ErrConnDone)
+
+	store := NewRepository(db)
+
+	ctx := context.Background()
+
+	_, err = store.PurgeDeleted(ctx, before)
+	require.Equal(t, err, sql.ErrConnDone)
+}
+
+func TestCreateMany_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	userID := "test"
+	card := &types.CardInfo{Number: "4111111111111111", Expiration: "12/30", CVV: "123", Metadata: "test_meta"}
+	records := []types.Record[types.CardInfo]{
+		{ID: "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", Data: card},
+		{ID: "51e4389c-dd1d-5f3e-92c2-62fd92bb3f94", Data: card},
+	}
+
+	mock.ExpectExec(`^INSERT INTO cards\(.+\) SELECT (.+) FROM \(VALUES \(\$2, (.+)\), \((.+)\)\)`).
+		WithArgs(userID, records[0].ID, card.Number, card.Expiration, card.CVV, card.Metadata, records[1].ID, card.Number, card.Expiration, card.CVV, card.Metadata).
+		WillReturnResult(sqlmock.NewResult(2, 2))
+
+	store := NewRepository(db)
+
+	err = store.CreateMany(context.Background(), userID, records)
+	require.NoError(t, err)
+}
+
+func TestUpdateMany_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	userID := "test"
+	card := &types.CardInfo{Number: "4111111111111111", Expiration: "12/30", CVV: "123", Metadata: "test_meta"}
+	records := []types.Record[types.CardInfo]{
+		{ID: "40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", Data: card},
+		{ID: "51e4389c-dd1d-5f3e-92c2-62fd92bb3f94", Data: card},
+	}
+
+	mock.ExpectQuery(`^UPDATE cards SET (.+) FROM \(VALUES (.+)\) (.+) RETURNING cards.id`).
+		WithArgs(userID, records[0].ID, card.Number, card.Expiration, card.CVV, card.Metadata, records[1].ID, card.Number, card.Expiration, card.CVV, card.Metadata).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(records[0].ID))
+
+	store := NewRepository(db)
+
+	err = store.UpdateMany(context.Background(), userID, records)
+	var itemErr *types.ItemError
+	require.ErrorAs(t, err, &itemErr)
+	require.Equal(t, 1, itemErr.Index)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestDeleteMany_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	userID := "test"
+	ids := []string{"40d3289b-cc0c-4e2d-81b1-51ec81aa2e83", "51e4389c-dd1d-5f3e-92c2-62fd92bb3f94"}
+
+	mock.ExpectQuery(`^UPDATE cards SET deleted_at=now\(\) WHERE user_id=\$1 and id IN \(\$2, \$3\) (.+) RETURNING id`).
+		WithArgs(userID, ids[0], ids[1]).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(ids[1]).AddRow(ids[0]))
+
+	store := NewRepository(db)
+
+	err = store.DeleteMany(context.Background(), userID, ids)
+	require.NoError(t, err)
+}
@@ -5,11 +5,25 @@ import (
 	"database/sql"
 	"errors"
 	"strings"
+	"time"
 
 	"keeper-project/internal/store"
+	"keeper-project/internal/store/postgres"
 	"keeper-project/types"
 )
 
+var listQuery = postgres.ListQuery{
+	Select:  "id, key, uploaded_at, updated_at",
+	From:    "texts",
+	Where:   "user_id=$1 and deleted_at IS NULL",
+	Created: "uploaded_at",
+	Sorts: map[string]postgres.Column{
+		"":                postgres.TimeColumn("uploaded_at"),
+		types.SortCreated: postgres.TimeColumn("uploaded_at"),
+		types.SortUpdated: postgres.TimeColumn("updated_at"),
+	},
+}
+
 type repo struct {
 	db *sql.DB
 }
@@ -23,7 +37,7 @@ func (repo *repo) Create(ctx context.Context, userID, id string, text *types.Not
 		return errors.New("repository: incorrect parameters")
 	}
 
-	_, err := repo.db.ExecContext(ctx,
+	_, err := postgres.Conn(ctx, repo.db).ExecContext(ctx,
 		"INSERT INTO texts(user_id, id, key, data, metadata) VALUES ($1, $2, $3, $4, $5)",
 		userID, id, text.Key, text.Text, text.Metadata)
 	if err != nil {
@@ -42,7 +56,7 @@ func (repo *repo) Get(ctx context.Context, userID, id string) (*types.Note, erro
 
 	ret := types.Note{}
 
-	err := repo.db.QueryRowContext(ctx, "SELECT key, data, metadata FROM texts WHERE user_id=$1 and id=$2", userID, id).Scan(
+	err := postgres.Conn(ctx, repo.db).QueryRowContext(ctx, "SELECT key, data, metadata FROM texts WHERE user_id=$1 and id=$2 and deleted_at IS NULL", userID, id).Scan(
 		&ret.Key, &ret.Text, &ret.Metadata)
 	if err != nil {
 		return nil, err
@@ -51,30 +65,28 @@ func (repo *repo) Get(ctx context.Context, userID, id string) (*types.Note, erro
 	return &ret, nil
 }
 
-func (repo *repo) GetKeysList(ctx context.Context, userID string) ([]types.Key, error) {
-	var ret []types.Key
-
-	rows, err := repo.db.QueryContext(ctx, "SELECT id, key FROM texts WHERE user_id=$1", userID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
+func (repo *repo) GetKeysList(ctx context.Context, userID string, opts types.ListOptions) (*types.Page[types.Key], error) {
+	page := &types.Page[types.Key]{Items: make([]types.Key, 0)}
 
-	for rows.Next() {
-		var id, key string
-		err = rows.Scan(&id, &key)
+	var err error
+	page.Next, page.Total, err = postgres.List(ctx, repo.db, listQuery, userID, opts, func(rows *sql.Rows, sortValue any) (string, error) {
+		var (
+			key              types.Key
+			created, updated time.Time
+		)
+		err := rows.Scan(&key.Id, &key.Key, &created, &updated, sortValue)
 		if err != nil {
-			return nil, err
+			return "", err
 		}
-
-		ret = append(ret, types.Key{Id: id, Key: key})
-	}
-
-	if err = rows.Err(); err != nil {
+		key.CreatedAt, key.UpdatedAt = &created, &updated
+		page.Items = append(page.Items, key)
+		return key.Id, nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	return ret, nil
+	return page, nil
 }
 
 func (repo *repo) Update(ctx context.Context, userID, id string, text *types.Note) error {
@@ -82,7 +94,7 @@ func (repo *repo) Update(ctx context.Context, userID, id string, text *types.Not
 		return errors.New("repository: incorrect parameters")
 	}
 
-	result, err := repo.db.ExecContext(ctx, "UPDATE texts SET key=$1, data=$2, metadata = $3 WHERE user_id=$4 and id =$5;",
+	result, err := postgres.Conn(ctx, repo.db).ExecContext(ctx, "UPDATE texts SET key=$1, data=$2, metadata = $3, updated_at=now() WHERE user_id=$4 and id =$5 and deleted_at IS NULL;",
 		text.Key, text.Text, text.Metadata, userID, id)
 	if err != nil {
 		return err
@@ -102,7 +114,7 @@ func (repo *repo) Delete(ctx context.Context, userID, key string) error {
 		return errors.New("repository: incorrect parameters")
 	}
 
-	result, err := repo.db.ExecContext(ctx, "DELETE FROM texts WHERE user_id=$1 and id =$2;",
+	result, err := postgres.Conn(ctx, repo.db).ExecContext(ctx, "UPDATE texts SET deleted_at=now() WHERE user_id=$1 and id =$2 and deleted_at IS NULL;",
 		userID, key)
 	if err != nil {
 		return err
@@ -116,3 +128,136 @@ func (repo *repo) Delete(ctx context.Context, userID, key string) error {
 	}
 	return nil
 }
+
+func (repo *repo) CreateMany(ctx context.Context, userID string, records []types.Record[types.Note]) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	args := []any{userID}
+	for _, r := range records {
+		if r.ID == "" || r.Data == nil {
+			return errors.New("repository: incorrect parameters")
+		}
+		args = append(args, r.ID, r.Data.Key, r.Data.Text, r.Data.Metadata)
+	}
+
+	_, err := postgres.Conn(ctx, repo.db).ExecContext(ctx,
+		"INSERT INTO texts(user_id, id, key, data, metadata) SELECT $1, v.id::uuid, v.key, v.data, v.metadata FROM (VALUES "+
+			postgres.Values(len(records), 4, 2)+") AS v(id, key, data, metadata)",
+		args...)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
+			return types.ErrRecordAlreadyExists
+		}
+		return err
+	}
+	return nil
+}
+
+func (repo *repo) UpdateMany(ctx context.Context, userID string, records []types.Record[types.Note]) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	args := []any{userID}
+	ids := make([]string, 0, len(records))
+	for _, r := range records {
+		if r.ID == "" || r.Data == nil {
+			return errors.New("repository: incorrect parameters")
+		}
+		args = append(args, r.ID, r.Data.Key, r.Data.Text, r.Data.Metadata)
+		ids = append(ids, r.ID)
+	}
+
+	rows, err := postgres.Conn(ctx, repo.db).QueryContext(ctx,
+		"UPDATE texts SET key=v.key, data=v.data, metadata=v.metadata, updated_at=now() FROM (VALUES "+postgres.Values(len(records), 4, 2)+") AS v(id, key, data, metadata) "+
+			"WHERE user_id=$1 and texts.id=v.id::uuid and deleted_at IS NULL RETURNING texts.id",
+		args...)
+	if err != nil {
+		return err
+	}
+	return postgres.CheckReturned(rows, ids)
+}
+
+func (repo *repo) DeleteMany(ctx context.Context, userID string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	args := []any{userID}
+	for _, id := range ids {
+		if id == "" {
+			return errors.New("repository: incorrect parameters")
+		}
+		args = append(args, id)
+	}
+
+	rows, err := postgres.Conn(ctx, repo.db).QueryContext(ctx,
+		"UPDATE texts SET deleted_at=now() WHERE user_id=$1 and id IN "+postgres.Values(1, len(ids), 2)+" and deleted_at IS NULL RETURNING id",
+		args...)
+	if err != nil {
+		return err
+	}
+	return postgres.CheckReturned(rows, ids)
+}
+
+func (repo *repo) GetDeletedList(ctx context.Context, userID string) ([]types.TrashItem, error) {
+	var ret []types.TrashItem
+
+	rows, err := postgres.Conn(ctx, repo.db).QueryContext(ctx,
+		"SELECT id, key, deleted_at FROM texts WHERE user_id=$1 and deleted_at IS NOT NULL", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item types.TrashItem
+		err = rows.Scan(&item.Id, &item.Key, &item.DeletedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (repo *repo) Restore(ctx context.Context, userID, id string) error {
+	if id == "" {
+		return errors.New("repository: incorrect parameters")
+	}
+
+	result, err := postgres.Conn(ctx, repo.db).ExecContext(ctx, "UPDATE texts SET deleted_at=NULL WHERE user_id=$1 and id =$2 and deleted_at IS NOT NULL;",
+		userID, id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows != 1 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (repo *repo) EmptyTrash(ctx context.Context, userID string) error {
+	_, err := postgres.Conn(ctx, repo.db).ExecContext(ctx, "DELETE FROM texts WHERE user_id=$1 and deleted_at IS NOT NULL;", userID)
+	return err
+}
+
+func (repo *repo) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	result, err := postgres.Conn(ctx, repo.db).ExecContext(ctx, "DELETE FROM texts WHERE deleted_at < $1;", before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInTx_Commit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("^SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("^DELETE FROM texts").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("^ROLLBACK TO SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	tx := NewTx(db)
+	err = tx.InTx(context.Background(), func(ctx context.Context) error {
+		err := tx.Savepoint(ctx, func(ctx context.Context) error {
+			_, err := Conn(ctx, db).ExecContext(ctx, "DELETE FROM texts")
+			require.NoError(t, err)
+			return errors.New("item failed")
+		})
+		require.EqualError(t, err, "item failed")
+		return nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInTx_Rollback(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	err = NewTx(db).InTx(context.Background(), func(ctx context.Context) error {
+		return errors.New("batch failed")
+	})
+	require.EqualError(t, err, "batch failed")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSavepoint_OutsideTx(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	err = NewTx(db).Savepoint(context.Background(), func(ctx context.Context) error { return nil })
+	require.Error(t, err)
+}
+
+func TestValues(t *testing.T) {
+	require.Equal(t, "($2, $3), ($4, $5)", Values(2, 2, 2))
+	require.Equal(t, "($2, $3, $4)", Values(1, 3, 2))
+}
@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"time"
 
 	"keeper-project/types"
 )
@@ -11,17 +12,122 @@ type User interface {
 	GetByLogin(ctx context.Context, login string) (*types.User, error)
 }
 
+// Trash is implemented by every store that soft deletes its records.
+type Trash interface {
+	GetDeletedList(ctx context.Context, userID string) ([]types.TrashItem, error)
+	Restore(ctx context.Context, userID, id string) error
+	EmptyTrash(ctx context.Context, userID string) error
+	// PurgeDeleted hard removes records of all users deleted before the given moment.
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
+}
+
 type Secrets[T any] interface {
 	Create(context.Context, string, string, *T) error
 	Get(context.Context, string, string) (*T, error)
-	GetKeysList(context.Context, string) ([]types.Key, error)
+	GetKeysList(ctx context.Context, userID string, opts types.ListOptions) (*types.Page[types.Key], error)
 	Update(context.Context, string, string, *T) error
 	Delete(context.Context, string, string) error
+	// CreateMany, UpdateMany and DeleteMany change the records with as few
+	// queries as possible and return *types.ItemError when a record is missing,
+	// they run in the transaction of Tx when there is one.
+	CreateMany(ctx context.Context, userID string, records []types.Record[T]) error
+	UpdateMany(ctx context.Context, userID string, records []types.Record[T]) error
+	DeleteMany(ctx context.Context, userID string, ids []string) error
+	Trash
+}
+
+// Files keeps the records of stored files, the contents are kept by the file storage.
+type Files interface {
+	// Create adds the file and references the blob with its hash. A new blob is
+	// stored under info.StorageKey, otherwise info.StorageKey is replaced with the
+	// key of the existing one.
+	Create(ctx context.Context, info *types.FileInfo) error
+	// Import adds a file found in the file storage like Create, keeping its
+	// creation time and putting it in the trash when deletedAt is not nil.
+	Import(ctx context.Context, info *types.FileInfo, deletedAt *time.Time) error
+	// Link adds the file referring to an existing blob with info.Hash, the storage
+	// key and size are taken from the blob.
+	Link(ctx context.Context, info *types.FileInfo) error
+	Get(ctx context.Context, userID, id string) (*types.FileInfo, error)
+	List(ctx context.Context, userID string, opts types.ListOptions) (*types.Page[*types.FileInfo], error)
+	// Delete moves the file to the trash.
+	Delete(ctx context.Context, userID, id string) error
+	GetDeletedList(ctx context.Context, userID string) ([]types.TrashItem, error)
+	Restore(ctx context.Context, userID, id string) error
+	GetDeleted(ctx context.Context, userID string) ([]*types.FileInfo, error)
+	// GetExpired returns files of all users deleted before the given moment.
+	GetExpired(ctx context.Context, before time.Time) ([]*types.FileInfo, error)
+	// Remove deletes the record for good and releases its blob.
+	Remove(ctx context.Context, userID, id string) error
+	Blobs
+}
+
+// Blobs counts the references to file contents, so identical contents are stored once.
+type Blobs interface {
+	HasBlob(ctx context.Context, userID, hash string) (bool, error)
+	// GetUnreferenced returns blobs of all users no file refers to.
+	GetUnreferenced(ctx context.Context) ([]types.Blob, error)
+	// DeleteBlob removes an unreferenced blob, deleteContent is called with its key while the blob
+	// is locked and the blob is kept when it fails. Blobs referenced again are skipped.
+	DeleteBlob(ctx context.Context, blob types.Blob, deleteContent func(storageKey string) error) error
+}
+
+// Quotas reports what users store and how much they are allowed to.
+type Quotas interface {
+	// GetUsage returns the usage of the user with the quota applied to them.
+	GetUsage(ctx context.Context, userID string) (*types.Usage, error)
+	// LockUsage returns the usage like GetUsage and keeps the quota of the user
+	// locked until the transaction of ctx ends, so a check and the write it
+	// allows are not interleaved with those of concurrent requests. It must be
+	// called within Tx.InTx.
+	LockUsage(ctx context.Context, userID string) (*types.Usage, error)
+	// SetQuota replaces the per-user override of the default quota.
+	SetQuota(ctx context.Context, userID string, quota types.QuotaOverride) error
 }
 
 type FileService interface {
 	GetFile(ctx context.Context, bucketName, fileName string) (f *types.File, err error)
-	GetFilesList(ctx context.Context, bucketName string) ([]*types.Key, error)
+	GetFilesList(ctx context.Context, bucketName string, opts types.ListOptions) (*types.Page[*types.FileInfo], error)
 	Create(ctx context.Context, bucketName string, dto types.CreateFileDTO) error
 	Delete(ctx context.Context, bucketName, fileName string) error
+	// HasBlob reports whether the user has stored contents with the hash.
+	HasBlob(ctx context.Context, bucketName, hash string) (bool, error)
+	// Link adds a file with the stored contents of the given hash.
+	Link(ctx context.Context, bucketName string, req types.LinkFileRequest) (*types.FileInfo, error)
+	Trash
+	Uploads
+}
+
+// Uploads is a resumable upload of a file sent in numbered chunks.
+type Uploads interface {
+	CreateUpload(ctx context.Context, bucketName string, req types.CreateUploadRequest) (*types.UploadSession, error)
+	GetUpload(ctx context.Context, bucketName, uploadID string) (*types.UploadSession, error)
+	UploadChunk(ctx context.Context, bucketName, uploadID string, chunk types.UploadChunk) (*types.UploadChunkInfo, error)
+	CompleteUpload(ctx context.Context, bucketName, uploadID string) (*types.UploadSession, error)
+	AbortUpload(ctx context.Context, bucketName, uploadID string) error
+	// PurgeUploads aborts sessions of all users created before the given moment.
+	PurgeUploads(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Tx runs store calls in a single transaction, the stores pick it up from
+// the context passed to fn.
+type Tx interface {
+	// InTx commits when fn succeeds and rolls back otherwise, it joins the
+	// transaction of ctx if there is one.
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+	// Savepoint rolls back the changes of fn when it fails and keeps the
+	// transaction going, it must be called within InTx.
+	Savepoint(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Search keeps the blind index tokens of the records, the server never learns
+// the words they are made of.
+type Search interface {
+	// SetTokens replaces the tokens of the record.
+	SetTokens(ctx context.Context, userID, kind, id string, tokens []string) error
+	// Search returns the records not in the trash holding at least req.MinMatch
+	// of the tokens, the best matches first.
+	Search(ctx context.Context, userID string, req types.SearchRequest) ([]types.SearchHit, error)
+	// PurgeOrphans removes the tokens of the records of all users removed for good.
+	PurgeOrphans(ctx context.Context) (int64, error)
 }
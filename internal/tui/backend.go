@@ -0,0 +1,74 @@
+// Package tui is the full screen interface of the client: a tab with the list
+// of every kind of records, fuzzy filtering, a detail view hiding the secrets
+// until they are revealed, forms to create and edit records and a
+// confirmation before a record is moved to the trash.
+//
+// The records come decrypted from a Backend, the interface knows nothing of
+// the server and the encryption.
+package tui
+
+// Item is a record of a list.
+type Item struct {
+	ID   string
+	Name string
+}
+
+// Field is a field of the records of a kind, secret fields are hidden until
+// they are revealed and typed like passwords.
+type Field struct {
+	Name   string
+	Label  string
+	Secret bool
+}
+
+// Kind is a kind of records with a tab of its own, the first field names the
+// record and can't be empty.
+type Kind struct {
+	Name   string
+	Title  string
+	Fields []Field
+}
+
+// Kinds are the tabs of the interface, Name is the kind of the Backend calls.
+var Kinds = []Kind{
+	{
+		Name:  "note",
+		Title: "Notes",
+		Fields: []Field{
+			{Name: "title", Label: "Title"},
+			{Name: "text", Label: "Text", Secret: true},
+			{Name: "metadata", Label: "Metadata"},
+		},
+	},
+	{
+		Name:  "card",
+		Title: "Cards",
+		Fields: []Field{
+			{Name: "number", Label: "Number", Secret: true},
+			{Name: "expiration", Label: "Expiration"},
+			{Name: "cvv", Label: "CVV", Secret: true},
+			{Name: "metadata", Label: "Metadata"},
+		},
+	},
+	{
+		Name:  "cred",
+		Title: "Credentials",
+		Fields: []Field{
+			{Name: "site", Label: "Site"},
+			{Name: "login", Label: "Login"},
+			{Name: "password", Label: "Password", Secret: true},
+			{Name: "metadata", Label: "Metadata"},
+		},
+	},
+}
+
+// Backend reads and changes the records, values are keyed by the names of
+// the fields of the kind.
+type Backend interface {
+	List(kind string) ([]Item, error)
+	Get(kind, id string) (map[string]string, error)
+	// Save creates a record when the id is empty and updates it otherwise.
+	Save(kind, id string, values map[string]string) error
+	// Delete moves the record to the trash.
+	Delete(kind, id string) error
+}
@@ -0,0 +1,75 @@
+package tui
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Match tells whether the letters of the pattern appear in s in order, case
+// insensitively. Letters following each other and letters starting words of
+// s score more, so the better matches have the higher score.
+func Match(pattern, s string) (int, bool) {
+	p := []rune(strings.ToLower(pattern))
+	if len(p) == 0 {
+		return 0, true
+	}
+
+	var (
+		score int
+		i     int
+		prev  = -2
+		runes = []rune(s)
+	)
+	for j, r := range runes {
+		if unicode.ToLower(r) != p[i] {
+			continue
+		}
+
+		score++
+		if prev == j-1 {
+			score += 3
+		}
+		if j == 0 || !unicode.IsLetter(runes[j-1]) && !unicode.IsDigit(runes[j-1]) {
+			score += 2
+		}
+		prev = j
+		i++
+		if i == len(p) {
+			return score, true
+		}
+	}
+	return 0, false
+}
+
+// Filter keeps the items whose names match the pattern, the best matches
+// first, then the shorter names, otherwise in the order of items.
+func Filter(pattern string, items []Item) []Item {
+	if pattern == "" {
+		return items
+	}
+
+	type scored struct {
+		item  Item
+		score int
+	}
+
+	var matched []scored
+	for _, item := range items {
+		if score, ok := Match(pattern, item.Name); ok {
+			matched = append(matched, scored{item: item, score: score})
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		if matched[i].score != matched[j].score {
+			return matched[i].score > matched[j].score
+		}
+		return len(matched[i].item.Name) < len(matched[j].item.Name)
+	})
+
+	filtered := make([]Item, len(matched))
+	for i := range matched {
+		filtered[i] = matched[i].item
+	}
+	return filtered
+}
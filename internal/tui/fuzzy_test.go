@@ -0,0 +1,40 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	_, ok := Match("ghb", "GitHub")
+	assert.True(t, ok)
+	_, ok = Match("", "anything")
+	assert.True(t, ok)
+	_, ok = Match("hg", "GitHub")
+	assert.False(t, ok)
+	_, ok = Match("githubs", "GitHub")
+	assert.False(t, ok)
+
+	consecutive, _ := Match("git", "GitHub")
+	scattered, _ := Match("git", "Go in time")
+	assert.Greater(t, consecutive, scattered)
+
+	wordStart, _ := Match("mail", "work mail")
+	inside, _ := Match("mail", "hotmails")
+	assert.Greater(t, wordStart, inside)
+}
+
+func TestFilter(t *testing.T) {
+	items := []Item{
+		{ID: "1", Name: "bank"},
+		{ID: "2", Name: "my github"},
+		{ID: "3", Name: "gmail"},
+		{ID: "4", Name: "github"},
+	}
+
+	assert.Equal(t, items, Filter("", items))
+	assert.Equal(t, []Item{{ID: "4", Name: "github"}, {ID: "2", Name: "my github"}}, Filter("GitH", items))
+	assert.Equal(t, []Item{{ID: "3", Name: "gmail"}, {ID: "4", Name: "github"}, {ID: "2", Name: "my github"}}, Filter("g", items))
+	assert.Empty(t, Filter("xyz", items))
+}
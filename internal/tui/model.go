@@ -0,0 +1,453 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// mask stands for a hidden secret, it doesn't tell the length of the secret.
+const mask = "••••••••"
+
+type screen int
+
+const (
+	screenList screen = iota
+	screenDetail
+	screenForm
+	screenConfirm
+)
+
+var (
+	activeTabStyle = lipgloss.NewStyle().Bold(true).Reverse(true).Padding(0, 1)
+	tabStyle       = lipgloss.NewStyle().Padding(0, 1)
+	selectedStyle  = lipgloss.NewStyle().Bold(true)
+	labelStyle     = lipgloss.NewStyle().Faint(true)
+	errorStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	helpStyle      = lipgloss.NewStyle().Faint(true)
+)
+
+// messages of the backend calls, kind is the index of the tab
+type (
+	listMsg struct {
+		kind  int
+		items []Item
+		err   error
+	}
+	recordMsg struct {
+		id     string
+		values map[string]string
+		edit   bool
+		err    error
+	}
+	doneMsg struct {
+		kind   int
+		status string
+		err    error
+	}
+)
+
+// Model is the state of the interface.
+type Model struct {
+	backend Backend
+
+	tab     int
+	items   [][]Item
+	loaded  []bool
+	visible []Item
+	cursor  int
+
+	filter    textinput.Model
+	filtering bool
+
+	screen screen
+	// record is the record of the detail view and of the edit form
+	recordID string
+	record   map[string]string
+	reveal   bool
+
+	// inputs are the fields of the form, editID is empty for a new record
+	inputs []textinput.Model
+	focus  int
+	editID string
+
+	status string
+	err    error
+}
+
+// New makes the interface showing the records of the backend.
+func New(backend Backend) Model {
+	filter := newInput()
+	filter.Prompt = "/"
+
+	return Model{
+		backend: backend,
+		items:   make([][]Item, len(Kinds)),
+		loaded:  make([]bool, len(Kinds)),
+		filter:  filter,
+	}
+}
+
+// Run shows the interface until it is quit.
+func Run(backend Backend) error {
+	_, err := tea.NewProgram(New(backend), tea.WithAltScreen()).Run()
+	return err
+}
+
+func newInput() textinput.Model {
+	in := textinput.New()
+	in.Cursor.SetMode(cursor.CursorStatic)
+	return in
+}
+
+func (m Model) Init() tea.Cmd {
+	return m.load(m.tab)
+}
+
+func (m Model) load(kind int) tea.Cmd {
+	return func() tea.Msg {
+		items, err := m.backend.List(Kinds[kind].Name)
+		return listMsg{kind: kind, items: items, err: err}
+	}
+}
+
+func (m Model) get(id string, edit bool) tea.Cmd {
+	kind := Kinds[m.tab].Name
+	return func() tea.Msg {
+		values, err := m.backend.Get(kind, id)
+		return recordMsg{id: id, values: values, edit: edit, err: err}
+	}
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case listMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.items[msg.kind], m.loaded[msg.kind] = msg.items, true
+		if msg.kind == m.tab {
+			m.refilter()
+		}
+		return m, nil
+	case recordMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.recordID, m.record, m.reveal = msg.id, msg.values, false
+		if msg.edit {
+			m.openForm(msg.id, msg.values)
+		} else {
+			m.screen = screenDetail
+		}
+		return m, nil
+	case doneMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.status, m.screen = msg.status, screenList
+		return m, m.load(msg.kind)
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+		m.status, m.err = "", nil
+		switch m.screen {
+		case screenDetail:
+			return m.updateDetail(msg)
+		case screenForm:
+			return m.updateForm(msg)
+		case screenConfirm:
+			return m.updateConfirm(msg)
+		}
+		return m.updateList(msg)
+	}
+	return m, nil
+}
+
+func (m Model) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.filtering {
+		switch msg.String() {
+		case "esc":
+			m.filtering = false
+			m.filter.Blur()
+			m.filter.SetValue("")
+		case "enter":
+			m.filtering = false
+			m.filter.Blur()
+		case "up", "down":
+			m.moveCursor(msg.String())
+		default:
+			m.filter, _ = m.filter.Update(msg)
+		}
+		m.refilter()
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "q", "esc":
+		return m, tea.Quit
+	case "tab", "right", "l":
+		return m.switchTab((m.tab + 1) % len(Kinds))
+	case "shift+tab", "left", "h":
+		return m.switchTab((m.tab + len(Kinds) - 1) % len(Kinds))
+	case "1", "2", "3":
+		return m.switchTab(int(msg.Runes[0] - '1'))
+	case "up", "k", "down", "j":
+		m.moveCursor(msg.String())
+	case "/":
+		m.filtering = true
+		m.filter.Focus()
+	case "r":
+		return m, m.load(m.tab)
+	case "n":
+		m.openForm("", nil)
+	case "enter", "e", "d":
+		item, ok := m.selected()
+		if !ok {
+			return m, nil
+		}
+		switch msg.String() {
+		case "enter":
+			return m, m.get(item.ID, false)
+		case "e":
+			return m, m.get(item.ID, true)
+		}
+		m.recordID, m.screen = item.ID, screenConfirm
+	}
+	return m, nil
+}
+
+func (m Model) updateDetail(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q", "backspace":
+		m.screen = screenList
+	case " ", "v":
+		m.reveal = !m.reveal
+	case "e":
+		m.openForm(m.recordID, m.record)
+	case "d":
+		m.screen = screenConfirm
+	}
+	return m, nil
+}
+
+func (m Model) updateForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.screen = screenList
+		return m, nil
+	case "ctrl+s":
+		return m.save()
+	case "enter":
+		if m.focus == len(m.inputs)-1 {
+			return m.save()
+		}
+		m.focusInput(m.focus + 1)
+		return m, nil
+	case "tab", "down":
+		m.focusInput((m.focus + 1) % len(m.inputs))
+		return m, nil
+	case "shift+tab", "up":
+		m.focusInput((m.focus + len(m.inputs) - 1) % len(m.inputs))
+		return m, nil
+	}
+
+	m.inputs[m.focus], _ = m.inputs[m.focus].Update(msg)
+	return m, nil
+}
+
+func (m Model) updateConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y":
+		kind, id := m.tab, m.recordID
+		return m, func() tea.Msg {
+			err := m.backend.Delete(Kinds[kind].Name, id)
+			return doneMsg{kind: kind, status: "Moved to trash", err: err}
+		}
+	case "n", "esc", "q":
+		m.screen = screenList
+	}
+	return m, nil
+}
+
+func (m Model) switchTab(tab int) (tea.Model, tea.Cmd) {
+	if tab < 0 || tab >= len(Kinds) {
+		return m, nil
+	}
+	m.tab, m.cursor = tab, 0
+	m.filtering = false
+	m.filter.Blur()
+	m.filter.SetValue("")
+	m.refilter()
+	if !m.loaded[tab] {
+		return m, m.load(tab)
+	}
+	return m, nil
+}
+
+func (m *Model) moveCursor(key string) {
+	switch key {
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	default:
+		if m.cursor < len(m.visible)-1 {
+			m.cursor++
+		}
+	}
+}
+
+func (m *Model) refilter() {
+	m.visible = Filter(m.filter.Value(), m.items[m.tab])
+	m.cursor = max(0, min(m.cursor, len(m.visible)-1))
+}
+
+func (m Model) selected() (Item, bool) {
+	if len(m.visible) == 0 {
+		return Item{}, false
+	}
+	return m.visible[m.cursor], true
+}
+
+// openForm shows the form of the current kind filled with the values.
+func (m *Model) openForm(id string, values map[string]string) {
+	fields := Kinds[m.tab].Fields
+	m.inputs = make([]textinput.Model, len(fields))
+	for i, f := range fields {
+		in := newInput()
+		in.Prompt = ""
+		in.Placeholder = f.Label
+		if f.Secret {
+			in.EchoMode = textinput.EchoPassword
+		}
+		in.SetValue(values[f.Name])
+		m.inputs[i] = in
+	}
+	m.editID, m.screen, m.focus = id, screenForm, 0
+	m.focusInput(0)
+}
+
+func (m *Model) focusInput(i int) {
+	m.inputs[m.focus].Blur()
+	m.focus = i
+	m.inputs[i].Focus()
+}
+
+func (m Model) save() (tea.Model, tea.Cmd) {
+	fields := Kinds[m.tab].Fields
+	values := make(map[string]string, len(fields))
+	for i, f := range fields {
+		values[f.Name] = m.inputs[i].Value()
+	}
+	if strings.TrimSpace(values[fields[0].Name]) == "" {
+		m.err = fmt.Errorf("%s can't be empty", strings.ToLower(fields[0].Label))
+		return m, nil
+	}
+
+	kind, id := m.tab, m.editID
+	return m, func() tea.Msg {
+		err := m.backend.Save(Kinds[kind].Name, id, values)
+		return doneMsg{kind: kind, status: "Saved", err: err}
+	}
+}
+
+func (m Model) View() string {
+	var b strings.Builder
+
+	for i, k := range Kinds {
+		style := tabStyle
+		if i == m.tab {
+			style = activeTabStyle
+		}
+		b.WriteString(style.Render(fmt.Sprintf("%d %s", i+1, k.Title)))
+	}
+	b.WriteString("\n\n")
+
+	var help string
+	switch m.screen {
+	case screenList:
+		m.viewList(&b)
+		help = "↑/↓ move • enter open • n new • e edit • d delete • / filter • tab switch • r reload • q quit"
+	case screenDetail:
+		m.viewDetail(&b)
+		help = "space reveal • e edit • d delete • esc back"
+	case screenForm:
+		m.viewForm(&b)
+		help = "tab next field • enter next/save • ctrl+s save • esc cancel"
+	case screenConfirm:
+		fmt.Fprintf(&b, "Move %s to the trash? (y/n)\n", m.recordName())
+	}
+
+	b.WriteString("\n")
+	switch {
+	case m.err != nil:
+		b.WriteString(errorStyle.Render("Error: "+m.err.Error()) + "\n")
+	case m.status != "":
+		b.WriteString(m.status + "\n")
+	}
+	if help != "" {
+		b.WriteString(helpStyle.Render(help) + "\n")
+	}
+	return b.String()
+}
+
+func (m Model) viewList(b *strings.Builder) {
+	if m.filtering || m.filter.Value() != "" {
+		b.WriteString(m.filter.View() + "\n")
+	}
+	if !m.loaded[m.tab] {
+		b.WriteString("Loading...\n")
+		return
+	}
+	if len(m.visible) == 0 {
+		b.WriteString("Nothing here\n")
+		return
+	}
+	for i, item := range m.visible {
+		if i == m.cursor {
+			b.WriteString(selectedStyle.Render("> "+item.Name) + "\n")
+		} else {
+			b.WriteString("  " + item.Name + "\n")
+		}
+	}
+}
+
+func (m Model) viewDetail(b *strings.Builder) {
+	for _, f := range Kinds[m.tab].Fields {
+		value := m.record[f.Name]
+		if f.Secret && !m.reveal && value != "" {
+			value = mask
+		}
+		fmt.Fprintf(b, "%s %s\n", labelStyle.Render(fmt.Sprintf("%-11s", f.Label+":")), value)
+	}
+}
+
+func (m Model) viewForm(b *strings.Builder) {
+	title := "New record"
+	if m.editID != "" {
+		title = "Edit " + m.recordName()
+	}
+	b.WriteString(title + "\n\n")
+	for i, f := range Kinds[m.tab].Fields {
+		fmt.Fprintf(b, "%s %s\n", labelStyle.Render(fmt.Sprintf("%-11s", f.Label+":")), m.inputs[i].View())
+	}
+}
+
+// recordName is the name the list shows for the current record.
+func (m Model) recordName() string {
+	for _, item := range m.items[m.tab] {
+		if item.ID == m.recordID {
+			return item.Name
+		}
+	}
+	return m.recordID
+}
@@ -0,0 +1,160 @@
+package tui_test
+
+import (
+	"errors"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"keeper-project/internal/mocks"
+	"keeper-project/internal/tui"
+)
+
+// press sends the keys to the model and runs the commands they return, the
+// model is returned once there is nothing left to run.
+func press(t *testing.T, m tea.Model, keys ...string) tea.Model {
+	t.Helper()
+	for _, k := range keys {
+		var msg tea.Msg
+		switch k {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		case "tab":
+			msg = tea.KeyMsg{Type: tea.KeyTab}
+		case "ctrl+s":
+			msg = tea.KeyMsg{Type: tea.KeyCtrlS}
+		case "space":
+			msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+		m = run(t, m, msg)
+	}
+	return m
+}
+
+func run(t *testing.T, m tea.Model, msg tea.Msg) tea.Model {
+	t.Helper()
+	m, cmd := m.Update(msg)
+	for cmd != nil {
+		msg = cmd()
+		if _, ok := msg.(tea.QuitMsg); ok {
+			return m
+		}
+		m, cmd = m.Update(msg)
+	}
+	return m
+}
+
+func start(t *testing.T, backend tui.Backend) tea.Model {
+	t.Helper()
+	m := tui.New(backend)
+	cmd := m.Init()
+	require.NotNil(t, cmd)
+	return run(t, m, cmd())
+}
+
+func TestModel_Browse(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	backend := mocks.NewMockBackend(mockCtrl)
+	backend.EXPECT().List("note").Return([]tui.Item{{ID: "1", Name: "shopping"}, {ID: "2", Name: "deploy key"}}, nil)
+	backend.EXPECT().List("cred").Return([]tui.Item{{ID: "3", Name: "github.com"}, {ID: "4", Name: "gitlab.com"}}, nil)
+	backend.EXPECT().Get("cred", "4").Return(map[string]string{
+		"site": "gitlab.com", "login": "me", "password": "s3cret", "metadata": "work",
+	}, nil)
+
+	m := start(t, backend)
+	assert.Contains(t, m.View(), "shopping")
+	assert.Contains(t, m.View(), "deploy key")
+
+	m = press(t, m, "/", "d", "k", "enter")
+	assert.NotContains(t, m.View(), "shopping")
+	assert.Contains(t, m.View(), "> deploy key")
+
+	m = press(t, m, "3", "/", "l", "b", "enter", "enter")
+	view := m.View()
+	assert.Contains(t, view, "gitlab.com")
+	assert.Contains(t, view, "me")
+	assert.NotContains(t, view, "s3cret")
+
+	m = press(t, m, "space")
+	assert.Contains(t, m.View(), "s3cret")
+
+	m = press(t, m, "space")
+	assert.NotContains(t, m.View(), "s3cret")
+}
+
+func TestModel_Create(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	backend := mocks.NewMockBackend(mockCtrl)
+	gomock.InOrder(
+		backend.EXPECT().List("note").Return(nil, nil),
+		backend.EXPECT().Save("note", "", map[string]string{"title": "todo", "text": "milk", "metadata": ""}).Return(nil),
+		backend.EXPECT().List("note").Return([]tui.Item{{ID: "1", Name: "todo"}}, nil),
+	)
+
+	m := start(t, backend)
+	assert.Contains(t, m.View(), "Nothing here")
+
+	m = press(t, m, "n", "ctrl+s")
+	assert.Contains(t, m.View(), "title can't be empty")
+
+	m = press(t, m, "t", "o", "d", "o", "tab", "m", "i", "l", "k", "enter", "enter")
+	assert.Contains(t, m.View(), "Saved")
+	assert.Contains(t, m.View(), "> todo")
+}
+
+func TestModel_Edit(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	backend := mocks.NewMockBackend(mockCtrl)
+	backend.EXPECT().List("note").Return(nil, nil)
+	backend.EXPECT().List("card").Return([]tui.Item{{ID: "5", Name: "*4242"}}, nil).Times(2)
+	backend.EXPECT().Get("card", "5").Return(map[string]string{
+		"number": "4242424242424242", "expiration": "12/30", "cvv": "123", "metadata": "",
+	}, nil)
+	backend.EXPECT().Save("card", "5", map[string]string{
+		"number": "4242424242424242", "expiration": "12/30", "cvv": "123", "metadata": "bank",
+	}).Return(nil)
+
+	m := start(t, backend)
+	m = press(t, m, "2", "e", "tab", "tab", "tab", "b", "a", "n", "k", "enter")
+	assert.Contains(t, m.View(), "Saved")
+}
+
+func TestModel_Delete(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	backend := mocks.NewMockBackend(mockCtrl)
+	gomock.InOrder(
+		backend.EXPECT().List("note").Return([]tui.Item{{ID: "1", Name: "old"}}, nil),
+		backend.EXPECT().Delete("note", "1").Return(errors.New("server is down")),
+		backend.EXPECT().Delete("note", "1").Return(nil),
+		backend.EXPECT().List("note").Return(nil, nil),
+	)
+
+	m := start(t, backend)
+	m = press(t, m, "d")
+	assert.Contains(t, m.View(), "Move old to the trash?")
+
+	m = press(t, m, "n")
+	assert.Contains(t, m.View(), "> old")
+
+	m = press(t, m, "d", "y")
+	assert.Contains(t, m.View(), "server is down")
+
+	m = press(t, m, "y")
+	assert.Contains(t, m.View(), "Moved to trash")
+	assert.Contains(t, m.View(), "Nothing here")
+}
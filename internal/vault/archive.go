@@ -0,0 +1,252 @@
+// Package vault reads and writes vault archives, the portable backup of all
+// the records of an account.
+//
+// An archive is a tar stream encrypted as a single file of package crypto
+// with a passphrase chosen on export:
+//
+//	"KPRV\x01" | "KPRF\x01" | salt (32 bytes) | AES-GCM chunks of the tar stream
+//
+// The chunk key is HKDF-SHA256 (info "keeper file", salt) of the hex encoded
+// Argon2id (time 3, memory 64 MiB, 4 threads, 32 bytes) of the passphrase and
+// the salt, see crypto.StretchPassphrase and crypto.NewFileCipher for the
+// chunk layout. The first tar entry is manifest.json with the records in
+// plain text, it is followed by an entry per file in the order of
+// Manifest.Files holding the file contents.
+package vault
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"keeper-project/internal/crypto"
+	"keeper-project/types"
+)
+
+// Version is the manifest version written by this package.
+const Version = 1
+
+const (
+	magic        = "KPRV\x01"
+	manifestName = "manifest.json"
+)
+
+var ErrNotArchive = errors.New("vault: not a vault archive")
+
+// Manifest lists the records of an archive, secrets are kept decrypted
+// so that they can be imported into an account with another password.
+type Manifest struct {
+	Version     int                 `json:"version"`
+	CreatedAt   time.Time           `json:"created_at"`
+	Notes       []types.Note        `json:"notes"`
+	Cards       []types.CardInfo    `json:"cards"`
+	Credentials []types.Credentials `json:"credentials"`
+	Files       []File              `json:"files"`
+}
+
+// File is a file of the archive, its contents are stored in the entry Blob.
+type File struct {
+	Name     string `json:"name"`
+	Metadata string `json:"metadata"`
+	Size     int64  `json:"size"`
+	Blob     string `json:"blob"`
+}
+
+// BlobName returns the name of the entry with the contents of the file i.
+func BlobName(i int) string {
+	return fmt.Sprintf("files/%d", i)
+}
+
+// Writer writes an archive, the contents of the manifest files are added with
+// AddFile in the manifest order.
+type Writer struct {
+	manifest *Manifest
+	enc      io.WriteCloser
+	tw       *tar.Writer
+	next     int
+}
+
+// NewWriter writes the header and the manifest to dst.
+func NewWriter(dst io.Writer, passphrase string, manifest *Manifest) (*Writer, error) {
+	if passphrase == "" {
+		return nil, errors.New("vault: empty passphrase")
+	}
+
+	salt, err := crypto.NewSalt()
+	if err != nil {
+		return nil, err
+	}
+	fc, err := crypto.NewFileCipher(crypto.StretchPassphrase(passphrase, salt), salt)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = io.WriteString(dst, magic)
+	if err != nil {
+		return nil, err
+	}
+	enc, err := fc.NewWriter(dst)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest.Version = Version
+	for i := range manifest.Files {
+		manifest.Files[i].Blob = BlobName(i)
+	}
+	data, err := json.MarshalIndent(manifest, "", "\t")
+	if err != nil {
+		return nil, fmt.Errorf("vault: failed to encode manifest: %w", err)
+	}
+
+	w := &Writer{manifest: manifest, enc: enc, tw: tar.NewWriter(enc)}
+	err = w.writeEntry(manifestName, int64(len(data)), manifest.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	_, err = w.tw.Write(data)
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// AddFile writes the contents of the next manifest file, r must hold exactly
+// the Size of the file.
+func (w *Writer) AddFile(r io.Reader) error {
+	if w.next >= len(w.manifest.Files) {
+		return errors.New("vault: more files than in the manifest")
+	}
+	f := w.manifest.Files[w.next]
+	w.next++
+
+	err := w.writeEntry(f.Blob, f.Size, w.manifest.CreatedAt)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(w.tw, r)
+	if err != nil {
+		return fmt.Errorf("vault: failed to write %s: %w", f.Name, err)
+	}
+	if n != f.Size {
+		return fmt.Errorf("vault: %s is %d bytes instead of %d", f.Name, n, f.Size)
+	}
+	return nil
+}
+
+// Close finishes the archive, it does not close the underlying writer.
+func (w *Writer) Close() error {
+	if w.next != len(w.manifest.Files) {
+		return fmt.Errorf("vault: %d of %d files written", w.next, len(w.manifest.Files))
+	}
+	err := w.tw.Close()
+	if err != nil {
+		return err
+	}
+	return w.enc.Close()
+}
+
+func (w *Writer) writeEntry(name string, size int64, modTime time.Time) error {
+	return w.tw.WriteHeader(&tar.Header{
+		Name:     name,
+		Mode:     0600,
+		Size:     size,
+		ModTime:  modTime,
+		Typeflag: tar.TypeReg,
+	})
+}
+
+// Reader reads an archive. Every chunk is authenticated as it is read, a
+// truncated archive is only detected by Next returning an error instead of
+// io.EOF after the last file.
+type Reader struct {
+	Manifest *Manifest
+
+	dec  io.Reader
+	tr   *tar.Reader
+	next int
+}
+
+// NewReader reads the header and the manifest of the archive.
+func NewReader(src io.Reader, passphrase string) (*Reader, error) {
+	prefix := make([]byte, len(magic))
+	_, err := io.ReadFull(src, prefix)
+	if err != nil || string(prefix) != magic {
+		return nil, ErrNotArchive
+	}
+	salt, err := crypto.ReadFileHeader(src)
+	if err != nil {
+		return nil, ErrNotArchive
+	}
+	fc, err := crypto.NewFileCipher(crypto.StretchPassphrase(passphrase, salt), salt)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Reader{dec: fc.NewReader(src, 0)}
+	r.tr = tar.NewReader(r.dec)
+
+	hdr, err := r.tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("vault: wrong passphrase or damaged archive: %w", err)
+	}
+	if hdr.Name != manifestName {
+		return nil, fmt.Errorf("vault: unexpected entry %s instead of the manifest", hdr.Name)
+	}
+
+	var manifest Manifest
+	err = json.NewDecoder(r.tr).Decode(&manifest)
+	if err != nil {
+		return nil, fmt.Errorf("vault: failed to decode manifest: %w", err)
+	}
+	if manifest.Version != Version {
+		return nil, fmt.Errorf("vault: unsupported archive version %d", manifest.Version)
+	}
+	r.Manifest = &manifest
+	return r, nil
+}
+
+// Next returns the next manifest file and a reader of its contents, which is
+// valid until the next call. It returns io.EOF once the whole archive is read
+// and verified.
+func (r *Reader) Next() (*File, io.Reader, error) {
+	hdr, err := r.tr.Next()
+	if errors.Is(err, io.EOF) {
+		if r.next != len(r.Manifest.Files) {
+			return nil, nil, fmt.Errorf("vault: %d of %d files in the archive", r.next, len(r.Manifest.Files))
+		}
+		// the tar stream ends before the last chunk is authenticated
+		_, err = io.Copy(io.Discard, r.dec)
+		if err != nil {
+			return nil, nil, fmt.Errorf("vault: damaged archive: %w", err)
+		}
+		return nil, nil, io.EOF
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("vault: damaged archive: %w", err)
+	}
+
+	if r.next >= len(r.Manifest.Files) || hdr.Name != r.Manifest.Files[r.next].Blob {
+		return nil, nil, fmt.Errorf("vault: unexpected entry %s", hdr.Name)
+	}
+	f := &r.Manifest.Files[r.next]
+	r.next++
+	return f, r.tr, nil
+}
+
+// Verify reads the rest of the archive, it checks the archive before anything
+// is imported from it.
+func (r *Reader) Verify() error {
+	for {
+		_, _, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
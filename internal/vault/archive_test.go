@@ -0,0 +1,102 @@
+package vault
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"keeper-project/types"
+)
+
+func writeArchive(t *testing.T, passphrase string, files ...string) []byte {
+	t.Helper()
+
+	manifest := &Manifest{
+		CreatedAt:   time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		Notes:       []types.Note{{Key: "title", Text: "text", Metadata: "md"}},
+		Cards:       []types.CardInfo{{Number: "4111111111111111", Expiration: "12/30", CVV: "123"}},
+		Credentials: []types.Credentials{{Site: "example.com", Login: "user", Password: "secret"}},
+	}
+	for i, f := range files {
+		manifest.Files = append(manifest.Files, File{Name: "file" + string(rune('a'+i)), Size: int64(len(f))})
+	}
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, passphrase, manifest)
+	require.NoError(t, err)
+	for _, f := range files {
+		require.NoError(t, w.AddFile(strings.NewReader(f)))
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestArchive_RoundTrip(t *testing.T) {
+	large := strings.Repeat("x", 200<<10)
+	data := writeArchive(t, "passphrase", "first", "", large)
+	assert.NotContains(t, string(data), "secret")
+
+	r, err := NewReader(bytes.NewReader(data), "passphrase")
+	require.NoError(t, err)
+	assert.Equal(t, Version, r.Manifest.Version)
+	assert.Equal(t, "title", r.Manifest.Notes[0].Key)
+	assert.Equal(t, "4111111111111111", r.Manifest.Cards[0].Number)
+	assert.Equal(t, "secret", r.Manifest.Credentials[0].Password)
+	require.Len(t, r.Manifest.Files, 3)
+
+	for _, want := range []string{"first", "", large} {
+		f, content, err := r.Next()
+		require.NoError(t, err)
+		got, err := io.ReadAll(content)
+		require.NoError(t, err)
+		assert.Equal(t, want, string(got), f.Name)
+	}
+	_, _, err = r.Next()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestArchive_WrongPassphrase(t *testing.T) {
+	data := writeArchive(t, "passphrase", "first")
+
+	_, err := NewReader(bytes.NewReader(data), "other")
+	assert.Error(t, err)
+}
+
+func TestArchive_NotArchive(t *testing.T) {
+	_, err := NewReader(strings.NewReader("not an archive at all"), "passphrase")
+	assert.ErrorIs(t, err, ErrNotArchive)
+}
+
+func TestArchive_Truncated(t *testing.T) {
+	data := writeArchive(t, "passphrase", strings.Repeat("x", 200<<10))
+
+	r, err := NewReader(bytes.NewReader(data[:len(data)-100]), "passphrase")
+	require.NoError(t, err)
+	assert.Error(t, r.Verify())
+}
+
+func TestArchive_Tampered(t *testing.T) {
+	data := writeArchive(t, "passphrase", "first")
+	data[len(data)-1] ^= 1
+
+	r, err := NewReader(bytes.NewReader(data), "passphrase")
+	if err == nil {
+		err = r.Verify()
+	}
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, io.EOF))
+}
+
+func TestWriter_FileSizeMismatch(t *testing.T) {
+	manifest := &Manifest{Files: []File{{Name: "a", Size: 10}}}
+
+	w, err := NewWriter(io.Discard, "passphrase", manifest)
+	require.NoError(t, err)
+	assert.Error(t, w.AddFile(strings.NewReader("short")))
+}
@@ -0,0 +1,70 @@
+package keeperclient
+
+import (
+	"fmt"
+
+	"keeper-project/internal/crypto"
+)
+
+// Cipher encrypts the fields of the records on the client, the server only
+// stores what Encrypt returns.
+type Cipher interface {
+	Encrypt(plain string) (string, error)
+	Decrypt(encrypted string) (string, error)
+}
+
+// PasswordCipher encrypts with the key derived from the password of the
+// account, the way the keeper client does.
+func PasswordCipher(password string) Cipher {
+	return passwordCipher(password)
+}
+
+type passwordCipher string
+
+func (p passwordCipher) Encrypt(plain string) (string, error) {
+	return crypto.Encrypt(string(p), plain)
+}
+
+func (p passwordCipher) Decrypt(encrypted string) (string, error) {
+	return crypto.Decrypt(string(p), encrypted)
+}
+
+// encrypt encrypts the fields in place, they are sent as is without a cipher.
+func (c *Client) encrypt(fields ...*string) error {
+	if c.cipher == nil {
+		return nil
+	}
+	for _, f := range fields {
+		enc, err := c.cipher.Encrypt(*f)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt: %w", err)
+		}
+		*f = enc
+	}
+	return nil
+}
+
+// decrypt decrypts the fields in place, they are returned as is without a cipher.
+func (c *Client) decrypt(fields ...*string) error {
+	if c.cipher == nil {
+		return nil
+	}
+	for _, f := range fields {
+		plain, err := c.cipher.Decrypt(*f)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt: %w", err)
+		}
+		*f = plain
+	}
+	return nil
+}
+
+// decryptName returns the name as is for the files stored before their
+// names were encrypted.
+func (c *Client) decryptName(name string) string {
+	plain := name
+	if c.decrypt(&plain) != nil {
+		return name
+	}
+	return plain
+}
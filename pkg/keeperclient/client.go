@@ -0,0 +1,341 @@
+// Package keeperclient is the Go client of the keeper server API.
+//
+// Every endpoint has a typed method taking a context. Idempotent requests
+// failed by the network or by the server are retried with exponential
+// backoff, and a client logged in with Login logs in again once its token
+// expires. With a Cipher the fields of the records, the names and the
+// metadata of the files are encrypted before they are sent and decrypted
+// when they are received, the contents of the files are left to the caller.
+//
+//	c := keeperclient.New("http://localhost:8080", keeperclient.WithCipher(keeperclient.PasswordCipher(password)))
+//	err := c.Login(ctx, login, password)
+//	...
+//	note, err := c.GetNote(ctx, id)
+package keeperclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	"keeper-project/types"
+)
+
+// default retry policy of New
+const (
+	defaultRetries = 3
+	defaultMinWait = 100 * time.Millisecond
+	defaultMaxWait = 2 * time.Second
+)
+
+// maxErrorBody bounds the body of a failed streamed response kept in APIError.
+const maxErrorBody = 4 << 10
+
+var (
+	// ErrUnauthorized and ErrNotFound match the APIError of the 401 and 404
+	// responses with errors.Is.
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrNotFound     = errors.New("not found")
+)
+
+// APIError is an unexpected response of the server, Message is its body.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return http.StatusText(e.StatusCode)
+	}
+	return fmt.Sprintf("%s: %s", http.StatusText(e.StatusCode), e.Message)
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRangeNotSatisfiable:
+		return e.StatusCode == http.StatusRequestedRangeNotSatisfiable
+	}
+	return false
+}
+
+// Client calls the API of a keeper server, it is safe for concurrent use.
+type Client struct {
+	rc      *resty.Client
+	baseURL string
+	cipher  Cipher
+
+	retries          int
+	minWait, maxWait time.Duration
+
+	mu              sync.Mutex
+	token           string
+	login, password string
+}
+
+type Option func(*Client)
+
+// WithHTTPClient sends the requests with the client, e.g. one with a timeout
+// or a custom transport.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.rc = resty.NewWithClient(hc)
+	}
+}
+
+// WithRetries sets how many times a request is sent again and the bounds of
+// the wait before each retry, zero retries disables them.
+func WithRetries(retries int, minWait, maxWait time.Duration) Option {
+	return func(c *Client) {
+		c.retries, c.minWait, c.maxWait = retries, minWait, maxWait
+	}
+}
+
+// WithCipher encrypts the records on the client.
+func WithCipher(cipher Cipher) Option {
+	return func(c *Client) {
+		c.cipher = cipher
+	}
+}
+
+// WithToken authorizes the requests with a token got elsewhere, the client
+// can't log in again when it expires.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// New returns a client of the server at baseURL, e.g. http://localhost:8080.
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		rc:      resty.New(),
+		baseURL: strings.TrimRight(baseURL, "/"),
+		retries: defaultRetries,
+		minWait: defaultMinWait,
+		maxWait: defaultMaxWait,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Token returns the token the requests are authorized with.
+func (c *Client) Token() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.token
+}
+
+// Register creates the account and logs the client in as the new user.
+func (c *Client) Register(ctx context.Context, login, password string) error {
+	return c.authenticate(ctx, "/api/user/register", login, password)
+}
+
+// Login logs the client in, the credentials are kept to log in again when
+// the token expires.
+func (c *Client) Login(ctx context.Context, login, password string) error {
+	return c.authenticate(ctx, "/api/user/login", login, password)
+}
+
+func (c *Client) authenticate(ctx context.Context, path, login, password string) error {
+	res, err := c.rc.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetBody(types.UserLoginRequest{Login: login, Password: password}).
+		Post(c.baseURL + path)
+	if err != nil {
+		return err
+	}
+	if err = expect(res, http.StatusOK); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = res.Header().Get("Authorization")
+	c.login, c.password = login, password
+	return nil
+}
+
+// refresh logs in again unless another request already has since the stale
+// token was sent.
+func (c *Client) refresh(ctx context.Context, stale string) error {
+	c.mu.Lock()
+	token, login, password := c.token, c.login, c.password
+	c.mu.Unlock()
+
+	if token != stale {
+		return nil
+	}
+	return c.Login(ctx, login, password)
+}
+
+func (c *Client) canRefresh() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.login != ""
+}
+
+// Usage returns what the user stores and the quotas.
+func (c *Client) Usage(ctx context.Context) (*types.Usage, error) {
+	var usage types.Usage
+	err := c.call(ctx, http.MethodGet, "/api/user/usage", nil, &usage, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
+
+// call sends the body as JSON and decodes the response into result unless
+// it is nil.
+func (c *Client) call(ctx context.Context, method, path string, body, result any, status int) error {
+	res, err := c.do(ctx, method, path, func(req *resty.Request) {
+		if body != nil {
+			req.SetHeader("Content-Type", "application/json").SetBody(body)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if err = expect(res, status); err != nil {
+		return err
+	}
+	return decode(res, result)
+}
+
+// do sends the request made by build. Idempotent requests failed by the
+// network or by the server are sent again, a request rejected with 401 is
+// sent again once after logging in.
+func (c *Client) do(ctx context.Context, method, path string, build func(*resty.Request)) (*resty.Response, error) {
+	return c.send(ctx, method, path, true, build)
+}
+
+// send is do for the requests with a body that can only be read once, they
+// are never sent again when replayable is false.
+func (c *Client) send(ctx context.Context, method, path string, replayable bool, build func(*resty.Request)) (*resty.Response, error) {
+	var (
+		retry     int
+		refreshed bool
+	)
+	for {
+		token := c.Token()
+		req := c.rc.R().SetContext(ctx)
+		if token != "" {
+			req.SetHeader("Authorization", token)
+		}
+		if build != nil {
+			build(req)
+		}
+
+		res, err := req.Execute(method, c.baseURL+path)
+		if err != nil && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if replayable && retry < c.retries && retryable(method, res, err) {
+			discard(res)
+			if err = c.wait(ctx, retry); err != nil {
+				return nil, err
+			}
+			retry++
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if replayable && res.StatusCode() == http.StatusUnauthorized && !refreshed && c.canRefresh() {
+			discard(res)
+			if err = c.refresh(ctx, token); err != nil {
+				return nil, err
+			}
+			refreshed = true
+			continue
+		}
+		return res, nil
+	}
+}
+
+// retryable tells whether sending the request again can succeed and can't
+// apply it twice.
+func retryable(method string, res *resty.Response, err error) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	status := res.StatusCode()
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError && status != http.StatusNotImplemented
+}
+
+// wait sleeps before the retry, doubling the wait with every retry up to
+// maxWait, with jitter.
+func (c *Client) wait(ctx context.Context, retry int) error {
+	d := c.minWait << retry
+	if d > c.maxWait || d <= 0 {
+		d = c.maxWait
+	}
+	if d > 1 {
+		d = d/2 + time.Duration(rand.Int63n(int64(d/2)))
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// discard closes the body of a streamed response that won't be returned.
+func discard(res *resty.Response) {
+	if res != nil && res.RawResponse != nil {
+		res.RawResponse.Body.Close()
+	}
+}
+
+// expect returns the APIError of a response with another status.
+func expect(res *resty.Response, statuses ...int) error {
+	for _, status := range statuses {
+		if res.StatusCode() == status {
+			return nil
+		}
+	}
+
+	body := res.Body()
+	if body == nil && res.RawResponse != nil {
+		body, _ = io.ReadAll(io.LimitReader(res.RawBody(), maxErrorBody))
+		res.RawBody().Close()
+	}
+	return &APIError{StatusCode: res.StatusCode(), Message: strings.TrimSpace(string(body))}
+}
+
+func decode(res *resty.Response, result any) error {
+	if result == nil {
+		return nil
+	}
+	err := json.Unmarshal(res.Body(), result)
+	if err != nil {
+		return fmt.Errorf("unable to decode response: %w", err)
+	}
+	return nil
+}
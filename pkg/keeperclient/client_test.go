@@ -0,0 +1,288 @@
+package keeperclient_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"keeper-project/internal/crypto"
+	"keeper-project/pkg/keeperclient"
+	"keeper-project/types"
+)
+
+func newClient(t *testing.T, handler http.Handler, opts ...keeperclient.Option) *keeperclient.Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	opts = append([]keeperclient.Option{keeperclient.WithRetries(3, time.Millisecond, time.Millisecond)}, opts...)
+	return keeperclient.New(srv.URL+"/", opts...)
+}
+
+func TestClient_Login(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/user/login", func(w http.ResponseWriter, r *http.Request) {
+		var req types.UserLoginRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if req.Login != `bob "the" builder` || req.Password != `p\a"ss` {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Authorization", "Bearer token")
+	})
+
+	c := newClient(t, mux)
+	require.NoError(t, c.Login(context.Background(), `bob "the" builder`, `p\a"ss`))
+	assert.Equal(t, "Bearer token", c.Token())
+
+	err := c.Login(context.Background(), "bob", "wrong")
+	assert.ErrorIs(t, err, keeperclient.ErrUnauthorized)
+
+	var apiErr *keeperclient.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "Unauthorized", apiErr.Message)
+}
+
+func TestClient_Retry(t *testing.T) {
+	var gets, posts atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/secret/text/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if gets.Add(1) < 3 {
+			http.Error(w, "try later", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"key":"title","text":"text","metadata":"md"}`)
+	})
+	mux.HandleFunc("POST /api/secret/text", func(w http.ResponseWriter, r *http.Request) {
+		posts.Add(1)
+		http.Error(w, "try later", http.StatusServiceUnavailable)
+	})
+
+	c := newClient(t, mux)
+	note, err := c.GetNote(context.Background(), "1")
+	require.NoError(t, err)
+	assert.Equal(t, &types.Note{Key: "title", Text: "text", Metadata: "md"}, note)
+	assert.EqualValues(t, 3, gets.Load())
+
+	// creating twice would duplicate the note
+	err = c.CreateNote(context.Background(), types.CreateNoteRequest{Key: "title"})
+	var apiErr *keeperclient.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	assert.EqualValues(t, 1, posts.Load())
+
+	gets.Store(-10)
+	_, err = c.GetNote(context.Background(), "1")
+	require.Error(t, err)
+	assert.EqualValues(t, -6, gets.Load())
+}
+
+func TestClient_RefreshToken(t *testing.T) {
+	var logins atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/user/login", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Authorization", fmt.Sprintf("Bearer %d", logins.Add(1)))
+	})
+	mux.HandleFunc("DELETE /api/secret/card/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer 2" {
+			http.Error(w, "token is expired", http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	c := newClient(t, mux)
+	require.NoError(t, c.Login(context.Background(), "bob", "secret"))
+	require.NoError(t, c.DeleteCard(context.Background(), "1"))
+	assert.EqualValues(t, 2, logins.Load())
+	assert.Equal(t, "Bearer 2", c.Token())
+
+	// a client with a token only can't log in again
+	c = newClient(t, mux, keeperclient.WithToken("Bearer 1"))
+	assert.ErrorIs(t, c.DeleteCard(context.Background(), "1"), keeperclient.ErrUnauthorized)
+}
+
+func TestClient_Cipher(t *testing.T) {
+	var stored types.UpdateCredentialsRequest
+	mux := http.NewServeMux()
+	mux.HandleFunc("PUT /api/secret/cred", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&stored))
+	})
+	mux.HandleFunc("GET /api/secret/cred/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(types.Credentials{Site: stored.Site, Login: stored.Login, Password: stored.Password, Metadata: stored.Metadata})
+	})
+	mux.HandleFunc("GET /api/secret/creds", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]types.Key{{Id: "1", Key: stored.Site}})
+	})
+
+	c := newClient(t, mux, keeperclient.WithCipher(keeperclient.PasswordCipher("master")))
+	cred := types.UpdateCredentialsRequest{ID: "1", Site: "github.com", Login: `"me"`, Password: "s3cret", SearchTokens: []string{"abc"}}
+	require.NoError(t, c.UpdateCredentials(context.Background(), cred))
+	assert.Equal(t, "github.com", cred.Site, "the request of the caller is left as is")
+
+	site, err := crypto.Decrypt("master", stored.Site)
+	require.NoError(t, err)
+	assert.Equal(t, "github.com", site)
+	assert.NotContains(t, stored.Password, "s3cret")
+	assert.Equal(t, []string{"abc"}, stored.SearchTokens)
+
+	got, err := c.GetCredentials(context.Background(), "1")
+	require.NoError(t, err)
+	assert.Equal(t, &types.Credentials{Site: "github.com", Login: `"me"`, Password: "s3cret"}, got)
+
+	page, err := c.ListCredentials(context.Background(), types.ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "github.com", page.Items[0].Key)
+
+	c = newClient(t, mux, keeperclient.WithCipher(keeperclient.PasswordCipher("other")))
+	_, err = c.GetCredentials(context.Background(), "1")
+	assert.ErrorContains(t, err, "failed to decrypt")
+}
+
+func TestClient_ListFiles(t *testing.T) {
+	since := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	name, err := crypto.Encrypt("master", "report.pdf")
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/secret/files", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		assert.Equal(t, []string{"name", "desc", "2", "abc", "2024-05-01T10:00:00Z"},
+			[]string{q.Get("sort"), q.Get("order"), q.Get("limit"), q.Get("cursor"), q.Get("since")})
+		w.Header().Set(types.HeaderTotalCount, "5")
+		w.Header().Set(types.HeaderNextCursor, "def")
+		_ = json.NewEncoder(w).Encode([]types.FileInfo{{ID: "1", Name: name}, {ID: "2", Name: "legacy.txt"}})
+	})
+
+	c := newClient(t, mux, keeperclient.WithCipher(keeperclient.PasswordCipher("master")))
+	page, err := c.ListFiles(context.Background(), types.ListOptions{Sort: types.SortName, Desc: true, Limit: 2, Cursor: "abc", Since: since})
+	require.NoError(t, err)
+	assert.EqualValues(t, 5, page.Total)
+	assert.Equal(t, "def", page.Next)
+	require.Len(t, page.Items, 2)
+	assert.Equal(t, "report.pdf", page.Items[0].Name)
+	assert.Equal(t, "legacy.txt", page.Items[1].Name, "names stored before encryption are kept")
+}
+
+func TestClient_DownloadFile(t *testing.T) {
+	md, err := crypto.Encrypt("master", "invoice")
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/secret/file/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") != "1" {
+			http.Error(w, "no such file", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Meta", md)
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader("0123456789"))
+	})
+
+	c := newClient(t, mux, keeperclient.WithCipher(keeperclient.PasswordCipher("master")))
+
+	d, err := c.DownloadFile(context.Background(), "1", 0, 0)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(d.Body)
+	d.Body.Close()
+	assert.Equal(t, "0123456789", string(body))
+	assert.EqualValues(t, 0, d.Offset)
+	assert.Equal(t, "invoice", d.Metadata)
+
+	d, err = c.DownloadFile(context.Background(), "1", 4, 3)
+	require.NoError(t, err)
+	body, _ = io.ReadAll(d.Body)
+	d.Body.Close()
+	assert.Equal(t, "456", string(body))
+	assert.EqualValues(t, 4, d.Offset)
+
+	_, err = c.DownloadFile(context.Background(), "1", 10, 0)
+	assert.ErrorIs(t, err, keeperclient.ErrRangeNotSatisfiable)
+
+	_, err = c.DownloadFile(context.Background(), "2", 0, 0)
+	assert.ErrorIs(t, err, keeperclient.ErrNotFound)
+	assert.EqualError(t, err, "Not Found: no such file")
+}
+
+func TestClient_UploadFile(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/secret/file", func(w http.ResponseWriter, r *http.Request) {
+		mr, err := r.MultipartReader()
+		require.NoError(t, err)
+
+		var fields []string
+		for {
+			part, err := mr.NextPart()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+			value, _ := io.ReadAll(part)
+			fields = append(fields, part.FormName()+"="+string(value)+part.FileName())
+		}
+		assert.Equal(t, []string{"Metadata=md", "Size=8", "file=contentsa b.txt"}, fields)
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	c := newClient(t, mux)
+	require.NoError(t, c.UploadFile(context.Background(), "a b.txt", "md", 8, strings.NewReader("contents")))
+}
+
+func TestClient_Batch(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/secret/batch", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(types.BatchResponse{Mode: types.BatchAtomic, Results: []types.BatchResult{
+			{Status: http.StatusNotFound, Error: "no such record"},
+		}})
+	})
+
+	c := newClient(t, mux)
+	resp, err := c.Batch(context.Background(), types.BatchRequest{Ops: []types.BatchOp{{Op: types.OpDelete, Kind: types.KindNote, ID: "1"}}})
+	assert.ErrorIs(t, err, keeperclient.ErrNotFound)
+	require.NotNil(t, resp)
+	assert.Equal(t, "no such record", resp.Results[0].Error)
+}
+
+func TestClient_BatchCipher(t *testing.T) {
+	var sent types.BatchRequest
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/secret/batch", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&sent))
+		_ = json.NewEncoder(w).Encode(types.BatchResponse{Mode: types.BatchAtomic, Results: []types.BatchResult{{Status: http.StatusCreated}, {Status: http.StatusNoContent}}})
+	})
+
+	c := newClient(t, mux, keeperclient.WithCipher(keeperclient.PasswordCipher("master")))
+	create, err := keeperclient.NewBatchOp(types.OpCreate, types.KindCredentials, "",
+		types.CreateCredentialsRequest{Site: "github.com", Login: "me", Password: "s3cret", SearchTokens: []string{"abc"}})
+	require.NoError(t, err)
+	remove, err := keeperclient.NewBatchOp(types.OpDelete, types.KindNote, "1", nil)
+	require.NoError(t, err)
+
+	batch := types.BatchRequest{Ops: []types.BatchOp{create, remove}}
+	_, err = c.Batch(context.Background(), batch)
+	require.NoError(t, err)
+	assert.Equal(t, create, batch.Ops[0], "the operations of the caller are left as is")
+
+	var cred types.CreateCredentialsRequest
+	require.NoError(t, json.Unmarshal(sent.Ops[0].Data, &cred))
+	site, err := crypto.Decrypt("master", cred.Site)
+	require.NoError(t, err)
+	assert.Equal(t, "github.com", site)
+	assert.NotContains(t, cred.Password, "s3cret")
+	assert.Equal(t, []string{"abc"}, cred.SearchTokens)
+	assert.Equal(t, remove, sent.Ops[1])
+
+	_, err = c.Batch(context.Background(), types.BatchRequest{Ops: []types.BatchOp{{Op: types.OpCreate, Kind: "secret", Data: []byte(`{}`)}}})
+	assert.ErrorContains(t, err, `unknown kind "secret"`)
+}
@@ -0,0 +1,203 @@
+package keeperclient
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/go-resty/resty/v2"
+
+	"keeper-project/types"
+)
+
+// ErrRangeNotSatisfiable matches the APIError of a download starting at or
+// after the end of the file.
+var ErrRangeNotSatisfiable = errors.New("range not satisfiable")
+
+// Download is a stored file being downloaded. Body starts at Offset of the
+// stored contents, which is 0 when the server sends the whole file, and must
+// be closed.
+type Download struct {
+	Body     io.ReadCloser
+	Offset   int64
+	Metadata string
+}
+
+// UploadFile stores the contents read from r in a single request, size is
+// -1 when it isn't known. The request is never sent again, neither retried
+// nor after logging in, large files are better sent in chunks with CreateUpload.
+func (c *Client) UploadFile(ctx context.Context, name, metadata string, size int64, r io.Reader) error {
+	err := c.encrypt(&name, &metadata)
+	if err != nil {
+		return err
+	}
+
+	// the form is written through a pipe, so the file isn't held in memory
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		err := mw.WriteField("Metadata", metadata)
+		if err == nil && size >= 0 {
+			err = mw.WriteField("Size", strconv.FormatInt(size, 10))
+		}
+		var part io.Writer
+		if err == nil {
+			part, err = mw.CreateFormFile("file", name)
+		}
+		if err == nil {
+			_, err = io.Copy(part, r)
+		}
+		if err == nil {
+			err = mw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	defer pr.Close()
+
+	res, err := c.send(ctx, http.MethodPost, "/api/secret/file", false, func(req *resty.Request) {
+		req.SetHeader("Content-Type", mw.FormDataContentType()).SetBody(pr)
+	})
+	if err != nil {
+		return err
+	}
+	return expect(res, http.StatusCreated)
+}
+
+// DownloadFile streams the stored contents of the file from offset, to the
+// end when length isn't positive.
+func (c *Client) DownloadFile(ctx context.Context, id string, offset, length int64) (*Download, error) {
+	res, err := c.do(ctx, http.MethodGet, "/api/secret/file/"+url.PathEscape(id), func(req *resty.Request) {
+		req.SetDoNotParseResponse(true)
+		switch {
+		case length > 0:
+			req.SetHeader("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+		case offset > 0:
+			req.SetHeader("Range", fmt.Sprintf("bytes=%d-", offset))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = expect(res, http.StatusOK, http.StatusPartialContent); err != nil {
+		return nil, err
+	}
+
+	d := &Download{Body: res.RawBody(), Metadata: res.Header().Get("Meta")}
+	if res.StatusCode() == http.StatusPartialContent {
+		_, err = fmt.Sscanf(res.Header().Get("Content-Range"), "bytes %d-", &d.Offset)
+	}
+	if err == nil && d.Metadata != "" {
+		err = c.decrypt(&d.Metadata)
+	}
+	if err != nil {
+		d.Body.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+// ListFiles returns a page of the files.
+func (c *Client) ListFiles(ctx context.Context, opts types.ListOptions) (*types.Page[*types.FileInfo], error) {
+	page, err := list[*types.FileInfo](ctx, c, "/api/secret/files", opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range page.Items {
+		f.Name = c.decryptName(f.Name)
+	}
+	return page, nil
+}
+
+// DeleteFile moves the file to the trash.
+func (c *Client) DeleteFile(ctx context.Context, id string) error {
+	return c.call(ctx, http.MethodDelete, "/api/secret/file/"+url.PathEscape(id), nil, nil, http.StatusNoContent)
+}
+
+// HasBlob tells whether the user has stored contents with the hash, see LinkFile.
+func (c *Client) HasBlob(ctx context.Context, hash string) (bool, error) {
+	res, err := c.do(ctx, http.MethodHead, "/api/secret/blob/"+url.PathEscape(hash), nil)
+	if err != nil {
+		return false, err
+	}
+	if res.StatusCode() == http.StatusNotFound {
+		return false, nil
+	}
+	return true, expect(res, http.StatusOK)
+}
+
+// LinkFile adds a file with contents the user has stored already, ErrNotFound
+// is matched when they are gone.
+func (c *Client) LinkFile(ctx context.Context, link types.LinkFileRequest) (*types.FileInfo, error) {
+	err := c.encrypt(&link.Name, &link.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	var info types.FileInfo
+	err = c.call(ctx, http.MethodPost, "/api/secret/file/link", link, &info, http.StatusCreated)
+	if err != nil {
+		return nil, err
+	}
+	info.Name = c.decryptName(info.Name)
+	return &info, nil
+}
+
+// CreateUpload starts a resumable upload of a file, its chunks are sent with PutChunk.
+func (c *Client) CreateUpload(ctx context.Context, upload types.CreateUploadRequest) (*types.UploadSession, error) {
+	err := c.encrypt(&upload.Name, &upload.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	return c.uploadCall(ctx, http.MethodPost, "/api/secret/upload", upload, http.StatusCreated)
+}
+
+// GetUpload returns the upload with the chunks the server has received.
+func (c *Client) GetUpload(ctx context.Context, id string) (*types.UploadSession, error) {
+	return c.uploadCall(ctx, http.MethodGet, "/api/secret/upload/"+url.PathEscape(id), nil, http.StatusOK)
+}
+
+// PutChunk sends the chunk with the number, starting from 1, along with its
+// checksum. A chunk corrupted on the way is rejected with 400.
+func (c *Client) PutChunk(ctx context.Context, uploadID string, number int, chunk []byte) error {
+	sum := md5.Sum(chunk)
+	res, err := c.do(ctx, http.MethodPut, fmt.Sprintf("/api/secret/upload/%s/%d", url.PathEscape(uploadID), number),
+		func(req *resty.Request) {
+			req.SetHeader("Content-Type", "application/octet-stream").
+				SetHeader("Content-MD5", base64.StdEncoding.EncodeToString(sum[:])).
+				SetBody(chunk)
+		})
+	if err != nil {
+		return err
+	}
+	return expect(res, http.StatusNoContent)
+}
+
+// CompleteUpload assembles the chunks into the file, its id is the id of the upload.
+func (c *Client) CompleteUpload(ctx context.Context, id string) (*types.UploadSession, error) {
+	return c.uploadCall(ctx, http.MethodPost, "/api/secret/upload/"+url.PathEscape(id)+"/complete", nil, http.StatusCreated)
+}
+
+// AbortUpload drops the upload and the chunks received.
+func (c *Client) AbortUpload(ctx context.Context, id string) error {
+	return c.call(ctx, http.MethodDelete, "/api/secret/upload/"+url.PathEscape(id), nil, nil, http.StatusNoContent)
+}
+
+func (c *Client) uploadCall(ctx context.Context, method, path string, body any, status int) (*types.UploadSession, error) {
+	var upload types.UploadSession
+	err := c.call(ctx, method, path, body, &upload, status)
+	if err == nil && upload.Metadata != "" {
+		err = c.decrypt(&upload.Metadata)
+	}
+	if err != nil {
+		return nil, err
+	}
+	upload.Name = c.decryptName(upload.Name)
+	return &upload, nil
+}
@@ -0,0 +1,276 @@
+package keeperclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	"keeper-project/types"
+)
+
+// CreateNote saves a note, the server doesn't respond with its id.
+func (c *Client) CreateNote(ctx context.Context, note types.CreateNoteRequest) error {
+	err := c.encrypt(&note.Key, &note.Data, &note.Metadata)
+	if err != nil {
+		return err
+	}
+	return c.call(ctx, http.MethodPost, "/api/secret/text", note, nil, http.StatusAccepted)
+}
+
+func (c *Client) GetNote(ctx context.Context, id string) (*types.Note, error) {
+	var note types.Note
+	err := c.call(ctx, http.MethodGet, "/api/secret/text/"+url.PathEscape(id), nil, &note, http.StatusOK)
+	if err == nil {
+		err = c.decrypt(&note.Key, &note.Text, &note.Metadata)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
+
+// ListNotes returns a page of the titles of the notes.
+func (c *Client) ListNotes(ctx context.Context, opts types.ListOptions) (*types.Page[*types.Key], error) {
+	return c.listKeys(ctx, "/api/secret/texts", opts)
+}
+
+func (c *Client) UpdateNote(ctx context.Context, note types.UpdateNoteRequest) error {
+	err := c.encrypt(&note.Key, &note.Data, &note.Metadata)
+	if err != nil {
+		return err
+	}
+	return c.call(ctx, http.MethodPut, "/api/secret/text", note, nil, http.StatusOK)
+}
+
+// DeleteNote moves the note to the trash.
+func (c *Client) DeleteNote(ctx context.Context, id string) error {
+	return c.call(ctx, http.MethodDelete, "/api/secret/text/"+url.PathEscape(id), nil, nil, http.StatusNoContent)
+}
+
+// CreateCard saves a card, the server doesn't respond with its id.
+func (c *Client) CreateCard(ctx context.Context, card types.CreateCardRequest) error {
+	err := c.encrypt(&card.Number, &card.Expiration, &card.CVV, &card.Metadata)
+	if err != nil {
+		return err
+	}
+	return c.call(ctx, http.MethodPost, "/api/secret/card", card, nil, http.StatusAccepted)
+}
+
+func (c *Client) GetCard(ctx context.Context, id string) (*types.CardInfo, error) {
+	var card types.CardInfo
+	err := c.call(ctx, http.MethodGet, "/api/secret/card/"+url.PathEscape(id), nil, &card, http.StatusOK)
+	if err == nil {
+		err = c.decrypt(&card.Number, &card.Expiration, &card.CVV, &card.Metadata)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &card, nil
+}
+
+// ListCards returns a page of the numbers of the cards.
+func (c *Client) ListCards(ctx context.Context, opts types.ListOptions) (*types.Page[*types.Key], error) {
+	return c.listKeys(ctx, "/api/secret/cards", opts)
+}
+
+// UpdateCard replaces the card with the ID of the request.
+func (c *Client) UpdateCard(ctx context.Context, card types.CreateCardRequest) error {
+	err := c.encrypt(&card.Number, &card.Expiration, &card.CVV, &card.Metadata)
+	if err != nil {
+		return err
+	}
+	return c.call(ctx, http.MethodPut, "/api/secret/card", card, nil, http.StatusOK)
+}
+
+// DeleteCard moves the card to the trash.
+func (c *Client) DeleteCard(ctx context.Context, id string) error {
+	return c.call(ctx, http.MethodDelete, "/api/secret/card/"+url.PathEscape(id), nil, nil, http.StatusNoContent)
+}
+
+// CreateCredentials saves credentials, the server doesn't respond with their id.
+func (c *Client) CreateCredentials(ctx context.Context, cred types.CreateCredentialsRequest) error {
+	err := c.encrypt(&cred.Site, &cred.Login, &cred.Password, &cred.Metadata)
+	if err != nil {
+		return err
+	}
+	return c.call(ctx, http.MethodPost, "/api/secret/cred", cred, nil, http.StatusAccepted)
+}
+
+func (c *Client) GetCredentials(ctx context.Context, id string) (*types.Credentials, error) {
+	var cred types.Credentials
+	err := c.call(ctx, http.MethodGet, "/api/secret/cred/"+url.PathEscape(id), nil, &cred, http.StatusOK)
+	if err == nil {
+		err = c.decrypt(&cred.Site, &cred.Login, &cred.Password, &cred.Metadata)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cred, nil
+}
+
+// ListCredentials returns a page of the sites of the credentials.
+func (c *Client) ListCredentials(ctx context.Context, opts types.ListOptions) (*types.Page[*types.Key], error) {
+	return c.listKeys(ctx, "/api/secret/creds", opts)
+}
+
+func (c *Client) UpdateCredentials(ctx context.Context, cred types.UpdateCredentialsRequest) error {
+	err := c.encrypt(&cred.Site, &cred.Login, &cred.Password, &cred.Metadata)
+	if err != nil {
+		return err
+	}
+	return c.call(ctx, http.MethodPut, "/api/secret/cred", cred, nil, http.StatusOK)
+}
+
+// DeleteCredentials moves the credentials to the trash.
+func (c *Client) DeleteCredentials(ctx context.Context, id string) error {
+	return c.call(ctx, http.MethodDelete, "/api/secret/cred/"+url.PathEscape(id), nil, nil, http.StatusNoContent)
+}
+
+// NewBatchOp builds an operation of a batch, data is the create request of
+// the kind and is nil for deletes.
+func NewBatchOp(op, kind, id string, data any) (types.BatchOp, error) {
+	bop := types.BatchOp{Op: op, Kind: kind, ID: id}
+	if data == nil {
+		return bop, nil
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return bop, fmt.Errorf("failed to encode %s: %w", kind, err)
+	}
+	bop.Data = raw
+	return bop, nil
+}
+
+// Batch applies the operations in a single request. With a Cipher the fields
+// of the records are encrypted like by the single record methods, the
+// operations of the caller are left as is. The response is returned along
+// with the APIError of a failed atomic batch.
+func (c *Client) Batch(ctx context.Context, batch types.BatchRequest) (*types.BatchResponse, error) {
+	ops := make([]types.BatchOp, len(batch.Ops))
+	for i, op := range batch.Ops {
+		data, err := c.encryptOp(op)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+		op.Data = data
+		ops[i] = op
+	}
+	batch.Ops = ops
+
+	res, err := c.do(ctx, http.MethodPost, "/api/secret/batch", func(req *resty.Request) {
+		req.SetHeader("Content-Type", "application/json").SetBody(batch)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	statusErr := expect(res, http.StatusOK, http.StatusMultiStatus)
+
+	var resp types.BatchResponse
+	if err = decode(res, &resp); err != nil {
+		if statusErr != nil {
+			return nil, statusErr
+		}
+		return nil, err
+	}
+	return &resp, statusErr
+}
+
+// encryptOp returns the data of the operation with the fields of the record
+// encrypted. Data of unknown kinds is refused with a cipher rather than sent
+// in the clear.
+func (c *Client) encryptOp(op types.BatchOp) (json.RawMessage, error) {
+	if c.cipher == nil || len(op.Data) == 0 {
+		return op.Data, nil
+	}
+	switch op.Kind {
+	case types.KindNote:
+		return encryptData(op.Data, func(note *types.CreateNoteRequest) error {
+			return c.encrypt(&note.Key, &note.Data, &note.Metadata)
+		})
+	case types.KindCard:
+		return encryptData(op.Data, func(card *types.CreateCardRequest) error {
+			return c.encrypt(&card.Number, &card.Expiration, &card.CVV, &card.Metadata)
+		})
+	case types.KindCredentials:
+		return encryptData(op.Data, func(cred *types.CreateCredentialsRequest) error {
+			return c.encrypt(&cred.Site, &cred.Login, &cred.Password, &cred.Metadata)
+		})
+	}
+	return nil, fmt.Errorf("unknown kind %q", op.Kind)
+}
+
+func encryptData[T any](data json.RawMessage, encrypt func(*T) error) (json.RawMessage, error) {
+	var req T
+	err := json.Unmarshal(data, &req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode data: %w", err)
+	}
+	if err = encrypt(&req); err != nil {
+		return nil, err
+	}
+	return json.Marshal(req)
+}
+
+func (c *Client) listKeys(ctx context.Context, path string, opts types.ListOptions) (*types.Page[*types.Key], error) {
+	page, err := list[*types.Key](ctx, c, path, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range page.Items {
+		if err = c.decrypt(&k.Key); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// list gets a page of a list endpoint, the total count and the next cursor
+// are read from the headers.
+func list[T any](ctx context.Context, c *Client, path string, opts types.ListOptions) (*types.Page[T], error) {
+	res, err := c.do(ctx, http.MethodGet, path, func(req *resty.Request) {
+		req.SetQueryParamsFromValues(listQuery(opts))
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = expect(res, http.StatusOK); err != nil {
+		return nil, err
+	}
+
+	page := &types.Page[T]{Next: res.Header().Get(types.HeaderNextCursor)}
+	if err = decode(res, &page.Items); err != nil {
+		return nil, err
+	}
+	page.Total, _ = strconv.ParseInt(res.Header().Get(types.HeaderTotalCount), 10, 64)
+	return page, nil
+}
+
+func listQuery(opts types.ListOptions) url.Values {
+	q := url.Values{}
+	if opts.Sort != "" {
+		q.Set("sort", opts.Sort)
+	}
+	if opts.Desc {
+		q.Set("order", "desc")
+	}
+	if opts.Limit > 0 {
+		q.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Cursor != "" {
+		q.Set("cursor", opts.Cursor)
+	}
+	if opts.Offset > 0 {
+		q.Set("offset", strconv.Itoa(opts.Offset))
+	}
+	if !opts.Since.IsZero() {
+		q.Set("since", opts.Since.Format(time.RFC3339))
+	}
+	return q
+}
@@ -0,0 +1,27 @@
+package keeperclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"keeper-project/types"
+)
+
+// Search finds the records holding the blind index tokens of the request,
+// the tokens are made by the caller, e.g. with the search index of the client.
+func (c *Client) Search(ctx context.Context, search types.SearchRequest) ([]types.SearchHit, error) {
+	var hits []types.SearchHit
+	err := c.call(ctx, http.MethodPost, "/api/search/", search, &hits, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
+
+// SetSearchTokens replaces the search tokens of the record of the kind, one
+// of types.SearchKinds.
+func (c *Client) SetSearchTokens(ctx context.Context, kind, id string, tokens []string) error {
+	return c.call(ctx, http.MethodPut, "/api/search/"+url.PathEscape(kind)+"/"+url.PathEscape(id),
+		types.SearchTokensRequest{Tokens: tokens}, nil, http.StatusNoContent)
+}
@@ -0,0 +1,42 @@
+package keeperclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"keeper-project/types"
+)
+
+// Trash returns the deleted records, their keys are the titles of the notes,
+// the numbers of the cards, the sites of the credentials and the names of
+// the files.
+func (c *Client) Trash(ctx context.Context) ([]*types.TrashItem, error) {
+	var items []*types.TrashItem
+	err := c.call(ctx, http.MethodGet, "/api/trash/", nil, &items, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if item.Kind == types.KindFile {
+			item.Key = c.decryptName(item.Key)
+			continue
+		}
+		if err = c.decrypt(&item.Key); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+// RestoreFromTrash restores the deleted record of the kind, one of
+// types.KindNote, types.KindCard, types.KindCredentials or types.KindFile.
+func (c *Client) RestoreFromTrash(ctx context.Context, kind, id string) error {
+	return c.call(ctx, http.MethodPost, "/api/trash/"+url.PathEscape(kind)+"/"+url.PathEscape(id)+"/restore",
+		nil, nil, http.StatusOK)
+}
+
+// EmptyTrash permanently removes everything in the trash.
+func (c *Client) EmptyTrash(ctx context.Context) error {
+	return c.call(ctx, http.MethodDelete, "/api/trash/", nil, nil, http.StatusNoContent)
+}
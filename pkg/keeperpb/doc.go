@@ -0,0 +1,5 @@
+// Package keeperpb holds the gRPC API of the keeper server generated from
+// keeper.proto, the server is internal/grpcserver.
+package keeperpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative keeper.proto
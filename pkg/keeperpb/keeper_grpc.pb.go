@@ -0,0 +1,1138 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v4.25.3
+// source: keeper.proto
+
+// The gRPC API of the keeper server, it serves the same stores as the HTTP
+// API. Every call but Auth expects the token of Auth.Login in the
+// authorization metadata: "Bearer <token>".
+
+package keeperpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Auth_Register_FullMethodName = "/keeper.v1.Auth/Register"
+	Auth_Login_FullMethodName    = "/keeper.v1.Auth/Login"
+)
+
+// AuthClient is the client API for Auth service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AuthClient interface {
+	// Register creates the user and logs them in.
+	Register(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*Token, error)
+	Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*Token, error)
+}
+
+type authClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAuthClient(cc grpc.ClientConnInterface) AuthClient {
+	return &authClient{cc}
+}
+
+func (c *authClient) Register(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*Token, error) {
+	out := new(Token)
+	err := c.cc.Invoke(ctx, Auth_Register_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*Token, error) {
+	out := new(Token)
+	err := c.cc.Invoke(ctx, Auth_Login_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AuthServer is the server API for Auth service.
+// All implementations must embed UnimplementedAuthServer
+// for forward compatibility
+type AuthServer interface {
+	// Register creates the user and logs them in.
+	Register(context.Context, *LoginRequest) (*Token, error)
+	Login(context.Context, *LoginRequest) (*Token, error)
+	mustEmbedUnimplementedAuthServer()
+}
+
+// UnimplementedAuthServer must be embedded to have forward compatible implementations.
+type UnimplementedAuthServer struct {
+}
+
+func (UnimplementedAuthServer) Register(context.Context, *LoginRequest) (*Token, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Register not implemented")
+}
+func (UnimplementedAuthServer) Login(context.Context, *LoginRequest) (*Token, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Login not implemented")
+}
+func (UnimplementedAuthServer) mustEmbedUnimplementedAuthServer() {}
+
+// UnsafeAuthServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AuthServer will
+// result in compilation errors.
+type UnsafeAuthServer interface {
+	mustEmbedUnimplementedAuthServer()
+}
+
+func RegisterAuthServer(s grpc.ServiceRegistrar, srv AuthServer) {
+	s.RegisterService(&Auth_ServiceDesc, srv)
+}
+
+func _Auth_Register_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServer).Register(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Auth_Register_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).Register(ctx, req.(*LoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Auth_Login_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServer).Login(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Auth_Login_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).Login(ctx, req.(*LoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Auth_ServiceDesc is the grpc.ServiceDesc for Auth service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Auth_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "keeper.v1.Auth",
+	HandlerType: (*AuthServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Register",
+			Handler:    _Auth_Register_Handler,
+		},
+		{
+			MethodName: "Login",
+			Handler:    _Auth_Login_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "keeper.proto",
+}
+
+const (
+	Notes_Create_FullMethodName = "/keeper.v1.Notes/Create"
+	Notes_Get_FullMethodName    = "/keeper.v1.Notes/Get"
+	Notes_List_FullMethodName   = "/keeper.v1.Notes/List"
+	Notes_Update_FullMethodName = "/keeper.v1.Notes/Update"
+	Notes_Delete_FullMethodName = "/keeper.v1.Notes/Delete"
+)
+
+// NotesClient is the client API for Notes service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type NotesClient interface {
+	Create(ctx context.Context, in *CreateNoteRequest, opts ...grpc.CallOption) (*CreateResponse, error)
+	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*Note, error)
+	List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*KeysPage, error)
+	Update(ctx context.Context, in *Note, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Delete moves the note to the trash.
+	Delete(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type notesClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewNotesClient(cc grpc.ClientConnInterface) NotesClient {
+	return &notesClient{cc}
+}
+
+func (c *notesClient) Create(ctx context.Context, in *CreateNoteRequest, opts ...grpc.CallOption) (*CreateResponse, error) {
+	out := new(CreateResponse)
+	err := c.cc.Invoke(ctx, Notes_Create_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notesClient) Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*Note, error) {
+	out := new(Note)
+	err := c.cc.Invoke(ctx, Notes_Get_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notesClient) List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*KeysPage, error) {
+	out := new(KeysPage)
+	err := c.cc.Invoke(ctx, Notes_List_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notesClient) Update(ctx context.Context, in *Note, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Notes_Update_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notesClient) Delete(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Notes_Delete_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// NotesServer is the server API for Notes service.
+// All implementations must embed UnimplementedNotesServer
+// for forward compatibility
+type NotesServer interface {
+	Create(context.Context, *CreateNoteRequest) (*CreateResponse, error)
+	Get(context.Context, *GetRequest) (*Note, error)
+	List(context.Context, *ListRequest) (*KeysPage, error)
+	Update(context.Context, *Note) (*emptypb.Empty, error)
+	// Delete moves the note to the trash.
+	Delete(context.Context, *GetRequest) (*emptypb.Empty, error)
+	mustEmbedUnimplementedNotesServer()
+}
+
+// UnimplementedNotesServer must be embedded to have forward compatible implementations.
+type UnimplementedNotesServer struct {
+}
+
+func (UnimplementedNotesServer) Create(context.Context, *CreateNoteRequest) (*CreateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Create not implemented")
+}
+func (UnimplementedNotesServer) Get(context.Context, *GetRequest) (*Note, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
+}
+func (UnimplementedNotesServer) List(context.Context, *ListRequest) (*KeysPage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedNotesServer) Update(context.Context, *Note) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Update not implemented")
+}
+func (UnimplementedNotesServer) Delete(context.Context, *GetRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedNotesServer) mustEmbedUnimplementedNotesServer() {}
+
+// UnsafeNotesServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to NotesServer will
+// result in compilation errors.
+type UnsafeNotesServer interface {
+	mustEmbedUnimplementedNotesServer()
+}
+
+func RegisterNotesServer(s grpc.ServiceRegistrar, srv NotesServer) {
+	s.RegisterService(&Notes_ServiceDesc, srv)
+}
+
+func _Notes_Create_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateNoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotesServer).Create(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Notes_Create_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotesServer).Create(ctx, req.(*CreateNoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Notes_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotesServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Notes_Get_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotesServer).Get(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Notes_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotesServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Notes_List_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotesServer).List(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Notes_Update_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Note)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotesServer).Update(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Notes_Update_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotesServer).Update(ctx, req.(*Note))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Notes_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotesServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Notes_Delete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotesServer).Delete(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Notes_ServiceDesc is the grpc.ServiceDesc for Notes service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Notes_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "keeper.v1.Notes",
+	HandlerType: (*NotesServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Create",
+			Handler:    _Notes_Create_Handler,
+		},
+		{
+			MethodName: "Get",
+			Handler:    _Notes_Get_Handler,
+		},
+		{
+			MethodName: "List",
+			Handler:    _Notes_List_Handler,
+		},
+		{
+			MethodName: "Update",
+			Handler:    _Notes_Update_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _Notes_Delete_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "keeper.proto",
+}
+
+const (
+	Cards_Create_FullMethodName = "/keeper.v1.Cards/Create"
+	Cards_Get_FullMethodName    = "/keeper.v1.Cards/Get"
+	Cards_List_FullMethodName   = "/keeper.v1.Cards/List"
+	Cards_Update_FullMethodName = "/keeper.v1.Cards/Update"
+	Cards_Delete_FullMethodName = "/keeper.v1.Cards/Delete"
+)
+
+// CardsClient is the client API for Cards service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CardsClient interface {
+	Create(ctx context.Context, in *CreateCardRequest, opts ...grpc.CallOption) (*CreateResponse, error)
+	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*Card, error)
+	List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*KeysPage, error)
+	Update(ctx context.Context, in *Card, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	Delete(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type cardsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCardsClient(cc grpc.ClientConnInterface) CardsClient {
+	return &cardsClient{cc}
+}
+
+func (c *cardsClient) Create(ctx context.Context, in *CreateCardRequest, opts ...grpc.CallOption) (*CreateResponse, error) {
+	out := new(CreateResponse)
+	err := c.cc.Invoke(ctx, Cards_Create_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardsClient) Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*Card, error) {
+	out := new(Card)
+	err := c.cc.Invoke(ctx, Cards_Get_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardsClient) List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*KeysPage, error) {
+	out := new(KeysPage)
+	err := c.cc.Invoke(ctx, Cards_List_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardsClient) Update(ctx context.Context, in *Card, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Cards_Update_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardsClient) Delete(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Cards_Delete_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CardsServer is the server API for Cards service.
+// All implementations must embed UnimplementedCardsServer
+// for forward compatibility
+type CardsServer interface {
+	Create(context.Context, *CreateCardRequest) (*CreateResponse, error)
+	Get(context.Context, *GetRequest) (*Card, error)
+	List(context.Context, *ListRequest) (*KeysPage, error)
+	Update(context.Context, *Card) (*emptypb.Empty, error)
+	Delete(context.Context, *GetRequest) (*emptypb.Empty, error)
+	mustEmbedUnimplementedCardsServer()
+}
+
+// UnimplementedCardsServer must be embedded to have forward compatible implementations.
+type UnimplementedCardsServer struct {
+}
+
+func (UnimplementedCardsServer) Create(context.Context, *CreateCardRequest) (*CreateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Create not implemented")
+}
+func (UnimplementedCardsServer) Get(context.Context, *GetRequest) (*Card, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
+}
+func (UnimplementedCardsServer) List(context.Context, *ListRequest) (*KeysPage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedCardsServer) Update(context.Context, *Card) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Update not implemented")
+}
+func (UnimplementedCardsServer) Delete(context.Context, *GetRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedCardsServer) mustEmbedUnimplementedCardsServer() {}
+
+// UnsafeCardsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CardsServer will
+// result in compilation errors.
+type UnsafeCardsServer interface {
+	mustEmbedUnimplementedCardsServer()
+}
+
+func RegisterCardsServer(s grpc.ServiceRegistrar, srv CardsServer) {
+	s.RegisterService(&Cards_ServiceDesc, srv)
+}
+
+func _Cards_Create_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateCardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardsServer).Create(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Cards_Create_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardsServer).Create(ctx, req.(*CreateCardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Cards_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardsServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Cards_Get_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardsServer).Get(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Cards_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardsServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Cards_List_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardsServer).List(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Cards_Update_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Card)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardsServer).Update(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Cards_Update_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardsServer).Update(ctx, req.(*Card))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Cards_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardsServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Cards_Delete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardsServer).Delete(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Cards_ServiceDesc is the grpc.ServiceDesc for Cards service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Cards_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "keeper.v1.Cards",
+	HandlerType: (*CardsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Create",
+			Handler:    _Cards_Create_Handler,
+		},
+		{
+			MethodName: "Get",
+			Handler:    _Cards_Get_Handler,
+		},
+		{
+			MethodName: "List",
+			Handler:    _Cards_List_Handler,
+		},
+		{
+			MethodName: "Update",
+			Handler:    _Cards_Update_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _Cards_Delete_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "keeper.proto",
+}
+
+const (
+	Credentials_Create_FullMethodName = "/keeper.v1.Credentials/Create"
+	Credentials_Get_FullMethodName    = "/keeper.v1.Credentials/Get"
+	Credentials_List_FullMethodName   = "/keeper.v1.Credentials/List"
+	Credentials_Update_FullMethodName = "/keeper.v1.Credentials/Update"
+	Credentials_Delete_FullMethodName = "/keeper.v1.Credentials/Delete"
+)
+
+// CredentialsClient is the client API for Credentials service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CredentialsClient interface {
+	Create(ctx context.Context, in *CreateCredentialsRequest, opts ...grpc.CallOption) (*CreateResponse, error)
+	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*Credential, error)
+	List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*KeysPage, error)
+	Update(ctx context.Context, in *Credential, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	Delete(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type credentialsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCredentialsClient(cc grpc.ClientConnInterface) CredentialsClient {
+	return &credentialsClient{cc}
+}
+
+func (c *credentialsClient) Create(ctx context.Context, in *CreateCredentialsRequest, opts ...grpc.CallOption) (*CreateResponse, error) {
+	out := new(CreateResponse)
+	err := c.cc.Invoke(ctx, Credentials_Create_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *credentialsClient) Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*Credential, error) {
+	out := new(Credential)
+	err := c.cc.Invoke(ctx, Credentials_Get_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *credentialsClient) List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*KeysPage, error) {
+	out := new(KeysPage)
+	err := c.cc.Invoke(ctx, Credentials_List_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *credentialsClient) Update(ctx context.Context, in *Credential, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Credentials_Update_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *credentialsClient) Delete(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Credentials_Delete_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CredentialsServer is the server API for Credentials service.
+// All implementations must embed UnimplementedCredentialsServer
+// for forward compatibility
+type CredentialsServer interface {
+	Create(context.Context, *CreateCredentialsRequest) (*CreateResponse, error)
+	Get(context.Context, *GetRequest) (*Credential, error)
+	List(context.Context, *ListRequest) (*KeysPage, error)
+	Update(context.Context, *Credential) (*emptypb.Empty, error)
+	Delete(context.Context, *GetRequest) (*emptypb.Empty, error)
+	mustEmbedUnimplementedCredentialsServer()
+}
+
+// UnimplementedCredentialsServer must be embedded to have forward compatible implementations.
+type UnimplementedCredentialsServer struct {
+}
+
+func (UnimplementedCredentialsServer) Create(context.Context, *CreateCredentialsRequest) (*CreateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Create not implemented")
+}
+func (UnimplementedCredentialsServer) Get(context.Context, *GetRequest) (*Credential, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
+}
+func (UnimplementedCredentialsServer) List(context.Context, *ListRequest) (*KeysPage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedCredentialsServer) Update(context.Context, *Credential) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Update not implemented")
+}
+func (UnimplementedCredentialsServer) Delete(context.Context, *GetRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedCredentialsServer) mustEmbedUnimplementedCredentialsServer() {}
+
+// UnsafeCredentialsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CredentialsServer will
+// result in compilation errors.
+type UnsafeCredentialsServer interface {
+	mustEmbedUnimplementedCredentialsServer()
+}
+
+func RegisterCredentialsServer(s grpc.ServiceRegistrar, srv CredentialsServer) {
+	s.RegisterService(&Credentials_ServiceDesc, srv)
+}
+
+func _Credentials_Create_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateCredentialsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CredentialsServer).Create(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Credentials_Create_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CredentialsServer).Create(ctx, req.(*CreateCredentialsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Credentials_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CredentialsServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Credentials_Get_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CredentialsServer).Get(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Credentials_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CredentialsServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Credentials_List_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CredentialsServer).List(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Credentials_Update_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Credential)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CredentialsServer).Update(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Credentials_Update_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CredentialsServer).Update(ctx, req.(*Credential))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Credentials_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CredentialsServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Credentials_Delete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CredentialsServer).Delete(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Credentials_ServiceDesc is the grpc.ServiceDesc for Credentials service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Credentials_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "keeper.v1.Credentials",
+	HandlerType: (*CredentialsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Create",
+			Handler:    _Credentials_Create_Handler,
+		},
+		{
+			MethodName: "Get",
+			Handler:    _Credentials_Get_Handler,
+		},
+		{
+			MethodName: "List",
+			Handler:    _Credentials_List_Handler,
+		},
+		{
+			MethodName: "Update",
+			Handler:    _Credentials_Update_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _Credentials_Delete_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "keeper.proto",
+}
+
+const (
+	Files_Upload_FullMethodName   = "/keeper.v1.Files/Upload"
+	Files_Download_FullMethodName = "/keeper.v1.Files/Download"
+	Files_List_FullMethodName     = "/keeper.v1.Files/List"
+	Files_Delete_FullMethodName   = "/keeper.v1.Files/Delete"
+)
+
+// FilesClient is the client API for Files service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type FilesClient interface {
+	// Upload stores the file, the first message holds the header and the
+	// others the chunks of the contents.
+	Upload(ctx context.Context, opts ...grpc.CallOption) (Files_UploadClient, error)
+	// Download streams the header of the file and then its contents.
+	Download(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (Files_DownloadClient, error)
+	List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*FilesPage, error)
+	Delete(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type filesClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewFilesClient(cc grpc.ClientConnInterface) FilesClient {
+	return &filesClient{cc}
+}
+
+func (c *filesClient) Upload(ctx context.Context, opts ...grpc.CallOption) (Files_UploadClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Files_ServiceDesc.Streams[0], Files_Upload_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &filesUploadClient{stream}
+	return x, nil
+}
+
+type Files_UploadClient interface {
+	Send(*UploadRequest) error
+	CloseAndRecv() (*emptypb.Empty, error)
+	grpc.ClientStream
+}
+
+type filesUploadClient struct {
+	grpc.ClientStream
+}
+
+func (x *filesUploadClient) Send(m *UploadRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *filesUploadClient) CloseAndRecv() (*emptypb.Empty, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(emptypb.Empty)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *filesClient) Download(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (Files_DownloadClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Files_ServiceDesc.Streams[1], Files_Download_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &filesDownloadClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Files_DownloadClient interface {
+	Recv() (*DownloadResponse, error)
+	grpc.ClientStream
+}
+
+type filesDownloadClient struct {
+	grpc.ClientStream
+}
+
+func (x *filesDownloadClient) Recv() (*DownloadResponse, error) {
+	m := new(DownloadResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *filesClient) List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*FilesPage, error) {
+	out := new(FilesPage)
+	err := c.cc.Invoke(ctx, Files_List_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filesClient) Delete(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Files_Delete_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// FilesServer is the server API for Files service.
+// All implementations must embed UnimplementedFilesServer
+// for forward compatibility
+type FilesServer interface {
+	// Upload stores the file, the first message holds the header and the
+	// others the chunks of the contents.
+	Upload(Files_UploadServer) error
+	// Download streams the header of the file and then its contents.
+	Download(*GetRequest, Files_DownloadServer) error
+	List(context.Context, *ListRequest) (*FilesPage, error)
+	Delete(context.Context, *GetRequest) (*emptypb.Empty, error)
+	mustEmbedUnimplementedFilesServer()
+}
+
+// UnimplementedFilesServer must be embedded to have forward compatible implementations.
+type UnimplementedFilesServer struct {
+}
+
+func (UnimplementedFilesServer) Upload(Files_UploadServer) error {
+	return status.Errorf(codes.Unimplemented, "method Upload not implemented")
+}
+func (UnimplementedFilesServer) Download(*GetRequest, Files_DownloadServer) error {
+	return status.Errorf(codes.Unimplemented, "method Download not implemented")
+}
+func (UnimplementedFilesServer) List(context.Context, *ListRequest) (*FilesPage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedFilesServer) Delete(context.Context, *GetRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedFilesServer) mustEmbedUnimplementedFilesServer() {}
+
+// UnsafeFilesServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to FilesServer will
+// result in compilation errors.
+type UnsafeFilesServer interface {
+	mustEmbedUnimplementedFilesServer()
+}
+
+func RegisterFilesServer(s grpc.ServiceRegistrar, srv FilesServer) {
+	s.RegisterService(&Files_ServiceDesc, srv)
+}
+
+func _Files_Upload_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(FilesServer).Upload(&filesUploadServer{stream})
+}
+
+type Files_UploadServer interface {
+	SendAndClose(*emptypb.Empty) error
+	Recv() (*UploadRequest, error)
+	grpc.ServerStream
+}
+
+type filesUploadServer struct {
+	grpc.ServerStream
+}
+
+func (x *filesUploadServer) SendAndClose(m *emptypb.Empty) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *filesUploadServer) Recv() (*UploadRequest, error) {
+	m := new(UploadRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Files_Download_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FilesServer).Download(m, &filesDownloadServer{stream})
+}
+
+type Files_DownloadServer interface {
+	Send(*DownloadResponse) error
+	grpc.ServerStream
+}
+
+type filesDownloadServer struct {
+	grpc.ServerStream
+}
+
+func (x *filesDownloadServer) Send(m *DownloadResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Files_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Files_List_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesServer).List(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Files_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Files_Delete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesServer).Delete(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Files_ServiceDesc is the grpc.ServiceDesc for Files service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Files_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "keeper.v1.Files",
+	HandlerType: (*FilesServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "List",
+			Handler:    _Files_List_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _Files_Delete_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Upload",
+			Handler:       _Files_Upload_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Download",
+			Handler:       _Files_Download_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "keeper.proto",
+}
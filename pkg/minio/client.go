@@ -1,94 +1,271 @@
 package minio
 
 import (
+	"bytes"
 	"context"
+	"crypto/md5"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"strings"
 	"time"
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
 	"go.uber.org/zap"
 )
 
+// uploadPartSize bounds memory used for uploads of unknown size,
+// minio-go buffers one part at a time before sending it.
+const uploadPartSize = 16 << 20
+
+// UploadsPrefix is the key prefix of resumable upload manifests.
+const UploadsPrefix = "uploads/"
+
 type Object struct {
-	ID   string
-	Size int64
-	Name string
+	ID           string
+	Size         int64
+	LastModified time.Time
+	// Metadata is the user metadata without the "X-Amz-Meta-" prefix, it is
+	// only filled by ListObjectsWithMetadata.
+	Metadata map[string]string
+}
+
+// metaPrefix is the prefix of user metadata keys returned by listings.
+const metaPrefix = "X-Amz-Meta-"
+
+var ErrNotFound = errors.New("object not found")
+var ErrBadDigest = errors.New("checksum mismatch")
+
+// Part is an uploaded part of a multipart upload, MD5 is the base64 encoded
+// digest of its contents or empty when it is unknown.
+type Part struct {
+	Number int
+	Size   int64
+	ETag   string
+	MD5    string
+}
+
+// Upload is an incomplete multipart upload.
+type Upload struct {
+	Key       string
+	UploadID  string
+	Initiated time.Time
 }
 
 type Client struct {
 	logger      *zap.Logger
 	minioClient *minio.Client
+	core        *minio.Core
+
+	tlsConfig       *tls.Config
+	encryption      *Encryption
+	encryptedBucket string
+	lockedBucket    string
+}
+
+type Option func(*Client)
+
+// WithTLS connects to MinIO over HTTPS, config may be nil for the system defaults.
+func WithTLS(config *tls.Config) Option {
+	return func(c *Client) {
+		if config == nil {
+			config = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		c.tlsConfig = config
+	}
+}
+
+// WithEncryption encrypts the objects of the bucket with SSE-C, every object is
+// owned by the first segment of its key and encrypted with the data key of the
+// owner. MinIO accepts SSE-C only over TLS.
+func WithEncryption(bucketName string, e *Encryption) Option {
+	return func(c *Client) {
+		c.encryptedBucket = bucketName
+		c.encryption = e
+	}
+}
+
+// WithObjectLock creates the bucket with object locking, so that objects can be
+// kept under retention. The bucket is versioned then, DeleteFile removes all the
+// versions of an object bypassing the retention.
+func WithObjectLock(bucketName string) Option {
+	return func(c *Client) {
+		c.lockedBucket = bucketName
+	}
 }
 
-func NewClient(endpoint, accessKeyID, secretAccessKey string, logger *zap.Logger) (*Client, error) {
-	minioClient, err := minio.New(endpoint, &minio.Options{
+func NewClient(endpoint, accessKeyID, secretAccessKey string, logger *zap.Logger, opts ...Option) (*Client, error) {
+	c := &Client{logger: logger}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	minioOpts := &minio.Options{
 		Creds:  credentials.NewStaticV4(accessKeyID, secretAccessKey, ""),
-		Secure: false,
-	})
+		Secure: c.tlsConfig != nil,
+	}
+	if c.tlsConfig != nil {
+		transport, err := minio.DefaultTransport(true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create minio transport. err: %w", err)
+		}
+		transport.TLSClientConfig = c.tlsConfig
+		minioOpts.Transport = transport
+	}
+
+	minioClient, err := minio.New(endpoint, minioOpts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create minio client. err: %w", err)
 	}
 
-	return &Client{
-		logger:      logger,
-		minioClient: minioClient,
-	}, nil
+	c.minioClient = minioClient
+	c.core = &minio.Core{Client: minioClient}
+	return c, nil
 }
 
+// serverSide returns the SSE-C key of the object, nil if it is not encrypted.
+func (c *Client) serverSide(ctx context.Context, bucketName, key string) (encrypt.ServerSide, error) {
+	if c.encryption == nil || bucketName != c.encryptedBucket {
+		return nil, nil
+	}
+	owner, _, ok := strings.Cut(key, "/")
+	if !ok {
+		return nil, fmt.Errorf("object %s has no owner to encrypt it for", key)
+	}
+	return c.encryption.serverSide(ctx, owner)
+}
+
+// GetFile reads an object, with encryption on the objects stored before it was
+// enabled are read without the key until RotateKeys encrypts them.
 func (c *Client) GetFile(ctx context.Context, bucketName, fileId string) (*minio.Object, error) {
-	obj, err := c.minioClient.GetObject(ctx, bucketName, fileId, minio.GetObjectOptions{})
+	sse, err := c.serverSide(ctx, bucketName, fileId)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := c.getObject(ctx, bucketName, fileId, sse)
+	if err != nil && sse != nil && !errors.Is(err, ErrNotFound) {
+		if plain, errPlain := c.getObject(ctx, bucketName, fileId, nil); errPlain == nil {
+			return plain, nil
+		}
+	}
+	return obj, err
+}
+
+func (c *Client) getObject(ctx context.Context, bucketName, fileId string, sse encrypt.ServerSide) (*minio.Object, error) {
+	obj, err := c.minioClient.GetObject(ctx, bucketName, fileId, minio.GetObjectOptions{ServerSideEncryption: sse})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get file with id: %s from minio bucket %s. err: %w", fileId, bucketName, err)
 	}
 
+	_, err = obj.Stat()
+	if err != nil {
+		obj.Close()
+		switch minio.ToErrorResponse(err).Code {
+		case "NoSuchKey", "NoSuchBucket":
+			return nil, fmt.Errorf("failed to get file with id: %s. err: %w", fileId, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get file with id: %s from minio bucket %s. err: %w", fileId, bucketName, err)
+	}
+
 	return obj, nil
 }
 
-func (c *Client) GetFilesList(ctx context.Context, bucketName string) ([]*Object, error) {
+// GetUploadManifests lists manifests of resumable uploads stored under keyPrefix,
+// LastModified is the session creation time.
+func (c *Client) GetUploadManifests(ctx context.Context, bucketName, keyPrefix string) ([]*Object, error) {
 	reqCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
+	prefix := keyPrefix + UploadsPrefix
+	return c.listPrefix(reqCtx, bucketName, prefix, minio.ListObjectsOptions{Prefix: prefix})
+}
+
+// ListObjects lists all objects under the prefix, IDs are the keys with the prefix trimmed.
+func (c *Client) ListObjects(ctx context.Context, bucketName, prefix string) ([]*Object, error) {
+	return c.listPrefix(ctx, bucketName, prefix, minio.ListObjectsOptions{Prefix: prefix, Recursive: true})
+}
+
+// ListObjectsWithMetadata lists all objects under the prefix together with their
+// user metadata, it relies on an extension of MinIO to the S3 listing.
+func (c *Client) ListObjectsWithMetadata(ctx context.Context, bucketName, prefix string) ([]*Object, error) {
+	return c.listPrefix(ctx, bucketName, prefix,
+		minio.ListObjectsOptions{Prefix: prefix, Recursive: true, WithMetadata: true})
+}
+
+// ListPrefixes lists the top level "directories" of the bucket without the trailing slash.
+func (c *Client) ListPrefixes(ctx context.Context, bucketName string) ([]string, error) {
+	var prefixes []string
+
+	for lobj := range c.minioClient.ListObjects(ctx, bucketName, minio.ListObjectsOptions{}) {
+		if lobj.Err != nil {
+			return nil, fmt.Errorf("failed to list prefixes of bucket %s. err: %w", bucketName, lobj.Err)
+		}
+		if strings.HasSuffix(lobj.Key, "/") {
+			prefixes = append(prefixes, strings.TrimSuffix(lobj.Key, "/"))
+		}
+	}
+
+	return prefixes, nil
+}
+
+func (c *Client) listPrefix(ctx context.Context, bucketName, prefix string, opts minio.ListObjectsOptions) ([]*Object, error) {
 	var list []*Object
 
-	for lobj := range c.minioClient.ListObjects(reqCtx, bucketName, minio.ListObjectsOptions{WithMetadata: true}) {
+	for lobj := range c.minioClient.ListObjects(ctx, bucketName, opts) {
 		if lobj.Err != nil {
-			c.logger.Error("failed to list object from minio bucket",
-				zap.String("bucket name", bucketName), zap.Error(lobj.Err))
-			continue
+			return nil, fmt.Errorf("failed to list objects of bucket %s with prefix %s. err: %w", bucketName, prefix, lobj.Err)
 		}
 		obj := new(Object)
-		obj.ID = lobj.Key
+		obj.ID = strings.TrimPrefix(lobj.Key, prefix)
 		obj.Size = lobj.Size
-		obj.Name = lobj.UserMetadata["X-Amz-Meta-Name"]
+		obj.LastModified = lobj.LastModified
+		if opts.WithMetadata {
+			obj.Metadata = make(map[string]string, len(lobj.UserMetadata))
+			for k, v := range lobj.UserMetadata {
+				if name, ok := strings.CutPrefix(k, metaPrefix); ok {
+					obj.Metadata[name] = v
+				}
+			}
+		}
 		list = append(list, obj)
 	}
 
 	return list, nil
 }
 
-func (c *Client) UploadFile(ctx context.Context, fileId, fileName, bucketName, metadata string, fileSize int64, reader io.Reader) error {
-	reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
-	defer cancel()
+func (c *Client) GetBuckets(ctx context.Context) ([]string, error) {
+	buckets, err := c.minioClient.ListBuckets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list buckets. err: %w", err)
+	}
 
-	exists, errBucketExists := c.minioClient.BucketExists(ctx, bucketName)
-	if errBucketExists != nil || !exists {
-		c.logger.Warn("no bucket with this key. creating new one...", zap.String("key", bucketName))
-		err := c.minioClient.MakeBucket(ctx, bucketName, minio.MakeBucketOptions{})
-		if err != nil {
-			return fmt.Errorf("failed to create new bucket. err: %w", err)
-		}
+	names := make([]string, 0, len(buckets))
+	for _, b := range buckets {
+		names = append(names, b.Name)
+	}
+	return names, nil
+}
+
+// UploadFile streams reader into the bucket, fileSize is -1 when unknown.
+// Large and unsized uploads are sent as a multipart upload in uploadPartSize chunks.
+func (c *Client) UploadFile(ctx context.Context, fileId, bucketName string, fileSize int64, reader io.Reader) error {
+	sse, err := c.serverSide(ctx, bucketName, fileId)
+	if err != nil {
+		return err
 	}
 
-	c.logger.Debug("put new object to bucket", zap.String("file", fileName), zap.String("bucket", bucketName))
-	_, err := c.minioClient.PutObject(reqCtx, bucketName, fileId, reader, fileSize,
+	c.logger.Debug("put new object to bucket", zap.String("file", fileId), zap.String("bucket", bucketName))
+	_, err = c.minioClient.PutObject(ctx, bucketName, fileId, reader, fileSize,
 		minio.PutObjectOptions{
-			UserMetadata: map[string]string{
-				"Name":     fileName,
-				"Metadata": metadata,
-			},
-			ContentType: "application/octet-stream",
+			ContentType:          "application/octet-stream",
+			PartSize:             uploadPartSize,
+			ServerSideEncryption: sse,
 		})
 	if err != nil {
 		return fmt.Errorf("failed to upload file. err: %w", err)
@@ -97,9 +274,317 @@ func (c *Client) UploadFile(ctx context.Context, fileId, fileName, bucketName, m
 }
 
 func (c *Client) DeleteFile(ctx context.Context, bucketName, fileName string) error {
+	if bucketName == c.lockedBucket {
+		return c.deleteVersions(ctx, bucketName, fileName)
+	}
+
 	err := c.minioClient.RemoveObject(ctx, bucketName, fileName, minio.RemoveObjectOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to delete file. err: %w", err)
 	}
 	return nil
 }
+
+// deleteVersions removes every version of the object, removing it without a
+// version would only hide it behind a delete marker.
+func (c *Client) deleteVersions(ctx context.Context, bucketName, key string) error {
+	opts := minio.ListObjectsOptions{Prefix: key, WithVersions: true}
+	for lobj := range c.minioClient.ListObjects(ctx, bucketName, opts) {
+		if lobj.Err != nil {
+			return fmt.Errorf("failed to list versions of %s. err: %w", key, lobj.Err)
+		}
+		if lobj.Key != key {
+			continue
+		}
+		err := c.minioClient.RemoveObject(ctx, bucketName, key,
+			minio.RemoveObjectOptions{VersionID: lobj.VersionID, GovernanceBypass: true})
+		if err != nil {
+			return fmt.Errorf("failed to delete version %s of %s. err: %w", lobj.VersionID, key, err)
+		}
+	}
+	return nil
+}
+
+// Retain keeps the object from being deleted or overwritten until the given
+// moment, it is a no-op outside of the bucket with object locking. The GOVERNANCE
+// mode lets DeleteFile remove the object earlier, e.g. when the trash is emptied.
+func (c *Client) Retain(ctx context.Context, bucketName, key string, until time.Time) error {
+	if bucketName != c.lockedBucket {
+		return nil
+	}
+
+	mode := minio.Governance
+	until = until.UTC()
+	err := c.minioClient.PutObjectRetention(ctx, bucketName, key, minio.PutObjectRetentionOptions{
+		Mode:             &mode,
+		RetainUntilDate:  &until,
+		GovernanceBypass: true,
+	})
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return fmt.Errorf("failed to retain object %s. err: %w", key, ErrNotFound)
+		}
+		return fmt.Errorf("failed to retain object %s. err: %w", key, err)
+	}
+	return nil
+}
+
+// CopyObject copies an object between buckets server-side, ComposeObject is used
+// instead of CopyObject as the latter is limited to 5GiB.
+func (c *Client) CopyObject(ctx context.Context, srcBucket, srcKey, dstBucket, dstKey string) error {
+	srcSSE, err := c.serverSide(ctx, srcBucket, srcKey)
+	if err != nil {
+		return err
+	}
+	dstSSE, err := c.serverSide(ctx, dstBucket, dstKey)
+	if err != nil {
+		return err
+	}
+
+	compose := func(srcSSE encrypt.ServerSide) error {
+		_, err := c.minioClient.ComposeObject(ctx,
+			minio.CopyDestOptions{Bucket: dstBucket, Object: dstKey, Encryption: dstSSE},
+			minio.CopySrcOptions{Bucket: srcBucket, Object: srcKey, Encryption: srcSSE})
+		return err
+	}
+
+	err = compose(srcSSE)
+	if err != nil && srcSSE != nil && minio.ToErrorResponse(err).Code != "NoSuchKey" {
+		// the source may have been stored before encryption was enabled
+		if errPlain := compose(nil); errPlain == nil {
+			return nil
+		}
+	}
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return fmt.Errorf("failed to copy object %s. err: %w", srcKey, ErrNotFound)
+		}
+		return fmt.Errorf("failed to copy object %s. err: %w", srcKey, err)
+	}
+	return nil
+}
+
+// Encrypt rewrites an object of the encrypted bucket stored before encryption
+// was enabled with its SSE-C key, it reports whether the object was rewritten.
+func (c *Client) Encrypt(ctx context.Context, bucketName, key string) (bool, error) {
+	sse, err := c.serverSide(ctx, bucketName, key)
+	if err != nil || sse == nil {
+		return false, err
+	}
+
+	// objects encrypted with SSE-C cannot even be stat'ed without the key
+	plain, err := c.minioClient.StatObject(ctx, bucketName, key, minio.StatObjectOptions{})
+	if err != nil {
+		_, errSSE := c.minioClient.StatObject(ctx, bucketName, key, minio.StatObjectOptions{ServerSideEncryption: sse})
+		if errSSE == nil {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to stat object %s. err: %w", key, err)
+	}
+
+	_, err = c.minioClient.ComposeObject(ctx,
+		minio.CopyDestOptions{Bucket: bucketName, Object: key, Encryption: sse},
+		minio.CopySrcOptions{Bucket: bucketName, Object: key})
+	if err != nil {
+		return false, fmt.Errorf("failed to encrypt object %s. err: %w", key, err)
+	}
+
+	// the plaintext is kept as the previous version of a versioned bucket
+	if bucketName == c.lockedBucket && plain.VersionID != "" {
+		err = c.minioClient.RemoveObject(ctx, bucketName, key,
+			minio.RemoveObjectOptions{VersionID: plain.VersionID, GovernanceBypass: true})
+		if err != nil {
+			return true, fmt.Errorf("failed to remove plaintext version of %s. err: %w", key, err)
+		}
+	}
+	return true, nil
+}
+
+// EnsureBucket creates the bucket unless it exists, it is called once at startup
+// rather than before every write.
+func (c *Client) EnsureBucket(ctx context.Context, bucketName string) error {
+	locked := bucketName == c.lockedBucket
+
+	exists, err := c.minioClient.BucketExists(ctx, bucketName)
+	if err != nil {
+		return fmt.Errorf("failed to check bucket %s. err: %w", bucketName, err)
+	}
+	if !exists {
+		c.logger.Info("creating bucket", zap.String("bucket", bucketName), zap.Bool("object lock", locked))
+		err = c.minioClient.MakeBucket(ctx, bucketName, minio.MakeBucketOptions{ObjectLocking: locked})
+		if err != nil && minio.ToErrorResponse(err).Code != "BucketAlreadyOwnedByYou" {
+			return fmt.Errorf("failed to create new bucket. err: %w", err)
+		}
+	}
+	if !locked {
+		return nil
+	}
+
+	// object locking can only be enabled when the bucket is created
+	enabled, _, _, _, err := c.minioClient.GetObjectLockConfig(ctx, bucketName)
+	if err != nil && minio.ToErrorResponse(err).Code != "ObjectLockConfigurationNotFoundError" {
+		return fmt.Errorf("failed to check object lock of bucket %s. err: %w", bucketName, err)
+	}
+	if enabled != "Enabled" {
+		return fmt.Errorf("bucket %s was created without object locking", bucketName)
+	}
+	return nil
+}
+
+func (c *Client) RemoveBucket(ctx context.Context, bucketName string) error {
+	err := c.minioClient.RemoveBucket(ctx, bucketName)
+	if err != nil {
+		return fmt.Errorf("failed to remove bucket %s. err: %w", bucketName, err)
+	}
+	return nil
+}
+
+// PutObject stores a small object such as an upload manifest.
+func (c *Client) PutObject(ctx context.Context, bucketName, key string, data []byte) error {
+	sse, err := c.serverSide(ctx, bucketName, key)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.minioClient.PutObject(ctx, bucketName, key, bytes.NewReader(data), int64(len(data)),
+		minio.PutObjectOptions{ContentType: "application/json", ServerSideEncryption: sse})
+	if err != nil {
+		return fmt.Errorf("failed to put object %s. err: %w", key, err)
+	}
+	return nil
+}
+
+// ReadObject reads a small object such as an upload manifest, objects stored
+// before encryption was enabled are read like in GetFile.
+func (c *Client) ReadObject(ctx context.Context, bucketName, key string) ([]byte, error) {
+	obj, err := c.GetFile(ctx, bucketName, key)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("failed to read object %s. err: %w", key, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to read object %s. err: %w", key, err)
+	}
+	defer obj.Close()
+
+	data, err := io.ReadAll(obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object %s. err: %w", key, err)
+	}
+	return data, nil
+}
+
+func (c *Client) NewMultipartUpload(ctx context.Context, bucketName, fileId string) (string, error) {
+	sse, err := c.serverSide(ctx, bucketName, fileId)
+	if err != nil {
+		return "", err
+	}
+
+	uploadID, err := c.core.NewMultipartUpload(ctx, bucketName, fileId, minio.PutObjectOptions{
+		ContentType:          "application/octet-stream",
+		ServerSideEncryption: sse,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to start multipart upload. err: %w", err)
+	}
+	return uploadID, nil
+}
+
+// PutPart uploads a single part, md5Base64 is verified by the object storage.
+func (c *Client) PutPart(ctx context.Context, bucketName, fileId, uploadID string, number int,
+	reader io.Reader, size int64, md5Base64 string) (Part, error) {
+	sse, err := c.serverSide(ctx, bucketName, fileId)
+	if err != nil {
+		return Part{}, err
+	}
+
+	part, err := c.core.PutObjectPart(ctx, bucketName, fileId, uploadID, number, reader, size,
+		minio.PutObjectPartOptions{Md5Base64: md5Base64, SSE: sse})
+	if err != nil {
+		switch minio.ToErrorResponse(err).Code {
+		case "BadDigest", "InvalidDigest":
+			return Part{}, fmt.Errorf("failed to upload part %d. err: %w", number, ErrBadDigest)
+		case "NoSuchUpload":
+			return Part{}, fmt.Errorf("failed to upload part %d. err: %w", number, ErrNotFound)
+		}
+		return Part{}, fmt.Errorf("failed to upload part %d. err: %w", number, err)
+	}
+	return Part{Number: part.PartNumber, Size: part.Size, ETag: strings.Trim(part.ETag, `"`), MD5: md5Base64}, nil
+}
+
+func (c *Client) ListParts(ctx context.Context, bucketName, fileId, uploadID string) ([]Part, error) {
+	var (
+		parts  []Part
+		marker int
+	)
+	for {
+		res, err := c.core.ListObjectParts(ctx, bucketName, fileId, uploadID, marker, 1000)
+		if err != nil {
+			if minio.ToErrorResponse(err).Code == "NoSuchUpload" {
+				return nil, fmt.Errorf("failed to list parts. err: %w", ErrNotFound)
+			}
+			return nil, fmt.Errorf("failed to list parts. err: %w", err)
+		}
+		for _, p := range res.ObjectParts {
+			etag := strings.Trim(p.ETag, `"`)
+			parts = append(parts, Part{Number: p.PartNumber, Size: p.Size, ETag: etag, MD5: c.partMD5(bucketName, etag)})
+		}
+		if !res.IsTruncated {
+			return parts, nil
+		}
+		marker = res.NextPartNumberMarker
+	}
+}
+
+// partMD5 returns the digest of a part from its ETag. The ETag of an SSE-C
+// encrypted part is not the MD5 of the contents, the digest is unknown then.
+func (c *Client) partMD5(bucketName, etag string) string {
+	if c.encryption != nil && bucketName == c.encryptedBucket {
+		return ""
+	}
+	sum, err := hex.DecodeString(etag)
+	if err != nil || len(sum) != md5.Size {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(sum)
+}
+
+func (c *Client) CompleteMultipartUpload(ctx context.Context, bucketName, fileId, uploadID string, parts []Part) error {
+	sse, err := c.serverSide(ctx, bucketName, fileId)
+	if err != nil {
+		return err
+	}
+
+	complete := make([]minio.CompletePart, 0, len(parts))
+	for _, p := range parts {
+		complete = append(complete, minio.CompletePart{PartNumber: p.Number, ETag: p.ETag})
+	}
+
+	_, err = c.core.CompleteMultipartUpload(ctx, bucketName, fileId, uploadID, complete,
+		minio.PutObjectOptions{ServerSideEncryption: sse})
+	if err != nil {
+		return fmt.Errorf("failed to complete multipart upload. err: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) AbortMultipartUpload(ctx context.Context, bucketName, fileId, uploadID string) error {
+	err := c.core.AbortMultipartUpload(ctx, bucketName, fileId, uploadID)
+	if err != nil && minio.ToErrorResponse(err).Code != "NoSuchUpload" {
+		return fmt.Errorf("failed to abort multipart upload. err: %w", err)
+	}
+	return nil
+}
+
+// GetIncompleteUploads lists multipart uploads of the objects under the prefix.
+func (c *Client) GetIncompleteUploads(ctx context.Context, bucketName, prefix string) ([]*Upload, error) {
+	var list []*Upload
+
+	for info := range c.minioClient.ListIncompleteUploads(ctx, bucketName, prefix, true) {
+		if info.Err != nil {
+			return nil, fmt.Errorf("failed to list incomplete uploads. err: %w", info.Err)
+		}
+		list = append(list, &Upload{Key: info.Key, UploadID: info.UploadID, Initiated: info.Initiated})
+	}
+
+	return list, nil
+}
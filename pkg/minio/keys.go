@@ -0,0 +1,234 @@
+package minio
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+)
+
+// keySize is the size of master and data keys, SSE-C takes AES-256 keys.
+const keySize = 32
+
+// DataKey is the SSE-C key of the objects of one owner, it is only ever stored
+// wrapped by the master key with ID MasterKeyID.
+type DataKey struct {
+	Owner       string
+	MasterKeyID string
+	Wrapped     []byte
+}
+
+// KeyStore persists wrapped data keys.
+type KeyStore interface {
+	// GetDataKey returns ErrNotFound if the owner has no key yet.
+	GetDataKey(ctx context.Context, owner string) (*DataKey, error)
+	// CreateDataKey stores the key unless the owner already has one and
+	// returns the stored key, so that concurrent writers agree on a single key.
+	CreateDataKey(ctx context.Context, key *DataKey) (*DataKey, error)
+	ListDataKeys(ctx context.Context) ([]*DataKey, error)
+	// UpdateDataKey replaces the wrapped key, it is a no-op if the key was
+	// rewrapped concurrently and is no longer wrapped by prevMasterKeyID.
+	UpdateDataKey(ctx context.Context, key *DataKey, prevMasterKeyID string) error
+}
+
+// KeyRing holds the master keys, the current one wraps new data keys and the
+// retired ones are kept to unwrap data keys until they are rotated.
+type KeyRing struct {
+	keys    map[string][]byte
+	current string
+}
+
+// ParseKeyRing parses "<id>:<base64 key>" entries separated by commas or new
+// lines, the last entry is the current key. Empty lines and lines starting
+// with # are skipped.
+func ParseKeyRing(s string) (*KeyRing, error) {
+	ring := &KeyRing{keys: make(map[string][]byte)}
+
+	entries := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '\n' })
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || id == "" {
+			return nil, errors.New("master key must be set as <id>:<base64 key>")
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode master key %s. err: %w", id, err)
+		}
+		if len(key) != keySize {
+			return nil, fmt.Errorf("master key %s must be %d bytes long", id, keySize)
+		}
+		if _, ok := ring.keys[id]; ok {
+			return nil, fmt.Errorf("duplicate master key %s", id)
+		}
+
+		ring.keys[id] = key
+		ring.current = id
+	}
+
+	if ring.current == "" {
+		return nil, errors.New("no master keys")
+	}
+	return ring, nil
+}
+
+// CurrentID returns the ID of the master key wrapping new data keys.
+func (r *KeyRing) CurrentID() string {
+	return r.current
+}
+
+// wrap encrypts the data key with the current master key, the owner is
+// authenticated so that a wrapped key cannot be swapped between owners.
+func (r *KeyRing) wrap(owner string, dataKey []byte) (*DataKey, error) {
+	aead, err := newGCM(r.keys[r.current])
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(dataKey)+aead.Overhead())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate nonce. err: %w", err)
+	}
+
+	return &DataKey{
+		Owner:       owner,
+		MasterKeyID: r.current,
+		Wrapped:     aead.Seal(nonce, nonce, dataKey, []byte(owner)),
+	}, nil
+}
+
+func (r *KeyRing) unwrap(key *DataKey) ([]byte, error) {
+	masterKey, ok := r.keys[key.MasterKeyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown master key %s", key.MasterKeyID)
+	}
+	aead, err := newGCM(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(key.Wrapped) < aead.NonceSize() {
+		return nil, errors.New("wrapped data key is too short")
+	}
+
+	nonce, ciphertext := key.Wrapped[:aead.NonceSize()], key.Wrapped[aead.NonceSize():]
+	dataKey, err := aead.Open(nil, nonce, ciphertext, []byte(key.Owner))
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key of %s. err: %w", key.Owner, err)
+	}
+	return dataKey, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher. err: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// Encryption hands out SSE-C keys of the owners, creating them on first use.
+type Encryption struct {
+	ring  *KeyRing
+	store KeyStore
+
+	mu    sync.Mutex
+	cache map[string]encrypt.ServerSide
+}
+
+func NewEncryption(ring *KeyRing, store KeyStore) *Encryption {
+	return &Encryption{
+		ring:  ring,
+		store: store,
+		cache: make(map[string]encrypt.ServerSide),
+	}
+}
+
+func (e *Encryption) serverSide(ctx context.Context, owner string) (encrypt.ServerSide, error) {
+	e.mu.Lock()
+	sse, ok := e.cache[owner]
+	e.mu.Unlock()
+	if ok {
+		return sse, nil
+	}
+
+	key, err := e.store.GetDataKey(ctx, owner)
+	if errors.Is(err, ErrNotFound) {
+		key, err = e.createDataKey(ctx, owner)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data key of %s. err: %w", owner, err)
+	}
+
+	dataKey, err := e.ring.unwrap(key)
+	if err != nil {
+		return nil, err
+	}
+	sse, err = encrypt.NewSSEC(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SSE-C key. err: %w", err)
+	}
+
+	e.mu.Lock()
+	e.cache[owner] = sse
+	e.mu.Unlock()
+	return sse, nil
+}
+
+func (e *Encryption) createDataKey(ctx context.Context, owner string) (*DataKey, error) {
+	dataKey := make([]byte, keySize)
+	_, err := rand.Read(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate data key. err: %w", err)
+	}
+
+	key, err := e.ring.wrap(owner, dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return e.store.CreateDataKey(ctx, key)
+}
+
+// Rotate rewraps the data keys wrapped by retired master keys with the current
+// one. The data keys themselves and so the objects stay the same, a retired
+// master key can be dropped from the ring once Rotate succeeds.
+func (e *Encryption) Rotate(ctx context.Context) (int64, error) {
+	keys, err := e.store.ListDataKeys(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list data keys. err: %w", err)
+	}
+
+	var rotated int64
+	for _, key := range keys {
+		if key.MasterKeyID == e.ring.current {
+			continue
+		}
+
+		dataKey, err := e.ring.unwrap(key)
+		if err != nil {
+			return rotated, err
+		}
+		wrapped, err := e.ring.wrap(key.Owner, dataKey)
+		if err != nil {
+			return rotated, err
+		}
+		err = e.store.UpdateDataKey(ctx, wrapped, key.MasterKeyID)
+		if err != nil {
+			return rotated, fmt.Errorf("failed to update data key of %s. err: %w", key.Owner, err)
+		}
+		rotated++
+	}
+
+	return rotated, nil
+}
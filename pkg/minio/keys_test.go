@@ -0,0 +1,123 @@
+package minio
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryKeys is a KeyStore kept in memory.
+type memoryKeys map[string]*DataKey
+
+func (m memoryKeys) GetDataKey(_ context.Context, owner string) (*DataKey, error) {
+	key, ok := m[owner]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return key, nil
+}
+
+func (m memoryKeys) CreateDataKey(_ context.Context, key *DataKey) (*DataKey, error) {
+	if stored, ok := m[key.Owner]; ok {
+		return stored, nil
+	}
+	m[key.Owner] = key
+	return key, nil
+}
+
+func (m memoryKeys) ListDataKeys(_ context.Context) ([]*DataKey, error) {
+	var keys []*DataKey
+	for _, key := range m {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (m memoryKeys) UpdateDataKey(_ context.Context, key *DataKey, prevMasterKeyID string) error {
+	if m[key.Owner].MasterKeyID == prevMasterKeyID {
+		m[key.Owner] = key
+	}
+	return nil
+}
+
+func masterKey(id string, b byte) string {
+	return id + ":" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, keySize))
+}
+
+func TestParseKeyRing(t *testing.T) {
+	ring, err := ParseKeyRing("# retired\n" + masterKey("k1", 1) + "\n\n" + masterKey("k2", 2) + "\n")
+	require.NoError(t, err)
+	assert.Equal(t, "k2", ring.CurrentID())
+	assert.Len(t, ring.keys, 2)
+
+	ring, err = ParseKeyRing(masterKey("k1", 1) + "," + masterKey("k2", 2))
+	require.NoError(t, err)
+	assert.Equal(t, "k2", ring.CurrentID())
+
+	for _, s := range []string{
+		"",
+		"k1",
+		"k1:not base64",
+		"k1:" + base64.StdEncoding.EncodeToString([]byte("short")),
+		masterKey("k1", 1) + "," + masterKey("k1", 2),
+	} {
+		_, err = ParseKeyRing(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestWrapUnwrap(t *testing.T) {
+	ring, err := ParseKeyRing(masterKey("k1", 1))
+	require.NoError(t, err)
+
+	dataKey := bytes.Repeat([]byte{7}, keySize)
+	wrapped, err := ring.wrap("owner", dataKey)
+	require.NoError(t, err)
+	assert.Equal(t, "k1", wrapped.MasterKeyID)
+	assert.NotContains(t, string(wrapped.Wrapped), string(dataKey))
+
+	unwrapped, err := ring.unwrap(wrapped)
+	require.NoError(t, err)
+	assert.Equal(t, dataKey, unwrapped)
+
+	// a wrapped key is bound to its owner
+	wrapped.Owner = "other"
+	_, err = ring.unwrap(wrapped)
+	assert.Error(t, err)
+}
+
+func TestEncryption_Rotate(t *testing.T) {
+	ctx := context.Background()
+	store := memoryKeys{}
+
+	old, err := ParseKeyRing(masterKey("k1", 1))
+	require.NoError(t, err)
+	sse, err := NewEncryption(old, store).serverSide(ctx, "owner")
+	require.NoError(t, err)
+	// the same key is handed out once created
+	again, err := NewEncryption(old, store).serverSide(ctx, "owner")
+	require.NoError(t, err)
+	assert.Equal(t, sse, again)
+
+	ring, err := ParseKeyRing(masterKey("k1", 1) + "," + masterKey("k2", 2))
+	require.NoError(t, err)
+	rotated, err := NewEncryption(ring, store).Rotate(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), rotated)
+	assert.Equal(t, "k2", store["owner"].MasterKeyID)
+
+	// the data key survives the rotation and the retired master key is no longer needed
+	current, err := ParseKeyRing(masterKey("k2", 2))
+	require.NoError(t, err)
+	rotatedSSE, err := NewEncryption(current, store).serverSide(ctx, "owner")
+	require.NoError(t, err)
+	assert.Equal(t, sse, rotatedSSE)
+
+	rotated, err = NewEncryption(current, store).Rotate(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, rotated)
+}
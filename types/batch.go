@@ -0,0 +1,105 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// BatchAtomic applies either all operations of a batch or none of them.
+	BatchAtomic = "atomic"
+	// BatchPerItem applies every operation that succeeds and reports the
+	// failed ones.
+	BatchPerItem = "per_item"
+
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpDelete = "delete"
+
+	// MaxBatchOps limits the number of operations of a single batch.
+	MaxBatchOps = 1000
+)
+
+// Record is a secret with its ID, as passed to the slice methods of the stores.
+type Record[T any] struct {
+	ID   string
+	Data *T
+}
+
+// ItemError is returned by the slice methods of the stores when the record at
+// Index fails, e.g. with sql.ErrNoRows.
+type ItemError struct {
+	Index int
+	Err   error
+}
+
+func (e *ItemError) Error() string {
+	return fmt.Sprintf("record %d: %s", e.Index, e.Err)
+}
+
+func (e *ItemError) Unwrap() error {
+	return e.Err
+}
+
+// BatchOp is one operation of a batch. Data holds the body of the single
+// record endpoint of the kind and is ignored by deletes, ID is required by
+// updates and deletes.
+type BatchOp struct {
+	Op   string          `json:"op"`
+	Kind string          `json:"kind"`
+	ID   string          `json:"id,omitempty"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+type BatchRequest struct {
+	Mode string    `json:"mode"`
+	Ops  []BatchOp `json:"ops"`
+}
+
+// Validate checks the shape of the batch and defaults the mode to BatchAtomic,
+// the data of the operations is validated by the kinds. A record may appear
+// once in a batch: the slice methods of the stores don't define which of
+// several changes of the same record wins.
+func (req *BatchRequest) Validate() error {
+	if req.Mode == "" {
+		req.Mode = BatchAtomic
+	}
+	if req.Mode != BatchAtomic && req.Mode != BatchPerItem {
+		return fmt.Errorf("unknown mode %q", req.Mode)
+	}
+	if len(req.Ops) == 0 {
+		return errors.New("no operations")
+	}
+	if len(req.Ops) > MaxBatchOps {
+		return fmt.Errorf("more than %d operations", MaxBatchOps)
+	}
+
+	seen := make(map[string]int, len(req.Ops))
+	for i, op := range req.Ops {
+		if op.ID == "" {
+			continue
+		}
+		id := strings.ToLower(op.ID)
+		if first, ok := seen[id]; ok {
+			return fmt.Errorf("operations %d and %d change the same record %s", first, i, op.ID)
+		}
+		seen[id] = i
+	}
+	return nil
+}
+
+// BatchResult is the outcome of the operation with the same index, Status is
+// the code the single record endpoint would respond with. Operations of a
+// failed atomic batch that did not fail themselves have the status 424.
+type BatchResult struct {
+	ID     string `json:"id,omitempty"`
+	Status int    `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type BatchResponse struct {
+	Mode    string        `json:"mode"`
+	Results []BatchResult `json:"results"`
+}
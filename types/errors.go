@@ -4,3 +4,8 @@ import "errors"
 
 var ErrUserAlreadyExists = errors.New("user already exists")
 var ErrRecordAlreadyExists = errors.New("record with this key already exists")
+var ErrNotFound = errors.New("record not found")
+var ErrQuotaExceeded = errors.New("quota_exceeded")
+var ErrFileTooLarge = errors.New("file_too_large")
+var ErrSizeMismatch = errors.New("size mismatch")
+var ErrInvalidCursor = errors.New("invalid cursor")
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/google/uuid"
@@ -12,14 +13,19 @@ import (
 	"golang.org/x/text/unicode/norm"
 )
 
+// File describes a stored file, its contents are streamed through Content
+// which must be closed by the receiver. Storages return an io.ReadSeeker
+// implementation when they support random access, allowing range requests.
 type File struct {
-	ID       string `json:"id"`
-	Name     string `json:"name"`
-	Size     int64  `json:"size"`
-	Bytes    []byte `json:"file"`
-	Metadata string `json:"metadata"`
+	ID       string        `json:"id"`
+	Name     string        `json:"name"`
+	Size     int64         `json:"size"`
+	ModTime  time.Time     `json:"modified"`
+	Metadata string        `json:"metadata"`
+	Content  io.ReadCloser `json:"-"`
 }
 
+// CreateFileDTO describes an upload, Size is -1 when the length is not known in advance.
 type CreateFileDTO struct {
 	Name     string `json:"name"`
 	Size     int64  `json:"size"`
@@ -34,9 +40,8 @@ func (d CreateFileDTO) NormalizeName() {
 }
 
 func NewFile(dto CreateFileDTO) (*File, error) {
-	bytes, err := io.ReadAll(dto.Reader)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create file model. err: %w", err)
+	if dto.Reader == nil {
+		return nil, fmt.Errorf("failed to create file model. err: empty reader")
 	}
 	id, err := uuid.NewUUID()
 	if err != nil {
@@ -47,7 +52,56 @@ func NewFile(dto CreateFileDTO) (*File, error) {
 		ID:       id.String(),
 		Name:     dto.Name,
 		Size:     dto.Size,
-		Bytes:    bytes,
 		Metadata: dto.Metadata,
+		Content:  io.NopCloser(dto.Reader),
 	}, nil
 }
+
+// FileInfo is the record of a stored file, the contents live in the file storage under StorageKey.
+// Name is serialized as "key" to stay compatible with the Key listing.
+type FileInfo struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"-"`
+	Name       string    `json:"key"`
+	Size       int64     `json:"size"`
+	Hash       string    `json:"hash"`
+	Metadata   string    `json:"-"`
+	StorageKey string    `json:"-"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Blob is the stored contents shared by the files of a user with the same hash.
+type Blob struct {
+	UserID     string
+	Hash       string
+	StorageKey string
+	Size       int64
+	Refs       int
+}
+
+// LinkFileRequest adds a file with contents the user has already stored, so they aren't sent again.
+type LinkFileRequest struct {
+	Name     string `json:"name"`
+	Hash     string `json:"hash"`
+	Metadata string `json:"metadata"`
+}
+
+func (r LinkFileRequest) Validate() error {
+	if r.Name == "" || !ValidHash(r.Hash) {
+		return fmt.Errorf("incorrect name or hash")
+	}
+	return nil
+}
+
+// ValidHash reports whether h is a hex encoded SHA-256 sum as produced by the file service.
+func ValidHash(h string) bool {
+	if len(h) != 64 {
+		return false
+	}
+	for _, c := range h {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -1,5 +1,7 @@
 package types
 
+import "time"
+
 type Credentials struct {
 	Site     string `json:"site"`
 	Login    string `json:"login"`
@@ -22,6 +24,8 @@ type Note struct {
 }
 
 type Key struct {
-	Id  string `json:"id"`
-	Key string `json:"key"`
+	Id        string     `json:"id"`
+	Key       string     `json:"key"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
 }
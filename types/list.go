@@ -0,0 +1,105 @@
+package types
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+)
+
+const (
+	SortCreated = "created"
+	SortUpdated = "updated"
+	// SortName is sorted by the clients, the server only has the names encrypted.
+	SortName = "name"
+	// SortSize is only supported by files.
+	SortSize = "size"
+
+	// HeaderTotalCount and HeaderNextCursor carry Page.Total and Page.Next of
+	// the list endpoints, the body holds the items.
+	HeaderTotalCount = "X-Total-Count"
+	HeaderNextCursor = "X-Next-Cursor"
+
+	// DefaultPageSize is the page size of the list endpoints without a limit.
+	DefaultPageSize = 100
+	MaxPageSize     = 1000
+)
+
+// ListOptions sorts, filters and pages a list, zero Limit means no limit.
+// Cursor continues the list after the Next cursor of the previous page and
+// must be used with the same sort and order, Offset is kept for the clients
+// paging the files list by offset and can't be used with Cursor.
+type ListOptions struct {
+	Sort   string
+	Desc   bool
+	Limit  int
+	Cursor string
+	Offset int
+	// Since keeps the records created at or after the moment.
+	Since time.Time
+}
+
+// Validate checks the options against the sort fields of the list.
+func (o ListOptions) Validate(sorts ...string) error {
+	if o.Sort != "" && !slices.Contains(sorts, o.Sort) {
+		return fmt.Errorf("unknown sort field %q", o.Sort)
+	}
+	if o.Limit < 0 || o.Offset < 0 {
+		return fmt.Errorf("limit and offset must not be negative")
+	}
+	if o.Limit > MaxPageSize {
+		return fmt.Errorf("limit must not exceed %d", MaxPageSize)
+	}
+	if o.Cursor == "" {
+		return nil
+	}
+	if o.Offset > 0 {
+		return fmt.Errorf("offset can't be used with a cursor")
+	}
+
+	cursor, err := DecodeCursor(o.Cursor)
+	if err != nil {
+		return err
+	}
+	if cursor.Sort != o.Sort || cursor.Desc != o.Desc {
+		return fmt.Errorf("%w: sort or order changed", ErrInvalidCursor)
+	}
+	return nil
+}
+
+// Page is a page of a list. Total counts the items of all the pages, Next is
+// the cursor of the following page and is empty on the last one.
+type Page[T any] struct {
+	Items []T
+	Total int64
+	Next  string
+}
+
+// Cursor is the position after the last item of a page: the value of the sort
+// field and the ID breaking ties. Clients get it encoded and opaque.
+type Cursor struct {
+	Sort  string          `json:"s"`
+	Desc  bool            `json:"d,omitempty"`
+	Value json.RawMessage `json:"v"`
+	ID    string          `json:"id"`
+}
+
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c Cursor
+	err = json.Unmarshal(data, &c)
+	if err != nil || c.ID == "" || len(c.Value) == 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
@@ -0,0 +1,73 @@
+package types
+
+import (
+	"fmt"
+)
+
+// Quota limits what a user can store, a zero limit means no limit.
+type Quota struct {
+	FileBytes   int64 `json:"file_bytes"`
+	Notes       int64 `json:"notes"`
+	Cards       int64 `json:"cards"`
+	Credentials int64 `json:"credentials"`
+	// MaxFileSize limits a single file.
+	MaxFileSize int64 `json:"max_file_size"`
+}
+
+// QuotaOverride is the quota of a single user, nil limits are taken from the
+// default quota.
+type QuotaOverride struct {
+	FileBytes   *int64
+	Notes       *int64
+	Cards       *int64
+	Credentials *int64
+	MaxFileSize *int64
+}
+
+// Usage is what a user stores, records in the trash count until they are purged.
+// FileBytes counts identical contents once.
+type Usage struct {
+	Files       int64 `json:"files"`
+	FileBytes   int64 `json:"file_bytes"`
+	Notes       int64 `json:"notes"`
+	Cards       int64 `json:"cards"`
+	Credentials int64 `json:"credentials"`
+	Quota       Quota `json:"quota"`
+}
+
+// Check returns ErrQuotaExceeded when one more record of the kind, or size more
+// bytes for files, doesn't fit the quota, and ErrFileTooLarge when a file of the
+// size is larger than the user may store at once.
+func (u *Usage) Check(kind string, size int64) error {
+	if kind == KindFile && u.Quota.MaxFileSize > 0 && size > u.Quota.MaxFileSize {
+		return fmt.Errorf("%w: %d bytes allowed", ErrFileTooLarge, u.Quota.MaxFileSize)
+	}
+
+	var used, limit int64
+	var what string
+	switch kind {
+	case KindNote:
+		used, limit, what = u.Notes+1, u.Quota.Notes, "notes"
+	case KindCard:
+		used, limit, what = u.Cards+1, u.Quota.Cards, "cards"
+	case KindCredentials:
+		used, limit, what = u.Credentials+1, u.Quota.Credentials, "credentials"
+	case KindFile:
+		used, limit, what = u.FileBytes+size, u.Quota.FileBytes, "bytes of files"
+	default:
+		return fmt.Errorf("unknown kind %q", kind)
+	}
+
+	if limit > 0 && used > limit {
+		return fmt.Errorf("%w: %d %s allowed", ErrQuotaExceeded, limit, what)
+	}
+	return nil
+}
+
+// FileBytesLeft returns how many bytes of files the user can add, -1 means no limit.
+func (u *Usage) FileBytesLeft() int64 {
+	if u.Quota.FileBytes <= 0 {
+		return -1
+	}
+	return max(u.Quota.FileBytes-u.FileBytes, 0)
+}
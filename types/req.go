@@ -3,15 +3,19 @@ package types
 import "errors"
 
 type CreateNoteRequest struct {
-	Key      string `json:"key"`
-	Data     string `json:"data"`
-	Metadata string `json:"metadata"`
+	Key          string   `json:"key"`
+	Data         string   `json:"data"`
+	Metadata     string   `json:"metadata"`
+	SearchTokens []string `json:"search_tokens,omitempty"`
 }
 
 func (req *CreateNoteRequest) Validate() (*Note, error) {
 	if req.Key == "" {
 		return nil, errors.New("incorrect key")
 	}
+	if err := ValidateSearchTokens(req.SearchTokens); err != nil {
+		return nil, err
+	}
 	return &Note{
 		Key:      req.Key,
 		Text:     req.Data,
@@ -20,16 +24,20 @@ func (req *CreateNoteRequest) Validate() (*Note, error) {
 }
 
 type UpdateNoteRequest struct {
-	ID       string `json:"id"`
-	Key      string `json:"key"`
-	Data     string `json:"data"`
-	Metadata string `json:"metadata"`
+	ID           string   `json:"id"`
+	Key          string   `json:"key"`
+	Data         string   `json:"data"`
+	Metadata     string   `json:"metadata"`
+	SearchTokens []string `json:"search_tokens,omitempty"`
 }
 
 func (req *UpdateNoteRequest) Validate() (*Note, error) {
 	if req.Key == "" || req.ID == "" {
 		return nil, errors.New("incorrect request")
 	}
+	if err := ValidateSearchTokens(req.SearchTokens); err != nil {
+		return nil, err
+	}
 	return &Note{
 		Key:      req.Key,
 		Text:     req.Data,
@@ -38,17 +46,21 @@ func (req *UpdateNoteRequest) Validate() (*Note, error) {
 }
 
 type CreateCardRequest struct {
-	ID         string `json:"id,omitempty"`
-	Number     string `json:"number"`
-	Expiration string `json:"expiration"`
-	CVV        string `json:"cvv"`
-	Metadata   string `json:"metadata"`
+	ID           string   `json:"id,omitempty"`
+	Number       string   `json:"number"`
+	Expiration   string   `json:"expiration"`
+	CVV          string   `json:"cvv"`
+	Metadata     string   `json:"metadata"`
+	SearchTokens []string `json:"search_tokens,omitempty"`
 }
 
 func (req *CreateCardRequest) Validate() (*CardInfo, error) {
 	if req.Number == "" || req.Expiration == "" || req.CVV == "" {
 		return nil, errors.New("incorrect data")
 	}
+	if err := ValidateSearchTokens(req.SearchTokens); err != nil {
+		return nil, err
+	}
 
 	return &CardInfo{
 		ID:         req.ID,
@@ -60,16 +72,20 @@ func (req *CreateCardRequest) Validate() (*CardInfo, error) {
 }
 
 type CreateCredentialsRequest struct {
-	Site     string `json:"site"`
-	Login    string `json:"login"`
-	Password string `json:"password"`
-	Metadata string `json:"metadata"`
+	Site         string   `json:"site"`
+	Login        string   `json:"login"`
+	Password     string   `json:"password"`
+	Metadata     string   `json:"metadata"`
+	SearchTokens []string `json:"search_tokens,omitempty"`
 }
 
 func (req *CreateCredentialsRequest) Validate() (*Credentials, error) {
 	if req.Site == "" || req.Login == "" {
 		return nil, errors.New("incorrect key")
 	}
+	if err := ValidateSearchTokens(req.SearchTokens); err != nil {
+		return nil, err
+	}
 	return &Credentials{
 		Site:     req.Site,
 		Login:    req.Login,
@@ -79,17 +95,21 @@ func (req *CreateCredentialsRequest) Validate() (*Credentials, error) {
 }
 
 type UpdateCredentialsRequest struct {
-	ID       string `json:"id"`
-	Site     string `json:"site"`
-	Login    string `json:"login"`
-	Password string `json:"password"`
-	Metadata string `json:"metadata"`
+	ID           string   `json:"id"`
+	Site         string   `json:"site"`
+	Login        string   `json:"login"`
+	Password     string   `json:"password"`
+	Metadata     string   `json:"metadata"`
+	SearchTokens []string `json:"search_tokens,omitempty"`
 }
 
 func (req *UpdateCredentialsRequest) Validate() (*Credentials, error) {
 	if req.Site == "" || req.Login == "" || req.ID == "" {
 		return nil, errors.New("incorrect request")
 	}
+	if err := ValidateSearchTokens(req.SearchTokens); err != nil {
+		return nil, err
+	}
 	return &Credentials{
 		Site:     req.Site,
 		Login:    req.Login,
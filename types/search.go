@@ -0,0 +1,85 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+const (
+	// MaxSearchTokens limits the blind index tokens of a record and of a query.
+	MaxSearchTokens = 4096
+	// maxTokenSize is well above the 22 characters of the tokens of package search.
+	maxTokenSize = 64
+)
+
+// SearchTokensRequest replaces the search tokens of a record.
+type SearchTokensRequest struct {
+	Tokens []string `json:"tokens"`
+}
+
+// SearchRequest finds the records holding at least MinMatch of the tokens,
+// all of them when MinMatch is zero.
+type SearchRequest struct {
+	Tokens   []string `json:"tokens"`
+	MinMatch int      `json:"min_match,omitempty"`
+	Kinds    []string `json:"kinds,omitempty"`
+	Limit    int      `json:"limit,omitempty"`
+}
+
+func (req *SearchRequest) Validate() error {
+	if len(req.Tokens) == 0 {
+		return errors.New("no tokens")
+	}
+	err := ValidateSearchTokens(req.Tokens)
+	if err != nil {
+		return err
+	}
+	if req.MinMatch < 0 || req.MinMatch > len(req.Tokens) {
+		return fmt.Errorf("min_match must be between 0 and %d", len(req.Tokens))
+	}
+	if req.MinMatch == 0 {
+		req.MinMatch = len(req.Tokens)
+	}
+	for _, kind := range req.Kinds {
+		if !slices.Contains(SearchKinds, kind) {
+			return fmt.Errorf("unknown kind %q", kind)
+		}
+	}
+	if req.Limit < 0 || req.Limit > MaxPageSize {
+		return fmt.Errorf("limit must be between 0 and %d", MaxPageSize)
+	}
+	if req.Limit == 0 {
+		req.Limit = DefaultPageSize
+	}
+	return nil
+}
+
+// SearchKinds are the kinds of records with search tokens.
+var SearchKinds = []string{KindNote, KindCard, KindCredentials}
+
+// SearchHit is a record holding Matched of the tokens of a search.
+type SearchHit struct {
+	Kind    string `json:"kind"`
+	ID      string `json:"id"`
+	Matched int    `json:"matched"`
+}
+
+// ValidateSearchTokens checks the number and the alphabet of the tokens,
+// their contents are opaque to the server.
+func ValidateSearchTokens(tokens []string) error {
+	if len(tokens) > MaxSearchTokens {
+		return fmt.Errorf("more than %d search tokens", MaxSearchTokens)
+	}
+	for _, token := range tokens {
+		if token == "" || len(token) > maxTokenSize {
+			return errors.New("incorrect search token")
+		}
+		for _, r := range token {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+				return errors.New("incorrect search token")
+			}
+		}
+	}
+	return nil
+}
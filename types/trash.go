@@ -0,0 +1,17 @@
+package types
+
+import "time"
+
+const (
+	KindNote        = "text"
+	KindCard        = "card"
+	KindCredentials = "cred"
+	KindFile        = "file"
+)
+
+type TrashItem struct {
+	Kind      string    `json:"kind,omitempty"`
+	Id        string    `json:"id"`
+	Key       string    `json:"key"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
@@ -0,0 +1,94 @@
+package types
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+// UploadChunkSize is the size of every chunk of a resumable upload except the last one.
+// It must not be less than 5 MiB, the minimal part size of an S3 multipart upload.
+const UploadChunkSize = 8 << 20
+
+var ErrInvalidChunk = errors.New("invalid chunk")
+var ErrUploadIncomplete = errors.New("upload is incomplete")
+var ErrChecksumMismatch = errors.New("chunk checksum mismatch")
+
+type CreateUploadRequest struct {
+	Name     string `json:"name"`
+	Size     int64  `json:"size"`
+	Metadata string `json:"metadata"`
+}
+
+func (req *CreateUploadRequest) Validate() error {
+	if req.Name == "" || req.Size <= 0 {
+		return errors.New("incorrect name or size")
+	}
+	return nil
+}
+
+// UploadSession is the manifest of a resumable upload, Chunks lists the parts
+// already received by the server.
+type UploadSession struct {
+	ID        string            `json:"id"`
+	UploadID  string            `json:"-"`
+	Name      string            `json:"name"`
+	Size      int64             `json:"size"`
+	ChunkSize int64             `json:"chunk_size"`
+	Metadata  string            `json:"metadata"`
+	CreatedAt time.Time         `json:"created_at"`
+	Chunks    []UploadChunkInfo `json:"chunks"`
+	Offset    int64             `json:"offset"`
+}
+
+// UploadChunkInfo is a chunk received by the server. MD5 is the base64 encoded
+// digest of the received bytes, empty when the storage can't tell it: the ETag
+// of a part encrypted with SSE-C is not a digest of the contents.
+type UploadChunkInfo struct {
+	Number int    `json:"number"`
+	Size   int64  `json:"size"`
+	ETag   string `json:"etag"`
+	MD5    string `json:"md5,omitempty"`
+}
+
+// UploadChunk is a numbered part of an upload, numbers start from 1.
+// MD5 holds the base64 encoded digest of the chunk as sent in the Content-MD5 header.
+type UploadChunk struct {
+	Number int
+	Size   int64
+	MD5    string
+	Reader io.Reader
+}
+
+func (s *UploadSession) ChunksCount() int {
+	return int((s.Size + s.ChunkSize - 1) / s.ChunkSize)
+}
+
+// ChunkLength returns the expected size of chunk n or -1 if there is no such chunk.
+func (s *UploadSession) ChunkLength(n int) int64 {
+	count := s.ChunksCount()
+	if n < 1 || n > count {
+		return -1
+	}
+	if n == count {
+		return s.Size - int64(count-1)*s.ChunkSize
+	}
+	return s.ChunkSize
+}
+
+// UpdateOffset sets Offset to the number of bytes received without gaps from the start.
+func (s *UploadSession) UpdateOffset() {
+	received := make(map[int]int64, len(s.Chunks))
+	for _, c := range s.Chunks {
+		received[c.Number] = c.Size
+	}
+
+	s.Offset = 0
+	for n := 1; n <= s.ChunksCount(); n++ {
+		size, ok := received[n]
+		if !ok || size != s.ChunkLength(n) {
+			break
+		}
+		s.Offset += size
+	}
+}